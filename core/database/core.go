@@ -69,14 +69,16 @@ func initConfigPars(c *dig.Container) error {
 
 	type cfgResult struct {
 		dig.Out
-		DatabaseEngine           database.Engine `name:"databaseEngine"`
-		DatabasePath             string          `name:"databasePath"`
-		TangleDatabasePath       string          `name:"tangleDatabasePath"`
-		UTXODatabasePath         string          `name:"utxoDatabasePath"`
-		DeleteDatabaseFlag       bool            `name:"deleteDatabase"`
-		DeleteAllFlag            bool            `name:"deleteAll"`
-		DatabaseDebug            bool            `name:"databaseDebug"`
-		DatabaseAutoRevalidation bool            `name:"databaseAutoRevalidation"`
+		DatabaseEngine               database.Engine `name:"databaseEngine"`
+		DatabasePath                 string          `name:"databasePath"`
+		TangleDatabasePath           string          `name:"tangleDatabasePath"`
+		UTXODatabasePath             string          `name:"utxoDatabasePath"`
+		DeleteDatabaseFlag           bool            `name:"deleteDatabase"`
+		DeleteAllFlag                bool            `name:"deleteAll"`
+		DatabaseDebug                bool            `name:"databaseDebug"`
+		DatabaseAutoRevalidation     bool            `name:"databaseAutoRevalidation"`
+		DatabaseOutputIndex          bool            `name:"databaseOutputIndex"`
+		DatabaseHistoryDiffCacheSize int             `name:"databaseHistoryDiffCacheSize"`
 	}
 
 	if err := c.Provide(func() cfgResult {
@@ -86,14 +88,16 @@ func initConfigPars(c *dig.Container) error {
 		}
 
 		return cfgResult{
-			DatabaseEngine:           dbEngine,
-			DatabasePath:             ParamsDatabase.Path,
-			TangleDatabasePath:       filepath.Join(ParamsDatabase.Path, TangleDatabaseDirectoryName),
-			UTXODatabasePath:         filepath.Join(ParamsDatabase.Path, UTXODatabaseDirectoryName),
-			DeleteDatabaseFlag:       *deleteDatabase,
-			DeleteAllFlag:            *deleteAll,
-			DatabaseDebug:            ParamsDatabase.Debug,
-			DatabaseAutoRevalidation: ParamsDatabase.AutoRevalidation,
+			DatabaseEngine:               dbEngine,
+			DatabasePath:                 ParamsDatabase.Path,
+			TangleDatabasePath:           filepath.Join(ParamsDatabase.Path, TangleDatabaseDirectoryName),
+			UTXODatabasePath:             filepath.Join(ParamsDatabase.Path, UTXODatabaseDirectoryName),
+			DeleteDatabaseFlag:           *deleteDatabase,
+			DeleteAllFlag:                *deleteAll,
+			DatabaseDebug:                ParamsDatabase.Debug,
+			DatabaseAutoRevalidation:     ParamsDatabase.AutoRevalidation,
+			DatabaseOutputIndex:          ParamsDatabase.OutputIndex,
+			DatabaseHistoryDiffCacheSize: ParamsDatabase.HistoryDiffCacheSize,
 		}
 	}); err != nil {
 		CoreComponent.LogPanic(err)
@@ -192,9 +196,11 @@ func provide(c *dig.Container) error {
 
 	type storageDeps struct {
 		dig.In
-		TangleDatabase *database.Database `name:"tangleDatabase"`
-		UTXODatabase   *database.Database `name:"utxoDatabase"`
-		Profile        *profile.Profile
+		TangleDatabase               *database.Database `name:"tangleDatabase"`
+		UTXODatabase                 *database.Database `name:"utxoDatabase"`
+		Profile                      *profile.Profile
+		DatabaseOutputIndex          bool `name:"databaseOutputIndex"`
+		DatabaseHistoryDiffCacheSize int  `name:"databaseHistoryDiffCacheSize"`
 	}
 
 	type storageOut struct {
@@ -212,6 +218,17 @@ func provide(c *dig.Container) error {
 
 		store.PrintSnapshotInfo()
 
+		if deps.DatabaseOutputIndex {
+			CoreComponent.LogInfo("Preparing output index ...")
+		}
+		if err := store.UTXOManager().ConfigureOutputIndex(deps.DatabaseOutputIndex); err != nil {
+			CoreComponent.LogPanicf("can't configure output index: %s", err)
+		}
+
+		if err := store.UTXOManager().ConfigureHistoryDiffCache(deps.DatabaseHistoryDiffCacheSize); err != nil {
+			CoreComponent.LogPanicf("invalid '%s': %s", CoreComponent.App.Config().GetParameterPath(&(ParamsDatabase.HistoryDiffCacheSize)), err)
+		}
+
 		return storageOut{
 			Storage:     store,
 			UTXOManager: store.UTXOManager(),
@@ -12,6 +12,10 @@ type ParametersDatabase struct {
 	Path string `default:"mainnetdb" usage:"the path to the database folder"`
 	// AutoRevalidation defines whether to automatically start revalidation on startup if the database is corrupted.
 	AutoRevalidation bool `default:"false" usage:"whether to automatically start revalidation on startup if the database is corrupted"`
+	// OutputIndex defines whether to maintain an index of the unspent outputs by address, sender, issuer and chain ID.
+	OutputIndex bool `default:"false" usage:"whether to maintain an index of the unspent outputs by address, sender, issuer and chain ID"`
+	// HistoryDiffCacheSize defines the amount of milestone diffs kept in memory for queries of the ledger state at older milestone indexes.
+	HistoryDiffCacheSize int `default:"1000" usage:"the amount of milestone diffs kept in memory for queries of the ledger state at older milestone indexes"`
 	// Debug defines whether to ignore the check for corrupted databases (should only be used for debug reasons).
 	Debug bool `default:"false" usage:"ignore the check for corrupted databases (should only be used for debug reasons)"`
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/iotaledger/hive.go/timeutil"
 	"github.com/iotaledger/hornet/pkg/daemon"
 	"github.com/iotaledger/hornet/pkg/metrics"
+	"github.com/iotaledger/hornet/pkg/model/milestonemanager"
 	"github.com/iotaledger/hornet/pkg/model/storage"
 	"github.com/iotaledger/hornet/pkg/model/syncmanager"
 	"github.com/iotaledger/hornet/pkg/p2p"
@@ -25,6 +26,7 @@ import (
 	"github.com/iotaledger/hornet/pkg/pruning"
 	"github.com/iotaledger/hornet/pkg/snapshot"
 	"github.com/iotaledger/hornet/pkg/tangle"
+	iotago "github.com/iotaledger/iota.go/v3"
 )
 
 const (
@@ -35,7 +37,12 @@ const (
 	heartbeatReceiveTimeout = 100 * time.Second
 	checkHeartbeatsInterval = 5 * time.Second
 
-	iotaGossipProtocolIDTemplate = "/iota-gossip/%d/1.0.0"
+	iotaGossipProtocolIDTemplate = "/iota-gossip/%d/%s"
+
+	// the version of the gossip protocol which exchanges a handshake when a stream is opened.
+	iotaGossipProtocolVersion = "1.1.0"
+	// the version of the gossip protocol that is still used by older nodes.
+	iotaGossipLegacyProtocolVersion = "1.0.0"
 )
 
 func init() {
@@ -88,13 +95,14 @@ func provide(c *dig.Container) error {
 
 	type msgProcDeps struct {
 		dig.In
-		Storage         *storage.Storage
-		SyncManager     *syncmanager.SyncManager
-		ServerMetrics   *metrics.ServerMetrics
-		RequestQueue    gossip.RequestQueue
-		PeeringManager  *p2p.Manager
-		ProtocolManager *proto.Manager
-		Profile         *profile.Profile
+		Storage          *storage.Storage
+		SyncManager      *syncmanager.SyncManager
+		ServerMetrics    *metrics.ServerMetrics
+		RequestQueue     gossip.RequestQueue
+		PeeringManager   *p2p.Manager
+		ProtocolManager  *proto.Manager
+		MilestoneManager *milestonemanager.MilestoneManager
+		Profile          *profile.Profile
 	}
 
 	if err := c.Provide(func(deps msgProcDeps) *gossip.MessageProcessor {
@@ -105,6 +113,7 @@ func provide(c *dig.Container) error {
 			deps.PeeringManager,
 			deps.ServerMetrics,
 			deps.ProtocolManager,
+			deps.MilestoneManager,
 			&gossip.Options{
 				WorkUnitCacheOpts: deps.Profile.Caches.IncomingBlocksFilter,
 			})
@@ -119,16 +128,28 @@ func provide(c *dig.Container) error {
 
 	type serviceDeps struct {
 		dig.In
-		Host            host.Host
-		PeeringManager  *p2p.Manager
-		Storage         *storage.Storage
-		ServerMetrics   *metrics.ServerMetrics
-		ProtocolManager *proto.Manager
+		Host                  host.Host
+		PeeringManager        *p2p.Manager
+		Storage               *storage.Storage
+		ServerMetrics         *metrics.ServerMetrics
+		ProtocolManager       *proto.Manager
+		PruningArchiveEnabled bool `name:"pruningArchiveEnabled"`
+		SnapshotsServeToPeers bool `name:"snapshotsServeToPeers"`
 	}
 
 	if err := c.Provide(func(deps serviceDeps) *gossip.Service {
+		networkID := deps.ProtocolManager.Current().NetworkID()
+
+		var capabilities gossip.Capabilities
+		if deps.PruningArchiveEnabled {
+			capabilities |= gossip.CapabilityArchive
+		}
+		if deps.SnapshotsServeToPeers {
+			capabilities |= gossip.CapabilitySnapshots
+		}
+
 		return gossip.NewService(
-			protocol.ID(fmt.Sprintf(iotaGossipProtocolIDTemplate, deps.ProtocolManager.Current().NetworkID())),
+			protocol.ID(fmt.Sprintf(iotaGossipProtocolIDTemplate, networkID, iotaGossipProtocolVersion)),
 			deps.Host,
 			deps.PeeringManager,
 			deps.ServerMetrics,
@@ -136,6 +157,16 @@ func provide(c *dig.Container) error {
 			gossip.WithUnknownPeersLimit(ParamsGossip.UnknownPeersLimit),
 			gossip.WithStreamReadTimeout(ParamsGossip.StreamReadTimeout),
 			gossip.WithStreamWriteTimeout(ParamsGossip.StreamWriteTimeout),
+			gossip.WithBandwidthLimits(
+				ParamsGossip.Bandwidth.UploadLimit,
+				ParamsGossip.Bandwidth.DownloadLimit,
+				ParamsGossip.Bandwidth.PeerUploadLimit,
+				ParamsGossip.Bandwidth.PeerDownloadLimit,
+			),
+			gossip.WithLegacyProtocols(protocol.ID(fmt.Sprintf(iotaGossipProtocolIDTemplate, networkID, iotaGossipLegacyProtocolVersion))),
+			gossip.WithHandshakeFunc(func() *gossip.Handshake {
+				return createHandshake(deps.Storage, capabilities)
+			}),
 		)
 	}); err != nil {
 		CoreComponent.LogPanic(err)
@@ -249,6 +280,16 @@ func run() error {
 	return nil
 }
 
+// createHandshake creates the handshake which advertises the capabilities of the node to its peers.
+func createHandshake(dbStorage *storage.Storage, capabilities gossip.Capabilities) *gossip.Handshake {
+	var pruningIndex iotago.MilestoneIndex
+	if snapshotInfo := dbStorage.SnapshotInfo(); snapshotInfo != nil {
+		pruningIndex = snapshotInfo.PruningIndex()
+	}
+
+	return gossip.NewHandshake(pruningIndex, capabilities)
+}
+
 // checkHeartbeats sends a heartbeat to each peer and also checks
 // whether we received heartbeats from other peers. if a peer didn't send any
 // heartbeat for a defined period of time, then the connection to it is dropped.
@@ -350,11 +391,25 @@ func configureEvents() {
 			}
 
 			for {
+				// the messages of the priority send queue are never starved by the bulk messages
+				select {
+				case data := <-proto.PrioritySendQueue:
+					if err := proto.SendPriority(data); err != nil {
+						return
+					}
+					continue
+				default:
+				}
+
 				select {
 				case <-proto.Terminated():
 					return
 				case <-ctx.Done():
 					return
+				case data := <-proto.PrioritySendQueue:
+					if err := proto.SendPriority(data); err != nil {
+						return
+					}
 				case data := <-proto.SendQueue:
 					if err := proto.Send(data); err != nil {
 						return
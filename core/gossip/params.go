@@ -22,6 +22,17 @@ type ParametersGossip struct {
 	StreamReadTimeout time.Duration `default:"60s" usage:"the read timeout for reads from the gossip stream"`
 	// Defines the write timeout for writes to the gossip stream.
 	StreamWriteTimeout time.Duration `default:"10s" usage:"the write timeout for writes to the gossip stream"`
+
+	Bandwidth struct {
+		// Defines the maximum amount of bytes per second sent to all peers.
+		UploadLimit int `default:"0" usage:"the maximum amount of bytes per second sent to all peers (0 = unlimited)"`
+		// Defines the maximum amount of bytes per second received from all peers.
+		DownloadLimit int `default:"0" usage:"the maximum amount of bytes per second received from all peers (0 = unlimited)"`
+		// Defines the maximum amount of bytes per second sent to a single peer.
+		PeerUploadLimit int `default:"0" usage:"the maximum amount of bytes per second sent to a single peer (0 = unlimited)"`
+		// Defines the maximum amount of bytes per second received from a single peer.
+		PeerDownloadLimit int `default:"0" usage:"the maximum amount of bytes per second received from a single peer (0 = unlimited)"`
+	}
 }
 
 var ParamsRequests = &ParametersRequests{}
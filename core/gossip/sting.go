@@ -34,6 +34,28 @@ func attachEventsProtocolMessages(proto *gossip.Protocol) {
 		deps.ServerMetrics.SentBlockRequests.Inc()
 	}))
 
+	proto.Parser.Events.Received[gossip.MessageTypeBlocks].Attach(events.NewClosure(func(data []byte) {
+		// the received blocks are counted by the message processor
+		deps.MessageProcessor.Process(proto, gossip.MessageTypeBlocks, data)
+	}))
+
+	proto.Events.Sent[gossip.MessageTypeBlocks].Attach(events.NewClosure(func() {
+		// the sent blocks are counted by the SentBlocks event
+		proto.Metrics.SentPackets.Inc()
+	}))
+
+	proto.Parser.Events.Received[gossip.MessageTypeBlockRequests].Attach(events.NewClosure(func(data []byte) {
+		proto.Metrics.ReceivedBlockRequests.Inc()
+		deps.ServerMetrics.ReceivedBlockRequests.Inc()
+		deps.MessageProcessor.Process(proto, gossip.MessageTypeBlockRequests, data)
+	}))
+
+	proto.Events.Sent[gossip.MessageTypeBlockRequests].Attach(events.NewClosure(func() {
+		proto.Metrics.SentPackets.Inc()
+		proto.Metrics.SentBlockRequests.Inc()
+		deps.ServerMetrics.SentBlockRequests.Inc()
+	}))
+
 	proto.Parser.Events.Received[gossip.MessageTypeMilestoneRequest].Attach(events.NewClosure(func(data []byte) {
 		proto.Metrics.ReceivedMilestoneRequests.Inc()
 		deps.ServerMetrics.ReceivedMilestoneRequests.Inc()
@@ -46,6 +68,33 @@ func attachEventsProtocolMessages(proto *gossip.Protocol) {
 		deps.ServerMetrics.SentMilestoneRequests.Inc()
 	}))
 
+	proto.Parser.Events.Received[gossip.MessageTypeMilestoneConeRequest].Attach(events.NewClosure(func(data []byte) {
+		proto.Metrics.ReceivedMilestoneRequests.Inc()
+		deps.ServerMetrics.ReceivedMilestoneRequests.Inc()
+		deps.MessageProcessor.Process(proto, gossip.MessageTypeMilestoneConeRequest, data)
+	}))
+
+	proto.Events.Sent[gossip.MessageTypeMilestoneConeRequest].Attach(events.NewClosure(func() {
+		proto.Metrics.SentPackets.Inc()
+		proto.Metrics.SentMilestoneRequests.Inc()
+		deps.ServerMetrics.SentMilestoneRequests.Inc()
+	}))
+
+	proto.Parser.Events.Received[gossip.MessageTypeMilestoneCone].Attach(events.NewClosure(func(data []byte) {
+		// the received blocks are counted by the message processor
+		deps.MessageProcessor.Process(proto, gossip.MessageTypeMilestoneCone, data)
+	}))
+
+	proto.Events.Sent[gossip.MessageTypeMilestoneCone].Attach(events.NewClosure(func() {
+		// the sent blocks are counted by the SentBlocks event
+		proto.Metrics.SentPackets.Inc()
+	}))
+
+	proto.Events.SentBlocks.Attach(events.NewClosure(func(blocksCount int) {
+		proto.Metrics.SentBlocks.Add(uint32(blocksCount))
+		deps.ServerMetrics.SentBlocks.Add(uint32(blocksCount))
+	}))
+
 	proto.Parser.Events.Received[gossip.MessageTypeHeartbeat].Attach(events.NewClosure(func(data []byte) {
 		proto.Metrics.ReceivedHeartbeats.Inc()
 		deps.ServerMetrics.ReceivedHeartbeats.Inc()
@@ -73,6 +122,20 @@ func attachEventsProtocolMessages(proto *gossip.Protocol) {
 		deps.ServerMetrics.SentHeartbeats.Inc()
 		proto.HeartbeatSentTime = time.Now()
 	}))
+
+	proto.Parser.Events.Received[gossip.MessageTypeHandshake].Attach(events.NewClosure(func(data []byte) {
+		handshake, err := gossip.ParseHandshake(data)
+		if err != nil {
+			proto.Events.Errors.Trigger(err)
+			return
+		}
+
+		proto.SetHandshake(handshake)
+	}))
+
+	proto.Events.Sent[gossip.MessageTypeHandshake].Attach(events.NewClosure(func() {
+		proto.Metrics.SentPackets.Inc()
+	}))
 }
 
 // detachEventsProtocolMessages removes all the event handlers for sent and received messages.
@@ -98,4 +161,8 @@ func detachEventsProtocolMessages(proto *gossip.Protocol) {
 			event.DetachAll()
 		}
 	}
+
+	if proto.Events.SentBlocks != nil {
+		proto.Events.SentBlocks.DetachAll()
+	}
 }
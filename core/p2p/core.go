@@ -151,6 +151,7 @@ func provide(c *dig.Container) error {
 			return p2p.NewManager(deps.Host,
 				p2p.WithManagerLogger(logger.NewLogger("P2P-Manager")),
 				p2p.WithManagerReconnectInterval(ParamsP2P.ReconnectInterval, 1*time.Second),
+				p2p.WithManagerPeerBanning(ParamsP2P.Ban.ScoreThreshold, ParamsP2P.Ban.Duration),
 			)
 		}
 		return nil
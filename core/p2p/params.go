@@ -33,6 +33,13 @@ type ParametersP2P struct {
 
 	// Defines the time to wait before trying to reconnect to a disconnected peer.
 	ReconnectInterval time.Duration `default:"30s" usage:"the time to wait before trying to reconnect to a disconnected peer"`
+
+	Ban struct {
+		// Defines the score below which a peer gets banned.
+		ScoreThreshold float64 `default:"-50" usage:"the score below which a peer gets banned"`
+		// Defines the duration for which a peer gets banned.
+		Duration time.Duration `default:"1h" usage:"the duration for which a peer gets banned"`
+	}
 }
 
 // ParametersPeers contains the definition of the parameters used by peers.
@@ -8,6 +8,7 @@ import (
 
 	"github.com/iotaledger/hive.go/app"
 	"github.com/iotaledger/hive.go/events"
+	"github.com/iotaledger/hornet/pkg/archive"
 	"github.com/iotaledger/hornet/pkg/daemon"
 	"github.com/iotaledger/hornet/pkg/database"
 	"github.com/iotaledger/hornet/pkg/model/storage"
@@ -33,6 +34,9 @@ func init() {
 var (
 	CoreComponent *app.CoreComponent
 	deps          dependencies
+
+	// the archive the milestone cones are written to before they are pruned, nil if archiving is disabled.
+	blockArchive *archive.Archive
 )
 
 type dependencies struct {
@@ -45,12 +49,14 @@ func initConfigPars(c *dig.Container) error {
 
 	type cfgResult struct {
 		dig.Out
-		PruningPruneReceipts bool `name:"pruneReceipts"`
+		PruningPruneReceipts  bool `name:"pruneReceipts"`
+		PruningArchiveEnabled bool `name:"pruningArchiveEnabled"`
 	}
 
 	return c.Provide(func() cfgResult {
 		return cfgResult{
-			PruningPruneReceipts: ParamsPruning.PruneReceipts,
+			PruningPruneReceipts:  ParamsPruning.PruneReceipts,
+			PruningArchiveEnabled: ParamsPruning.Archive.Enabled,
 		}
 	})
 }
@@ -86,6 +92,23 @@ func provide(c *dig.Container) error {
 			CoreComponent.LogPanicf("%s has to be specified if %s is enabled", CoreComponent.App.Config().GetParameterPath(&(ParamsPruning.Size.TargetSize)), CoreComponent.App.Config().GetParameterPath(&(ParamsPruning.Size.Enabled)))
 		}
 
+		var archiveSink pruning.ArchiveSink
+		if ParamsPruning.Archive.Enabled {
+			segmentSizeBytes, err := bytes.Parse(ParamsPruning.Archive.SegmentSize)
+			if err != nil || segmentSizeBytes <= 0 {
+				CoreComponent.LogPanicf("parameter %s invalid", CoreComponent.App.Config().GetParameterPath(&(ParamsPruning.Archive.SegmentSize)))
+			}
+
+			blockArchive, err = archive.Open(CoreComponent.Logger(), ParamsPruning.Archive.Path, segmentSizeBytes)
+			if err != nil {
+				CoreComponent.LogPanicf("opening the archive failed: %s", err)
+			}
+
+			// archived blocks can still be queried after they were pruned
+			deps.Storage.SetBlockArchive(blockArchive)
+			archiveSink = blockArchive
+		}
+
 		return pruning.NewPruningManager(
 			CoreComponent.Logger(),
 			deps.Storage,
@@ -100,10 +123,56 @@ func provide(c *dig.Container) error {
 			ParamsPruning.Size.ThresholdPercentage,
 			ParamsPruning.Size.CooldownTime,
 			deps.PruningPruneReceipts,
+			classRetentions(),
+			archiveSink,
 		)
 	})
 }
 
+// classRetentions returns the retentions of the pruning classes.
+// The data needed to find the milestone cones is kept at least as long as the data that is found with it.
+func classRetentions() map[storage.PruningClass]syncmanager.MilestoneIndexDelta {
+
+	retentionParams := map[storage.PruningClass]*int{
+		storage.PruningClassBlocks:         &ParamsPruning.Retention.Blocks,
+		storage.PruningClassMetadata:       &ParamsPruning.Retention.Metadata,
+		storage.PruningClassChildren:       &ParamsPruning.Retention.Children,
+		storage.PruningClassMilestones:     &ParamsPruning.Retention.Milestones,
+		storage.PruningClassLedgerDiffs:    &ParamsPruning.Retention.LedgerDiffs,
+		storage.PruningClassReceipts:       &ParamsPruning.Retention.Receipts,
+		storage.PruningClassProtocolParams: &ParamsPruning.Retention.ProtocolParameters,
+	}
+
+	retentions := make(map[storage.PruningClass]syncmanager.MilestoneIndexDelta, len(retentionParams))
+	for class, param := range retentionParams {
+		if *param < 0 {
+			CoreComponent.LogPanicf("parameter %s invalid", CoreComponent.App.Config().GetParameterPath(param))
+		}
+		retentions[class] = syncmanager.MilestoneIndexDelta(*param)
+	}
+
+	// the metadata contains the parents of the blocks, which are needed to walk the cones and to find the children.
+	// the milestone payloads are needed to find the start of the cones.
+	requiredRetentions := []struct {
+		class     storage.PruningClass
+		dependent []storage.PruningClass
+	}{
+		{storage.PruningClassMetadata, []storage.PruningClass{storage.PruningClassBlocks, storage.PruningClassChildren}},
+		{storage.PruningClassMilestones, []storage.PruningClass{storage.PruningClassBlocks, storage.PruningClassMetadata, storage.PruningClassChildren}},
+	}
+
+	for _, required := range requiredRetentions {
+		for _, dependent := range required.dependent {
+			if retentions[required.class] < retentions[dependent] {
+				CoreComponent.LogWarnf("parameter '%s' is too small (%d). value was changed to %d", CoreComponent.App.Config().GetParameterPath(retentionParams[required.class]), retentions[required.class], retentions[dependent])
+				retentions[required.class] = retentions[dependent]
+			}
+		}
+	}
+
+	return retentions
+}
+
 func run() error {
 
 	onSnapshotHandledConfirmedMilestoneIndexChanged := events.NewClosure(func(confirmedMilestoneIndex iotago.MilestoneIndex) {
@@ -123,5 +192,22 @@ func run() error {
 		CoreComponent.LogPanicf("failed to start worker: %s", err)
 	}
 
+	if blockArchive != nil {
+		// the archive is closed after all components reading from it were stopped
+		if err := CoreComponent.Daemon().BackgroundWorker("Close archive", func(ctx context.Context) {
+			<-ctx.Done()
+
+			CoreComponent.LogInfo("Closing the archive...")
+			if err := blockArchive.Close(); err != nil {
+				CoreComponent.LogWarnf("Closing the archive... failed: %s", err)
+
+				return
+			}
+			CoreComponent.LogInfo("Closing the archive... done")
+		}, daemon.PriorityCloseArchive); err != nil {
+			CoreComponent.LogPanicf("failed to start worker: %s", err)
+		}
+	}
+
 	return nil
 }
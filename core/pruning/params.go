@@ -25,6 +25,34 @@ type ParametersPruning struct {
 		CooldownTime time.Duration `default:"5m" usage:"cooldown time between two pruning by database size events"`
 	}
 
+	// Retention defines the amount of milestones the data of a class is kept for, if it should be kept longer than the tangle history.
+	// A value of 0 prunes the data together with the tangle history.
+	Retention struct {
+		// Blocks defines the amount of milestones the block bodies are kept for
+		Blocks int `default:"0" usage:"the amount of milestones the block bodies are kept for (0 = pruned with the tangle history)"`
+		// Metadata defines the amount of milestones the block metadata is kept for
+		Metadata int `default:"0" usage:"the amount of milestones the block metadata is kept for (0 = pruned with the tangle history)"`
+		// Children defines the amount of milestones the references to the children of blocks are kept for
+		Children int `default:"0" usage:"the amount of milestones the references to the children of blocks are kept for (0 = pruned with the tangle history)"`
+		// Milestones defines the amount of milestones the milestone payloads are kept for
+		Milestones int `default:"0" usage:"the amount of milestones the milestone payloads are kept for (0 = pruned with the tangle history)"`
+		// LedgerDiffs defines the amount of milestones the milestone diffs and spent outputs are kept for
+		LedgerDiffs int `default:"0" usage:"the amount of milestones the milestone diffs and spent outputs are kept for (0 = pruned with the tangle history)"`
+		// Receipts defines the amount of milestones the receipts are kept for
+		Receipts int `default:"0" usage:"the amount of milestones the receipts are kept for if pruneReceipts is enabled (0 = pruned with the tangle history)"`
+		// ProtocolParameters defines the amount of milestones the protocol parameters milestone options are kept for
+		ProtocolParameters int `default:"0" usage:"the amount of milestones the protocol parameters milestone options are kept for (0 = pruned with the tangle history)"`
+	}
+
+	Archive struct {
+		// Enabled defines whether to archive the milestone cones to segment files before they are pruned
+		Enabled bool `default:"false" usage:"whether to archive the milestone cones to segment files before they are pruned"`
+		// Path defines the path to the archive folder
+		Path string `default:"archive" usage:"the path to the archive folder"`
+		// SegmentSize defines the size after which a segment file is sealed and a new one is started
+		SegmentSize string `default:"256MB" usage:"the size after which a segment file is sealed and a new one is started"`
+	}
+
 	// PruneReceipts defines whether to delete old receipts data from the database
 	PruneReceipts bool `default:"false" usage:"whether to delete old receipts data from the database"`
 }
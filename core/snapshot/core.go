@@ -2,9 +2,14 @@ package snapshot
 
 import (
 	"context"
+	"fmt"
 	"os"
 
 	"github.com/labstack/gommon/bytes"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/multiformats/go-multiaddr"
 	flag "github.com/spf13/pflag"
 	"go.uber.org/dig"
 
@@ -16,10 +21,13 @@ import (
 	"github.com/iotaledger/hornet/pkg/model/storage"
 	"github.com/iotaledger/hornet/pkg/model/syncmanager"
 	"github.com/iotaledger/hornet/pkg/model/utxo"
-	"github.com/iotaledger/hornet/pkg/protocol"
+	"github.com/iotaledger/hornet/pkg/p2p"
+	proto "github.com/iotaledger/hornet/pkg/protocol"
+	"github.com/iotaledger/hornet/pkg/protocol/snapshotexchange"
 	"github.com/iotaledger/hornet/pkg/snapshot"
 	"github.com/iotaledger/hornet/pkg/tangle"
 	iotago "github.com/iotaledger/iota.go/v3"
+	"github.com/iotaledger/iota.go/v3/keymanager"
 )
 
 const (
@@ -32,6 +40,8 @@ const (
 	// AdditionalPruningThreshold is the additional threshold (to BMD), which is needed, because the blocks in the getMilestoneParents call in solidEntryPoints
 	// can reference older blocks as well
 	AdditionalPruningThreshold = 5
+
+	iotaSnapshotsProtocolIDTemplate = "/iota-snapshots/%d/1.0.0"
 )
 
 const (
@@ -68,23 +78,28 @@ type dependencies struct {
 	UTXOManager        *utxo.Manager
 	SnapshotImporter   *snapshot.Importer
 	SnapshotManager    *snapshot.Manager
+	SnapshotJobManager *snapshot.JobManager
 	SnapshotsFullPath  string `name:"snapshotsFullPath"`
 	SnapshotsDeltaPath string `name:"snapshotsDeltaPath"`
 	StorageMetrics     *metrics.StorageMetrics
+	ProtocolManager    *proto.Manager
+	Host               host.Host
 }
 
 func initConfigPars(c *dig.Container) error {
 
 	type cfgResult struct {
 		dig.Out
-		SnapshotsFullPath  string `name:"snapshotsFullPath"`
-		SnapshotsDeltaPath string `name:"snapshotsDeltaPath"`
+		SnapshotsFullPath     string `name:"snapshotsFullPath"`
+		SnapshotsDeltaPath    string `name:"snapshotsDeltaPath"`
+		SnapshotsServeToPeers bool   `name:"snapshotsServeToPeers"`
 	}
 
 	return c.Provide(func() cfgResult {
 		return cfgResult{
-			SnapshotsFullPath:  ParamsSnapshots.FullPath,
-			SnapshotsDeltaPath: ParamsSnapshots.DeltaPath,
+			SnapshotsFullPath:     ParamsSnapshots.FullPath,
+			SnapshotsDeltaPath:    ParamsSnapshots.DeltaPath,
+			SnapshotsServeToPeers: ParamsSnapshots.ServeToPeers,
 		}
 	})
 }
@@ -93,16 +108,31 @@ func provide(c *dig.Container) error {
 
 	type snapshotImporterDeps struct {
 		dig.In
-		DeleteAllFlag        bool `name:"deleteAll"`
-		PruningPruneReceipts bool `name:"pruneReceipts"`
-		Storage              *storage.Storage
-		SnapshotsFullPath    string `name:"snapshotsFullPath"`
-		SnapshotsDeltaPath   string `name:"snapshotsDeltaPath"`
-		TargetNetworkName    string `name:"targetNetworkName"`
+		DeleteAllFlag           bool `name:"deleteAll"`
+		PruningPruneReceipts    bool `name:"pruneReceipts"`
+		Storage                 *storage.Storage
+		SnapshotsFullPath       string `name:"snapshotsFullPath"`
+		SnapshotsDeltaPath      string `name:"snapshotsDeltaPath"`
+		TargetNetworkName       string `name:"targetNetworkName"`
+		Host                    host.Host
+		PeeringConfigManager    *p2p.ConfigManager
+		KeyManager              *keymanager.KeyManager
+		MilestonePublicKeyCount int `name:"milestonePublicKeyCount"`
 	}
 
 	if err := c.Provide(func(deps snapshotImporterDeps) *snapshot.Importer {
 
+		downloadChunkSizeBytes, err := bytes.Parse(ParamsSnapshots.DownloadChunkSize)
+		if err != nil || downloadChunkSizeBytes <= 0 {
+			CoreComponent.LogPanicf("parameter %s invalid", CoreComponent.App.Config().GetParameterPath(&(ParamsSnapshots.DownloadChunkSize)))
+		}
+
+		downloadParallelism := ParamsSnapshots.DownloadParallelism
+		if downloadParallelism < 1 {
+			CoreComponent.LogWarnf("parameter '%s' is too small (%d). value was changed to %d", CoreComponent.App.Config().GetParameterPath(&(ParamsSnapshots.DownloadParallelism)), downloadParallelism, 1)
+			downloadParallelism = 1
+		}
+
 		if deps.DeleteAllFlag {
 			// delete old snapshot files
 			if err := os.Remove(deps.SnapshotsFullPath); err != nil && !os.IsNotExist(err) {
@@ -114,6 +144,19 @@ func provide(c *dig.Container) error {
 			}
 		}
 
+		var peerDownloader snapshot.PeerDownloader
+		if ParamsSnapshots.DownloadFromPeers {
+			peerDownloader = snapshotexchange.NewClient(
+				CoreComponent.Logger(),
+				deps.Host,
+				snapshotsProtocolID(iotago.NetworkIDFromString(deps.TargetNetworkName)),
+				deps.KeyManager,
+				deps.MilestonePublicKeyCount,
+				knownPeers(deps.PeeringConfigManager),
+				downloadParallelism,
+			)
+		}
+
 		importer := snapshot.NewSnapshotImporter(
 			CoreComponent.Logger(),
 			deps.Storage,
@@ -121,6 +164,9 @@ func provide(c *dig.Container) error {
 			deps.SnapshotsDeltaPath,
 			deps.TargetNetworkName,
 			ParamsSnapshots.DownloadURLs,
+			downloadParallelism,
+			downloadChunkSizeBytes,
+			peerDownloader,
 		)
 
 		switch {
@@ -137,6 +183,12 @@ func provide(c *dig.Container) error {
 			}
 		}
 
+		// the commitment is computed from all unspent outputs if it was not stored yet,
+		// which is done at startup instead of blocking the ledger while the node is running.
+		if err := deps.Storage.UTXOManager().LoadLedgerStateCommitment(); err != nil {
+			CoreComponent.LogErrorAndExit(fmt.Errorf("loading ledger state commitment failed: %w", err))
+		}
+
 		return importer
 	}); err != nil {
 		return err
@@ -149,13 +201,13 @@ func provide(c *dig.Container) error {
 		Storage              *storage.Storage
 		SyncManager          *syncmanager.SyncManager
 		UTXOManager          *utxo.Manager
-		ProtocolManager      *protocol.Manager
+		ProtocolManager      *proto.Manager
 		PruningPruneReceipts bool   `name:"pruneReceipts"`
 		SnapshotsFullPath    string `name:"snapshotsFullPath"`
 		SnapshotsDeltaPath   string `name:"snapshotsDeltaPath"`
 	}
 
-	return c.Provide(func(deps snapshotDeps) *snapshot.Manager {
+	if err := c.Provide(func(deps snapshotDeps) *snapshot.Manager {
 		deltaSnapshotSizeThresholdMinSizeBytes, err := bytes.Parse(ParamsSnapshots.DeltaSizeThresholdMinSize)
 		if err != nil {
 			CoreComponent.LogPanicf("parameter %s invalid", CoreComponent.App.Config().GetParameterPath(&(ParamsSnapshots.DeltaSizeThresholdMinSize)))
@@ -187,7 +239,11 @@ func provide(c *dig.Container) error {
 			snapshotDepth,
 			syncmanager.MilestoneIndexDelta(ParamsSnapshots.Interval),
 		)
-	})
+	}); err != nil {
+		return err
+	}
+
+	return c.Provide(snapshot.NewJobManager)
 }
 
 func run() error {
@@ -221,5 +277,85 @@ func run() error {
 		CoreComponent.LogPanicf("failed to start worker: %s", err)
 	}
 
+	if err := CoreComponent.Daemon().BackgroundWorker("SnapshotJobs", func(ctx context.Context) {
+		CoreComponent.LogInfo("Starting snapshot jobs ... done")
+		deps.SnapshotJobManager.Run(ctx)
+		CoreComponent.LogInfo("Stopping snapshot jobs ... done")
+	}, daemon.PrioritySnapshots); err != nil {
+		CoreComponent.LogPanicf("failed to start worker: %s", err)
+	}
+
+	if ParamsSnapshots.ServeToPeers {
+		server := snapshotexchange.NewServer(
+			CoreComponent.Logger(),
+			deps.Host,
+			snapshotsProtocolID(deps.ProtocolManager.Current().NetworkID()),
+			deps.SnapshotsFullPath,
+			deps.SnapshotsDeltaPath,
+			snapshotexchange.DefaultChunkSize,
+		)
+
+		// the manifests of new snapshot files are computed before they are served.
+		snapshotFilesChangedSignal := make(chan struct{}, 1)
+		onSnapshotMetricsUpdated := events.NewClosure(func(_ *snapshot.SnapshotMetrics) {
+			select {
+			case snapshotFilesChangedSignal <- struct{}{}:
+			default:
+			}
+		})
+
+		if err := CoreComponent.Daemon().BackgroundWorker("SnapshotExchange", func(ctx context.Context) {
+			CoreComponent.LogInfo("Starting snapshot exchange ... done")
+
+			// the metrics are updated after every written snapshot file.
+			deps.SnapshotManager.Events.SnapshotMetricsUpdated.Attach(onSnapshotMetricsUpdated)
+			defer deps.SnapshotManager.Events.SnapshotMetricsUpdated.Detach(onSnapshotMetricsUpdated)
+
+			server.Start()
+			server.PrepareFiles(ctx)
+
+			for {
+				select {
+				case <-ctx.Done():
+					server.Stop()
+					CoreComponent.LogInfo("Stopping snapshot exchange ... done")
+					return
+
+				case <-snapshotFilesChangedSignal:
+					server.PrepareFiles(ctx)
+				}
+			}
+		}, daemon.PrioritySnapshotExchange); err != nil {
+			CoreComponent.LogPanicf("failed to start worker: %s", err)
+		}
+	}
+
 	return nil
 }
+
+// snapshotsProtocolID returns the ID of the protocol to exchange snapshot files with peers of the given network.
+func snapshotsProtocolID(networkID iotago.NetworkID) protocol.ID {
+	return protocol.ID(fmt.Sprintf(iotaSnapshotsProtocolIDTemplate, networkID))
+}
+
+// knownPeers returns the address infos of the peers defined in the peering config.
+func knownPeers(peeringConfigManager *p2p.ConfigManager) []peer.AddrInfo {
+	var addrInfos []peer.AddrInfo
+	for _, p := range peeringConfigManager.Peers() {
+		multiAddr, err := multiaddr.NewMultiaddr(p.MultiAddress)
+		if err != nil {
+			CoreComponent.LogWarnf("invalid peer address: %s", err)
+			continue
+		}
+
+		addrInfo, err := peer.AddrInfoFromP2pAddr(multiAddr)
+		if err != nil {
+			CoreComponent.LogWarnf("invalid peer address info: %s", err)
+			continue
+		}
+
+		addrInfos = append(addrInfos, *addrInfo)
+	}
+
+	return addrInfos
+}
@@ -23,6 +23,15 @@ type ParametersSnapshots struct {
 	DeltaSizeThresholdMinSize string `default:"50M" usage:"the minimum size of the delta snapshot file before the threshold percentage condition is checked (below that size the delta snapshot is always created)"`
 	// DownloadURLs defines the URLs to load the snapshot files from.
 	DownloadURLs []*snapshot.DownloadTarget `noflag:"true" usage:"URLs to load the snapshot files from"`
+	// DownloadParallelism defines the amount of parallel requests used to download a snapshot file
+	// (distributed over all targets that serve the same compressed snapshot file)
+	DownloadParallelism int `default:"4" usage:"the amount of parallel requests used to download a snapshot file (distributed over all targets that serve the same compressed snapshot file)"`
+	// DownloadChunkSize defines the size of the chunks a snapshot file is downloaded in, if the target supports range requests
+	DownloadChunkSize string `default:"16M" usage:"the size of the chunks a snapshot file is downloaded in, if the target supports range requests"`
+	// DownloadFromPeers defines whether to download the snapshot files from peers if no download URL is configured or reachable
+	DownloadFromPeers bool `default:"true" usage:"whether to download the snapshot files from peers if no download URL is configured or reachable"`
+	// ServeToPeers defines whether to serve the latest snapshot files of the node to peers
+	ServeToPeers bool `default:"true" usage:"whether to serve the latest snapshot files of the node to peers"`
 }
 
 var ParamsSnapshots = &ParametersSnapshots{
@@ -0,0 +1,473 @@
+package archive
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/hornet/pkg/common"
+	"github.com/iotaledger/hornet/pkg/pruning"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// DefaultSegmentSize is the default size after which a segment file is sealed and a new one is started.
+	DefaultSegmentSize = 256 * 1024 * 1024
+)
+
+var (
+	// ErrArchiveClosed is returned if the archive is used after it was closed.
+	ErrArchiveClosed = errors.New("archive was closed")
+)
+
+// Archive stores the milestone cones that are pruned from the database in append-only segment files,
+// so that the archived blocks can still be queried.
+type Archive struct {
+	// the logger used to log events.
+	*logger.WrappedLogger
+
+	directory   string
+	segmentSize int64
+
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+
+	lock sync.RWMutex
+	// the sealed segments, the oldest first.
+	sealedSegments []*sealedSegment
+	// the segment the records are appended to.
+	activeSequence uint64
+	activeFile     *os.File
+	activeSize     int64
+	// the index of the active segment, it is written to the index file once the segment is sealed.
+	activeIndex map[iotago.BlockID]int64
+	closed      bool
+}
+
+// sealedSegment is a segment that is no longer appended to.
+type sealedSegment struct {
+	sequence uint64
+	// the filter over the block IDs in the segment.
+	filter *segmentFilter
+}
+
+// Open opens the archive in the given directory and creates it if it doesn't exist.
+// Segments that were not sealed are recovered and a partially written record at the end of the last segment is removed.
+// Corrupted records in the middle of a segment are skipped and reported.
+func Open(log *logger.Logger, directory string, segmentSize int64) (*Archive, error) {
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return nil, err
+	}
+
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Archive{
+		WrappedLogger: logger.NewWrappedLogger(log),
+		directory:     directory,
+		segmentSize:   segmentSize,
+		encoder:       encoder,
+		decoder:       decoder,
+	}
+
+	if err := a.loadSegments(); err != nil {
+		_ = a.Close()
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// segmentSequences returns the sequence numbers of the segment files in the archive directory in ascending order.
+func (a *Archive) segmentSequences() ([]uint64, error) {
+	entries, err := os.ReadDir(a.directory)
+	if err != nil {
+		return nil, err
+	}
+
+	var sequences []uint64
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != segmentFileExtension {
+			continue
+		}
+
+		sequence, err := strconv.ParseUint(strings.TrimSuffix(entry.Name(), segmentFileExtension), 10, 64)
+		if err != nil {
+			// not a segment file of the archive
+			continue
+		}
+		sequences = append(sequences, sequence)
+	}
+
+	sort.Slice(sequences, func(i, j int) bool { return sequences[i] < sequences[j] })
+
+	return sequences, nil
+}
+
+func (a *Archive) loadSegments() error {
+	sequences, err := a.segmentSequences()
+	if err != nil {
+		return err
+	}
+
+	for i, sequence := range sequences {
+		_, err := os.Stat(indexFilePath(a.directory, sequence))
+		sealed := err == nil
+
+		if i == len(sequences)-1 && !sealed {
+			// the last segment is continued
+			return a.openActiveSegment(sequence)
+		}
+
+		if !sealed {
+			// the node was stopped before the segment was sealed
+			a.LogInfof("sealing archive segment %d ...", sequence)
+			if err := a.sealSegment(sequence); err != nil {
+				return err
+			}
+		}
+
+		filter, err := readIndexFilter(indexFilePath(a.directory, sequence))
+		if err != nil {
+			return errors.Wrapf(err, "reading index of archive segment %d failed", sequence)
+		}
+
+		a.sealedSegments = append(a.sealedSegments, &sealedSegment{sequence: sequence, filter: filter})
+	}
+
+	var nextSequence uint64 = 1
+	if len(sequences) > 0 {
+		nextSequence = sequences[len(sequences)-1] + 1
+	}
+
+	return a.openActiveSegment(nextSequence)
+}
+
+// sealSegment recovers the index of a segment that was not sealed and writes the index file.
+func (a *Archive) sealSegment(sequence uint64) error {
+	f, err := os.Open(segmentFilePath(a.directory, sequence))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	index, _, _, err := a.scanSegment(f)
+	if err != nil {
+		return err
+	}
+
+	return writeIndexFile(indexFilePath(a.directory, sequence), indexEntries(index))
+}
+
+// openActiveSegment opens the segment the records are appended to.
+func (a *Archive) openActiveSegment(sequence uint64) error {
+	f, err := os.OpenFile(segmentFilePath(a.directory, sequence), os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	index := make(map[iotago.BlockID]int64)
+	size := int64(fileHeaderLength)
+
+	if info.Size() == 0 {
+		if err := writeFileHeader(f, segmentMagic); err != nil {
+			_ = f.Close()
+			return err
+		}
+	} else {
+		var corrupted bool
+		if index, size, corrupted, err = a.scanSegment(f); err != nil {
+			_ = f.Close()
+			return err
+		}
+
+		if size < info.Size() {
+			if corrupted {
+				// the end of the segment might not be a partially written record if the segment is corrupted,
+				// so the segment is kept as it is and sealed with the records that could be read.
+				a.LogWarnf("archive segment %d is corrupted, sealing it and starting a new segment", sequence)
+				_ = f.Close()
+
+				return a.sealCorruptedSegment(sequence, index)
+			}
+
+			a.LogWarnf("removing partially written record at the end of archive segment %d", sequence)
+			if err := f.Truncate(size); err != nil {
+				_ = f.Close()
+				return err
+			}
+		}
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	a.activeSequence = sequence
+	a.activeFile = f
+	a.activeSize = size
+	a.activeIndex = index
+
+	return nil
+}
+
+// scanSegment reads all valid records of a segment and returns the index of the blocks
+// and the length of the segment without a partially written record at its end.
+// Corrupted records in the middle of the segment are skipped, so the records after them are kept,
+// in that case the returned flag is set.
+func (a *Archive) scanSegment(f *os.File) (map[iotago.BlockID]int64, int64, bool, error) {
+	if err := readFileHeader(f, segmentMagic); err != nil {
+		return nil, 0, false, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	index := make(map[iotago.BlockID]int64)
+	var corrupted bool
+
+	offset := int64(fileHeaderLength)
+	for offset < info.Size() {
+		compressed, recordLength, err := readRecord(f, offset, info.Size())
+		if err != nil && !errors.Is(err, ErrInvalidSegment) {
+			// the record exceeds the end of the segment, so it was partially written
+			return index, offset, corrupted, nil
+		}
+
+		var blockIDs iotago.BlockIDs
+		if err == nil {
+			var data []byte
+			if data, err = a.decoder.DecodeAll(compressed, nil); err == nil {
+				blockIDs, err = blockIDsInRecord(data)
+			}
+		}
+
+		if err != nil {
+			if offset+recordLength == info.Size() {
+				// the last record of the segment was partially written
+				return index, offset, corrupted, nil
+			}
+
+			a.LogWarnf("skipping corrupted record at offset %d of archive segment %s: %s", offset, f.Name(), err)
+			corrupted = true
+			offset += recordLength
+
+			continue
+		}
+
+		for _, blockID := range blockIDs {
+			index[blockID] = offset
+		}
+
+		offset += recordLength
+	}
+
+	return index, offset, corrupted, nil
+}
+
+func indexEntries(index map[iotago.BlockID]int64) []indexEntry {
+	entries := make([]indexEntry, 0, len(index))
+	for blockID, offset := range index {
+		entries = append(entries, indexEntry{blockID: blockID, offset: offset})
+	}
+	return entries
+}
+
+// rotateSegmentWithoutLocking seals the active segment and starts a new one.
+func (a *Archive) rotateSegmentWithoutLocking() error {
+	if err := writeIndexFile(indexFilePath(a.directory, a.activeSequence), indexEntries(a.activeIndex)); err != nil {
+		return err
+	}
+
+	if err := a.activeFile.Close(); err != nil {
+		return err
+	}
+
+	a.addSealedSegment(a.activeSequence, a.activeIndex)
+
+	return a.openActiveSegment(a.activeSequence + 1)
+}
+
+// sealCorruptedSegment seals the segment with the given index of its readable records and starts a new one.
+func (a *Archive) sealCorruptedSegment(sequence uint64, index map[iotago.BlockID]int64) error {
+	if err := writeIndexFile(indexFilePath(a.directory, sequence), indexEntries(index)); err != nil {
+		return err
+	}
+
+	a.addSealedSegment(sequence, index)
+
+	return a.openActiveSegment(sequence + 1)
+}
+
+// addSealedSegment adds the segment with the given index to the sealed segments.
+func (a *Archive) addSealedSegment(sequence uint64, index map[iotago.BlockID]int64) {
+	filter := newSegmentFilter(len(index))
+	for blockID := range index {
+		filter.add(blockID)
+	}
+	a.sealedSegments = append(a.sealedSegments, &sealedSegment{sequence: sequence, filter: filter})
+}
+
+// ArchiveMilestoneCone appends the given milestone cone to the active segment.
+func (a *Archive) ArchiveMilestoneCone(cone *pruning.MilestoneCone) error {
+	data, err := encodeMilestoneCone(cone)
+	if err != nil {
+		return errors.Wrapf(err, "encoding milestone cone %d failed", cone.Index)
+	}
+
+	compressed := a.encoder.EncodeAll(data, nil)
+
+	record := make([]byte, recordHeaderLength, recordHeaderLength+len(compressed))
+	binary.LittleEndian.PutUint32(record[:4], uint32(len(compressed)))
+	binary.LittleEndian.PutUint32(record[4:], crc32.Checksum(compressed, crc32Table))
+	record = append(record, compressed...)
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.closed {
+		return ErrArchiveClosed
+	}
+
+	if a.activeSize > fileHeaderLength && a.activeSize+int64(len(record)) > a.segmentSize {
+		if err := a.rotateSegmentWithoutLocking(); err != nil {
+			return errors.Wrapf(err, "sealing archive segment %d failed", a.activeSequence)
+		}
+	}
+
+	if _, err := a.activeFile.WriteAt(record, a.activeSize); err != nil {
+		// remove the partially written record
+		_ = a.activeFile.Truncate(a.activeSize)
+		return err
+	}
+
+	// the cone is deleted from the database afterwards, so it has to be persisted
+	if err := a.activeFile.Sync(); err != nil {
+		_ = a.activeFile.Truncate(a.activeSize)
+		return err
+	}
+
+	for _, block := range cone.Blocks {
+		a.activeIndex[block.BlockID] = a.activeSize
+	}
+	a.activeSize += int64(len(record))
+
+	return nil
+}
+
+// ArchivedBlock returns the serialized block and the serialized metadata of an archived block.
+// common.ErrBlockNotFound is returned if the block was not archived.
+func (a *Archive) ArchivedBlock(blockID iotago.BlockID) ([]byte, []byte, error) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	if a.closed {
+		return nil, nil, ErrArchiveClosed
+	}
+
+	if offset, exists := a.activeIndex[blockID]; exists {
+		return a.readBlock(a.activeFile, offset, blockID)
+	}
+
+	// the newest segments are searched first
+	for i := len(a.sealedSegments) - 1; i >= 0; i-- {
+		segment := a.sealedSegments[i]
+		if !segment.filter.mayContain(blockID) {
+			continue
+		}
+		sequence := segment.sequence
+
+		offset, found, err := searchIndexFile(indexFilePath(a.directory, sequence), blockID)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "searching index of archive segment %d failed", sequence)
+		}
+		if !found {
+			continue
+		}
+
+		f, err := os.Open(segmentFilePath(a.directory, sequence))
+		if err != nil {
+			return nil, nil, err
+		}
+		blockData, metadataData, err := a.readBlock(f, offset, blockID)
+		_ = f.Close()
+
+		return blockData, metadataData, err
+	}
+
+	return nil, nil, common.ErrBlockNotFound
+}
+
+// readBlock reads the block with the given ID from the record at the given offset.
+func (a *Archive) readBlock(f *os.File, offset int64, blockID iotago.BlockID) ([]byte, []byte, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	compressed, _, err := readRecord(f, offset, info.Size())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := a.decoder.DecodeAll(compressed, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blockData, metadataData, found, err := findBlockInRecord(data, blockID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !found {
+		return nil, nil, errors.Wrapf(ErrInvalidSegment, "block %s missing in record at offset %d", blockID.ToHex(), offset)
+	}
+
+	return blockData, metadataData, nil
+}
+
+// Close closes the active segment.
+// The active segment is continued the next time the archive is opened.
+func (a *Archive) Close() error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+
+	a.encoder.Close()
+	a.decoder.Close()
+
+	if a.activeFile == nil {
+		return nil
+	}
+
+	return a.activeFile.Close()
+}
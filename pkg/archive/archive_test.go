@@ -0,0 +1,187 @@
+package archive
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/hornet/pkg/common"
+	"github.com/iotaledger/hornet/pkg/pruning"
+	"github.com/iotaledger/hornet/pkg/tpkg"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func randMilestoneCone(index iotago.MilestoneIndex, blocksCount int) *pruning.MilestoneCone {
+	cone := &pruning.MilestoneCone{
+		Index: index,
+		Milestone: &iotago.Milestone{
+			Index:               index,
+			Timestamp:           tpkg.RandMilestoneTimestamp(),
+			PreviousMilestoneID: tpkg.RandMilestoneID(),
+			Parents:             iotago.BlockIDs{tpkg.RandBlockID()},
+		},
+	}
+
+	for i := 0; i < blocksCount; i++ {
+		cone.Blocks = append(cone.Blocks, &pruning.ArchivedBlock{
+			BlockID:  tpkg.RandBlockID(),
+			Data:     tpkg.RandBytes(200),
+			Metadata: tpkg.RandBytes(60),
+		})
+	}
+
+	return cone
+}
+
+func requireArchivedBlocks(t *testing.T, a *Archive, cones []*pruning.MilestoneCone) {
+	for _, cone := range cones {
+		for _, block := range cone.Blocks {
+			blockData, metadataData, err := a.ArchivedBlock(block.BlockID)
+			require.NoError(t, err)
+			require.Equal(t, block.Data, blockData)
+			require.Equal(t, block.Metadata, metadataData)
+		}
+	}
+}
+
+func TestArchive(t *testing.T) {
+
+	directory := t.TempDir()
+
+	// the random data can't be compressed, so every segment holds a few cones
+	a, err := Open(logger.NewNopLogger(), directory, 8*1024)
+	require.NoError(t, err)
+
+	var cones []*pruning.MilestoneCone
+	for i := iotago.MilestoneIndex(1); i <= 20; i++ {
+		cone := randMilestoneCone(i, 10)
+		require.NoError(t, a.ArchiveMilestoneCone(cone))
+		cones = append(cones, cone)
+	}
+
+	require.Greater(t, len(a.sealedSegments), 1)
+	requireArchivedBlocks(t, a, cones)
+	sealedSegments := len(a.sealedSegments)
+
+	_, _, err = a.ArchivedBlock(tpkg.RandBlockID())
+	require.ErrorIs(t, err, common.ErrBlockNotFound)
+
+	activeSequence := a.activeSequence
+	activeSize := a.activeSize
+	require.NoError(t, a.Close())
+
+	// simulate a partially written record at the end of the active segment
+	f, err := os.OpenFile(segmentFilePath(directory, activeSequence), os.O_APPEND|os.O_WRONLY, 0666)
+	require.NoError(t, err)
+	_, err = f.Write(tpkg.RandBytes(100))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	a, err = Open(logger.NewNopLogger(), directory, 8*1024)
+	require.NoError(t, err)
+	defer func() { _ = a.Close() }()
+
+	require.Equal(t, activeSequence, a.activeSequence)
+	require.Equal(t, activeSize, a.activeSize)
+	// the filters of the sealed segments are restored from the index files
+	require.Len(t, a.sealedSegments, sealedSegments)
+
+	cone := randMilestoneCone(21, 10)
+	require.NoError(t, a.ArchiveMilestoneCone(cone))
+	cones = append(cones, cone)
+
+	requireArchivedBlocks(t, a, cones)
+}
+
+func TestSegmentFilter(t *testing.T) {
+
+	blockIDs := make(iotago.BlockIDs, 1000)
+	filter := newSegmentFilter(len(blockIDs))
+	for i := range blockIDs {
+		blockIDs[i] = tpkg.RandBlockID()
+		filter.add(blockIDs[i])
+	}
+
+	for _, blockID := range blockIDs {
+		require.True(t, filter.mayContain(blockID))
+	}
+
+	// the segment doesn't need to be searched for most of the unknown blocks
+	falsePositives := 0
+	for i := 0; i < 1000; i++ {
+		if filter.mayContain(tpkg.RandBlockID()) {
+			falsePositives++
+		}
+	}
+	require.Less(t, falsePositives, 50)
+}
+
+func TestArchiveCorruptedRecord(t *testing.T) {
+
+	directory := t.TempDir()
+
+	a, err := Open(logger.NewNopLogger(), directory, 1024*1024)
+	require.NoError(t, err)
+
+	var cones []*pruning.MilestoneCone
+	for i := iotago.MilestoneIndex(1); i <= 3; i++ {
+		cone := randMilestoneCone(i, 10)
+		require.NoError(t, a.ArchiveMilestoneCone(cone))
+		cones = append(cones, cone)
+	}
+
+	activeSequence := a.activeSequence
+	activeSize := a.activeSize
+	corruptedOffset := a.activeIndex[cones[1].Blocks[0].BlockID]
+	require.NoError(t, a.Close())
+
+	// flip a byte in the record in the middle of the active segment
+	f, err := os.OpenFile(segmentFilePath(directory, activeSequence), os.O_RDWR, 0666)
+	require.NoError(t, err)
+	b := make([]byte, 1)
+	_, err = f.ReadAt(b, corruptedOffset+recordHeaderLength+10)
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte{^b[0]}, corruptedOffset+recordHeaderLength+10)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	a, err = Open(logger.NewNopLogger(), directory, 1024*1024)
+	require.NoError(t, err)
+
+	// the segment is not truncated at the corrupted record
+	require.Equal(t, activeSequence, a.activeSequence)
+	require.Equal(t, activeSize, a.activeSize)
+
+	cone := randMilestoneCone(4, 10)
+	require.NoError(t, a.ArchiveMilestoneCone(cone))
+	cones = append(cones, cone)
+
+	// the records after the corrupted one are kept
+	requireArchivedBlocks(t, a, []*pruning.MilestoneCone{cones[0], cones[2], cones[3]})
+
+	_, _, err = a.ArchivedBlock(cones[1].Blocks[0].BlockID)
+	require.ErrorIs(t, err, common.ErrBlockNotFound)
+
+	activeSize = a.activeSize
+	require.NoError(t, a.Close())
+
+	// the end of a corrupted segment is not truncated, the segment is sealed instead
+	f, err = os.OpenFile(segmentFilePath(directory, activeSequence), os.O_APPEND|os.O_WRONLY, 0666)
+	require.NoError(t, err)
+	_, err = f.Write(tpkg.RandBytes(100))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	a, err = Open(logger.NewNopLogger(), directory, 1024*1024)
+	require.NoError(t, err)
+	defer func() { _ = a.Close() }()
+
+	info, err := os.Stat(segmentFilePath(directory, activeSequence))
+	require.NoError(t, err)
+	require.Equal(t, activeSize+100, info.Size())
+	require.Equal(t, activeSequence+1, a.activeSequence)
+
+	requireArchivedBlocks(t, a, []*pruning.MilestoneCone{cones[0], cones[2], cones[3]})
+}
@@ -0,0 +1,62 @@
+package archive
+
+import (
+	"encoding/binary"
+
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// the amount of bits of the filter per block of a segment, which results in ~1% false positives.
+	filterBitsPerBlock = 10
+	// the amount of bits that are set per block.
+	filterHashCount = 7
+)
+
+// segmentFilter is a bloom filter over the block IDs of a sealed segment.
+// It is kept in memory, so the index files of segments that don't contain a block don't need to be searched.
+type segmentFilter struct {
+	bits []uint64
+}
+
+func newSegmentFilter(blocksCount int) *segmentFilter {
+	if blocksCount == 0 {
+		blocksCount = 1
+	}
+
+	return &segmentFilter{
+		bits: make([]uint64, (blocksCount*filterBitsPerBlock+63)/64),
+	}
+}
+
+// positions calls the given function with the bits of the block ID.
+// Block IDs are hashes, so the parts of the ID are used as the hash functions.
+func (f *segmentFilter) positions(blockID iotago.BlockID, consumer func(word int, mask uint64)) {
+	h1 := binary.LittleEndian.Uint64(blockID[:8])
+	h2 := binary.LittleEndian.Uint64(blockID[8:16])
+
+	bitsCount := uint64(len(f.bits)) * 64
+	for i := uint64(0); i < filterHashCount; i++ {
+		bit := (h1 + i*h2) % bitsCount
+		consumer(int(bit/64), 1<<(bit%64))
+	}
+}
+
+// add adds the block ID to the filter.
+func (f *segmentFilter) add(blockID iotago.BlockID) {
+	f.positions(blockID, func(word int, mask uint64) {
+		f.bits[word] |= mask
+	})
+}
+
+// mayContain returns false if the block ID was definitely not added to the filter.
+func (f *segmentFilter) mayContain(blockID iotago.BlockID) bool {
+	contained := true
+	f.positions(blockID, func(word int, mask uint64) {
+		if f.bits[word]&mask == 0 {
+			contained = false
+		}
+	})
+
+	return contained
+}
@@ -0,0 +1,414 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/serializer/v2"
+	"github.com/iotaledger/hornet/pkg/pruning"
+	"github.com/iotaledger/hornet/pkg/snapshot"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+// A segment file consists of a header followed by the records of the archived milestone cones:
+//	segment magic (4 bytes) | segment version (1 byte) | record... | record...
+//
+// Every record contains the zstd compressed data of a single milestone cone:
+//	compressed length (4 bytes) | crc32 checksum of the compressed data (4 bytes) | compressed data
+//
+// The uncompressed data of a record has the following layout:
+//	milestone index (4 bytes) | blocks count (4 bytes) | block... | diff type (1 byte) | diff length (4 bytes) | diff
+//
+// Every block is stored as:
+//	block ID (32 bytes) | block length (4 bytes) | block | metadata length (4 bytes) | metadata
+//
+// The diff is the milestone diff in the format of the snapshot files if the ledger diff was still available,
+// otherwise only the serialized milestone payload is stored.
+//
+// Once a segment is full, it is sealed by writing an index file that contains
+// the block IDs of the segment sorted in lexical order:
+//	index magic (4 bytes) | index version (1 byte) | entry... | entry...
+//
+// Every entry consists of:
+//	block ID (32 bytes) | offset of the record in the segment file (8 bytes)
+
+const (
+	// SegmentVersion defines the supported version of the segment files.
+	SegmentVersion byte = 1
+
+	segmentFileExtension = ".seg"
+	indexFileExtension   = ".idx"
+
+	// the length of the header of segment and index files.
+	fileHeaderLength = 4 + 1
+	// the length of the header of a record.
+	recordHeaderLength = 4 + 4
+	// the length of an entry of an index file.
+	indexEntryLength = iotago.BlockIDLength + 8
+
+	diffTypeMilestone     byte = 0
+	diffTypeMilestoneDiff byte = 1
+)
+
+var (
+	// the magic bytes that identify a segment file.
+	segmentMagic = [4]byte{'H', 'A', 'R', 'C'}
+	// the magic bytes that identify an index file.
+	indexMagic = [4]byte{'H', 'I', 'D', 'X'}
+
+	crc32Table = crc32.MakeTable(crc32.Castagnoli)
+)
+
+var (
+	// ErrInvalidSegment is returned if a segment or index file is malformed.
+	ErrInvalidSegment = errors.New("invalid archive segment")
+)
+
+// indexEntry points to the record of an archived block.
+type indexEntry struct {
+	blockID iotago.BlockID
+	offset  int64
+}
+
+// segmentFilePath returns the path of the segment file with the given sequence number.
+func segmentFilePath(directory string, sequence uint64) string {
+	return filepath.Join(directory, fmt.Sprintf("%08d%s", sequence, segmentFileExtension))
+}
+
+// indexFilePath returns the path of the index file of the segment with the given sequence number.
+func indexFilePath(directory string, sequence uint64) string {
+	return filepath.Join(directory, fmt.Sprintf("%08d%s", sequence, indexFileExtension))
+}
+
+func writeFileHeader(w io.Writer, magic [4]byte) error {
+	_, err := w.Write(append(magic[:], SegmentVersion))
+	return err
+}
+
+func readFileHeader(r io.ReaderAt, magic [4]byte) error {
+	header := make([]byte, fileHeaderLength)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return errors.Wrap(ErrInvalidSegment, "file header missing")
+	}
+
+	if !bytes.Equal(header[:4], magic[:]) {
+		return errors.Wrap(ErrInvalidSegment, "wrong magic bytes")
+	}
+
+	if header[4] != SegmentVersion {
+		return errors.Wrapf(ErrInvalidSegment, "unsupported version %d", header[4])
+	}
+
+	return nil
+}
+
+// encodeMilestoneCone serializes the uncompressed data of a record.
+func encodeMilestoneCone(cone *pruning.MilestoneCone) ([]byte, error) {
+	var b bytes.Buffer
+
+	if err := binary.Write(&b, binary.LittleEndian, cone.Index); err != nil {
+		return nil, err
+	}
+
+	if err := binary.Write(&b, binary.LittleEndian, uint32(len(cone.Blocks))); err != nil {
+		return nil, err
+	}
+
+	for _, block := range cone.Blocks {
+		if _, err := b.Write(block.BlockID[:]); err != nil {
+			return nil, err
+		}
+		if err := writeBytes(&b, block.Data); err != nil {
+			return nil, err
+		}
+		if err := writeBytes(&b, block.Metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	diffType := diffTypeMilestone
+	var diffBytes []byte
+
+	if cone.Diff != nil {
+		diffType = diffTypeMilestoneDiff
+
+		msDiff := &snapshot.MilestoneDiff{
+			Milestone:           cone.Milestone,
+			Created:             cone.Diff.Outputs,
+			Consumed:            cone.Diff.Spents,
+			SpentTreasuryOutput: cone.Diff.SpentTreasuryOutput,
+		}
+
+		var err error
+		if diffBytes, err = msDiff.MarshalBinary(); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if diffBytes, err = cone.Milestone.Serialize(serializer.DeSeriModeNoValidation, nil); err != nil {
+			return nil, fmt.Errorf("unable to serialize milestone %d: %w", cone.Index, err)
+		}
+	}
+
+	if err := b.WriteByte(diffType); err != nil {
+		return nil, err
+	}
+	if err := writeBytes(&b, diffBytes); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// findBlockInRecord searches the block with the given ID in the uncompressed data of a record.
+func findBlockInRecord(data []byte, blockID iotago.BlockID) (blockData []byte, metadataData []byte, found bool, err error) {
+	reader := bytes.NewReader(data)
+
+	var msIndex iotago.MilestoneIndex
+	if err := binary.Read(reader, binary.LittleEndian, &msIndex); err != nil {
+		return nil, nil, false, errors.Wrap(ErrInvalidSegment, "milestone index missing")
+	}
+
+	var blocksCount uint32
+	if err := binary.Read(reader, binary.LittleEndian, &blocksCount); err != nil {
+		return nil, nil, false, errors.Wrap(ErrInvalidSegment, "blocks count missing")
+	}
+
+	for i := uint32(0); i < blocksCount; i++ {
+		var recordBlockID iotago.BlockID
+		if _, err := io.ReadFull(reader, recordBlockID[:]); err != nil {
+			return nil, nil, false, errors.Wrapf(ErrInvalidSegment, "block %d of milestone %d truncated", i, msIndex)
+		}
+
+		blockData, err := readBytes(reader)
+		if err != nil {
+			return nil, nil, false, errors.Wrapf(ErrInvalidSegment, "block %d of milestone %d truncated", i, msIndex)
+		}
+
+		metadataData, err := readBytes(reader)
+		if err != nil {
+			return nil, nil, false, errors.Wrapf(ErrInvalidSegment, "metadata of block %d of milestone %d truncated", i, msIndex)
+		}
+
+		if recordBlockID == blockID {
+			return blockData, metadataData, true, nil
+		}
+	}
+
+	return nil, nil, false, nil
+}
+
+// blockIDsInRecord returns the IDs of the blocks in the uncompressed data of a record.
+func blockIDsInRecord(data []byte) (iotago.BlockIDs, error) {
+	reader := bytes.NewReader(data)
+
+	if _, err := reader.Seek(serializer.UInt32ByteSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var blocksCount uint32
+	if err := binary.Read(reader, binary.LittleEndian, &blocksCount); err != nil {
+		return nil, errors.Wrap(ErrInvalidSegment, "blocks count missing")
+	}
+
+	blockIDs := make(iotago.BlockIDs, 0, blocksCount)
+	for i := uint32(0); i < blocksCount; i++ {
+		var blockID iotago.BlockID
+		if _, err := io.ReadFull(reader, blockID[:]); err != nil {
+			return nil, errors.Wrapf(ErrInvalidSegment, "block %d truncated", i)
+		}
+
+		// skip the block and the metadata
+		for j := 0; j < 2; j++ {
+			var length uint32
+			if err := binary.Read(reader, binary.LittleEndian, &length); err != nil {
+				return nil, errors.Wrapf(ErrInvalidSegment, "block %d truncated", i)
+			}
+			if _, err := reader.Seek(int64(length), io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+
+		blockIDs = append(blockIDs, blockID)
+	}
+
+	return blockIDs, nil
+}
+
+func writeBytes(w io.Writer, data []byte) error {
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func readBytes(reader *bytes.Reader) ([]byte, error) {
+	var length uint32
+	if err := binary.Read(reader, binary.LittleEndian, &length); err != nil {
+		return nil, err
+	}
+
+	if int64(length) > int64(reader.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// readRecord reads and verifies the compressed data of the record at the given offset of a segment with the given size.
+// It returns the compressed data and the length of the whole record.
+// If the checksum doesn't match, ErrInvalidSegment is returned together with the length of the whole record.
+func readRecord(r io.ReaderAt, offset int64, segmentSize int64) ([]byte, int64, error) {
+	header := make([]byte, recordHeaderLength)
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return nil, 0, err
+	}
+
+	length := binary.LittleEndian.Uint32(header[:4])
+	checksum := binary.LittleEndian.Uint32(header[4:])
+
+	if offset+recordHeaderLength+int64(length) > segmentSize {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+
+	compressed := make([]byte, length)
+	if _, err := r.ReadAt(compressed, offset+recordHeaderLength); err != nil {
+		return nil, 0, err
+	}
+
+	if crc32.Checksum(compressed, crc32Table) != checksum {
+		return nil, recordHeaderLength + int64(length), errors.Wrapf(ErrInvalidSegment, "checksum mismatch of record at offset %d", offset)
+	}
+
+	return compressed, recordHeaderLength + int64(length), nil
+}
+
+// writeIndexFile writes the sorted index of a segment.
+// The index is written to a temporary file first, so that a segment is only sealed if the index is complete.
+func writeIndexFile(filePath string, entries []indexEntry) error {
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].blockID[:], entries[j].blockID[:]) < 0
+	})
+
+	tempFilePath := filePath + ".tmp"
+
+	f, err := os.OpenFile(tempFilePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	if err := writeFileHeader(&b, indexMagic); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	entryBytes := make([]byte, indexEntryLength)
+	for _, entry := range entries {
+		copy(entryBytes, entry.blockID[:])
+		binary.LittleEndian.PutUint64(entryBytes[iotago.BlockIDLength:], uint64(entry.offset))
+		b.Write(entryBytes)
+	}
+
+	if _, err := f.Write(b.Bytes()); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFilePath, filePath)
+}
+
+// searchIndexFile searches the offset of the record of the given block in a sorted index file.
+func searchIndexFile(filePath string, blockID iotago.BlockID) (int64, bool, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, false, err
+	}
+	defer func() { _ = f.Close() }()
+
+	if err := readFileHeader(f, indexMagic); err != nil {
+		return 0, false, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, false, err
+	}
+
+	entriesCount := int((info.Size() - fileHeaderLength) / indexEntryLength)
+
+	var searchErr error
+	entry := make([]byte, indexEntryLength)
+	readEntry := func(i int) []byte {
+		if _, err := f.ReadAt(entry, fileHeaderLength+int64(i)*indexEntryLength); err != nil && searchErr == nil {
+			searchErr = err
+		}
+		return entry
+	}
+
+	i := sort.Search(entriesCount, func(i int) bool {
+		return bytes.Compare(readEntry(i)[:iotago.BlockIDLength], blockID[:]) >= 0
+	})
+	if searchErr != nil {
+		return 0, false, searchErr
+	}
+
+	if i >= entriesCount {
+		return 0, false, nil
+	}
+
+	if entry = readEntry(i); searchErr != nil || !bytes.Equal(entry[:iotago.BlockIDLength], blockID[:]) {
+		return 0, false, searchErr
+	}
+
+	return int64(binary.LittleEndian.Uint64(entry[iotago.BlockIDLength:])), true, nil
+}
+
+// readIndexFilter reads all entries of an index file into a segmentFilter.
+func readIndexFilter(filePath string) (*segmentFilter, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := readFileHeader(bytes.NewReader(data), indexMagic); err != nil {
+		return nil, err
+	}
+
+	entries := data[fileHeaderLength:]
+	if len(entries)%indexEntryLength != 0 {
+		return nil, errors.Wrap(ErrInvalidSegment, "index file truncated")
+	}
+
+	filter := newSegmentFilter(len(entries) / indexEntryLength)
+	for offset := 0; offset < len(entries); offset += indexEntryLength {
+		var blockID iotago.BlockID
+		copy(blockID[:], entries[offset:offset+iotago.BlockIDLength])
+		filter.add(blockID)
+	}
+
+	return filter, nil
+}
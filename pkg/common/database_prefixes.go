@@ -9,5 +9,6 @@ const (
 	StorePrefixChildren           byte = 6
 	StorePrefixUnreferencedBlocks byte = 7
 	StorePrefixProtocol           byte = 8
+	StorePrefixMilestoneConeSizes byte = 9
 	StorePrefixHealth             byte = 255
 )
@@ -5,6 +5,7 @@ package daemon
 
 const (
 	PriorityCloseDatabase   = iota // no dependencies
+	PriorityCloseArchive           // triggered by PriorityMessageProcessor, PriorityPruning, PriorityRestAPI, PriorityIndexer
 	PriorityFlushToDatabase        // depends on PriorityCloseDatabase
 	PriorityDatabaseHealth
 	PriorityTipselection        // depends on PriorityFlushToDatabase, triggered by PriorityReceiveTxWorker, PriorityMilestoneSolidifier
@@ -16,6 +17,7 @@ const (
 	PriorityPeerGossipProtocolWrite
 	PriorityPeerGossipProtocolRead
 	PriorityGossipService
+	PrioritySnapshotExchange
 	PriorityRequestsProcessor // depends on PriorityGossipService
 	PriorityBroadcastQueue    // depends on PriorityGossipService
 	PriorityP2PManager
@@ -0,0 +1,62 @@
+package dag
+
+import (
+	"context"
+	"sort"
+
+	"github.com/iotaledger/hornet/pkg/model/storage"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+// MilestoneConeBlockIDs returns the IDs of all blocks referenced by the milestone with the given index and parents in white flag order.
+func MilestoneConeBlockIDs(ctx context.Context, parentsTraverserStorage ParentsTraverserStorage, msIndex iotago.MilestoneIndex, parents iotago.BlockIDs) (iotago.BlockIDs, error) {
+
+	type coneBlock struct {
+		blockID iotago.BlockID
+		wfIndex uint32
+	}
+
+	coneBlocks := make([]coneBlock, 0)
+	if err := TraverseParents(
+		ctx,
+		parentsTraverserStorage,
+		parents,
+		// traversal stops if no more blocks pass the given condition
+		// Caution: condition func is not in DFS order
+		func(cachedBlockMeta *storage.CachedMetadata) (bool, error) { // meta +1
+			defer cachedBlockMeta.Release(true) // meta -1
+			if referenced, at := cachedBlockMeta.Metadata().ReferencedWithIndex(); referenced {
+				if at < msIndex {
+					return false, nil
+				}
+			}
+			return true, nil
+		},
+		// consumer
+		func(cachedBlockMeta *storage.CachedMetadata) error { // meta +1
+			defer cachedBlockMeta.Release(true) // meta -1
+			_, _, wfIndex := cachedBlockMeta.Metadata().ReferencedWithIndexAndWhiteFlagIndex()
+			coneBlocks = append(coneBlocks, coneBlock{blockID: cachedBlockMeta.Metadata().BlockID(), wfIndex: wfIndex})
+			return nil
+		},
+		// called on missing parents
+		// return error on missing parents
+		nil,
+		// called on solid entry points
+		// Ignore solid entry points (snapshot milestone included)
+		nil,
+		false); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(coneBlocks, func(i, j int) bool {
+		return coneBlocks[i].wfIndex < coneBlocks[j].wfIndex
+	})
+
+	blockIDs := make(iotago.BlockIDs, len(coneBlocks))
+	for i, coneBlock := range coneBlocks {
+		blockIDs[i] = coneBlock.blockID
+	}
+
+	return blockIDs, nil
+}
@@ -0,0 +1,40 @@
+package dag_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hornet/pkg/dag"
+	"github.com/iotaledger/hornet/pkg/testsuite"
+	"github.com/iotaledger/hornet/pkg/whiteflag"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func TestMilestoneConeBlockIDs(t *testing.T) {
+
+	te := testsuite.SetupTestEnvironment(t, &iotago.Ed25519Address{}, 0, ProtocolVersion, BelowMaxDepth, MinPoWScore, false)
+	defer te.CleanupTestEnvironment(true)
+
+	confirmations := make(map[iotago.MilestoneIndex]*whiteflag.Confirmation)
+
+	_, _ = te.BuildTangle(10, BelowMaxDepth, 10, 10, 50,
+		nil,
+		func(blockIDs iotago.BlockIDs, blockIDsPerMilestones []iotago.BlockIDs) iotago.BlockIDs {
+			return iotago.BlockIDs{blockIDs[len(blockIDs)-1]}
+		},
+		func(msIndex iotago.MilestoneIndex, _ iotago.BlockIDs, confirmation *whiteflag.Confirmation, _ *whiteflag.ConfirmedMilestoneStats) {
+			confirmations[msIndex] = confirmation
+		},
+	)
+	require.NotEmpty(t, confirmations)
+
+	for msIndex, confirmation := range confirmations {
+		blockIDs, err := dag.MilestoneConeBlockIDs(context.Background(), te.Storage(), msIndex, confirmation.MilestoneParents)
+		require.NoError(t, err)
+
+		// the blocks are returned in the order they were applied by white flag
+		require.Equal(t, confirmation.Mutations.ReferencedBlocks.BlockIDs(), blockIDs, "milestone %d", msIndex)
+	}
+}
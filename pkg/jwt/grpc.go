@@ -0,0 +1,126 @@
+package jwt
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// GRPCMetadataAuthorization is the gRPC metadata key which contains the JWT of a client ("Bearer <token>").
+	GRPCMetadataAuthorization = "authorization"
+
+	bearerPrefix = "bearer "
+)
+
+// Errors
+var (
+	ErrGRPCMissingToken = status.Error(codes.Unauthenticated, "missing jwt")
+	ErrGRPCInvalidToken = status.Error(codes.Unauthenticated, "invalid jwt")
+	ErrGRPCAccessDenied = status.Error(codes.PermissionDenied, "jwt does not grant access")
+)
+
+// tokenFromGRPCContext returns the JWT sent in the metadata of a gRPC request.
+func tokenFromGRPCContext(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", ErrGRPCMissingToken
+	}
+
+	values := md.Get(GRPCMetadataAuthorization)
+	if len(values) == 0 || len(values[0]) == 0 {
+		return "", ErrGRPCMissingToken
+	}
+
+	if len(values[0]) <= len(bearerPrefix) || !strings.EqualFold(values[0][:len(bearerPrefix)], bearerPrefix) {
+		return "", ErrGRPCInvalidToken
+	}
+
+	return values[0][len(bearerPrefix):], nil
+}
+
+// authorizeGRPC verifies the JWT of a gRPC request and checks whether its claims are allowed to call the method.
+// Returns the claims of the JWT.
+func (j *JWTAuth) authorizeGRPC(ctx context.Context, allow func(claims *AuthClaims) bool) (*AuthClaims, error) {
+	token, err := tokenFromGRPCContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var denied bool
+	var verifiedClaims *AuthClaims
+	if !j.VerifyJWT(token, func(claims *AuthClaims) bool {
+		if !claims.VerifySubject(j.subject) {
+			return false
+		}
+		if !allow(claims) {
+			denied = true
+			return false
+		}
+		verifiedClaims = claims
+		return true
+	}) {
+		if denied {
+			return nil, ErrGRPCAccessDenied
+		}
+		return nil, ErrGRPCInvalidToken
+	}
+
+	return verifiedClaims, nil
+}
+
+// authorizedServerStream is a grpc.ServerStream which checks the claims of the JWT of the stream again
+// before every message it sends, so that streams are closed once the JWT expired or is not allowed anymore.
+type authorizedServerStream struct {
+	grpc.ServerStream
+	claims *AuthClaims
+	allow  func(claims *AuthClaims) bool
+}
+
+func (s *authorizedServerStream) SendMsg(m interface{}) error {
+	if err := s.claims.Valid(); err != nil {
+		return ErrGRPCInvalidToken
+	}
+	if !s.allow(s.claims) {
+		return ErrGRPCAccessDenied
+	}
+
+	return s.ServerStream.SendMsg(m)
+}
+
+// UnaryServerInterceptor returns a gRPC interceptor which only lets unary calls through
+// if they carry a valid JWT whose claims are allowed to call the method with the given request.
+func (j *JWTAuth) UnaryServerInterceptor(allow func(fullMethod string, req interface{}, claims *AuthClaims) bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if _, err := j.authorizeGRPC(ctx, func(claims *AuthClaims) bool {
+			return allow(info.FullMethod, req, claims)
+		}); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a gRPC interceptor which only lets streams through
+// if they carry a valid JWT whose claims are allowed to call the method.
+// The claims are checked again before every message sent on the stream,
+// so the stream fails once the JWT expired or its claims are not allowed anymore, e.g. because it was revoked.
+func (j *JWTAuth) StreamServerInterceptor(allow func(fullMethod string, claims *AuthClaims) bool) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		allowStream := func(claims *AuthClaims) bool {
+			return allow(info.FullMethod, claims)
+		}
+
+		claims, err := j.authorizeGRPC(ss.Context(), allowStream)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authorizedServerStream{ServerStream: ss, claims: claims, allow: allowStream})
+	}
+}
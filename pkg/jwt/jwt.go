@@ -1,9 +1,13 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
+	"encoding/hex"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -19,6 +23,18 @@ var (
 	ErrJWTInvalidClaims = echo.NewHTTPError(http.StatusUnauthorized, "invalid jwt claims")
 )
 
+const (
+	// ScopeAll is the scope that grants access to all routes.
+	ScopeAll = "*"
+	// ScopeINXPrefix is the prefix of the scopes of INX tokens.
+	ScopeINXPrefix = "inx:"
+
+	// the length of the random token IDs in bytes.
+	tokenIDLength = 16
+	// the prefix of the revocation IDs of tokens issued before the token IDs were random.
+	legacyRevocationIDPrefix = "sha256:"
+)
+
 type JWTAuth struct {
 	subject        string
 	sessionTimeout time.Duration
@@ -47,6 +63,42 @@ func NewJWTAuth(subject string, sessionTimeout time.Duration, nodeID string, sec
 
 type AuthClaims struct {
 	jwt.StandardClaims
+	// The scopes the token grants access to, a trailing * matches all scopes with the same prefix.
+	// Tokens without scopes were issued before scopes existed and grant access to all routes.
+	Scopes []string `json:"scopes,omitempty"`
+
+	// the encoded token the claims were parsed from.
+	raw string
+}
+
+// RevocationID returns the ID the token is revoked by.
+// Tokens issued before scopes existed use the unix timestamp they were issued at as ID, which is not unique,
+// so they are revoked by the hash of the encoded token instead.
+func (c *AuthClaims) RevocationID() string {
+	if c.Scopes != nil || len(c.raw) == 0 {
+		return c.Id
+	}
+
+	hash := sha256.Sum256([]byte(c.raw))
+	return legacyRevocationIDPrefix + hex.EncodeToString(hash[:])
+}
+
+// IsRandomTokenID tells whether the given token ID was randomly generated,
+// so that it is unique and a token can be revoked by it.
+func IsRandomTokenID(tokenID string) bool {
+	decoded, err := hex.DecodeString(tokenID)
+	return err == nil && len(decoded) == tokenIDLength
+}
+
+// ParseClaimsUnverified parses the claims of the given encoded token without verifying its signature.
+func ParseClaimsUnverified(token string) (*AuthClaims, error) {
+	claims := &AuthClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(token, claims); err != nil {
+		return nil, err
+	}
+	claims.raw = token
+
+	return claims, nil
 }
 
 func (c *AuthClaims) compare(field string, expected string) bool {
@@ -64,6 +116,24 @@ func (c *AuthClaims) VerifySubject(expected string) bool {
 	return c.compare(c.Subject, expected)
 }
 
+// HasScope tells whether the token grants access to the given scope.
+func (c *AuthClaims) HasScope(scope string) bool {
+	if c.Scopes == nil {
+		return true
+	}
+
+	for _, grantedScope := range c.Scopes {
+		if grantedScope == scope {
+			return true
+		}
+		if strings.HasSuffix(grantedScope, "*") && strings.HasPrefix(scope, strings.TrimSuffix(grantedScope, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (j *JWTAuth) Middleware(skipper middleware.Skipper, allow func(c echo.Context, subject string, claims *AuthClaims) bool) echo.MiddlewareFunc {
 
 	config := middleware.JWTConfig{
@@ -105,6 +175,7 @@ func (j *JWTAuth) Middleware(skipper middleware.Skipper, allow func(c echo.Conte
 			if !ok || !claims.VerifyAudience(j.nodeID, true) {
 				return ErrJWTInvalidClaims
 			}
+			claims.raw = token.Raw
 
 			// validate claims
 			if !allow(c, j.subject, claims) {
@@ -118,6 +189,22 @@ func (j *JWTAuth) Middleware(skipper middleware.Skipper, allow func(c echo.Conte
 }
 
 func (j *JWTAuth) IssueJWT() (string, error) {
+	token, _, err := j.IssueScopedJWT([]string{ScopeAll}, j.sessionTimeout)
+	return token, err
+}
+
+// IssueScopedJWT issues a token which grants access to the given scopes and returns it together with its claims.
+// The token does not expire if the given expiry is zero.
+func (j *JWTAuth) IssueScopedJWT(scopes []string, expiry time.Duration) (string, *AuthClaims, error) {
+
+	if len(scopes) == 0 {
+		return "", nil, errors.New("scopes must not be empty")
+	}
+
+	tokenID := make([]byte, tokenIDLength)
+	if _, err := rand.Read(tokenID); err != nil {
+		return "", nil, fmt.Errorf("unable to create token ID: %w", err)
+	}
 
 	now := time.Now()
 
@@ -126,24 +213,30 @@ func (j *JWTAuth) IssueJWT() (string, error) {
 		Subject:   j.subject,
 		Issuer:    j.nodeID,
 		Audience:  j.nodeID,
-		Id:        fmt.Sprintf("%d", now.Unix()),
+		Id:        hex.EncodeToString(tokenID),
 		IssuedAt:  now.Unix(),
 		NotBefore: now.Unix(),
 	}
 
-	if j.sessionTimeout > 0 {
-		stdClaims.ExpiresAt = now.Add(j.sessionTimeout).Unix()
+	if expiry > 0 {
+		stdClaims.ExpiresAt = now.Add(expiry).Unix()
 	}
 
 	claims := &AuthClaims{
 		StandardClaims: stdClaims,
+		Scopes:         scopes,
 	}
 
 	// Create token
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Generate encoded token and send it as response.
-	return token.SignedString(j.secret)
+	signedToken, err := token.SignedString(j.secret)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return signedToken, claims, nil
 }
 
 func (j *JWTAuth) VerifyJWT(token string, allow func(claims *AuthClaims) bool) bool {
@@ -162,6 +255,7 @@ func (j *JWTAuth) VerifyJWT(token string, allow func(claims *AuthClaims) bool) b
 		if !ok || !claims.VerifyAudience(j.nodeID, true) {
 			return false
 		}
+		claims.raw = token
 
 		// validate claims
 		if !allow(claims) {
@@ -0,0 +1,210 @@
+package jwt
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	jwtlib "github.com/golang-jwt/jwt"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestScopedJWT(t *testing.T) {
+	sk, _, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+	require.NoError(t, err)
+
+	jwtAuth, err := NewJWTAuth("HORNET", 0, "node", sk)
+	require.NoError(t, err)
+
+	token, claims, err := jwtAuth.IssueScopedJWT([]string{"read", "control:*"}, time.Hour)
+	require.NoError(t, err)
+	require.NotEmpty(t, claims.Id)
+	require.NotZero(t, claims.ExpiresAt)
+
+	var verifiedClaims *AuthClaims
+	require.True(t, jwtAuth.VerifyJWT(token, func(claims *AuthClaims) bool {
+		verifiedClaims = claims
+		return claims.VerifySubject("HORNET")
+	}))
+	require.Equal(t, claims.Id, verifiedClaims.Id)
+
+	require.True(t, verifiedClaims.HasScope("read"))
+	require.True(t, verifiedClaims.HasScope("control:database"))
+	require.False(t, verifiedClaims.HasScope("peers:write"))
+	require.False(t, verifiedClaims.HasScope("submit-blocks"))
+
+	// tokens issued before scopes existed grant access to all routes
+	require.True(t, (&AuthClaims{}).HasScope("peers:write"))
+	require.True(t, (&AuthClaims{Scopes: []string{ScopeAll}}).HasScope("peers:write"))
+
+	_, _, err = jwtAuth.IssueScopedJWT(nil, 0)
+	require.Error(t, err)
+}
+
+func TestRevocationList(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), RevokedTokensFileName)
+
+	revocationList, err := NewRevocationList(filePath)
+	require.NoError(t, err)
+
+	require.NoError(t, revocationList.Revoke("expired", time.Now().Add(-time.Minute)))
+	require.NoError(t, revocationList.Revoke("valid", time.Now().Add(time.Hour)))
+	require.NoError(t, revocationList.Revoke("forever", time.Time{}))
+	require.Error(t, revocationList.Revoke("", time.Time{}))
+
+	require.True(t, revocationList.IsRevoked("valid"))
+	require.False(t, revocationList.IsRevoked("unknown"))
+
+	// the revoked tokens are loaded from the file and expired tokens are removed
+	revocationList, err = NewRevocationList(filePath)
+	require.NoError(t, err)
+
+	require.False(t, revocationList.IsRevoked("expired"))
+	require.True(t, revocationList.IsRevoked("valid"))
+	require.True(t, revocationList.IsRevoked("forever"))
+	require.Len(t, revocationList.RevokedTokens(), 2)
+}
+
+func TestGRPCInterceptors(t *testing.T) {
+	sk, _, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+	require.NoError(t, err)
+
+	jwtAuth, err := NewJWTAuth("HORNET-INX", 0, "node", sk)
+	require.NoError(t, err)
+
+	otherAuth, err := NewJWTAuth("HORNET", 0, "node", sk)
+	require.NoError(t, err)
+
+	readToken, _, err := jwtAuth.IssueScopedJWT([]string{"inx:read"}, time.Hour)
+	require.NoError(t, err)
+
+	otherToken, _, err := otherAuth.IssueScopedJWT([]string{ScopeAll}, time.Hour)
+	require.NoError(t, err)
+
+	interceptor := jwtAuth.UnaryServerInterceptor(func(fullMethod string, _ interface{}, claims *AuthClaims) bool {
+		return claims.HasScope(fullMethod)
+	})
+
+	call := func(md metadata.MD, fullMethod string) error {
+		ctx := context.Background()
+		if md != nil {
+			ctx = metadata.NewIncomingContext(ctx, md)
+		}
+
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: fullMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+		return err
+	}
+
+	require.NoError(t, call(metadata.Pairs(GRPCMetadataAuthorization, "Bearer "+readToken), "inx:read"))
+	require.ErrorIs(t, call(metadata.Pairs(GRPCMetadataAuthorization, "Bearer "+readToken), "inx:ledger"), ErrGRPCAccessDenied)
+	require.ErrorIs(t, call(nil, "inx:read"), ErrGRPCMissingToken)
+	require.ErrorIs(t, call(metadata.Pairs(GRPCMetadataAuthorization, readToken), "inx:read"), ErrGRPCInvalidToken)
+
+	// tokens with a different subject are rejected
+	require.ErrorIs(t, call(metadata.Pairs(GRPCMetadataAuthorization, "Bearer "+otherToken), "inx:read"), ErrGRPCInvalidToken)
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent int
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testServerStream) SendMsg(_ interface{}) error {
+	s.sent++
+	return nil
+}
+
+func TestGRPCStreamInterceptorRevocation(t *testing.T) {
+	sk, _, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+	require.NoError(t, err)
+
+	jwtAuth, err := NewJWTAuth("HORNET-INX", 0, "node", sk)
+	require.NoError(t, err)
+
+	token, claims, err := jwtAuth.IssueScopedJWT([]string{"inx:read"}, time.Hour)
+	require.NoError(t, err)
+
+	revocationList, err := NewRevocationList(filepath.Join(t.TempDir(), RevokedTokensFileName))
+	require.NoError(t, err)
+
+	interceptor := jwtAuth.StreamServerInterceptor(func(fullMethod string, claims *AuthClaims) bool {
+		return !revocationList.IsRevoked(claims.Id) && claims.HasScope(fullMethod)
+	})
+
+	ss := &testServerStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(GRPCMetadataAuthorization, "Bearer "+token))}
+	err = interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "inx:read"}, func(_ interface{}, stream grpc.ServerStream) error {
+		require.NoError(t, stream.SendMsg(nil))
+
+		// the stream fails once the token is revoked
+		require.NoError(t, revocationList.Revoke(claims.Id, time.Unix(claims.ExpiresAt, 0)))
+
+		return stream.SendMsg(nil)
+	})
+	require.ErrorIs(t, err, ErrGRPCAccessDenied)
+	require.Equal(t, 1, ss.sent)
+}
+
+func TestRevocationID(t *testing.T) {
+	sk, _, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+	require.NoError(t, err)
+
+	jwtAuth, err := NewJWTAuth("HORNET", 0, "node", sk)
+	require.NoError(t, err)
+
+	// tokens with random IDs are revoked by their ID
+	token, claims, err := jwtAuth.IssueScopedJWT([]string{"read"}, time.Hour)
+	require.NoError(t, err)
+	require.True(t, IsRandomTokenID(claims.Id))
+
+	parsedClaims, err := ParseClaimsUnverified(token)
+	require.NoError(t, err)
+	require.Equal(t, claims.Id, parsedClaims.RevocationID())
+	require.Equal(t, claims.ExpiresAt, parsedClaims.ExpiresAt)
+
+	// tokens issued before scopes existed use the time they were issued at as ID,
+	// so they are revoked by the hash of the token instead
+	issueLegacyToken := func(subject string) string {
+		legacyToken, err := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, &AuthClaims{
+			StandardClaims: jwtlib.StandardClaims{
+				Subject:  subject,
+				Audience: "node",
+				Id:       "1600000000",
+				IssuedAt: 1600000000,
+			},
+		}).SignedString(jwtAuth.secret)
+		require.NoError(t, err)
+
+		return legacyToken
+	}
+
+	require.False(t, IsRandomTokenID("1600000000"))
+
+	legacyToken := issueLegacyToken("HORNET")
+	legacyClaims, err := ParseClaimsUnverified(legacyToken)
+	require.NoError(t, err)
+	require.NotEqual(t, legacyClaims.Id, legacyClaims.RevocationID())
+
+	var verifiedClaims *AuthClaims
+	require.True(t, jwtAuth.VerifyJWT(legacyToken, func(claims *AuthClaims) bool {
+		verifiedClaims = claims
+		return true
+	}))
+	require.Equal(t, legacyClaims.RevocationID(), verifiedClaims.RevocationID())
+
+	// tokens issued in the same second have different revocation IDs
+	otherClaims, err := ParseClaimsUnverified(issueLegacyToken("other"))
+	require.NoError(t, err)
+	require.Equal(t, legacyClaims.Id, otherClaims.Id)
+	require.NotEqual(t, legacyClaims.RevocationID(), otherClaims.RevocationID())
+}
@@ -0,0 +1,178 @@
+package jwt
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/ioutils"
+)
+
+const (
+	// RevokedTokensFileName is the name of the file the revoked tokens are persisted in.
+	RevokedTokensFileName = "revoked_tokens.json"
+)
+
+// RevokedToken is a token which is not accepted anymore.
+type RevokedToken struct {
+	// The ID of the token.
+	ID string
+	// The time the token expires, zero if it never expires.
+	ExpiresAt time.Time
+}
+
+// revokedTokensFile is the content of the file the revoked tokens are persisted in.
+type revokedTokensFile struct {
+	// The unix timestamps at which the revoked tokens expire by their ID, zero if the token never expires.
+	Tokens map[string]int64 `json:"tokens"`
+}
+
+// RevocationList keeps track of the revoked tokens.
+// Tokens are removed from the list after they expired, since they are rejected anyway.
+type RevocationList struct {
+	filePath string
+
+	revokedLock sync.RWMutex
+	revoked     map[string]time.Time
+}
+
+// NewRevocationList creates a new RevocationList which persists the revoked tokens in the file at the given path.
+func NewRevocationList(filePath string) (*RevocationList, error) {
+	r := &RevocationList{
+		filePath: filePath,
+		revoked:  make(map[string]time.Time),
+	}
+
+	exists, err := ioutils.PathExists(filePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check revoked tokens file %s", filePath)
+	}
+
+	if exists {
+		file := &revokedTokensFile{}
+		if err := ioutils.ReadJSONFromFile(filePath, file); err != nil {
+			return nil, errors.Wrap(err, "failed to read revoked tokens")
+		}
+
+		for tokenID, expiresAtUnix := range file.Tokens {
+			var expiresAt time.Time
+			if expiresAtUnix != 0 {
+				expiresAt = time.Unix(expiresAtUnix, 0)
+			}
+			r.revoked[tokenID] = expiresAt
+		}
+	}
+
+	r.revokedLock.Lock()
+	defer r.revokedLock.Unlock()
+
+	if r.cleanupExpiredWithoutLocking() {
+		if err := r.storeWithoutLocking(); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// removes the tokens which expired from the list.
+// returns whether any token was removed.
+// revokedLock must be held.
+func (r *RevocationList) cleanupExpiredWithoutLocking() bool {
+	var removed bool
+
+	now := time.Now()
+	for tokenID, expiresAt := range r.revoked {
+		if expiresAt.IsZero() || expiresAt.After(now) {
+			continue
+		}
+
+		delete(r.revoked, tokenID)
+		removed = true
+	}
+
+	return removed
+}
+
+// persists the list in the file.
+// the file is replaced at once, so that the list is not lost if the node crashes while writing it.
+// revokedLock must be held.
+func (r *RevocationList) storeWithoutLocking() error {
+	file := &revokedTokensFile{
+		Tokens: make(map[string]int64, len(r.revoked)),
+	}
+
+	for tokenID, expiresAt := range r.revoked {
+		var expiresAtUnix int64
+		if !expiresAt.IsZero() {
+			expiresAtUnix = expiresAt.Unix()
+		}
+		file.Tokens[tokenID] = expiresAtUnix
+	}
+
+	tempFilePath := r.filePath + "_tmp"
+	if err := ioutils.WriteJSONToFile(tempFilePath, file, 0600); err != nil {
+		return errors.Wrap(err, "failed to store revoked tokens")
+	}
+
+	if err := os.Rename(tempFilePath, r.filePath); err != nil {
+		return errors.Wrap(err, "failed to store revoked tokens")
+	}
+
+	return nil
+}
+
+// Revoke adds the token with the given ID to the list.
+// The expiry of the token is used to remove it from the list after it expired, zero means that it never expires.
+func (r *RevocationList) Revoke(tokenID string, expiresAt time.Time) error {
+	if len(tokenID) == 0 {
+		return errors.New("token ID must not be empty")
+	}
+
+	r.revokedLock.Lock()
+	defer r.revokedLock.Unlock()
+
+	r.cleanupExpiredWithoutLocking()
+
+	previousExpiresAt, wasRevoked := r.revoked[tokenID]
+	r.revoked[tokenID] = expiresAt
+
+	if err := r.storeWithoutLocking(); err != nil {
+		// keep the list in sync with the file
+		if wasRevoked {
+			r.revoked[tokenID] = previousExpiresAt
+		} else {
+			delete(r.revoked, tokenID)
+		}
+		return errors.Wrapf(err, "failed to revoke token %s", tokenID)
+	}
+
+	return nil
+}
+
+// IsRevoked tells whether the token with the given revocation ID was revoked.
+func (r *RevocationList) IsRevoked(tokenID string) bool {
+	r.revokedLock.RLock()
+	defer r.revokedLock.RUnlock()
+
+	_, revoked := r.revoked[tokenID]
+	return revoked
+}
+
+// RevokedTokens returns the tokens in the list.
+func (r *RevocationList) RevokedTokens() []*RevokedToken {
+	r.revokedLock.RLock()
+	defer r.revokedLock.RUnlock()
+
+	revokedTokens := make([]*RevokedToken, 0, len(r.revoked))
+	for tokenID, expiresAt := range r.revoked {
+		revokedTokens = append(revokedTokens, &RevokedToken{
+			ID:        tokenID,
+			ExpiresAt: expiresAt,
+		})
+	}
+
+	return revokedTokens
+}
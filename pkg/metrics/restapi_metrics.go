@@ -23,6 +23,12 @@ type RestAPIMetrics struct {
 	HTTPRequestErrorCounter atomic.Uint32
 	// The total number of completed PoW requests.
 	PoWCompletedCounter atomic.Uint32
+	// The total number of read requests rejected by the rate limits.
+	RateLimitedReadsCounter atomic.Uint32
+	// The total number of block submissions rejected by the rate limits.
+	RateLimitedBlocksCounter atomic.Uint32
+	// The total number of PoW requests rejected by the rate limits.
+	RateLimitedPoWCounter atomic.Uint32
 
 	Events *RestAPIEvents
 }
@@ -40,6 +40,10 @@ type ServerMetrics struct {
 	SentHeartbeats atomic.Uint32
 	// The number of dropped packets.
 	DroppedPackets atomic.Uint32
+	// The number of bytes sent to all peers.
+	SentBytes atomic.Uint64
+	// The number of bytes received from all peers.
+	ReceivedBytes atomic.Uint64
 	// The number of sent spam blocks.
 	SentSpamBlocks atomic.Uint32
 	// The number of non-lazy tips.
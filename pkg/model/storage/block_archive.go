@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hornet/pkg/common"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+// BlockArchive is a read-only store of blocks that were pruned from the database.
+type BlockArchive interface {
+	// ArchivedBlock returns the serialized block and the serialized metadata of an archived block.
+	// common.ErrBlockNotFound is returned if the block was not archived.
+	ArchivedBlock(blockID iotago.BlockID) (blockData []byte, metadataData []byte, err error)
+}
+
+// SetBlockArchive sets the archive that is used to look up blocks that were pruned from the database.
+func (s *Storage) SetBlockArchive(blockArchive BlockArchive) {
+	s.blockArchive = blockArchive
+}
+
+// ArchivedBlockOrNil returns the block and its metadata from the archive, or nil if the block was not archived.
+func (s *Storage) ArchivedBlockOrNil(blockID iotago.BlockID) (*Block, *BlockMetadata, error) {
+	if s.blockArchive == nil {
+		return nil, nil, nil
+	}
+
+	blockData, metadataData, err := s.blockArchive.ArchivedBlock(blockID)
+	if err != nil {
+		if errors.Is(err, common.ErrBlockNotFound) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	block, err := BlockFactory(blockID[:], blockData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metadata, err := MetadataFactory(blockID[:], metadataData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return block.(*Block), metadata.(*BlockMetadata), nil
+}
+
+// BlockOrArchivedOrNil returns the block from the database or from the archive, or nil if the block doesn't exist.
+func (s *Storage) BlockOrArchivedOrNil(blockID iotago.BlockID) (*Block, error) {
+	cachedBlock := s.CachedBlockOrNil(blockID) // block +1
+	if cachedBlock != nil {
+		defer cachedBlock.Release(true) // block -1
+		return cachedBlock.Block(), nil
+	}
+
+	block, _, err := s.ArchivedBlockOrNil(blockID)
+	return block, err
+}
+
+// BlockMetadataOrArchivedOrNil returns the block metadata from the database or from the archive, or nil if the block doesn't exist.
+// The metadata of archived blocks is a detached copy that is not stored in the database.
+func (s *Storage) BlockMetadataOrArchivedOrNil(blockID iotago.BlockID) (*BlockMetadata, error) {
+	cachedBlockMeta := s.CachedBlockMetadataOrNil(blockID) // meta +1
+	if cachedBlockMeta != nil {
+		defer cachedBlockMeta.Release(true) // meta -1
+		return cachedBlockMeta.Metadata(), nil
+	}
+
+	_, metadata, err := s.ArchivedBlockOrNil(blockID)
+	return metadata, err
+}
@@ -396,6 +396,11 @@ func (s *Storage) DeleteBlock(blockID iotago.BlockID) {
 	s.blocksStorage.Delete(blockID[:])
 }
 
+// DeleteBlockBody deletes the block in the cache/persistence layer, but keeps its metadata.
+func (s *Storage) DeleteBlockBody(blockID iotago.BlockID) {
+	s.blocksStorage.Delete(blockID[:])
+}
+
 // DeleteBlockMetadata deletes the metadata in the cache/persistence layer.
 func (s *Storage) DeleteBlockMetadata(blockID iotago.BlockID) {
 	s.metadataStorage.Delete(blockID[:])
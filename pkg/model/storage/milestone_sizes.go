@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/marshalutil"
+	"github.com/iotaledger/hive.go/serializer/v2"
+	"github.com/iotaledger/hornet/pkg/common"
+	"github.com/iotaledger/hornet/pkg/model/utxo"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+// MilestoneConeSize contains the approximate amount of bytes that were written to the database for a milestone cone.
+type MilestoneConeSize struct {
+	// The index of the milestone.
+	Index iotago.MilestoneIndex
+	// The bytes of the block bodies of the cone.
+	Blocks uint64
+	// The bytes of the block metadata of the cone.
+	Metadata uint64
+	// The bytes of the references to the children of the blocks of the cone.
+	Children uint64
+	// The bytes of the milestone payload.
+	Milestones uint64
+	// The bytes of the milestone diff, the created outputs and the spents.
+	LedgerDiffs uint64
+}
+
+// Total returns the total amount of bytes of the milestone cone.
+func (s *MilestoneConeSize) Total() uint64 {
+	return s.Blocks + s.Metadata + s.Children + s.Milestones + s.LedgerDiffs
+}
+
+// BytesOf returns the amount of bytes of the given pruning class in the milestone cone.
+func (s *MilestoneConeSize) BytesOf(class PruningClass) uint64 {
+	switch class {
+	case PruningClassBlocks:
+		return s.Blocks
+	case PruningClassMetadata:
+		return s.Metadata
+	case PruningClassChildren:
+		return s.Children
+	case PruningClassMilestones:
+		return s.Milestones
+	case PruningClassLedgerDiffs:
+		return s.LedgerDiffs
+	default:
+		return 0
+	}
+}
+
+func (s *MilestoneConeSize) bytes() []byte {
+	m := marshalutil.New(5 * 8)
+	m.WriteUint64(s.Blocks)
+	m.WriteUint64(s.Metadata)
+	m.WriteUint64(s.Children)
+	m.WriteUint64(s.Milestones)
+	m.WriteUint64(s.LedgerDiffs)
+	return m.Bytes()
+}
+
+func milestoneConeSizeFromBytes(index iotago.MilestoneIndex, data []byte) (*MilestoneConeSize, error) {
+	m := marshalutil.New(data)
+
+	size := &MilestoneConeSize{Index: index}
+	for _, field := range []*uint64{&size.Blocks, &size.Metadata, &size.Children, &size.Milestones, &size.LedgerDiffs} {
+		value, err := m.ReadUint64()
+		if err != nil {
+			return nil, err
+		}
+		*field = value
+	}
+
+	return size, nil
+}
+
+func (s *Storage) configureMilestoneConeSizesStore(store kvstore.KVStore) error {
+	milestoneConeSizesStore, err := store.WithRealm([]byte{common.StorePrefixMilestoneConeSizes})
+	if err != nil {
+		return err
+	}
+
+	s.milestoneConeSizesStore = milestoneConeSizesStore
+	return nil
+}
+
+// StoreMilestoneConeSize stores the size of a milestone cone.
+func (s *Storage) StoreMilestoneConeSize(size *MilestoneConeSize) error {
+	if err := s.milestoneConeSizesStore.Set(databaseKeyForMilestoneIndex(size.Index), size.bytes()); err != nil {
+		return errors.Wrap(NewDatabaseError(err), "failed to store milestone cone size")
+	}
+
+	return nil
+}
+
+// MilestoneConeSize returns the size of a milestone cone, or nil if the size is unknown.
+func (s *Storage) MilestoneConeSize(index iotago.MilestoneIndex) (*MilestoneConeSize, error) {
+	data, err := s.milestoneConeSizesStore.Get(databaseKeyForMilestoneIndex(index))
+	if err != nil {
+		if !errors.Is(err, kvstore.ErrKeyNotFound) {
+			return nil, errors.Wrap(NewDatabaseError(err), "failed to retrieve milestone cone size")
+		}
+		return nil, nil
+	}
+
+	size, err := milestoneConeSizeFromBytes(index, data)
+	if err != nil {
+		return nil, errors.Wrap(NewDatabaseError(err), "failed to deserialize milestone cone size")
+	}
+
+	return size, nil
+}
+
+// ForEachMilestoneConeSize loops over the known sizes of the milestone cones in the database.
+// The sizes are not ordered by milestone index.
+func (s *Storage) ForEachMilestoneConeSize(consumer func(size *MilestoneConeSize) bool) error {
+
+	var innerErr error
+	if err := s.milestoneConeSizesStore.Iterate(kvstore.EmptyPrefix, func(key kvstore.Key, value kvstore.Value) bool {
+		size, err := milestoneConeSizeFromBytes(milestoneIndexFromDatabaseKey(key), value)
+		if err != nil {
+			innerErr = err
+			return false
+		}
+
+		return consumer(size)
+	}); err != nil {
+		return errors.Wrap(NewDatabaseError(err), "failed to iterate milestone cone sizes")
+	}
+
+	if innerErr != nil {
+		return errors.Wrap(NewDatabaseError(innerErr), "failed to deserialize milestone cone size")
+	}
+
+	return nil
+}
+
+// MilestoneConeSizes returns the known sizes of the milestone cones in the database, ordered by milestone index.
+func (s *Storage) MilestoneConeSizes() ([]*MilestoneConeSize, error) {
+	var sizes []*MilestoneConeSize
+
+	if err := s.ForEachMilestoneConeSize(func(size *MilestoneConeSize) bool {
+		sizes = append(sizes, size)
+		return true
+	}); err != nil {
+		return nil, err
+	}
+
+	// the keys are little endian encoded, so the iteration order doesn't match the order of the milestone indexes
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i].Index < sizes[j].Index })
+
+	return sizes, nil
+}
+
+// DeleteMilestoneConeSize deletes the size of a milestone cone.
+func (s *Storage) DeleteMilestoneConeSize(index iotago.MilestoneIndex) {
+	_ = s.milestoneConeSizesStore.Delete(databaseKeyForMilestoneIndex(index))
+}
+
+const (
+	// the size of the key of a block or block metadata in the database.
+	blockKeySize = 1 + iotago.BlockIDLength
+	// the size of a reference to a child in the database.
+	childrenEntrySize = 1 + iotago.BlockIDLength + iotago.BlockIDLength
+	// the size of the milestone index entry in the database.
+	milestoneIndexEntrySize = 1 + serializer.UInt32ByteSize + iotago.MilestoneIDLength
+	// the size of the milestone diff entry in the database without the output IDs.
+	milestoneDiffEntrySize = 1 + serializer.UInt32ByteSize + 2*serializer.UInt32ByteSize + 1
+)
+
+// AddBlock adds the approximate database size of the given block to the size of the milestone cone.
+func (s *MilestoneConeSize) AddBlock(block *Block, metadata *BlockMetadata) {
+	s.Blocks += uint64(blockKeySize + len(block.Data()))
+	s.Metadata += uint64(blockKeySize + len(metadata.ObjectStorageValue()))
+	s.Children += uint64(len(metadata.Parents()) * childrenEntrySize)
+}
+
+// AddMilestone adds the approximate database size of the given milestone to the size of the milestone cone.
+func (s *MilestoneConeSize) AddMilestone(milestone *Milestone) {
+	s.Milestones += uint64(1+len(milestone.ObjectStorageKey())+len(milestone.Data())) + milestoneIndexEntrySize
+}
+
+// AddLedgerChanges adds the approximate database size of the given ledger changes to the size of the milestone cone.
+func (s *MilestoneConeSize) AddLedgerChanges(newOutputs utxo.Outputs, newSpents utxo.Spents) {
+	s.LedgerDiffs += milestoneDiffEntrySize
+
+	for _, output := range newOutputs {
+		s.LedgerDiffs += uint64(len(output.KVStorableKey())+len(output.KVStorableValue())) + iotago.OutputIDLength
+	}
+
+	for _, spent := range newSpents {
+		s.LedgerDiffs += uint64(len(spent.KVStorableKey())+len(spent.KVStorableValue())) + iotago.OutputIDLength
+	}
+}
@@ -8,6 +8,58 @@ import (
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
+// PruningClass is a class of data in the database that is pruned with its own retention.
+type PruningClass byte
+
+const (
+	// PruningClassBlocks are the block bodies and the unreferenced blocks.
+	PruningClassBlocks PruningClass = iota
+	// PruningClassMetadata is the metadata of the blocks.
+	PruningClassMetadata
+	// PruningClassChildren are the references from blocks to their children.
+	PruningClassChildren
+	// PruningClassMilestones are the milestone payloads.
+	PruningClassMilestones
+	// PruningClassLedgerDiffs are the milestone diffs and the spent outputs of the ledger.
+	PruningClassLedgerDiffs
+	// PruningClassReceipts are the receipts of the legacy migration.
+	PruningClassReceipts
+	// PruningClassProtocolParams are the protocol parameters milestone options.
+	PruningClassProtocolParams
+
+	pruningClassCount = int(PruningClassProtocolParams) + 1
+)
+
+// PruningClasses are all classes of data that are pruned.
+var PruningClasses = []PruningClass{
+	PruningClassBlocks,
+	PruningClassMetadata,
+	PruningClassChildren,
+	PruningClassMilestones,
+	PruningClassLedgerDiffs,
+	PruningClassReceipts,
+	PruningClassProtocolParams,
+}
+
+// maps the pruning class to its name.
+var pruningClassNames = map[PruningClass]string{
+	PruningClassBlocks:         "blocks",
+	PruningClassMetadata:       "metadata",
+	PruningClassChildren:       "children",
+	PruningClassMilestones:     "milestones",
+	PruningClassLedgerDiffs:    "ledgerDiffs",
+	PruningClassReceipts:       "receipts",
+	PruningClassProtocolParams: "protocolParameters",
+}
+
+// String returns the name of the pruning class.
+func (c PruningClass) String() string {
+	if name, exists := pruningClassNames[c]; exists {
+		return name
+	}
+	return fmt.Sprintf("unknown (%d)", byte(c))
+}
+
 type SnapshotInfo struct {
 	// The index of the genesis milestone of the network.
 	genesisMilestoneIndex iotago.MilestoneIndex
@@ -20,6 +72,9 @@ type SnapshotInfo struct {
 	pruningIndex iotago.MilestoneIndex
 	// The timestamp of the target milestone of the snapshot.
 	snapshotTimestamp time.Time
+	// The indexes of the milestones before which the data of the pruning classes is pruned.
+	// Classes with a longer retention are pruned less than the tangle history.
+	classPruningIndexes [pruningClassCount]iotago.MilestoneIndex
 }
 
 // The index of the genesis milestone of the network.
@@ -48,6 +103,22 @@ func (i *SnapshotInfo) SnapshotTimestamp() time.Time {
 	return i.snapshotTimestamp
 }
 
+// PruningIndexOf returns the index of the milestone before which the data of the given class is pruned.
+// It is never bigger than the PruningIndex.
+func (i *SnapshotInfo) PruningIndexOf(class PruningClass) iotago.MilestoneIndex {
+	if int(class) >= pruningClassCount {
+		return i.pruningIndex
+	}
+	return i.classPruningIndexes[class]
+}
+
+// setPruningIndexOfAllClasses sets the pruning index of all classes to the given index.
+func (i *SnapshotInfo) setPruningIndexOfAllClasses(pruningIndex iotago.MilestoneIndex) {
+	for class := range i.classPruningIndexes {
+		i.classPruningIndexes[class] = pruningIndex
+	}
+}
+
 func (i *SnapshotInfo) Deserialize(data []byte, _ serializer.DeSerializationMode, _ interface{}) (int, error) {
 
 	var (
@@ -84,12 +155,26 @@ func (i *SnapshotInfo) Deserialize(data []byte, _ serializer.DeSerializationMode
 	i.entryPointIndex = entryPointIndex
 	i.pruningIndex = pruningIndex
 	i.snapshotTimestamp = time.Unix(int64(snapshotTimestamp), 0)
+	i.setPruningIndexOfAllClasses(pruningIndex)
 
-	return offset, nil
+	// the pruning indexes of the classes were added later, older databases don't contain them.
+	if len(data)-offset < pruningClassCount*serializer.UInt32ByteSize {
+		return offset, nil
+	}
+
+	deserializer := serializer.NewDeserializer(data[offset:])
+	for class := range i.classPruningIndexes {
+		deserializer.ReadNum(&i.classPruningIndexes[class], func(err error) error {
+			return fmt.Errorf("unable to deserialize pruning index of class %s: %w", PruningClass(class), err)
+		})
+	}
+	classesOffset, err := deserializer.Done()
+
+	return offset + classesOffset, err
 }
 
 func (i *SnapshotInfo) Serialize(_ serializer.DeSerializationMode, _ interface{}) ([]byte, error) {
-	return serializer.NewSerializer().
+	ser := serializer.NewSerializer().
 		WriteNum(i.genesisMilestoneIndex, func(err error) error {
 			return fmt.Errorf("unable to serialize genesis milestone index: %w", err)
 		}).
@@ -104,8 +189,15 @@ func (i *SnapshotInfo) Serialize(_ serializer.DeSerializationMode, _ interface{}
 		}).
 		WriteNum(uint32(i.snapshotTimestamp.Unix()), func(err error) error {
 			return fmt.Errorf("unable to serialize timestamp: %w", err)
-		}).
-		Serialize()
+		})
+
+	for class, pruningIndex := range i.classPruningIndexes {
+		ser.WriteNum(pruningIndex, func(err error) error {
+			return fmt.Errorf("unable to serialize pruning index of class %s: %w", PruningClass(class), err)
+		})
+	}
+
+	return ser.Serialize()
 }
 
 func (s *Storage) loadSnapshotInfo() error {
@@ -132,6 +224,9 @@ func (s *Storage) PrintSnapshotInfo() {
     EntryPointIndex: %d
     PruningIndex: %d
     Timestamp: %v`, s.snapshot.genesisMilestoneIndex, s.snapshot.snapshotIndex, s.snapshot.entryPointIndex, s.snapshot.pruningIndex, s.snapshot.snapshotTimestamp.Truncate(time.Second)))
+		for _, class := range PruningClasses {
+			println(fmt.Sprintf("    PruningIndex (%s): %d", class, s.snapshot.PruningIndexOf(class)))
+		}
 	}
 }
 
@@ -146,6 +241,7 @@ func (s *Storage) SetInitialSnapshotInfo(genesisMilestoneIndex iotago.MilestoneI
 		pruningIndex:          pruningIndex,
 		snapshotTimestamp:     snapshotTimestamp,
 	}
+	s.snapshot.setPruningIndexOfAllClasses(pruningIndex)
 
 	return s.storeSnapshotInfo(s.snapshot)
 }
@@ -158,6 +254,7 @@ func (s *Storage) UpdateSnapshotInfo(snapshotIndex iotago.MilestoneIndex, entryP
 	s.snapshot.entryPointIndex = entryPointIndex
 	s.snapshot.pruningIndex = pruningIndex
 	s.snapshot.snapshotTimestamp = snapshotTimestamp
+	s.snapshot.setPruningIndexOfAllClasses(pruningIndex)
 
 	return s.storeSnapshotInfo(s.snapshot)
 }
@@ -190,6 +287,25 @@ func (s *Storage) SetPruningIndex(pruningIndex iotago.MilestoneIndex) error {
 	return s.storeSnapshotInfo(s.snapshot)
 }
 
+// SetPruningIndexesOfClasses sets the pruning indexes of the given classes.
+// The pruning index of a class can't exceed the pruning index of the tangle history.
+func (s *Storage) SetPruningIndexesOfClasses(pruningIndexes map[PruningClass]iotago.MilestoneIndex) error {
+	s.snapshotMutex.Lock()
+	defer s.snapshotMutex.Unlock()
+
+	for class, pruningIndex := range pruningIndexes {
+		if int(class) >= pruningClassCount {
+			return fmt.Errorf("unknown pruning class: %d", class)
+		}
+		if pruningIndex > s.snapshot.pruningIndex {
+			pruningIndex = s.snapshot.pruningIndex
+		}
+		s.snapshot.classPruningIndexes[class] = pruningIndex
+	}
+
+	return s.storeSnapshotInfo(s.snapshot)
+}
+
 func (s *Storage) SnapshotInfo() *SnapshotInfo {
 	s.snapshotMutex.RLock()
 	defer s.snapshotMutex.RUnlock()
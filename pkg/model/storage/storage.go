@@ -88,8 +88,9 @@ type Storage struct {
 	utxoStore   kvstore.KVStore
 
 	// kv storages
-	protocolStore kvstore.KVStore
-	snapshotStore kvstore.KVStore
+	protocolStore           kvstore.KVStore
+	snapshotStore           kvstore.KVStore
+	milestoneConeSizesStore kvstore.KVStore
 
 	// healthTrackers
 	healthTrackers []*StoreHealthTracker
@@ -113,6 +114,9 @@ type Storage struct {
 	// utxo
 	utxoManager *utxo.Manager
 
+	// the archive of pruned blocks, nil if no archive is used
+	blockArchive BlockArchive
+
 	// events
 	Events *packageEvents
 }
@@ -391,6 +395,10 @@ func (s *Storage) configureStorages(tangleStore kvstore.KVStore, cachesProfile .
 		return err
 	}
 
+	if err := s.configureMilestoneConeSizesStore(tangleStore); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -0,0 +1,47 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/hornet/pkg/model/storage"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func TestMilestoneConeSizes(t *testing.T) {
+
+	dbStorage, err := storage.New(mapdb.NewMapDB(), mapdb.NewMapDB())
+	require.NoError(t, err)
+
+	// the little endian keys of these indexes are not ordered
+	for _, index := range []iotago.MilestoneIndex{256, 1, 2, 512} {
+		require.NoError(t, dbStorage.StoreMilestoneConeSize(&storage.MilestoneConeSize{
+			Index:       index,
+			Blocks:      uint64(index),
+			Metadata:    1,
+			Children:    2,
+			Milestones:  3,
+			LedgerDiffs: 4,
+		}))
+	}
+
+	size, err := dbStorage.MilestoneConeSize(256)
+	require.NoError(t, err)
+	require.EqualValues(t, 256+1+2+3+4, size.Total())
+
+	dbStorage.DeleteMilestoneConeSize(2)
+
+	size, err = dbStorage.MilestoneConeSize(2)
+	require.NoError(t, err)
+	require.Nil(t, size)
+
+	sizes, err := dbStorage.MilestoneConeSizes()
+	require.NoError(t, err)
+	require.Len(t, sizes, 3)
+	for i, index := range []iotago.MilestoneIndex{1, 256, 512} {
+		require.Equal(t, index, sizes[i].Index)
+		require.EqualValues(t, index, sizes[i].Blocks)
+	}
+}
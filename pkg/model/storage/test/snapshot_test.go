@@ -0,0 +1,48 @@
+package storage_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/serializer/v2"
+	"github.com/iotaledger/hornet/pkg/model/storage"
+)
+
+func TestSnapshotInfoPruningIndexes(t *testing.T) {
+
+	// the snapshot info before the pruning indexes of the classes were added
+	legacyData := make([]byte, 5*serializer.UInt32ByteSize)
+	binary.LittleEndian.PutUint32(legacyData[0:], 1)
+	binary.LittleEndian.PutUint32(legacyData[4:], 1000)
+	binary.LittleEndian.PutUint32(legacyData[8:], 900)
+	binary.LittleEndian.PutUint32(legacyData[12:], 800)
+	binary.LittleEndian.PutUint32(legacyData[16:], 1234567)
+
+	info := &storage.SnapshotInfo{}
+	offset, err := info.Deserialize(legacyData, serializer.DeSeriModePerformValidation, nil)
+	require.NoError(t, err)
+	require.Equal(t, len(legacyData), offset)
+	require.EqualValues(t, 800, info.PruningIndex())
+
+	// all classes are pruned up to the pruning index in legacy databases
+	for _, class := range storage.PruningClasses {
+		require.EqualValues(t, 800, info.PruningIndexOf(class))
+	}
+
+	data, err := info.Serialize(serializer.DeSeriModePerformValidation, nil)
+	require.NoError(t, err)
+	require.Equal(t, legacyData, data[:len(legacyData)])
+
+	// lower the pruning index of the milestones to check the round trip
+	binary.LittleEndian.PutUint32(data[len(legacyData)+int(storage.PruningClassMilestones)*serializer.UInt32ByteSize:], 100)
+
+	restored := &storage.SnapshotInfo{}
+	offset, err = restored.Deserialize(data, serializer.DeSeriModePerformValidation, nil)
+	require.NoError(t, err)
+	require.Equal(t, len(data), offset)
+	require.EqualValues(t, 800, restored.PruningIndex())
+	require.EqualValues(t, 100, restored.PruningIndexOf(storage.PruningClassMilestones))
+	require.EqualValues(t, 800, restored.PruningIndexOf(storage.PruningClassBlocks))
+}
@@ -16,6 +16,15 @@ const (
 	// UTXOStoreKeyPrefixTreasuryOutput defines the prefix for the Treasury Output
 	UTXOStoreKeyPrefixTreasuryOutput byte = 5
 	UTXOStoreKeyPrefixReceipts       byte = 6
+
+	// UTXOStoreKeyPrefixOutputIndex defines the prefix for the optional secondary index of unspent outputs
+	UTXOStoreKeyPrefixOutputIndex        byte = 7
+	UTXOStoreKeyPrefixOutputIndexEnabled byte = 8
+
+	// UTXOStoreKeyPrefixLedgerStateCommitment defines the prefix for the commitment of the current ledger state
+	UTXOStoreKeyPrefixLedgerStateCommitment byte = 9
+	// UTXOStoreKeyPrefixMilestoneLedgerStateCommitment defines the prefix for the ledger state commitment digests per milestone
+	UTXOStoreKeyPrefixMilestoneLedgerStateCommitment byte = 10
 )
 
 /*
@@ -90,4 +99,42 @@ const (
    Value:
        Receipt (iotago.ReceiptMilestoneOpt.Serialized())
                 1 byte type + X bytes
+
+   Output Index:
+   =============
+   Key:
+       UTXOStoreKeyPrefixOutputIndex + OutputIndexType + indexed key (address, alias ID, NFT ID or foundry ID) + iotago.OutputID
+                   1 byte            +     1 byte      +                       X bytes                          +     34 bytes
+
+   Value:
+       Empty
+
+   Output Index Enabled:
+   =====================
+   Key:
+       UTXOStoreKeyPrefixOutputIndexEnabled
+                     1 byte
+
+   Value:
+       Empty
+
+   Ledger State Commitment:
+   ========================
+   Key:
+       UTXOStoreKeyPrefixLedgerStateCommitment
+                     1 byte
+
+   Value:
+       LtHash lanes
+       1024 * 2 bytes
+
+   Milestone Ledger State Commitment:
+   ==================================
+   Key:
+       UTXOStoreKeyPrefixMilestoneLedgerStateCommitment + iotago.MilestoneIndex
+                          1 byte                        +     4 bytes
+
+   Value:
+       Digest (BLAKE2b-256 of the LtHash lanes)
+                    32 bytes
 */
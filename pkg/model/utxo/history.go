@@ -0,0 +1,288 @@
+package utxo
+
+import (
+	"bytes"
+	"sort"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// the default amount of milestone diffs that are kept in memory for historical ledger queries.
+	defaultHistoryDiffCacheSize = 1000
+)
+
+var (
+	// ErrHistoricalStateUnavailable is returned if the ledger state at a given milestone index can't be reconstructed,
+	// because it is newer than the ledger index or the needed milestone diffs were already pruned.
+	ErrHistoricalStateUnavailable = errors.New("ledger state at the given milestone index is not available")
+)
+
+// ConfigureHistoryDiffCache sets the amount of milestone diffs that are kept in memory for historical ledger queries.
+func (u *Manager) ConfigureHistoryDiffCache(size int) error {
+	historyDiffCache, err := lru.New(size)
+	if err != nil {
+		return err
+	}
+
+	u.WriteLockLedger()
+	defer u.WriteUnlockLedger()
+
+	u.historyDiffCache = historyDiffCache
+
+	return nil
+}
+
+// cachedMilestoneDiffWithoutLocking returns the milestone diff for the given index.
+// Milestone diffs don't change until they are pruned or rolled back,
+// so they are kept in the cache to speed up walking the diffs for subsequent historical queries.
+func (u *Manager) cachedMilestoneDiffWithoutLocking(msIndex iotago.MilestoneIndex) (*MilestoneDiff, error) {
+	if diff, ok := u.historyDiffCache.Get(msIndex); ok {
+		return diff.(*MilestoneDiff), nil
+	}
+
+	diff, err := u.MilestoneDiffWithoutLocking(msIndex)
+	if err != nil {
+		return nil, err
+	}
+	u.historyDiffCache.Add(msIndex, diff)
+
+	return diff, nil
+}
+
+// HistoricalStateAvailableWithoutLocking checks whether the ledger state at the given milestone index can be reconstructed
+// and returns the current ledger index.
+// The state is available if all milestone diffs between the given index and the ledger index still exist.
+func (u *Manager) HistoricalStateAvailableWithoutLocking(msIndex iotago.MilestoneIndex) (iotago.MilestoneIndex, error) {
+	ledgerIndex, err := u.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return 0, err
+	}
+
+	if msIndex > ledgerIndex {
+		return 0, ErrHistoricalStateUnavailable
+	}
+
+	if msIndex == ledgerIndex {
+		return ledgerIndex, nil
+	}
+
+	// diffs are pruned from the oldest to the newest, so it is sufficient to check the oldest needed diff.
+	diffExists, err := u.utxoStorage.Has(milestoneDiffKeyForIndex(msIndex + 1))
+	if err != nil {
+		return 0, err
+	}
+	if !diffExists {
+		return 0, ErrHistoricalStateUnavailable
+	}
+
+	return ledgerIndex, nil
+}
+
+// ReadOutputAtIndexWithoutLocking returns the output with the given ID as it was known to the ledger at the given milestone index.
+// If the output was already spent at that index, the spent is returned as well, otherwise the spent is nil.
+// kvstore.ErrKeyNotFound is returned if the output didn't exist yet at that index or if it was pruned.
+func (u *Manager) ReadOutputAtIndexWithoutLocking(outputID iotago.OutputID, msIndex iotago.MilestoneIndex) (*Output, *Spent, error) {
+	if _, err := u.HistoricalStateAvailableWithoutLocking(msIndex); err != nil {
+		return nil, nil, err
+	}
+
+	// the output and spent entries contain the milestone indexes of their creation and consumption,
+	// so there is no need to walk the diffs for a single output.
+	isUnspent, err := u.IsOutputIDUnspentWithoutLocking(outputID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if isUnspent {
+		output, err := u.ReadOutputByOutputIDWithoutLocking(outputID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if output.MilestoneIndexBooked() > msIndex {
+			return nil, nil, kvstore.ErrKeyNotFound
+		}
+
+		return output, nil, nil
+	}
+
+	spent, err := u.ReadSpentForOutputIDWithoutLocking(outputID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if spent.Output().MilestoneIndexBooked() > msIndex {
+		return nil, nil, kvstore.ErrKeyNotFound
+	}
+
+	if spent.MilestoneIndexSpent() > msIndex {
+		// the output was still unspent at the given index
+		return spent.Output(), nil, nil
+	}
+
+	return spent.Output(), spent, nil
+}
+
+// ReadOutputAtIndex returns the output with the given ID as it was known to the ledger at the given milestone index.
+// If the output was already spent at that index, the spent is returned as well, otherwise the spent is nil.
+func (u *Manager) ReadOutputAtIndex(outputID iotago.OutputID, msIndex iotago.MilestoneIndex) (*Output, *Spent, error) {
+	u.ReadLockLedger()
+	defer u.ReadUnlockLedger()
+
+	return u.ReadOutputAtIndexWithoutLocking(outputID, msIndex)
+}
+
+// changesAfterIndexWithoutLocking walks the milestone diffs backwards from the ledger index to the given index.
+// It returns the IDs of the outputs created after the given index, which are not part of the historical state,
+// and the outputs spent after the given index, which are unspent in the historical state.
+func (u *Manager) changesAfterIndexWithoutLocking(msIndex iotago.MilestoneIndex) (map[iotago.OutputID]struct{}, Outputs, error) {
+	ledgerIndex, err := u.HistoricalStateAvailableWithoutLocking(msIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	createdAfter := make(map[iotago.OutputID]struct{})
+	var spentAfter Outputs
+
+	for index := ledgerIndex; index > msIndex; index-- {
+		diff, err := u.cachedMilestoneDiffWithoutLocking(index)
+		if err != nil {
+			if errors.Is(err, kvstore.ErrKeyNotFound) {
+				return nil, nil, ErrHistoricalStateUnavailable
+			}
+			return nil, nil, err
+		}
+
+		for _, output := range diff.Outputs {
+			createdAfter[output.OutputID()] = struct{}{}
+		}
+		for _, spent := range diff.Spents {
+			spentAfter = append(spentAfter, spent.Output())
+		}
+	}
+
+	return createdAfter, spentAfter, nil
+}
+
+// ForEachUnspentOutputAtIndexWithoutLocking iterates over all outputs that were unspent at the given milestone index.
+// The state is reconstructed by walking the milestone diffs backwards from the ledger index to the given index,
+// and reverting their changes on top of the current unspent outputs.
+func (u *Manager) ForEachUnspentOutputAtIndexWithoutLocking(msIndex iotago.MilestoneIndex, consumer OutputConsumer) error {
+	createdAfter, spentAfter, err := u.changesAfterIndexWithoutLocking(msIndex)
+	if err != nil {
+		return err
+	}
+
+	consumerStopped := false
+	if err := u.ForEachUnspentOutput(func(output *Output) bool {
+		if _, created := createdAfter[output.OutputID()]; created {
+			return true
+		}
+		if !consumer(output) {
+			consumerStopped = true
+			return false
+		}
+		return true
+	}, ReadLockLedger(false)); err != nil {
+		return err
+	}
+
+	if consumerStopped {
+		return nil
+	}
+
+	for _, output := range spentAfter {
+		if _, created := createdAfter[output.OutputID()]; created {
+			// the output was created and spent after the given index
+			continue
+		}
+		if !consumer(output) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// UnspentOutputsAtIndex returns all outputs that were unspent at the given milestone index.
+func (u *Manager) UnspentOutputsAtIndex(msIndex iotago.MilestoneIndex, options ...UTXOIterateOption) (Outputs, error) {
+	opt := iterateOptions(options)
+
+	if opt.readLockLedger {
+		u.ReadLockLedger()
+		defer u.ReadUnlockLedger()
+	}
+
+	var outputs Outputs
+	if err := u.ForEachUnspentOutputAtIndexWithoutLocking(msIndex, func(output *Output) bool {
+		if opt.maxResultCount > 0 && len(outputs) >= opt.maxResultCount {
+			return false
+		}
+		outputs = append(outputs, output)
+		return true
+	}); err != nil {
+		return nil, err
+	}
+
+	return outputs, nil
+}
+
+// ForEachUnspentOutputIDInIndexAtIndexWithoutLocking iterates over the IDs of all outputs that were unspent
+// at the given milestone index and that are indexed by the given key, in lexical order.
+// The outputs indexed by an address are the balance of the address at the given milestone index.
+func (u *Manager) ForEachUnspentOutputIDInIndexAtIndexWithoutLocking(indexType OutputIndexType, key []byte, msIndex iotago.MilestoneIndex, consumer OutputIDConsumer) error {
+	if !u.outputIndexEnabled {
+		return ErrOutputIndexDisabled
+	}
+
+	createdAfter, spentAfter, err := u.changesAfterIndexWithoutLocking(msIndex)
+	if err != nil {
+		return err
+	}
+
+	outputIDs := iotago.OutputIDs{}
+	if err := u.ForEachUnspentOutputIDInIndexWithoutLocking(indexType, key, func(outputID iotago.OutputID) bool {
+		if _, created := createdAfter[outputID]; !created {
+			outputIDs = append(outputIDs, outputID)
+		}
+		return true
+	}); err != nil {
+		return err
+	}
+
+	for _, output := range spentAfter {
+		if _, created := createdAfter[output.OutputID()]; created {
+			// the output was created and spent after the given index
+			continue
+		}
+
+		indexKeys, err := outputIndexKeys(output)
+		if err != nil {
+			return err
+		}
+
+		for _, indexKey := range indexKeys[indexType] {
+			if bytes.Equal(indexKey, key) {
+				outputIDs = append(outputIDs, output.OutputID())
+				break
+			}
+		}
+	}
+
+	sort.Slice(outputIDs, func(i, j int) bool {
+		return bytes.Compare(outputIDs[i][:], outputIDs[j][:]) < 0
+	})
+
+	for _, outputID := range outputIDs {
+		if !consumer(outputID) {
+			return nil
+		}
+	}
+
+	return nil
+}
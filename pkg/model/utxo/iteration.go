@@ -18,6 +18,14 @@ func ReadLockLedger(lockLedger bool) UTXOIterateOption {
 	}
 }
 
+// MaxResultCount limits the amount of results returned by an iteration.
+// A value of 0 means no limit.
+func MaxResultCount(maxResultCount int) UTXOIterateOption {
+	return func(args *UTXOIterateOptions) {
+		args.maxResultCount = maxResultCount
+	}
+}
+
 func iterateOptions(optionalOptions []UTXOIterateOption) *UTXOIterateOptions {
 	result := &UTXOIterateOptions{
 		readLockLedger: true,
@@ -0,0 +1,223 @@
+package utxo
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/marshalutil"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// the amount of 16 bit lanes of the lattice hash.
+	ledgerStateCommitmentLanes = 1024
+	// LedgerStateCommitmentStateLength is the length of the serialized state of a LedgerStateCommitment.
+	LedgerStateCommitmentStateLength = ledgerStateCommitmentLanes * 2
+	// LedgerStateCommitmentLength is the length of the digest of a LedgerStateCommitment.
+	LedgerStateCommitmentLength = blake2b.Size256
+)
+
+// LedgerStateCommitment is an order-independent commitment to a set of unspent outputs.
+// It is a lattice based multiset hash (LtHash), where every output is hashed to a vector of
+// 16 bit lanes, which are added to (or subtracted from) the state with wrap-around arithmetic.
+// This allows to update the commitment incrementally with the changes of a milestone,
+// instead of rehashing the whole ledger state.
+type LedgerStateCommitment struct {
+	lanes [ledgerStateCommitmentLanes]uint16
+}
+
+// NewLedgerStateCommitment creates a commitment to the empty set of outputs.
+func NewLedgerStateCommitment() *LedgerStateCommitment {
+	return &LedgerStateCommitment{}
+}
+
+// LedgerStateCommitmentFromBytes parses a serialized LedgerStateCommitment state.
+func LedgerStateCommitmentFromBytes(data []byte) (*LedgerStateCommitment, error) {
+	if len(data) != LedgerStateCommitmentStateLength {
+		return nil, fmt.Errorf("invalid ledger state commitment length: %d, expected: %d", len(data), LedgerStateCommitmentStateLength)
+	}
+
+	c := &LedgerStateCommitment{}
+	for i := range c.lanes {
+		c.lanes[i] = binary.LittleEndian.Uint16(data[i*2:])
+	}
+	return c, nil
+}
+
+func ledgerStateCommitmentElement(output *Output) [LedgerStateCommitmentStateLength]byte {
+	var element [LedgerStateCommitmentStateLength]byte
+
+	xof, err := blake2b.NewXOF(LedgerStateCommitmentStateLength, nil)
+	if err != nil {
+		// can only happen with invalid parameters
+		panic(err)
+	}
+	_, _ = xof.Write(output.outputID[:])
+	_, _ = xof.Write(output.KVStorableValue())
+	_, _ = xof.Read(element[:])
+
+	return element
+}
+
+// AddOutput adds the output to the committed set.
+func (c *LedgerStateCommitment) AddOutput(output *Output) {
+	element := ledgerStateCommitmentElement(output)
+	for i := range c.lanes {
+		c.lanes[i] += binary.LittleEndian.Uint16(element[i*2:])
+	}
+}
+
+// RemoveOutput removes the output from the committed set.
+func (c *LedgerStateCommitment) RemoveOutput(output *Output) {
+	element := ledgerStateCommitmentElement(output)
+	for i := range c.lanes {
+		c.lanes[i] -= binary.LittleEndian.Uint16(element[i*2:])
+	}
+}
+
+// Clone returns a copy of the commitment.
+func (c *LedgerStateCommitment) Clone() *LedgerStateCommitment {
+	clone := *c
+	return &clone
+}
+
+// Bytes returns the serialized state of the commitment.
+func (c *LedgerStateCommitment) Bytes() []byte {
+	data := make([]byte, LedgerStateCommitmentStateLength)
+	for i := range c.lanes {
+		binary.LittleEndian.PutUint16(data[i*2:], c.lanes[i])
+	}
+	return data
+}
+
+// Digest returns the short form of the commitment that is used to compare ledger states.
+func (c *LedgerStateCommitment) Digest() [LedgerStateCommitmentLength]byte {
+	return blake2b.Sum256(c.Bytes())
+}
+
+// DigestHex returns the hex encoded short form of the commitment.
+func (c *LedgerStateCommitment) DigestHex() string {
+	digest := c.Digest()
+	return iotago.EncodeHex(digest[:])
+}
+
+var (
+	// ErrLedgerStateCommitmentNotLoaded is returned if the commitment of the current ledger state was not loaded yet.
+	ErrLedgerStateCommitmentNotLoaded = errors.New("ledger state commitment not loaded")
+)
+
+var ledgerStateCommitmentKey = []byte{UTXOStoreKeyPrefixLedgerStateCommitment}
+
+func milestoneLedgerStateCommitmentKey(msIndex iotago.MilestoneIndex) []byte {
+	m := marshalutil.New(5)
+	m.WriteByte(UTXOStoreKeyPrefixMilestoneLedgerStateCommitment) // 1 byte
+	m.WriteUint32(msIndex)                                        // 4 bytes
+	return m.Bytes()
+}
+
+// loadLedgerStateCommitmentWithoutLocking loads the commitment of the current ledger state into memory.
+// If no commitment was stored yet (e.g. the database was created by an older version), it is computed from all unspent outputs.
+// The ledger must be write locked.
+func (u *Manager) loadLedgerStateCommitmentWithoutLocking() error {
+	if u.ledgerStateCommitment != nil {
+		return nil
+	}
+
+	value, err := u.utxoStorage.Get(ledgerStateCommitmentKey)
+	if err == nil {
+		commitment, err := LedgerStateCommitmentFromBytes(value)
+		if err != nil {
+			return err
+		}
+		u.ledgerStateCommitment = commitment
+		u.ledgerStateCommitmentStored = true
+		return nil
+	}
+	if !errors.Is(err, kvstore.ErrKeyNotFound) {
+		return err
+	}
+
+	commitment := NewLedgerStateCommitment()
+	if err := u.ForEachUnspentOutput(func(output *Output) bool {
+		commitment.AddOutput(output)
+		return true
+	}, ReadLockLedger(false)); err != nil {
+		return err
+	}
+
+	if err := u.utxoStorage.Set(ledgerStateCommitmentKey, commitment.Bytes()); err != nil {
+		return err
+	}
+	u.ledgerStateCommitment = commitment
+	u.ledgerStateCommitmentStored = true
+
+	return nil
+}
+
+// LoadLedgerStateCommitment loads the commitment of the current ledger state into memory.
+// It should be called at startup, since computing a missing commitment iterates all unspent outputs
+// while the ledger is write locked.
+func (u *Manager) LoadLedgerStateCommitment() error {
+	u.WriteLockLedger()
+	defer u.WriteUnlockLedger()
+
+	return u.loadLedgerStateCommitmentWithoutLocking()
+}
+
+// LedgerStateCommitment returns the commitment of the current ledger state and the ledger index it belongs to.
+// Returns ErrLedgerStateCommitmentNotLoaded if the commitment was not loaded into memory yet.
+func (u *Manager) LedgerStateCommitment() (iotago.MilestoneIndex, *LedgerStateCommitment, error) {
+	u.ReadLockLedger()
+	defer u.ReadUnlockLedger()
+
+	if u.ledgerStateCommitment == nil {
+		return 0, nil, ErrLedgerStateCommitmentNotLoaded
+	}
+
+	ledgerIndex, err := u.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return ledgerIndex, u.ledgerStateCommitment.Clone(), nil
+}
+
+// MilestoneLedgerStateCommitmentDigest returns the digest of the ledger state commitment after the given milestone was applied.
+// The digests are stored for all milestones that were confirmed by this node and not pruned yet.
+func (u *Manager) MilestoneLedgerStateCommitmentDigest(msIndex iotago.MilestoneIndex) ([LedgerStateCommitmentLength]byte, error) {
+	u.ReadLockLedger()
+	defer u.ReadUnlockLedger()
+
+	var digest [LedgerStateCommitmentLength]byte
+
+	value, err := u.utxoStorage.Get(milestoneLedgerStateCommitmentKey(msIndex))
+	if err != nil {
+		return digest, err
+	}
+
+	if len(value) != LedgerStateCommitmentLength {
+		return digest, fmt.Errorf("invalid ledger state commitment digest length: %d", len(value))
+	}
+	copy(digest[:], value)
+
+	return digest, nil
+}
+
+// storeLedgerStateCommitment stores the updated commitment of the current ledger state
+// and the digest for the given milestone index.
+func storeLedgerStateCommitment(msIndex iotago.MilestoneIndex, commitment *LedgerStateCommitment, mutations kvstore.BatchedMutations) error {
+	if err := mutations.Set(ledgerStateCommitmentKey, commitment.Bytes()); err != nil {
+		return err
+	}
+
+	digest := commitment.Digest()
+	return mutations.Set(milestoneLedgerStateCommitmentKey(msIndex), digest[:])
+}
+
+func deleteMilestoneLedgerStateCommitment(msIndex iotago.MilestoneIndex, mutations kvstore.BatchedMutations) error {
+	return mutations.Delete(milestoneLedgerStateCommitmentKey(msIndex))
+}
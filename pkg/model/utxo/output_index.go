@@ -0,0 +1,295 @@
+package utxo
+
+import (
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/marshalutil"
+	"github.com/iotaledger/hive.go/serializer/v2"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+// OutputIndexType defines the kind of key an unspent output is indexed by.
+type OutputIndexType byte
+
+const (
+	// OutputIndexTypeAddress indexes unspent outputs by the addresses that are able to unlock them
+	// (address, state controller, governor and immutable alias unlock conditions).
+	OutputIndexTypeAddress OutputIndexType = iota
+	// OutputIndexTypeSender indexes unspent outputs by the address of their sender feature.
+	OutputIndexTypeSender
+	// OutputIndexTypeIssuer indexes unspent outputs by the address of their issuer feature.
+	OutputIndexTypeIssuer
+	// OutputIndexTypeAliasID indexes unspent alias outputs by their alias ID.
+	OutputIndexTypeAliasID
+	// OutputIndexTypeNFTID indexes unspent NFT outputs by their NFT ID.
+	OutputIndexTypeNFTID
+	// OutputIndexTypeFoundryID indexes unspent foundry outputs by their foundry ID.
+	OutputIndexTypeFoundryID
+)
+
+// outputIndexEnabledKey is the key of the marker that the output index is maintained for the ledger.
+var outputIndexEnabledKey = []byte{UTXOStoreKeyPrefixOutputIndexEnabled}
+
+func outputIndexKeyPrefix(indexType OutputIndexType, key []byte) []byte {
+	ms := marshalutil.New(2 + len(key))
+	ms.WriteByte(UTXOStoreKeyPrefixOutputIndex) // 1 byte
+	ms.WriteByte(byte(indexType))               // 1 byte
+	ms.WriteBytes(key)                          // X bytes
+	return ms.Bytes()
+}
+
+func outputIndexKey(indexType OutputIndexType, key []byte, outputID iotago.OutputID) []byte {
+	ms := marshalutil.New(2 + len(key) + iotago.OutputIDLength)
+	ms.WriteBytes(outputIndexKeyPrefix(indexType, key)) // 2 bytes + X bytes
+	ms.WriteBytes(outputID[:])                          // 34 bytes
+	return ms.Bytes()
+}
+
+// OutputIndexKeyForAddress returns the output index key for the given address.
+func OutputIndexKeyForAddress(address iotago.Address) ([]byte, error) {
+	return address.Serialize(serializer.DeSeriModeNoValidation, nil)
+}
+
+// outputIndexKeys returns the keys the given output is indexed by, grouped by index type.
+func outputIndexKeys(output *Output) (map[OutputIndexType][][]byte, error) {
+	keys := make(map[OutputIndexType][][]byte)
+
+	addAddress := func(indexType OutputIndexType, address iotago.Address) error {
+		key, err := OutputIndexKeyForAddress(address)
+		if err != nil {
+			return err
+		}
+		keys[indexType] = append(keys[indexType], key)
+		return nil
+	}
+
+	unlockConditions := output.Output().UnlockConditionSet()
+	if addressUnlock := unlockConditions.Address(); addressUnlock != nil {
+		if err := addAddress(OutputIndexTypeAddress, addressUnlock.Address); err != nil {
+			return nil, err
+		}
+	}
+	if stateControllerUnlock := unlockConditions.StateControllerAddress(); stateControllerUnlock != nil {
+		if err := addAddress(OutputIndexTypeAddress, stateControllerUnlock.Address); err != nil {
+			return nil, err
+		}
+	}
+	if governorUnlock := unlockConditions.GovernorAddress(); governorUnlock != nil {
+		if err := addAddress(OutputIndexTypeAddress, governorUnlock.Address); err != nil {
+			return nil, err
+		}
+	}
+	if immutableAliasUnlock := unlockConditions.ImmutableAlias(); immutableAliasUnlock != nil {
+		if err := addAddress(OutputIndexTypeAddress, immutableAliasUnlock.Address); err != nil {
+			return nil, err
+		}
+	}
+
+	if senderFeature := output.Output().FeatureSet().SenderFeature(); senderFeature != nil {
+		if err := addAddress(OutputIndexTypeSender, senderFeature.Address); err != nil {
+			return nil, err
+		}
+	}
+
+	if chainOutput, ok := output.Output().(iotago.ChainConstrainedOutput); ok {
+		if issuerFeature := chainOutput.ImmutableFeatureSet().IssuerFeature(); issuerFeature != nil {
+			if err := addAddress(OutputIndexTypeIssuer, issuerFeature.Address); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch o := output.Output().(type) {
+	case *iotago.AliasOutput:
+		aliasID := o.AliasID
+		if aliasID.Empty() {
+			// the alias ID of a newly created alias is derived from its output ID
+			aliasID = iotago.AliasIDFromOutputID(output.OutputID())
+		}
+		keys[OutputIndexTypeAliasID] = append(keys[OutputIndexTypeAliasID], aliasID[:])
+
+	case *iotago.NFTOutput:
+		nftID := o.NFTID
+		if nftID.Empty() {
+			// the NFT ID of a newly created NFT is derived from its output ID
+			nftID = iotago.NFTIDFromOutputID(output.OutputID())
+		}
+		keys[OutputIndexTypeNFTID] = append(keys[OutputIndexTypeNFTID], nftID[:])
+
+	case *iotago.FoundryOutput:
+		foundryID, err := o.ID()
+		if err != nil {
+			return nil, err
+		}
+		keys[OutputIndexTypeFoundryID] = append(keys[OutputIndexTypeFoundryID], foundryID[:])
+	}
+
+	return keys, nil
+}
+
+func (u *Manager) addToOutputIndex(output *Output, mutations kvstore.BatchedMutations) error {
+	if !u.outputIndexEnabled {
+		return nil
+	}
+
+	keys, err := outputIndexKeys(output)
+	if err != nil {
+		return err
+	}
+
+	for indexType, indexKeys := range keys {
+		for _, key := range indexKeys {
+			if err := mutations.Set(outputIndexKey(indexType, key, output.OutputID()), []byte{}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (u *Manager) removeFromOutputIndex(output *Output, mutations kvstore.BatchedMutations) error {
+	if !u.outputIndexEnabled {
+		return nil
+	}
+
+	keys, err := outputIndexKeys(output)
+	if err != nil {
+		return err
+	}
+
+	for indexType, indexKeys := range keys {
+		for _, key := range indexKeys {
+			if err := mutations.Delete(outputIndexKey(indexType, key, output.OutputID())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// OutputIndexEnabled returns whether the output index is maintained.
+func (u *Manager) OutputIndexEnabled() bool {
+	return u.outputIndexEnabled
+}
+
+// ConfigureOutputIndex enables or disables the output index.
+// If the index gets enabled but was not maintained for the current ledger state, it is rebuilt from all unspent outputs.
+// If the index gets disabled, all existing index entries are removed, so they can't get stale.
+func (u *Manager) ConfigureOutputIndex(enabled bool) error {
+	u.WriteLockLedger()
+	defer u.WriteUnlockLedger()
+
+	indexExists, err := u.utxoStorage.Has(outputIndexEnabledKey)
+	if err != nil {
+		return err
+	}
+
+	u.outputIndexEnabled = enabled
+
+	switch {
+	case enabled && !indexExists:
+		return u.rebuildOutputIndexWithoutLocking()
+
+	case !enabled && indexExists:
+		if err := u.utxoStorage.DeletePrefix([]byte{UTXOStoreKeyPrefixOutputIndex}); err != nil {
+			return err
+		}
+		if err := u.utxoStorage.Delete(outputIndexEnabledKey); err != nil {
+			return err
+		}
+		return u.utxoStorage.Flush()
+	}
+
+	return nil
+}
+
+func (u *Manager) rebuildOutputIndexWithoutLocking() error {
+	if err := u.utxoStorage.DeletePrefix([]byte{UTXOStoreKeyPrefixOutputIndex}); err != nil {
+		return err
+	}
+
+	mutations, err := u.utxoStorage.Batched()
+	if err != nil {
+		return err
+	}
+
+	var innerErr error
+	if err := u.ForEachUnspentOutput(func(output *Output) bool {
+		if err := u.addToOutputIndex(output, mutations); err != nil {
+			innerErr = err
+			return false
+		}
+		return true
+	}, ReadLockLedger(false)); err != nil {
+		mutations.Cancel()
+		return err
+	}
+	if innerErr != nil {
+		mutations.Cancel()
+		return innerErr
+	}
+
+	if err := mutations.Set(outputIndexEnabledKey, []byte{}); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
+	if err := mutations.Commit(); err != nil {
+		return err
+	}
+
+	return u.utxoStorage.Flush()
+}
+
+// ForEachUnspentOutputIDInIndexWithoutLocking iterates over the IDs of all unspent outputs that are indexed by the given key.
+func (u *Manager) ForEachUnspentOutputIDInIndexWithoutLocking(indexType OutputIndexType, key []byte, consumer OutputIDConsumer) error {
+	if !u.outputIndexEnabled {
+		return ErrOutputIndexDisabled
+	}
+
+	prefix := outputIndexKeyPrefix(indexType, key)
+
+	var innerErr error
+	if err := u.utxoStorage.IterateKeys(prefix, func(key kvstore.Key) bool {
+		if len(key) != len(prefix)+iotago.OutputIDLength {
+			// the key belongs to a longer index key with the same prefix
+			return true
+		}
+
+		outputID, err := ParseOutputID(marshalutil.New(key[len(prefix):]))
+		if err != nil {
+			innerErr = err
+			return false
+		}
+
+		return consumer(outputID)
+	}); err != nil {
+		return err
+	}
+
+	return innerErr
+}
+
+// UnspentOutputsIDsInIndex returns the IDs of all unspent outputs that are indexed by the given key.
+func (u *Manager) UnspentOutputsIDsInIndex(indexType OutputIndexType, key []byte, options ...UTXOIterateOption) (iotago.OutputIDs, error) {
+	opt := iterateOptions(options)
+
+	if opt.readLockLedger {
+		u.ReadLockLedger()
+		defer u.ReadUnlockLedger()
+	}
+
+	outputIDs := iotago.OutputIDs{}
+	if err := u.ForEachUnspentOutputIDInIndexWithoutLocking(indexType, key, func(outputID iotago.OutputID) bool {
+		if opt.maxResultCount > 0 && len(outputIDs) >= opt.maxResultCount {
+			return false
+		}
+		outputIDs = append(outputIDs, outputID)
+		return true
+	}); err != nil {
+		return nil, err
+	}
+
+	return outputIDs, nil
+}
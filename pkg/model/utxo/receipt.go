@@ -73,6 +73,38 @@ func deleteReceipt(rt *ReceiptTuple, mutations kvstore.BatchedMutations) error {
 	return mutations.Delete(rt.kvStorableKey())
 }
 
+// PruneReceiptsWithoutLocking deletes all receipts that were included in milestones up to the given index.
+func (u *Manager) PruneReceiptsWithoutLocking(targetIndex iotago.MilestoneIndex) error {
+
+	var receiptsToDelete []*ReceiptTuple
+	if err := u.ForEachReceiptTuple(func(rt *ReceiptTuple) bool {
+		if rt.MilestoneIndex <= targetIndex {
+			receiptsToDelete = append(receiptsToDelete, rt)
+		}
+		return true
+	}, ReadLockLedger(false)); err != nil {
+		return err
+	}
+
+	if len(receiptsToDelete) == 0 {
+		return nil
+	}
+
+	mutations, err := u.utxoStorage.Batched()
+	if err != nil {
+		return err
+	}
+
+	for _, rt := range receiptsToDelete {
+		if err := deleteReceipt(rt, mutations); err != nil {
+			mutations.Cancel()
+			return err
+		}
+	}
+
+	return mutations.Commit()
+}
+
 // SearchHighestReceiptMigratedAtIndex searches the highest migratedAt of all stored receipts.
 func (u *Manager) SearchHighestReceiptMigratedAtIndex(options ...UTXOIterateOption) (iotago.MilestoneIndex, error) {
 	var highestMigratedAtIndex iotago.MilestoneIndex
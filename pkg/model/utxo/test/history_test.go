@@ -0,0 +1,138 @@
+package utxo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/hornet/pkg/model/utxo"
+	"github.com/iotaledger/hornet/pkg/tpkg"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func randUTXOOutputBookedAt(outputType iotago.OutputType, msIndex iotago.MilestoneIndex) *utxo.Output {
+	return utxo.CreateOutput(tpkg.RandOutputID(), tpkg.RandBlockID(), msIndex, tpkg.RandMilestoneTimestamp(), tpkg.RandOutput(outputType))
+}
+
+func outputIDsAtIndex(t *testing.T, manager *utxo.Manager, msIndex iotago.MilestoneIndex) iotago.OutputIDs {
+	outputs, err := manager.UnspentOutputsAtIndex(msIndex)
+	require.NoError(t, err)
+
+	outputIDs := iotago.OutputIDs{}
+	for _, output := range outputs {
+		outputIDs = append(outputIDs, output.OutputID())
+	}
+	return outputIDs
+}
+
+func TestHistoricalLedgerState(t *testing.T) {
+
+	manager := utxo.New(mapdb.NewMapDB())
+
+	outputA := randUTXOOutputBookedAt(iotago.OutputBasic, 1)
+	outputB := randUTXOOutputBookedAt(iotago.OutputNFT, 1)
+	outputC := randUTXOOutputBookedAt(iotago.OutputBasic, 2)
+	outputD := randUTXOOutputBookedAt(iotago.OutputAlias, 3)
+
+	spentA := tpkg.RandUTXOSpentWithOutput(outputA, 2, tpkg.RandMilestoneTimestamp())
+	spentB := tpkg.RandUTXOSpentWithOutput(outputB, 3, tpkg.RandMilestoneTimestamp())
+	spentC := tpkg.RandUTXOSpentWithOutput(outputC, 3, tpkg.RandMilestoneTimestamp())
+
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(1, utxo.Outputs{outputA, outputB}, utxo.Spents{}, nil, nil))
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(2, utxo.Outputs{outputC}, utxo.Spents{spentA}, nil, nil))
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(3, utxo.Outputs{outputD}, utxo.Spents{spentB, spentC}, nil, nil))
+
+	require.ElementsMatch(t, iotago.OutputIDs{outputA.OutputID(), outputB.OutputID()}, outputIDsAtIndex(t, manager, 1))
+	require.ElementsMatch(t, iotago.OutputIDs{outputB.OutputID(), outputC.OutputID()}, outputIDsAtIndex(t, manager, 2))
+	require.ElementsMatch(t, iotago.OutputIDs{outputD.OutputID()}, outputIDsAtIndex(t, manager, 3))
+
+	_, err := manager.UnspentOutputsAtIndex(4)
+	require.ErrorIs(t, err, utxo.ErrHistoricalStateUnavailable)
+
+	// output A was unspent at index 1 and spent at index 2
+	output, spent, err := manager.ReadOutputAtIndex(outputA.OutputID(), 1)
+	require.NoError(t, err)
+	require.Equal(t, outputA.OutputID(), output.OutputID())
+	require.Nil(t, spent)
+
+	output, spent, err = manager.ReadOutputAtIndex(outputA.OutputID(), 2)
+	require.NoError(t, err)
+	require.Equal(t, outputA.OutputID(), output.OutputID())
+	require.NotNil(t, spent)
+	require.Equal(t, iotago.MilestoneIndex(2), spent.MilestoneIndexSpent())
+
+	// output C didn't exist yet at index 1
+	_, _, err = manager.ReadOutputAtIndex(outputC.OutputID(), 1)
+	require.ErrorIs(t, err, kvstore.ErrKeyNotFound)
+
+	output, spent, err = manager.ReadOutputAtIndex(outputD.OutputID(), 3)
+	require.NoError(t, err)
+	require.Equal(t, outputD.OutputID(), output.OutputID())
+	require.Nil(t, spent)
+
+	// pruning the first milestone keeps the state at index 1 available, since the diffs after it still exist
+	require.NoError(t, manager.PruneMilestoneIndexWithoutLocking(1, false))
+	require.ElementsMatch(t, iotago.OutputIDs{outputA.OutputID(), outputB.OutputID()}, outputIDsAtIndex(t, manager, 1))
+
+	// pruning the second milestone removes the diff needed to reconstruct the state at index 1
+	require.NoError(t, manager.PruneMilestoneIndexWithoutLocking(2, false))
+	_, err = manager.UnspentOutputsAtIndex(1)
+	require.ErrorIs(t, err, utxo.ErrHistoricalStateUnavailable)
+	_, _, err = manager.ReadOutputAtIndex(outputA.OutputID(), 1)
+	require.ErrorIs(t, err, utxo.ErrHistoricalStateUnavailable)
+
+	require.ElementsMatch(t, iotago.OutputIDs{outputB.OutputID(), outputC.OutputID()}, outputIDsAtIndex(t, manager, 2))
+
+	// rolling back the last milestone must not return the cached diff anymore
+	require.NoError(t, manager.RollbackConfirmationWithoutLocking(3, utxo.Outputs{outputD}, utxo.Spents{spentB, spentC}, nil, nil))
+	_, err = manager.UnspentOutputsAtIndex(3)
+	require.ErrorIs(t, err, utxo.ErrHistoricalStateUnavailable)
+	require.ElementsMatch(t, iotago.OutputIDs{outputB.OutputID(), outputC.OutputID()}, outputIDsAtIndex(t, manager, 2))
+}
+
+func TestHistoricalOutputIndex(t *testing.T) {
+
+	manager := utxo.New(mapdb.NewMapDB())
+	require.NoError(t, manager.ConfigureOutputIndex(true))
+	require.NoError(t, manager.ConfigureHistoryDiffCache(10))
+
+	address := tpkg.RandAddress(iotago.AddressEd25519)
+	addressKey, err := utxo.OutputIndexKeyForAddress(address)
+	require.NoError(t, err)
+
+	outputA := tpkg.RandUTXOOutputOnAddress(iotago.OutputBasic, address)
+	outputB := tpkg.RandUTXOOutputOnAddress(iotago.OutputBasic, address)
+	outputC := tpkg.RandUTXOOutputOnAddress(iotago.OutputNFT, address)
+	outputOther := tpkg.RandUTXOOutputWithType(iotago.OutputBasic)
+
+	spentA := tpkg.RandUTXOSpentWithOutput(outputA, 2, tpkg.RandMilestoneTimestamp())
+	spentOther := tpkg.RandUTXOSpentWithOutput(outputOther, 3, tpkg.RandMilestoneTimestamp())
+
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(1, utxo.Outputs{outputA, outputOther}, utxo.Spents{}, nil, nil))
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(2, utxo.Outputs{outputB}, utxo.Spents{spentA}, nil, nil))
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(3, utxo.Outputs{outputC}, utxo.Spents{spentOther}, nil, nil))
+
+	outputIDsAtIndex := func(msIndex iotago.MilestoneIndex) iotago.OutputIDs {
+		outputIDs := iotago.OutputIDs{}
+		require.NoError(t, manager.ForEachUnspentOutputIDInIndexAtIndexWithoutLocking(utxo.OutputIndexTypeAddress, addressKey, msIndex, func(outputID iotago.OutputID) bool {
+			outputIDs = append(outputIDs, outputID)
+			return true
+		}))
+
+		return outputIDs
+	}
+
+	// spent outputs are part of the balance before they were spent, created outputs only afterwards
+	require.ElementsMatch(t, iotago.OutputIDs{outputA.OutputID()}, outputIDsAtIndex(1))
+	require.ElementsMatch(t, iotago.OutputIDs{outputB.OutputID()}, outputIDsAtIndex(2))
+	require.ElementsMatch(t, iotago.OutputIDs{outputB.OutputID(), outputC.OutputID()}, outputIDsAtIndex(3))
+
+	err = manager.ForEachUnspentOutputIDInIndexAtIndexWithoutLocking(utxo.OutputIndexTypeAddress, addressKey, 4, func(outputID iotago.OutputID) bool {
+		return true
+	})
+	require.ErrorIs(t, err, utxo.ErrHistoricalStateUnavailable)
+
+	require.Error(t, manager.ConfigureHistoryDiffCache(0))
+}
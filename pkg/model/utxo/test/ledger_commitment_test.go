@@ -0,0 +1,102 @@
+package utxo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/hornet/pkg/model/utxo"
+	"github.com/iotaledger/hornet/pkg/tpkg"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func commitmentOfOutputs(outputs ...*utxo.Output) *utxo.LedgerStateCommitment {
+	commitment := utxo.NewLedgerStateCommitment()
+	for _, output := range outputs {
+		commitment.AddOutput(output)
+	}
+	return commitment
+}
+
+func TestLedgerStateCommitmentOrderIndependence(t *testing.T) {
+
+	outputA := randUTXOOutputBookedAt(iotago.OutputBasic, 1)
+	outputB := randUTXOOutputBookedAt(iotago.OutputNFT, 1)
+	outputC := randUTXOOutputBookedAt(iotago.OutputAlias, 1)
+
+	require.Equal(t, commitmentOfOutputs(outputA, outputB, outputC).Digest(), commitmentOfOutputs(outputC, outputA, outputB).Digest())
+	require.NotEqual(t, commitmentOfOutputs(outputA, outputB).Digest(), commitmentOfOutputs(outputA, outputC).Digest())
+
+	commitment := commitmentOfOutputs(outputA, outputB, outputC)
+	commitment.RemoveOutput(outputB)
+	require.Equal(t, commitmentOfOutputs(outputA, outputC).Digest(), commitment.Digest())
+
+	commitment.RemoveOutput(outputA)
+	commitment.RemoveOutput(outputC)
+	require.Equal(t, utxo.NewLedgerStateCommitment().Digest(), commitment.Digest())
+
+	restored, err := utxo.LedgerStateCommitmentFromBytes(commitmentOfOutputs(outputA).Bytes())
+	require.NoError(t, err)
+	require.Equal(t, commitmentOfOutputs(outputA).Digest(), restored.Digest())
+}
+
+func TestLedgerStateCommitment(t *testing.T) {
+
+	store := mapdb.NewMapDB()
+	manager := utxo.New(store)
+
+	outputA := randUTXOOutputBookedAt(iotago.OutputBasic, 1)
+	outputB := randUTXOOutputBookedAt(iotago.OutputNFT, 1)
+	outputC := randUTXOOutputBookedAt(iotago.OutputBasic, 2)
+
+	spentA := tpkg.RandUTXOSpentWithOutput(outputA, 2, tpkg.RandMilestoneTimestamp())
+
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(1, utxo.Outputs{outputA, outputB}, utxo.Spents{}, nil, nil))
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(2, utxo.Outputs{outputC}, utxo.Spents{spentA}, nil, nil))
+
+	ledgerIndex, commitment, err := manager.LedgerStateCommitment()
+	require.NoError(t, err)
+	require.Equal(t, iotago.MilestoneIndex(2), ledgerIndex)
+	require.Equal(t, commitmentOfOutputs(outputB, outputC).Digest(), commitment.Digest())
+
+	digest, err := manager.MilestoneLedgerStateCommitmentDigest(1)
+	require.NoError(t, err)
+	require.Equal(t, commitmentOfOutputs(outputA, outputB).Digest(), digest)
+
+	digest, err = manager.MilestoneLedgerStateCommitmentDigest(2)
+	require.NoError(t, err)
+	require.Equal(t, commitment.Digest(), digest)
+
+	// the stored commitment is loaded by a new manager on the same database
+	loadedManager := utxo.New(store)
+	_, _, err = loadedManager.LedgerStateCommitment()
+	require.ErrorIs(t, err, utxo.ErrLedgerStateCommitmentNotLoaded)
+
+	require.NoError(t, loadedManager.LoadLedgerStateCommitment())
+	_, loaded, err := loadedManager.LedgerStateCommitment()
+	require.NoError(t, err)
+	require.Equal(t, commitment.Digest(), loaded.Digest())
+
+	// rolling back the milestone restores the previous commitment
+	require.NoError(t, manager.RollbackConfirmationWithoutLocking(2, utxo.Outputs{outputC}, utxo.Spents{spentA}, nil, nil))
+
+	ledgerIndex, commitment, err = manager.LedgerStateCommitment()
+	require.NoError(t, err)
+	require.Equal(t, iotago.MilestoneIndex(1), ledgerIndex)
+	require.Equal(t, commitmentOfOutputs(outputA, outputB).Digest(), commitment.Digest())
+
+	_, err = manager.MilestoneLedgerStateCommitmentDigest(2)
+	require.ErrorIs(t, err, kvstore.ErrKeyNotFound)
+
+	// pruning removes the digest of the milestone, but not the commitment of the current ledger state
+	require.NoError(t, manager.PruneMilestoneIndexWithoutLocking(1, false))
+
+	_, err = manager.MilestoneLedgerStateCommitmentDigest(1)
+	require.ErrorIs(t, err, kvstore.ErrKeyNotFound)
+
+	_, commitment, err = manager.LedgerStateCommitment()
+	require.NoError(t, err)
+	require.Equal(t, commitmentOfOutputs(outputA, outputB).Digest(), commitment.Digest())
+}
@@ -0,0 +1,75 @@
+package utxo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/hornet/pkg/model/utxo"
+	"github.com/iotaledger/hornet/pkg/tpkg"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func TestOutputIndexApplyAndRollback(t *testing.T) {
+
+	manager := utxo.New(mapdb.NewMapDB())
+	require.NoError(t, manager.ConfigureOutputIndex(true))
+
+	address := tpkg.RandAddress(iotago.AddressEd25519)
+	addressKey, err := utxo.OutputIndexKeyForAddress(address)
+	require.NoError(t, err)
+
+	outputs := utxo.Outputs{
+		tpkg.RandUTXOOutputOnAddress(iotago.OutputBasic, address),
+		tpkg.RandUTXOOutputOnAddress(iotago.OutputBasic, address), // spent
+		tpkg.RandUTXOOutputOnAddress(iotago.OutputNFT, address),
+		tpkg.RandUTXOOutputWithType(iotago.OutputBasic),
+	}
+
+	msIndex := iotago.MilestoneIndex(756)
+	msTimestamp := tpkg.RandMilestoneTimestamp()
+
+	spents := utxo.Spents{
+		tpkg.RandUTXOSpentWithOutput(outputs[1], msIndex, msTimestamp),
+	}
+
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(msIndex, outputs, spents, nil, nil))
+
+	outputIDs, err := manager.UnspentOutputsIDsInIndex(utxo.OutputIndexTypeAddress, addressKey)
+	require.NoError(t, err)
+	require.ElementsMatch(t, iotago.OutputIDs{outputs[0].OutputID(), outputs[2].OutputID()}, outputIDs)
+
+	nftID := outputs[2].Output().(*iotago.NFTOutput).NFTID
+	nftOutputIDs, err := manager.UnspentOutputsIDsInIndex(utxo.OutputIndexTypeNFTID, nftID[:])
+	require.NoError(t, err)
+	require.Equal(t, iotago.OutputIDs{outputs[2].OutputID()}, nftOutputIDs)
+
+	require.NoError(t, manager.RollbackConfirmationWithoutLocking(msIndex, outputs, spents, nil, nil))
+
+	outputIDs, err = manager.UnspentOutputsIDsInIndex(utxo.OutputIndexTypeAddress, addressKey)
+	require.NoError(t, err)
+	require.Empty(t, outputIDs)
+}
+
+func TestOutputIndexRebuild(t *testing.T) {
+
+	manager := utxo.New(mapdb.NewMapDB())
+
+	address := tpkg.RandAddress(iotago.AddressEd25519)
+	addressKey, err := utxo.OutputIndexKeyForAddress(address)
+	require.NoError(t, err)
+
+	output := tpkg.RandUTXOOutputOnAddress(iotago.OutputBasic, address)
+	require.NoError(t, manager.AddUnspentOutput(output))
+
+	_, err = manager.UnspentOutputsIDsInIndex(utxo.OutputIndexTypeAddress, addressKey)
+	require.ErrorIs(t, err, utxo.ErrOutputIndexDisabled)
+
+	// enabling the index builds it from the existing unspent outputs
+	require.NoError(t, manager.ConfigureOutputIndex(true))
+
+	outputIDs, err := manager.UnspentOutputsIDsInIndex(utxo.OutputIndexTypeAddress, addressKey)
+	require.NoError(t, err)
+	require.Equal(t, iotago.OutputIDs{output.OutputID()}, outputIDs)
+}
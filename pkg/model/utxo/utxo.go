@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sync"
 
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/pkg/errors"
 
 	"github.com/iotaledger/hive.go/kvstore"
@@ -15,16 +16,35 @@ import (
 var (
 	// ErrOutputsSumNotEqualTotalSupply is returned if the sum of the output deposits is not equal the total supply of tokens.
 	ErrOutputsSumNotEqualTotalSupply = errors.New("accumulated output balance is not equal to total supply")
+	// ErrOutputIndexDisabled is returned if the output index is queried but not maintained.
+	ErrOutputIndexDisabled = errors.New("output index is disabled")
 )
 
 type Manager struct {
 	utxoStorage kvstore.KVStore
 	utxoLock    sync.RWMutex
+
+	// whether the secondary index of unspent outputs is maintained
+	outputIndexEnabled bool
+
+	// the recently loaded milestone diffs used for historical ledger queries
+	historyDiffCache *lru.Cache
+
+	// the commitment of the current ledger state, nil if not loaded yet
+	ledgerStateCommitment *LedgerStateCommitment
+	// whether the in-memory commitment matches the stored one
+	ledgerStateCommitmentStored bool
 }
 
 func New(store kvstore.KVStore) *Manager {
+	historyDiffCache, err := lru.New(defaultHistoryDiffCacheSize)
+	if err != nil {
+		panic(err)
+	}
+
 	return &Manager{
-		utxoStorage: store,
+		utxoStorage:      store,
+		historyDiffCache: historyDiffCache,
 	}
 }
 
@@ -33,7 +53,7 @@ func (u *Manager) KVStore() kvstore.KVStore {
 	return u.utxoStorage
 }
 
-// ClearLedger removes all entries from the UTXO ledger (spent, unspent, diff, receipts, treasury).
+// ClearLedger removes all entries from the UTXO ledger (spent, unspent, diff, receipts, treasury, output index).
 func (u *Manager) ClearLedger(pruneReceipts bool) (err error) {
 	u.WriteLockLedger()
 	defer u.WriteUnlockLedger()
@@ -44,9 +64,23 @@ func (u *Manager) ClearLedger(pruneReceipts bool) (err error) {
 		}
 	}()
 
+	u.historyDiffCache.Purge()
+
+	// the commitment keys are removed with the rest of the ledger
+	u.ledgerStateCommitment = NewLedgerStateCommitment()
+	u.ledgerStateCommitmentStored = false
+
 	if pruneReceipts {
 		// if we also prune the receipts, we can just clear everything
-		return u.utxoStorage.Clear()
+		if err = u.utxoStorage.Clear(); err != nil {
+			return err
+		}
+
+		if u.outputIndexEnabled {
+			// the index is rebuilt while the new ledger state is added
+			return u.utxoStorage.Set(outputIndexEnabledKey, []byte{})
+		}
+		return nil
 	}
 
 	if err = u.utxoStorage.DeletePrefix([]byte{UTXOStoreKeyPrefixLedgerMilestoneIndex}); err != nil {
@@ -68,6 +102,15 @@ func (u *Manager) ClearLedger(pruneReceipts bool) (err error) {
 	if err = u.utxoStorage.DeletePrefix([]byte{UTXOStoreKeyPrefixTreasuryOutput}); err != nil {
 		return err
 	}
+	if err = u.utxoStorage.DeletePrefix([]byte{UTXOStoreKeyPrefixOutputIndex}); err != nil {
+		return err
+	}
+	if err = u.utxoStorage.DeletePrefix([]byte{UTXOStoreKeyPrefixLedgerStateCommitment}); err != nil {
+		return err
+	}
+	if err = u.utxoStorage.DeletePrefix([]byte{UTXOStoreKeyPrefixMilestoneLedgerStateCommitment}); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -116,19 +159,26 @@ func (u *Manager) PruneMilestoneIndexWithoutLocking(msIndex iotago.MilestoneInde
 		mutations.Cancel()
 		return err
 	}
+	u.historyDiffCache.Remove(msIndex)
+
+	if err := deleteMilestoneLedgerStateCommitment(msIndex, mutations); err != nil {
+		mutations.Cancel()
+		return err
+	}
 
-	if len(receiptMigratedAtIndex) > 0 {
-		if pruneReceipts {
-			placeHolder := &ReceiptTuple{Receipt: &iotago.ReceiptMilestoneOpt{MigratedAt: receiptMigratedAtIndex[0]}, MilestoneIndex: msIndex}
-			if err := deleteReceipt(placeHolder, mutations); err != nil {
-				mutations.Cancel()
-				return err
-			}
+	if len(receiptMigratedAtIndex) > 0 && pruneReceipts {
+		placeHolder := &ReceiptTuple{Receipt: &iotago.ReceiptMilestoneOpt{MigratedAt: receiptMigratedAtIndex[0]}, MilestoneIndex: msIndex}
+		if err := deleteReceipt(placeHolder, mutations); err != nil {
+			mutations.Cancel()
+			return err
 		}
+	}
 
-		// only ever delete spent treasury outputs, since the unspent treasury output must exist
-		// even after a milestone's lifetime
+	// only ever delete spent treasury outputs, since the unspent treasury output must exist
+	// even after a milestone's lifetime
+	if diff.SpentTreasuryOutput != nil {
 		if err := deleteTreasuryOutput(diff.SpentTreasuryOutput, mutations); err != nil {
+			mutations.Cancel()
 			return err
 		}
 	}
@@ -184,6 +234,11 @@ type TreasuryMutationTuple struct {
 
 func (u *Manager) ApplyConfirmationWithoutLocking(msIndex iotago.MilestoneIndex, newOutputs Outputs, newSpents Spents, tm *TreasuryMutationTuple, rt *ReceiptTuple) error {
 
+	if err := u.loadLedgerStateCommitmentWithoutLocking(); err != nil {
+		return err
+	}
+	ledgerStateCommitment := u.ledgerStateCommitment.Clone()
+
 	mutations, err := u.utxoStorage.Batched()
 	if err != nil {
 		return err
@@ -198,6 +253,11 @@ func (u *Manager) ApplyConfirmationWithoutLocking(msIndex iotago.MilestoneIndex,
 			mutations.Cancel()
 			return err
 		}
+		if err := u.addToOutputIndex(output, mutations); err != nil {
+			mutations.Cancel()
+			return err
+		}
+		ledgerStateCommitment.AddOutput(output)
 	}
 
 	for _, spent := range newSpents {
@@ -205,6 +265,11 @@ func (u *Manager) ApplyConfirmationWithoutLocking(msIndex iotago.MilestoneIndex,
 			mutations.Cancel()
 			return err
 		}
+		if err := u.removeFromOutputIndex(spent.output, mutations); err != nil {
+			mutations.Cancel()
+			return err
+		}
+		ledgerStateCommitment.RemoveOutput(spent.output)
 	}
 
 	msDiff := &MilestoneDiff{
@@ -241,12 +306,24 @@ func (u *Manager) ApplyConfirmationWithoutLocking(msIndex iotago.MilestoneIndex,
 		return err
 	}
 
+	if err := storeLedgerStateCommitment(msIndex, ledgerStateCommitment, mutations); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
 	if err := storeLedgerIndex(msIndex, mutations); err != nil {
 		mutations.Cancel()
 		return err
 	}
 
-	return mutations.Commit()
+	if err := mutations.Commit(); err != nil {
+		return err
+	}
+
+	u.ledgerStateCommitment = ledgerStateCommitment
+	u.ledgerStateCommitmentStored = true
+
+	return nil
 }
 
 func (u *Manager) ApplyConfirmation(msIndex iotago.MilestoneIndex, newOutputs Outputs, newSpents Spents, tm *TreasuryMutationTuple, rt *ReceiptTuple) error {
@@ -258,6 +335,11 @@ func (u *Manager) ApplyConfirmation(msIndex iotago.MilestoneIndex, newOutputs Ou
 
 func (u *Manager) RollbackConfirmationWithoutLocking(msIndex iotago.MilestoneIndex, newOutputs Outputs, newSpents Spents, tm *TreasuryMutationTuple, rt *ReceiptTuple) error {
 
+	if err := u.loadLedgerStateCommitmentWithoutLocking(); err != nil {
+		return err
+	}
+	ledgerStateCommitment := u.ledgerStateCommitment.Clone()
+
 	mutations, err := u.utxoStorage.Batched()
 	if err != nil {
 		return err
@@ -274,6 +356,12 @@ func (u *Manager) RollbackConfirmationWithoutLocking(msIndex iotago.MilestoneInd
 			mutations.Cancel()
 			return err
 		}
+
+		if err := u.addToOutputIndex(spent.output, mutations); err != nil {
+			mutations.Cancel()
+			return err
+		}
+		ledgerStateCommitment.AddOutput(spent.output)
 	}
 
 	// we have to delete the newOutputs of this milestone
@@ -286,6 +374,11 @@ func (u *Manager) RollbackConfirmationWithoutLocking(msIndex iotago.MilestoneInd
 			mutations.Cancel()
 			return err
 		}
+		if err := u.removeFromOutputIndex(output, mutations); err != nil {
+			mutations.Cancel()
+			return err
+		}
+		ledgerStateCommitment.RemoveOutput(output)
 	}
 
 	if rt != nil {
@@ -311,13 +404,31 @@ func (u *Manager) RollbackConfirmationWithoutLocking(msIndex iotago.MilestoneInd
 		mutations.Cancel()
 		return err
 	}
+	u.historyDiffCache.Remove(msIndex)
+
+	if err := mutations.Set(ledgerStateCommitmentKey, ledgerStateCommitment.Bytes()); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
+	if err := deleteMilestoneLedgerStateCommitment(msIndex, mutations); err != nil {
+		mutations.Cancel()
+		return err
+	}
 
 	if err := storeLedgerIndex(msIndex-1, mutations); err != nil {
 		mutations.Cancel()
 		return err
 	}
 
-	return mutations.Commit()
+	if err := mutations.Commit(); err != nil {
+		return err
+	}
+
+	u.ledgerStateCommitment = ledgerStateCommitment
+	u.ledgerStateCommitmentStored = true
+
+	return nil
 }
 
 func (u *Manager) RollbackConfirmation(msIndex iotago.MilestoneIndex, newOutputs Outputs, newSpents Spents, tm *TreasuryMutationTuple, rt *ReceiptTuple) error {
@@ -352,11 +463,24 @@ func (u *Manager) AddUnspentOutput(unspentOutput *Output) error {
 	u.WriteLockLedger()
 	defer u.WriteUnlockLedger()
 
+	if err := u.loadLedgerStateCommitmentWithoutLocking(); err != nil {
+		return err
+	}
+
 	mutations, err := u.utxoStorage.Batched()
 	if err != nil {
 		return err
 	}
 
+	if u.ledgerStateCommitmentStored {
+		// the commitment is only updated in memory while the outputs are added (e.g. during snapshot loading),
+		// the stored one is removed, so that it gets recomputed in case the node is stopped before it is stored again.
+		if err := mutations.Delete(ledgerStateCommitmentKey); err != nil {
+			mutations.Cancel()
+			return err
+		}
+	}
+
 	if err := storeOutput(unspentOutput, mutations); err != nil {
 		mutations.Cancel()
 		return err
@@ -367,7 +491,19 @@ func (u *Manager) AddUnspentOutput(unspentOutput *Output) error {
 		return err
 	}
 
-	return mutations.Commit()
+	if err := u.addToOutputIndex(unspentOutput, mutations); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
+	if err := mutations.Commit(); err != nil {
+		return err
+	}
+
+	u.ledgerStateCommitment.AddOutput(unspentOutput)
+	u.ledgerStateCommitmentStored = false
+
+	return nil
 }
 
 func (u *Manager) LedgerStateSHA256Sum() ([]byte, error) {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/libp2p/go-libp2p-core/host"
@@ -94,6 +95,8 @@ type ManagerEvents struct {
 	RelationUpdated *events.Event
 	// Fired when the Manager's state changes.
 	StateChange *events.Event
+	// Fired when a peer got banned because of its score.
+	Banned *events.Event
 	// Fired when internal error happens.
 	Error *events.Event
 }
@@ -129,6 +132,11 @@ func PeerDurationCaller(handler interface{}, params ...interface{}) {
 	handler.(func(*Peer, time.Duration))(params[0].(*Peer), params[1].(time.Duration))
 }
 
+// PeerIDDurationCaller gets called with a peer.ID and a time.Duration.
+func PeerIDDurationCaller(handler interface{}, params ...interface{}) {
+	handler.(func(peer.ID, time.Duration))(params[0].(peer.ID), params[1].(time.Duration))
+}
+
 // PeerConnCaller gets called with a Peer and its associated network.Conn.
 func PeerConnCaller(handler interface{}, params ...interface{}) {
 	handler.(func(*Peer, network.Conn))(params[0].(*Peer), params[1].(network.Conn))
@@ -142,6 +150,7 @@ func PeerRelationCaller(handler interface{}, params ...interface{}) {
 // the default options applied to the Manager.
 var defaultManagerOptions = []ManagerOption{
 	WithManagerReconnectInterval(30*time.Second, 1*time.Second),
+	WithManagerPeerBanning(-50, 1*time.Hour),
 }
 
 // ManagerOptions define options for a Manager.
@@ -152,6 +161,10 @@ type ManagerOptions struct {
 	reconnectInterval time.Duration
 	// The randomized jitter applied to the reconnect interval.
 	reconnectIntervalJitter time.Duration
+	// The score below which a peer gets banned.
+	banThreshold float64
+	// The duration for which a peer gets banned.
+	banDuration time.Duration
 }
 
 // ManagerOption is a function setting a ManagerOptions option.
@@ -173,6 +186,15 @@ func WithManagerReconnectInterval(interval time.Duration, jitter time.Duration)
 	}
 }
 
+// WithManagerPeerBanning defines the score below which a peer gets banned
+// and the duration for which no connections to the peer are allowed.
+func WithManagerPeerBanning(threshold float64, duration time.Duration) ManagerOption {
+	return func(opts *ManagerOptions) {
+		opts.banThreshold = threshold
+		opts.banDuration = duration
+	}
+}
+
 // applies the given ManagerOption.
 func (mo *ManagerOptions) apply(opts ...ManagerOption) {
 	for _, opt := range opts {
@@ -207,10 +229,12 @@ func NewManager(host host.Host, opts ...ManagerOption) *Manager {
 			Reconnected:        events.NewEvent(PeerCaller),
 			RelationUpdated:    events.NewEvent(PeerRelationCaller),
 			StateChange:        events.NewEvent(ManagerStateCaller),
+			Banned:             events.NewEvent(PeerIDDurationCaller),
 			Error:              events.NewEvent(events.ErrorCaller),
 		},
 		host:               host,
 		peers:              map[peer.ID]*Peer{},
+		scores:             map[peer.ID]*PeerScore{},
 		allowedPeers:       map[peer.ID]struct{}{},
 		opts:               mngOpts,
 		stopped:            typeutils.NewAtomicBool(),
@@ -245,6 +269,9 @@ type Manager struct {
 	peers map[peer.ID]*Peer
 	// holds the set of allowed peers (autopeering).
 	allowedPeers map[peer.ID]struct{}
+	// holds the scores of the peers, they are accessed outside the event loop.
+	scores     map[peer.ID]*PeerScore
+	scoresLock sync.Mutex
 	// holds the manager options.
 	opts *ManagerOptions
 	// tells whether the manager was shut down.
@@ -271,6 +298,7 @@ type Manager struct {
 	onP2PManagerReconnecting       *events.Closure
 	onP2PManagerRelationUpdated    *events.Closure
 	onP2PManagerStateChange        *events.Closure
+	onP2PManagerBanned             *events.Closure
 	onP2PManagerError              *events.Closure
 }
 
@@ -305,6 +333,7 @@ func (m *Manager) Start(ctx context.Context) {
 // shutdown sets the stopped flag and drains all outstanding requests of the event loop.
 func (m *Manager) shutdown() {
 	m.stopped.Set()
+	m.storePeerScores()
 
 	// drain all outstanding requests of the event loop.
 	// we do not care about correct handling of the channels, because we are shutting down anyway.
@@ -462,6 +491,7 @@ func (m *Manager) PeerInfoSnapshot(id peer.ID) *PeerInfoSnapshot {
 	m.Call(id, func(p *Peer) {
 		info = p.InfoSnapshot()
 		info.Connected = m.host.Network().Connectedness(p.ID) == network.Connected
+		info.Score = m.PeerScore(p.ID)
 	})
 	return info
 }
@@ -472,6 +502,7 @@ func (m *Manager) PeerInfoSnapshots() []*PeerInfoSnapshot {
 	m.ForEach(func(p *Peer) bool {
 		info := p.InfoSnapshot()
 		info.Connected = m.host.Network().Connectedness(p.ID) == network.Connected
+		info.Score = m.PeerScore(p.ID)
 		infos = append(infos, info)
 		return true
 	})
@@ -617,6 +648,12 @@ func (m *Manager) eventLoop(ctx context.Context) {
 			isConnectedReqMsg.back <- connected
 
 		case connectedMsg := <-m.connectedChan:
+			if m.IsBanned(connectedMsg.conn.RemotePeer()) {
+				// refuse connections from banned peers
+				_ = connectedMsg.conn.Close()
+				continue
+			}
+
 			p := m.peers[connectedMsg.conn.RemotePeer()]
 			m.addPeerAsUnknownIfAbsent(connectedMsg.conn)
 			if p != nil {
@@ -636,6 +673,7 @@ func (m *Manager) eventLoop(ctx context.Context) {
 				continue
 			}
 
+			m.flushPeerScore(id)
 			m.cleanupPeerIfNotKnown(id)
 			m.scheduleReconnectIfKnown(id)
 			if p != nil {
@@ -664,6 +702,10 @@ func (m *Manager) connectPeer(addrInfo *peer.AddrInfo, relation PeerRelation, al
 		return ErrCantConnectToItself
 	}
 
+	if m.IsBanned(addrInfo.ID) {
+		return ErrPeerBanned
+	}
+
 	p := NewPeer(addrInfo.ID, relation, addrInfo.Addrs, alias)
 	if p.Relation == PeerRelationKnown || p.Relation == PeerRelationAutopeered {
 		m.host.ConnManager().Protect(addrInfo.ID, PeerConnectivityProtectionTag)
@@ -770,7 +812,8 @@ func (m *Manager) scheduleReconnectIfKnown(peerID peer.ID) {
 	}
 	p.connectedEventCalled = false
 
-	delay := m.opts.reconnectDelay()
+	// banned peers are reconnected after the ban
+	delay := m.opts.reconnectDelay() + m.remainingBan(peerID)
 	p.reconnectTimer = time.AfterFunc(delay, func() {
 		if m.stopped.IsSet() {
 			return
@@ -925,6 +968,10 @@ func (m *Manager) configureEvents() {
 		m.LogInfo(mngState)
 	})
 
+	m.onP2PManagerBanned = events.NewClosure(func(peerID peer.ID, dur time.Duration) {
+		m.LogWarnf("banned %s for %v because of its score", peerID.ShortString(), dur)
+	})
+
 	m.onP2PManagerError = events.NewClosure(func(err error) {
 		m.LogWarn(err)
 	})
@@ -939,6 +986,7 @@ func (m *Manager) attachEvents() {
 	m.Events.Reconnecting.Attach(m.onP2PManagerReconnecting)
 	m.Events.RelationUpdated.Attach(m.onP2PManagerRelationUpdated)
 	m.Events.StateChange.Attach(m.onP2PManagerStateChange)
+	m.Events.Banned.Attach(m.onP2PManagerBanned)
 	m.Events.Error.Attach(m.onP2PManagerError)
 }
 
@@ -951,6 +999,7 @@ func (m *Manager) detachEvents() {
 	m.Events.Reconnecting.Detach(m.onP2PManagerReconnecting)
 	m.Events.RelationUpdated.Detach(m.onP2PManagerRelationUpdated)
 	m.Events.StateChange.Detach(m.onP2PManagerStateChange)
+	m.Events.Banned.Detach(m.onP2PManagerBanned)
 	m.Events.Error.Detach(m.onP2PManagerError)
 }
 
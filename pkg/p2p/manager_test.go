@@ -312,6 +312,83 @@ func TestManagerEvents(t *testing.T) {
 	require.True(t, reconnectedCalled)
 }
 
+func TestManagerPeerBanning(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := configuration.New()
+	err := cfg.Set("logger.disableStacktrace", true)
+	require.NoError(t, err)
+
+	// no need to check the error, since the global logger could already be initialized
+	_ = logger.InitGlobalLogger(cfg)
+
+	reconnectOpt := p2p.WithManagerReconnectInterval(1*time.Second, 500*time.Millisecond)
+	banDuration := 3 * time.Second
+
+	node1 := newNode(t)
+	node1Logger := logger.NewLogger(fmt.Sprintf("node1/%s", node1.ID().ShortString()))
+	node1Manager := p2p.NewManager(node1, p2p.WithManagerLogger(node1Logger), reconnectOpt, p2p.WithManagerPeerBanning(-50, banDuration))
+	go node1Manager.Start(ctx)
+	node1AddrInfo := &peer.AddrInfo{ID: node1.ID(), Addrs: node1.Addrs()[:1]}
+
+	node2 := newNode(t)
+	node2Logger := logger.NewLogger(fmt.Sprintf("node2/%s", node2.ID().ShortString()))
+	node2Manager := p2p.NewManager(node2, p2p.WithManagerLogger(node2Logger), reconnectOpt)
+	go node2Manager.Start(ctx)
+	node2AddrInfo := &peer.AddrInfo{ID: node2.ID(), Addrs: node2.Addrs()[:1]}
+
+	var bannedCalled bool
+	node1Manager.Events.Banned.Attach(events.NewClosure(func(_ peer.ID, _ time.Duration) {
+		bannedCalled = true
+	}))
+
+	go func() {
+		_ = node1Manager.ConnectPeer(node2AddrInfo, p2p.PeerRelationKnown)
+	}()
+	connectivity(t, node1Manager, node2.ID(), false)
+	connectivity(t, node2Manager, node1.ID(), false)
+
+	// timeouts alone never get a peer banned
+	for i := 0; i < 1000; i++ {
+		node1Manager.UpdatePeerUnansweredRequests(node2.ID(), 100)
+	}
+	require.False(t, node1Manager.IsBanned(node2.ID()))
+	score := node1Manager.PeerScore(node2.ID())
+	require.Less(t, score.Value(), 0.0)
+
+	// invalid data only decreases the score until the threshold is reached
+	node1Manager.UpdatePeerScore(node2.ID(), p2p.PeerScoreEventInvalidData)
+	node1Manager.UpdatePeerScore(node2.ID(), p2p.PeerScoreEventInvalidData)
+	require.False(t, node1Manager.IsBanned(node2.ID()))
+	connectivity(t, node1Manager, node2.ID(), false)
+
+	node1Manager.UpdatePeerScore(node2.ID(), p2p.PeerScoreEventInvalidData)
+	require.True(t, node1Manager.IsBanned(node2.ID()))
+	require.True(t, bannedCalled)
+	connectivity(t, node1Manager, node2.ID(), true)
+	connectivity(t, node2Manager, node1.ID(), true)
+
+	// the banned peer stays known
+	var known bool
+	node1Manager.Call(node2.ID(), func(peer *p2p.Peer) {
+		known = peer.Relation == p2p.PeerRelationKnown
+	})
+	require.True(t, known)
+
+	// connections from the banned peer are refused
+	_ = node2Manager.ConnectPeer(node1AddrInfo, p2p.PeerRelationUnknown)
+	require.Eventually(t, func() bool {
+		return node1.Network().Connectedness(node2.ID()) != network.Connected
+	}, 4*time.Second, 10*time.Millisecond)
+	require.NoError(t, node2Manager.DisconnectPeer(node1.ID()))
+
+	// the known peer is reconnected after the ban
+	connectivity(t, node1Manager, node2.ID(), false, banDuration+5*time.Second)
+	connectivity(t, node2Manager, node1.ID(), false)
+	require.False(t, node1Manager.IsBanned(node2.ID()))
+}
+
 func BenchmarkManager_ForEach(b *testing.B) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -0,0 +1,276 @@
+package p2p
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/pkg/errors"
+)
+
+const (
+	// the key under which the score of a peer is stored in the peer store.
+	peerScoreMetadataKey = "hornet-peer-score"
+
+	// the time after which half of the score of a peer is forgotten.
+	peerScoreHalfLife = 30 * time.Minute
+	// the bounds of the score of a peer.
+	peerScoreMin = -100
+	peerScoreMax = 100
+
+	// the score penalty per second of average latency.
+	peerScoreLatencyPenaltyPerSecond = 10
+	// the maximum score penalty for the average latency.
+	peerScoreLatencyPenaltyMax = 20
+
+	// the score penalty per interval in which the peer didn't answer requests.
+	peerScoreUnansweredPenaltyPerInterval = 1
+	// the maximum score penalty for unanswered requests.
+	peerScoreUnansweredPenaltyMax = 20
+)
+
+var (
+	// ErrPeerBanned gets returned if a connection to a banned peer is supposed to be created.
+	ErrPeerBanned = errors.New("peer is banned")
+)
+
+// PeerScoreEvent is an observed behavior of a peer that changes its score.
+type PeerScoreEvent int
+
+const (
+	// PeerScoreEventInvalidData means that the peer sent an invalid block or an invalid request.
+	PeerScoreEventInvalidData PeerScoreEvent = iota
+	// PeerScoreEventNewBlock means that the peer sent a block that was not known yet.
+	PeerScoreEventNewBlock
+)
+
+// the score changes of the events.
+var peerScoreEventWeights = map[PeerScoreEvent]float64{
+	PeerScoreEventInvalidData: -25,
+	PeerScoreEventNewBlock:    0.05,
+}
+
+// PeerScore is the reputation of a peer based on its past behavior.
+type PeerScore struct {
+	// The score based on the observed events, it decays towards zero over time.
+	Score float64 `json:"score"`
+	// The penalty for unanswered requests, it decays towards zero over time.
+	// It is kept apart from the score, so that timeouts alone never get a peer banned.
+	UnansweredPenalty float64 `json:"unansweredPenalty"`
+	// The amount of invalid blocks and requests received from the peer.
+	InvalidData uint64 `json:"invalidData"`
+	// The amount of requests the peer didn't answer.
+	UnansweredRequests uint64 `json:"unansweredRequests"`
+	// The amount of new blocks received from the peer.
+	NewBlocks uint64 `json:"newBlocks"`
+	// The moving average of the time it took the peer to answer a request.
+	Latency time.Duration `json:"latency"`
+	// The time the score was last updated.
+	UpdatedAt time.Time `json:"updatedAt"`
+	// The time until the peer is banned, zero if it was never banned.
+	BannedUntil time.Time `json:"bannedUntil"`
+}
+
+// Value returns the score reduced by the penalties for the average latency and the unanswered requests of the peer.
+func (s *PeerScore) Value() float64 {
+	latencyPenalty := math.Min(s.Latency.Seconds()*peerScoreLatencyPenaltyPerSecond, peerScoreLatencyPenaltyMax)
+	return s.ValueWithoutLatency() - latencyPenalty
+}
+
+// ValueWithoutLatency returns the score reduced by the penalty for the unanswered requests of the peer.
+// It is used by callers that already take the latency of the peer into account on their own.
+func (s *PeerScore) ValueWithoutLatency() float64 {
+	return s.Score - s.UnansweredPenalty
+}
+
+// Banned tells whether the peer is banned at the given time.
+func (s *PeerScore) Banned(now time.Time) bool {
+	return now.Before(s.BannedUntil)
+}
+
+// decays the score towards zero for the time passed since the last update.
+func (s *PeerScore) decay(now time.Time) {
+	if !s.UpdatedAt.IsZero() && now.After(s.UpdatedAt) {
+		factor := math.Pow(0.5, float64(now.Sub(s.UpdatedAt))/float64(peerScoreHalfLife))
+		s.Score *= factor
+		s.UnansweredPenalty *= factor
+	}
+	s.UpdatedAt = now
+}
+
+// applies the given event to the score.
+// invalid data forfeits the positive score the peer earned, so it can't be offset by valid blocks.
+func (s *PeerScore) apply(event PeerScoreEvent) {
+	switch event {
+	case PeerScoreEventInvalidData:
+		s.InvalidData++
+		s.Score = math.Min(s.Score, 0)
+	case PeerScoreEventNewBlock:
+		s.NewBlocks++
+	}
+
+	s.Score = math.Max(peerScoreMin, math.Min(peerScoreMax, s.Score+peerScoreEventWeights[event]))
+}
+
+// adds the given amount of requests which were not answered within an interval.
+// the penalty only grows by a fixed amount per interval, independent of the amount of requests.
+func (s *PeerScore) addUnansweredRequests(count int) {
+	s.UnansweredRequests += uint64(count)
+	s.UnansweredPenalty = math.Min(peerScoreUnansweredPenaltyMax, s.UnansweredPenalty+peerScoreUnansweredPenaltyPerInterval)
+}
+
+// adds the given latency to the moving average.
+func (s *PeerScore) addLatency(latency time.Duration) {
+	if s.Latency == 0 {
+		s.Latency = latency
+		return
+	}
+	s.Latency = (s.Latency*9 + latency) / 10
+}
+
+// returns the score of the given peer, it is loaded from the peer store if it is not cached.
+// scoresLock must be held.
+func (m *Manager) peerScoreWithoutLocking(peerID peer.ID) *PeerScore {
+	if score, has := m.scores[peerID]; has {
+		return score
+	}
+
+	score := m.loadPeerScore(peerID)
+	m.scores[peerID] = score
+
+	return score
+}
+
+// loads the score of the given peer from the peer store, without caching it.
+func (m *Manager) loadPeerScore(peerID peer.ID) *PeerScore {
+	score := &PeerScore{}
+	if value, err := m.host.Peerstore().Get(peerID, peerScoreMetadataKey); err == nil {
+		if data, ok := value.([]byte); ok {
+			if err := json.Unmarshal(data, score); err != nil {
+				score = &PeerScore{}
+			}
+		}
+	}
+
+	return score
+}
+
+// persists the score of the given peer in the peer store.
+// scoresLock must be held.
+func (m *Manager) storePeerScoreWithoutLocking(peerID peer.ID) {
+	score, has := m.scores[peerID]
+	if !has {
+		return
+	}
+
+	data, err := json.Marshal(score)
+	if err != nil {
+		return
+	}
+
+	if err := m.host.Peerstore().Put(peerID, peerScoreMetadataKey, data); err != nil {
+		m.Events.Error.Trigger(errors.Wrapf(err, "unable to store score of %s", peerID.ShortString()))
+	}
+}
+
+// persists the score of the given peer in the peer store and removes it from the cache.
+func (m *Manager) flushPeerScore(peerID peer.ID) {
+	m.scoresLock.Lock()
+	defer m.scoresLock.Unlock()
+
+	m.storePeerScoreWithoutLocking(peerID)
+	delete(m.scores, peerID)
+}
+
+// persists the scores of all peers in the peer store.
+func (m *Manager) storePeerScores() {
+	m.scoresLock.Lock()
+	defer m.scoresLock.Unlock()
+
+	for peerID := range m.scores {
+		m.storePeerScoreWithoutLocking(peerID)
+	}
+}
+
+// UpdatePeerScore applies the given event to the score of the given peer.
+// If the score drops below the ban threshold, the peer is banned and disconnected.
+func (m *Manager) UpdatePeerScore(peerID peer.ID, event PeerScoreEvent) {
+	m.scoresLock.Lock()
+
+	now := time.Now()
+	score := m.peerScoreWithoutLocking(peerID)
+	score.decay(now)
+	score.apply(event)
+
+	if score.Banned(now) || score.Score > m.opts.banThreshold {
+		m.scoresLock.Unlock()
+		return
+	}
+
+	score.BannedUntil = now.Add(m.opts.banDuration)
+	m.storePeerScoreWithoutLocking(peerID)
+	m.scoresLock.Unlock()
+
+	m.Events.Banned.Trigger(peerID, m.opts.banDuration)
+
+	// the peer stays in the Manager, so that known peers are reconnected after the ban
+	if err := m.host.Network().ClosePeer(peerID); err != nil {
+		m.Events.Error.Trigger(errors.Wrapf(err, "error disconnecting banned peer %s", peerID.ShortString()))
+	}
+}
+
+// UpdatePeerLatency adds the time it took the given peer to answer a request to its average latency.
+func (m *Manager) UpdatePeerLatency(peerID peer.ID, latency time.Duration) {
+	m.scoresLock.Lock()
+	defer m.scoresLock.Unlock()
+
+	score := m.peerScoreWithoutLocking(peerID)
+	score.decay(time.Now())
+	score.addLatency(latency)
+}
+
+// UpdatePeerUnansweredRequests adds the given amount of requests the given peer didn't answer within an interval to its score.
+// It is supposed to be called at most once per interval and peer. Unanswered requests never get a peer banned.
+func (m *Manager) UpdatePeerUnansweredRequests(peerID peer.ID, count int) {
+	m.scoresLock.Lock()
+	defer m.scoresLock.Unlock()
+
+	score := m.peerScoreWithoutLocking(peerID)
+	score.decay(time.Now())
+	score.addUnansweredRequests(count)
+}
+
+// PeerScore returns a copy of the current score of the given peer.
+func (m *Manager) PeerScore(peerID peer.ID) PeerScore {
+	m.scoresLock.Lock()
+	defer m.scoresLock.Unlock()
+
+	score := m.peerScoreWithoutLocking(peerID)
+	score.decay(time.Now())
+
+	return *score
+}
+
+// IsBanned tells whether the given peer is currently banned.
+func (m *Manager) IsBanned(peerID peer.ID) bool {
+	return m.remainingBan(peerID) > 0
+}
+
+// returns the remaining duration of the ban of the given peer.
+// the score of peers which are not cached is not added to the cache,
+// since this is checked for every peer that connects.
+func (m *Manager) remainingBan(peerID peer.ID) time.Duration {
+	m.scoresLock.Lock()
+	defer m.scoresLock.Unlock()
+
+	score, has := m.scores[peerID]
+	if !has {
+		score = m.loadPeerScore(peerID)
+	}
+	if !score.Banned(time.Now()) {
+		return 0
+	}
+
+	return time.Until(score.BannedUntil)
+}
@@ -0,0 +1,72 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerScore(t *testing.T) {
+	now := time.Now()
+
+	score := &PeerScore{}
+	score.decay(now)
+
+	score.apply(PeerScoreEventInvalidData)
+	score.addUnansweredRequests(2)
+	require.Equal(t, -25.0, score.Score)
+	require.Equal(t, -26.0, score.Value())
+	require.EqualValues(t, 1, score.InvalidData)
+	require.EqualValues(t, 2, score.UnansweredRequests)
+
+	// half of the score is forgotten after the half-life
+	score.decay(now.Add(peerScoreHalfLife))
+	require.InDelta(t, -12.5, score.Score, 0.0001)
+	require.InDelta(t, -13.0, score.Value(), 0.0001)
+
+	// the score is bounded
+	for i := 0; i < 10; i++ {
+		score.apply(PeerScoreEventInvalidData)
+	}
+	require.Equal(t, float64(peerScoreMin), score.Score)
+
+	// the latency penalty is bounded
+	score = &PeerScore{}
+	score.addLatency(500 * time.Millisecond)
+	require.Equal(t, -5.0, score.Value())
+	require.Equal(t, 0.0, score.ValueWithoutLatency())
+	score.addLatency(time.Minute)
+	require.Equal(t, float64(-peerScoreLatencyPenaltyMax), score.Value())
+
+	// invalid data forfeits the positive score
+	score = &PeerScore{}
+	for i := 0; i < 10000; i++ {
+		score.apply(PeerScoreEventNewBlock)
+	}
+	require.Equal(t, float64(peerScoreMax), score.Score)
+	score.apply(PeerScoreEventInvalidData)
+	require.Equal(t, -25.0, score.Score)
+
+	score.BannedUntil = now.Add(time.Hour)
+	require.True(t, score.Banned(now))
+	require.False(t, score.Banned(now.Add(2*time.Hour)))
+}
+
+func TestPeerScoreUnansweredRequests(t *testing.T) {
+	now := time.Now()
+
+	score := &PeerScore{}
+	score.decay(now)
+
+	// the penalty for unanswered requests is bounded and doesn't affect the score the bans are based on
+	for i := 0; i < 1000; i++ {
+		score.addUnansweredRequests(100)
+	}
+	require.EqualValues(t, 100000, score.UnansweredRequests)
+	require.Zero(t, score.Score)
+	require.Equal(t, float64(-peerScoreUnansweredPenaltyMax), score.Value())
+
+	score.decay(now.Add(peerScoreHalfLife))
+	require.InDelta(t, -peerScoreUnansweredPenaltyMax/2, score.Value(), 0.0001)
+}
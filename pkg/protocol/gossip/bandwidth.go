@@ -0,0 +1,170 @@
+package gossip
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/iotaledger/hive.go/protocol/message"
+	"github.com/iotaledger/hive.go/protocol/tlv"
+)
+
+const (
+	// priorityUploadShare is the divisor of the upload limit which defines the reserved upload bandwidth for priority messages.
+	priorityUploadShare = 10
+)
+
+var (
+	// priorityMessageTypes are the message types which are sent before any other queued messages
+	// and which are not delayed by the other traffic.
+	priorityMessageTypes = map[message.Type]struct{}{
+		MessageTypeHandshake:            {},
+		MessageTypeHeartbeat:            {},
+		MessageTypeMilestoneRequest:     {},
+		MessageTypeMilestoneConeRequest: {},
+	}
+)
+
+// isPriorityMessage tells whether the given gossip message is sent with priority.
+func isPriorityMessage(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+	_, priority := priorityMessageTypes[message.Type(data[0])]
+	return priority
+}
+
+// maxMessageBytesLength returns the maximum size of a gossip message including its header.
+func maxMessageBytesLength() int {
+	var maxBytesLength int
+	for _, def := range gossipMessageRegistry.Definitions() {
+		if def != nil && int(def.MaxBytesLength) > maxBytesLength {
+			maxBytesLength = int(def.MaxBytesLength)
+		}
+	}
+	return int(tlv.HeaderMessageDefinition.MaxBytesLength) + maxBytesLength
+}
+
+// newRateLimiter creates a token bucket which allows the given amount of bytes per second.
+// A limit of zero means that the bandwidth is not limited.
+func newRateLimiter(bytesPerSecond int) *rate.Limiter {
+	if bytesPerSecond <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+
+	// the burst must fit the biggest message, otherwise it could never be sent
+	burst := bytesPerSecond
+	if maxBytesLength := maxMessageBytesLength(); burst < maxBytesLength {
+		burst = maxBytesLength
+	}
+
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), burst)
+}
+
+// BandwidthLimiter limits the amount of bytes sent and received per second.
+type BandwidthLimiter struct {
+	upload *rate.Limiter
+	// the reserved upload bandwidth for priority messages, which only wait for this limiter.
+	priorityUpload *rate.Limiter
+	download       *rate.Limiter
+}
+
+// NewBandwidthLimiter creates a new BandwidthLimiter with the given limits in bytes per second.
+// A limit of zero means that the bandwidth is not limited.
+// A share of the upload limit is reserved for priority messages.
+func NewBandwidthLimiter(uploadBytesPerSecond int, downloadBytesPerSecond int) *BandwidthLimiter {
+	priorityUploadBytesPerSecond := uploadBytesPerSecond / priorityUploadShare
+	if uploadBytesPerSecond > 0 && priorityUploadBytesPerSecond == 0 {
+		priorityUploadBytesPerSecond = 1
+	}
+
+	return &BandwidthLimiter{
+		upload:         newRateLimiter(uploadBytesPerSecond),
+		priorityUpload: newRateLimiter(priorityUploadBytesPerSecond),
+		download:       newRateLimiter(downloadBytesPerSecond),
+	}
+}
+
+// waitForTokens waits until the given amount of bytes is allowed by all the given limiters.
+// If wait is false, the bytes are only taken from the limiters, so that the following traffic gets delayed instead.
+// Returns false if the protocol was terminated while waiting.
+func waitForTokens(terminated <-chan struct{}, limiters []*rate.Limiter, n int, wait bool) bool {
+	now := time.Now()
+
+	var delay time.Duration
+	reservations := make([]*rate.Reservation, 0, len(limiters))
+	for _, limiter := range limiters {
+		reservation := limiter.ReserveN(now, n)
+		if !reservation.OK() {
+			// the amount exceeds the burst of the limiter, so it can't be limited
+			continue
+		}
+		reservations = append(reservations, reservation)
+
+		if reservationDelay := reservation.DelayFrom(now); reservationDelay > delay {
+			delay = reservationDelay
+		}
+	}
+
+	if !wait || delay == 0 {
+		return true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-terminated:
+		for _, reservation := range reservations {
+			reservation.Cancel()
+		}
+		return false
+	}
+}
+
+// bandwidthMeter measures the amount of bytes transferred in the last full second.
+type bandwidthMeter struct {
+	sync.Mutex
+	// the second the bytes of the current bucket were transferred in.
+	currentSecond int64
+	// the bytes transferred in the current second.
+	currentBytes uint64
+	// the bytes transferred in the previous second.
+	previousBytes uint64
+}
+
+// rolls the buckets over to the given second.
+// the lock must be held.
+func (m *bandwidthMeter) rollWithoutLocking(second int64) {
+	switch {
+	case second == m.currentSecond:
+		return
+	case second == m.currentSecond+1:
+		m.previousBytes = m.currentBytes
+	default:
+		m.previousBytes = 0
+	}
+	m.currentSecond = second
+	m.currentBytes = 0
+}
+
+// add adds the given amount of transferred bytes.
+func (m *bandwidthMeter) add(bytes int) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.rollWithoutLocking(time.Now().Unix())
+	m.currentBytes += uint64(bytes)
+}
+
+// bytesPerSecond returns the amount of bytes transferred in the last full second.
+func (m *bandwidthMeter) bytesPerSecond() uint64 {
+	m.Lock()
+	defer m.Unlock()
+
+	m.rollWithoutLocking(time.Now().Unix())
+	return m.previousBytes
+}
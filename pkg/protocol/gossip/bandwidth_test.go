@@ -0,0 +1,41 @@
+package gossip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestBandwidthLimiter(t *testing.T) {
+	heartbeatMessage, err := newHeartbeatMessage(1, 0, 1, 1, 1)
+	require.NoError(t, err)
+	require.True(t, isPriorityMessage(heartbeatMessage))
+
+	blockMessage, err := newBlockMessage([]byte{1, 2, 3})
+	require.NoError(t, err)
+	require.False(t, isPriorityMessage(blockMessage))
+
+	terminated := make(chan struct{})
+	close(terminated)
+
+	// unlimited bandwidth never waits
+	unlimited := NewBandwidthLimiter(0, 0)
+	require.True(t, waitForTokens(terminated, []*rate.Limiter{unlimited.upload}, 1_000_000, true))
+
+	// the burst allows the biggest message, the following bytes have to wait
+	limited := NewBandwidthLimiter(1, 1)
+	require.True(t, waitForTokens(terminated, []*rate.Limiter{unlimited.upload, limited.upload}, maxMessageBytesLength(), true))
+	require.False(t, waitForTokens(terminated, []*rate.Limiter{unlimited.upload, limited.upload}, 10, true))
+
+	// priority messages are not delayed by the upload limits
+	require.True(t, waitForTokens(terminated, []*rate.Limiter{limited.upload}, 10, false))
+
+	// but priority messages are limited by the reserved upload bandwidth for priority messages
+	require.True(t, waitForTokens(terminated, []*rate.Limiter{limited.priorityUpload}, maxMessageBytesLength(), true))
+	require.False(t, waitForTokens(terminated, []*rate.Limiter{limited.priorityUpload}, 10, true))
+
+	// the reserved upload bandwidth for priority messages is a share of the upload limit
+	require.Equal(t, rate.Limit(100), NewBandwidthLimiter(1000, 0).priorityUpload.Limit())
+	require.Equal(t, rate.Inf, unlimited.priorityUpload.Limit())
+}
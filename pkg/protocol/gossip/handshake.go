@@ -0,0 +1,171 @@
+package gossip
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/protocol/message"
+	"github.com/iotaledger/hive.go/protocol/tlv"
+	"github.com/iotaledger/hive.go/serializer/v2"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// ProtocolVersion is the version of the gossip protocol advertised in the handshake.
+	ProtocolVersion byte = 1
+
+	// handshakeMinBytesLength defines the amount of bytes of a handshake without the supported message types.
+	// protocol version + pruning index + capabilities + message types count
+	handshakeMinBytesLength = 1 + serializer.UInt32ByteSize + serializer.UInt32ByteSize + 1
+
+	// handshakeMaxMessageTypesCount defines the maximum amount of supported message types in a handshake.
+	handshakeMaxMessageTypesCount = 255
+
+	// handshakeReservedBytesLength defines the amount of bytes reserved for fields added by later protocol versions.
+	handshakeReservedBytesLength = 256
+)
+
+var (
+	// ErrInvalidHandshake is returned when a received handshake is malformed.
+	ErrInvalidHandshake = errors.New("invalid handshake")
+
+	// handshakeMessageDefinition defines the handshake message which is exchanged when a stream is opened.
+	// Bytes after the supported message types are ignored, so that later protocol versions can append fields.
+	handshakeMessageDefinition = &message.Definition{
+		ID:             MessageTypeHandshake,
+		MaxBytesLength: handshakeMinBytesLength + handshakeMaxMessageTypesCount + handshakeReservedBytesLength,
+		VariableLength: true,
+	}
+
+	// legacyMessageTypes are the message types every peer supports, even if it didn't send a handshake.
+	legacyMessageTypes = []message.Type{
+		MessageTypeMilestoneRequest,
+		MessageTypeBlock,
+		MessageTypeBlockRequest,
+		MessageTypeHeartbeat,
+	}
+)
+
+// Capabilities are the features a node offers to its peers.
+type Capabilities uint32
+
+const (
+	// CapabilityArchive means that the node keeps the pruned milestone cones in an archive.
+	CapabilityArchive Capabilities = 1 << iota
+	// CapabilitySnapshots means that the node serves its snapshot files to peers.
+	CapabilitySnapshots
+)
+
+// Has tells whether the given capability is set.
+func (c Capabilities) Has(capability Capabilities) bool {
+	return c&capability == capability
+}
+
+// Handshake is exchanged when a gossip stream is opened and advertises the features of a node.
+type Handshake struct {
+	// The version of the gossip protocol.
+	ProtocolVersion byte
+	// The pruning index of the node.
+	PruningIndex iotago.MilestoneIndex
+	// The capabilities of the node.
+	Capabilities Capabilities
+	// The types of the gossip messages the node is able to process.
+	MessageTypes []message.Type
+}
+
+// NewHandshake creates a new handshake of the current protocol version advertising all registered message types.
+func NewHandshake(pruningIndex iotago.MilestoneIndex, capabilities Capabilities) *Handshake {
+	var messageTypes []message.Type
+	for _, def := range gossipMessageRegistry.Definitions() {
+		if def == nil || def.ID == tlv.HeaderMessageDefinition.ID {
+			continue
+		}
+		messageTypes = append(messageTypes, def.ID)
+	}
+
+	return &Handshake{
+		ProtocolVersion: ProtocolVersion,
+		PruningIndex:    pruningIndex,
+		Capabilities:    capabilities,
+		MessageTypes:    messageTypes,
+	}
+}
+
+// SupportsMessageType tells whether the node is able to process messages of the given type.
+func (h *Handshake) SupportsMessageType(msgType message.Type) bool {
+	for _, supportedType := range h.MessageTypes {
+		if supportedType == msgType {
+			return true
+		}
+	}
+	return false
+}
+
+// newHandshakeMessage creates a new handshake message.
+func newHandshakeMessage(handshake *Handshake) ([]byte, error) {
+	if len(handshake.MessageTypes) > handshakeMaxMessageTypesCount {
+		return nil, errors.Wrapf(ErrInvalidHandshake, "too many message types: %d", len(handshake.MessageTypes))
+	}
+
+	handshakeBytesLength := uint16(handshakeMinBytesLength + len(handshake.MessageTypes))
+	buf := bytes.NewBuffer(make([]byte, 0, tlv.HeaderMessageDefinition.MaxBytesLength+handshakeBytesLength))
+
+	if err := tlv.WriteHeader(buf, MessageTypeHandshake, handshakeBytesLength); err != nil {
+		return nil, err
+	}
+
+	if err := binary.Write(buf, binary.LittleEndian, handshake.ProtocolVersion); err != nil {
+		return nil, err
+	}
+
+	if err := binary.Write(buf, binary.LittleEndian, handshake.PruningIndex); err != nil {
+		return nil, err
+	}
+
+	if err := binary.Write(buf, binary.LittleEndian, uint32(handshake.Capabilities)); err != nil {
+		return nil, err
+	}
+
+	if err := binary.Write(buf, binary.LittleEndian, uint8(len(handshake.MessageTypes))); err != nil {
+		return nil, err
+	}
+
+	for _, msgType := range handshake.MessageTypes {
+		if err := binary.Write(buf, binary.LittleEndian, msgType); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ParseHandshake parses the given message into a handshake.
+func ParseHandshake(data []byte) (*Handshake, error) {
+	if len(data) < handshakeMinBytesLength {
+		return nil, errors.Wrapf(ErrInvalidHandshake, "length %d is too short", len(data))
+	}
+
+	handshake := &Handshake{
+		ProtocolVersion: data[0],
+		PruningIndex:    binary.LittleEndian.Uint32(data[1:5]),
+		Capabilities:    Capabilities(binary.LittleEndian.Uint32(data[5:9])),
+	}
+
+	messageTypesCount := int(data[9])
+	if len(data) < handshakeMinBytesLength+messageTypesCount {
+		return nil, errors.Wrapf(ErrInvalidHandshake, "length %d is too short for %d message types", len(data), messageTypesCount)
+	}
+
+	handshake.MessageTypes = make([]message.Type, messageTypesCount)
+	for i := 0; i < messageTypesCount; i++ {
+		handshake.MessageTypes[i] = message.Type(data[handshakeMinBytesLength+i])
+	}
+
+	return handshake, nil
+}
+
+func handshakeCaller(handler interface{}, params ...interface{}) {
+	handler.(func(handshake *Handshake))(params[0].(*Handshake))
+}
@@ -0,0 +1,41 @@
+package gossip_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hornet/pkg/protocol/gossip"
+)
+
+func TestParseHandshake(t *testing.T) {
+	data := []byte{
+		// protocol version
+		1,
+		// pruning index
+		0x39, 0x30, 0x00, 0x00,
+		// capabilities
+		byte(gossip.CapabilityArchive | gossip.CapabilitySnapshots), 0, 0, 0,
+		// message types
+		2, byte(gossip.MessageTypeBlock), byte(gossip.MessageTypeHandshake),
+		// fields of later protocol versions are ignored
+		0xff, 0xff,
+	}
+
+	handshake, err := gossip.ParseHandshake(data)
+	require.NoError(t, err)
+	require.Equal(t, byte(1), handshake.ProtocolVersion)
+	require.EqualValues(t, 12345, handshake.PruningIndex)
+	require.True(t, handshake.Capabilities.Has(gossip.CapabilityArchive))
+	require.True(t, handshake.Capabilities.Has(gossip.CapabilitySnapshots))
+	require.True(t, handshake.SupportsMessageType(gossip.MessageTypeHandshake))
+	require.False(t, handshake.SupportsMessageType(gossip.MessageTypeHeartbeat))
+
+	_, err = gossip.ParseHandshake(data[:11])
+	require.ErrorIs(t, err, gossip.ErrInvalidHandshake)
+
+	handshake = gossip.NewHandshake(1, 0)
+	require.Equal(t, gossip.ProtocolVersion, handshake.ProtocolVersion)
+	require.True(t, handshake.SupportsMessageType(gossip.MessageTypeHeartbeat))
+	require.True(t, handshake.SupportsMessageType(gossip.MessageTypeHandshake))
+}
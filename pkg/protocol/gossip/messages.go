@@ -17,6 +17,11 @@ func init() {
 		blockMessageDefinition,
 		blockRequestMessageDefinition,
 		heartbeatMessageDefinition,
+		handshakeMessageDefinition,
+		blockRequestsMessageDefinition,
+		blocksMessageDefinition,
+		milestoneConeRequestMessageDefinition,
+		milestoneConeMessageDefinition,
 	}
 	gossipMessageRegistry = hiveproto.NewRegistry(definitions)
 }
@@ -0,0 +1,142 @@
+package gossip
+
+import (
+	"sync"
+	"time"
+
+	"github.com/iotaledger/hornet/pkg/model/storage"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// milestoneConeMaxResponses defines the maximum amount of responses accepted for a milestone cone request.
+	// every response except the last one is only sent once the next block doesn't fit anymore,
+	// so it contains at least half of blocksBatchMaxBytesLength.
+	milestoneConeMaxResponses = 2*milestoneConeMaxBytesLength/blocksBatchMaxBytesLength + 2
+)
+
+// milestoneCone tracks the blocks received for a milestone cone requested from a peer.
+// only the blocks which are reached by walking back from the parents of the verified milestone
+// are treated as requested, all other blocks are handled like separately received blocks.
+type milestoneCone struct {
+	sync.Mutex
+
+	// the time the cone was requested.
+	requestTime time.Time
+	// whether the walk was started at the parents of the milestone.
+	started bool
+	// the IDs of the blocks reached by walking back from the parents of the milestone.
+	reached map[iotago.BlockID]struct{}
+	// the received blocks which were not reached yet.
+	pending map[iotago.BlockID]*storage.Block
+	// the amount of responses received for the cone, secured by the lock of the requested milestone cones of the peer.
+	responses int
+	// the amount of blocks accepted for the cone.
+	blocks int
+}
+
+func newMilestoneCone(requestTime time.Time) *milestoneCone {
+	return &milestoneCone{
+		requestTime: requestTime,
+		reached:     make(map[iotago.BlockID]struct{}),
+		pending:     make(map[iotago.BlockID]*storage.Block),
+	}
+}
+
+// isStarted tells whether the walk was already started at the parents of the milestone.
+func (c *milestoneCone) isStarted() bool {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.started
+}
+
+// start starts the walk at the given parents of the verified milestone.
+// returns the pending blocks which were reached by it.
+func (c *milestoneCone) start(milestoneParents iotago.BlockIDs) []*storage.Block {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.started {
+		return nil
+	}
+	c.started = true
+
+	var reachedBlocks []*storage.Block
+	for _, parent := range milestoneParents {
+		reachedBlocks = append(reachedBlocks, c.reachWithoutLocking(parent)...)
+	}
+
+	return reachedBlocks
+}
+
+// add adds a received block to the cone.
+// returns the blocks which were reached by it, including the block itself if it was reached.
+// returns false if the maximum amount of blocks for the cone was exceeded.
+func (c *milestoneCone) add(block *storage.Block) ([]*storage.Block, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.blocks >= milestoneConeMaxBlocks {
+		return nil, false
+	}
+	c.blocks++
+
+	blockID := block.BlockID()
+	if _, exists := c.pending[blockID]; exists {
+		// the block was already received
+		return nil, true
+	}
+	c.pending[blockID] = block
+
+	if _, reached := c.reached[blockID]; !reached {
+		return nil, true
+	}
+
+	return c.reachWithoutLocking(blockID), true
+}
+
+// reachWithoutLocking marks the block with the given ID as reached and walks back
+// through the pending blocks starting at it.
+// returns the pending blocks which were reached.
+func (c *milestoneCone) reachWithoutLocking(blockID iotago.BlockID) []*storage.Block {
+	var reachedBlocks []*storage.Block
+
+	stack := iotago.BlockIDs{blockID}
+	for len(stack) > 0 {
+		blockID := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		c.reached[blockID] = struct{}{}
+
+		block, exists := c.pending[blockID]
+		if !exists || block == nil {
+			// the block was not received yet or was already reached
+			continue
+		}
+
+		// keep the entry, so that duplicates of reached blocks are ignored
+		c.pending[blockID] = nil
+		reachedBlocks = append(reachedBlocks, block)
+		stack = append(stack, block.Parents()...)
+	}
+
+	return reachedBlocks
+}
+
+// unreached returns the received blocks which were not reached and removes them from the cone.
+func (c *milestoneCone) unreached() []*storage.Block {
+	c.Lock()
+	defer c.Unlock()
+
+	var unreachedBlocks []*storage.Block
+	for blockID, block := range c.pending {
+		if block == nil {
+			continue
+		}
+		unreachedBlocks = append(unreachedBlocks, block)
+		delete(c.pending, blockID)
+	}
+
+	return unreachedBlocks
+}
@@ -0,0 +1,120 @@
+package gossip
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/serializer/v2"
+	"github.com/iotaledger/hornet/pkg/model/storage"
+	"github.com/iotaledger/hornet/pkg/tpkg"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func newConeTestBlock(t *testing.T, parents ...iotago.BlockID) *storage.Block {
+	block, err := storage.NewBlock(&iotago.Block{
+		ProtocolVersion: 2,
+		Parents:         parents,
+		Payload:         &iotago.TaggedData{Tag: tpkg.RandBytes(8)},
+	}, serializer.DeSeriModeNoValidation, &iotago.ProtocolParameters{})
+	require.NoError(t, err)
+
+	return block
+}
+
+func blockIDsOf(blocks []*storage.Block) []iotago.BlockID {
+	blockIDs := make([]iotago.BlockID, 0, len(blocks))
+	for _, block := range blocks {
+		blockIDs = append(blockIDs, block.BlockID())
+	}
+
+	return blockIDs
+}
+
+func TestMilestoneCone(t *testing.T) {
+	// a <- b <- milestone, c is not part of the cone
+	a := newConeTestBlock(t, tpkg.RandBlockID())
+	b := newConeTestBlock(t, a.BlockID())
+	c := newConeTestBlock(t, tpkg.RandBlockID())
+
+	cone := newMilestoneCone(time.Now())
+
+	// the blocks are pending until the walk is started at the milestone
+	reached, accepted := cone.add(a)
+	require.True(t, accepted)
+	require.Empty(t, reached)
+
+	reached, accepted = cone.add(c)
+	require.True(t, accepted)
+	require.Empty(t, reached)
+
+	require.False(t, cone.isStarted())
+	require.Empty(t, cone.start(iotago.BlockIDs{b.BlockID()}))
+	require.True(t, cone.isStarted())
+
+	// b reaches a, which was received before
+	reached, accepted = cone.add(b)
+	require.True(t, accepted)
+	require.ElementsMatch(t, []iotago.BlockID{b.BlockID(), a.BlockID()}, blockIDsOf(reached))
+
+	// duplicates of reached blocks are ignored
+	reached, accepted = cone.add(a)
+	require.True(t, accepted)
+	require.Empty(t, reached)
+
+	// c was never reached
+	require.ElementsMatch(t, []iotago.BlockID{c.BlockID()}, blockIDsOf(cone.unreached()))
+	require.Empty(t, cone.unreached())
+}
+
+func TestMilestoneConeStartReachesPending(t *testing.T) {
+	a := newConeTestBlock(t, tpkg.RandBlockID())
+	b := newConeTestBlock(t, a.BlockID())
+
+	cone := newMilestoneCone(time.Now())
+
+	_, _ = cone.add(b)
+	_, _ = cone.add(a)
+
+	require.ElementsMatch(t, []iotago.BlockID{b.BlockID(), a.BlockID()}, blockIDsOf(cone.start(iotago.BlockIDs{b.BlockID()})))
+	require.Empty(t, cone.start(iotago.BlockIDs{b.BlockID()}))
+	require.Empty(t, cone.unreached())
+}
+
+func TestMilestoneConeMaxBlocks(t *testing.T) {
+	cone := newMilestoneCone(time.Now())
+	cone.blocks = milestoneConeMaxBlocks
+
+	_, accepted := cone.add(newConeTestBlock(t, tpkg.RandBlockID()))
+	require.False(t, accepted)
+	require.Empty(t, cone.unreached())
+}
+
+func TestRequestedMilestoneConeMaxResponses(t *testing.T) {
+	p := &Protocol{requestedMilestoneCones: make(map[iotago.MilestoneIndex]*milestoneCone)}
+	p.requestedMilestoneCones[5] = newMilestoneCone(time.Now())
+
+	cone, lastResponse := p.requestedMilestoneCone(4, false)
+	require.Nil(t, cone)
+	require.False(t, lastResponse)
+
+	for i := 1; i < milestoneConeMaxResponses; i++ {
+		cone, lastResponse = p.requestedMilestoneCone(5, false)
+		require.NotNil(t, cone)
+		require.False(t, lastResponse)
+	}
+
+	// the last accepted response removes the request
+	cone, lastResponse = p.requestedMilestoneCone(5, false)
+	require.NotNil(t, cone)
+	require.True(t, lastResponse)
+
+	cone, _ = p.requestedMilestoneCone(5, false)
+	require.Nil(t, cone)
+
+	// timed out requests are not accepted
+	p.requestedMilestoneCones[6] = newMilestoneCone(time.Now().Add(-2 * milestoneConeRequestTimeout))
+	cone, _ = p.requestedMilestoneCone(6, true)
+	require.Nil(t, cone)
+}
@@ -8,14 +8,17 @@ import (
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/pkg/errors"
 
+	"github.com/iotaledger/hive.go/contextutils"
 	"github.com/iotaledger/hive.go/events"
 	"github.com/iotaledger/hive.go/objectstorage"
 	"github.com/iotaledger/hive.go/protocol/message"
 	"github.com/iotaledger/hive.go/serializer/v2"
 	"github.com/iotaledger/hive.go/syncutils"
 	"github.com/iotaledger/hive.go/workerpool"
+	"github.com/iotaledger/hornet/pkg/common"
 	"github.com/iotaledger/hornet/pkg/dag"
 	"github.com/iotaledger/hornet/pkg/metrics"
+	"github.com/iotaledger/hornet/pkg/model/milestonemanager"
 	"github.com/iotaledger/hornet/pkg/model/storage"
 	"github.com/iotaledger/hornet/pkg/model/syncmanager"
 	"github.com/iotaledger/hornet/pkg/p2p"
@@ -28,12 +31,24 @@ import (
 
 const (
 	WorkerQueueSize = 50000
+
+	// milestoneConeWorkerCount defines the amount of workers answering milestone cone requests.
+	// They are separated from the workers processing blocks, since answering a cone waits for the send queue of the peer.
+	milestoneConeWorkerCount = 4
+	// milestoneConeWorkerQueueSize defines the amount of milestone cone requests waiting to be answered.
+	// Further requests are dropped.
+	milestoneConeWorkerQueueSize = 100
 )
 
 var (
 	workerCount           = 64
 	ErrBlockNotSolid      = errors.New("block is not solid")
 	ErrBlockBelowMaxDepth = errors.New("block is below max depth")
+
+	// errMilestoneConeLimitReached is returned if a milestone cone exceeds the amount of bytes or blocks sent in reply to a milestone cone request.
+	errMilestoneConeLimitReached = errors.New("milestone cone limit reached")
+	// errMilestoneConeSendAborted is returned if a milestone cone couldn't be sent because the peer or the node was shut down.
+	errMilestoneConeSendAborted = errors.New("sending milestone cone aborted")
 )
 
 func BlockProcessedCaller(handler interface{}, params ...interface{}) {
@@ -79,6 +94,8 @@ type MessageProcessor struct {
 	serverMetrics *metrics.ServerMetrics
 	// protocol manager
 	protocolManager *protocol.Manager
+	// used to verify the milestones of requested milestone cones.
+	milestoneManager *milestonemanager.MilestoneManager
 	// holds the message processor options.
 	opts Options
 
@@ -88,6 +105,11 @@ type MessageProcessor struct {
 	workUnits *objectstorage.ObjectStorage
 	// worker pool for incoming messages.
 	wp *workerpool.WorkerPool
+	// worker pool for incoming milestone cone requests.
+	milestoneConeWP *workerpool.WorkerPool
+	// context that is canceled when the message processor is shut down.
+	shutdownCtx       context.Context
+	shutdownCtxCancel context.CancelFunc
 
 	// mutex to secure the shutdown flag.
 	shutdownMutex syncutils.RWMutex
@@ -103,22 +125,26 @@ func NewMessageProcessor(
 	peeringManager *p2p.Manager,
 	serverMetrics *metrics.ServerMetrics,
 	protocolManager *protocol.Manager,
+	milestoneManager *milestonemanager.MilestoneManager,
 	opts *Options) (*MessageProcessor, error) {
 
 	proc := &MessageProcessor{
-		storage:         dbStorage,
-		syncManager:     syncManager,
-		requestQueue:    requestQueue,
-		peeringManager:  peeringManager,
-		serverMetrics:   serverMetrics,
-		protocolManager: protocolManager,
-		opts:            *opts,
+		storage:          dbStorage,
+		syncManager:      syncManager,
+		requestQueue:     requestQueue,
+		peeringManager:   peeringManager,
+		serverMetrics:    serverMetrics,
+		protocolManager:  protocolManager,
+		milestoneManager: milestoneManager,
+		opts:             *opts,
 		Events: &MessageProcessorEvents{
 			BlockProcessed: events.NewEvent(BlockProcessedCaller),
 			BroadcastBlock: events.NewEvent(BroadcastCaller),
 		},
 	}
 
+	proc.shutdownCtx, proc.shutdownCtxCancel = context.WithCancel(context.Background())
+
 	wuCacheOpts := opts.WorkUnitCacheOpts
 
 	cacheTime, err := time.ParseDuration(wuCacheOpts.CacheTime)
@@ -155,22 +181,49 @@ func NewMessageProcessor(
 
 		switch task.Param(1).(message.Type) {
 		case MessageTypeBlock:
-			proc.processBlockData(p, data)
+			proc.processBlockData(p, data, nil)
 		case MessageTypeBlockRequest:
 			proc.processBlockRequest(p, data)
+		case MessageTypeBlocks:
+			proc.processBlocks(p, data)
+		case MessageTypeBlockRequests:
+			proc.processBlockRequests(p, data)
 		case MessageTypeMilestoneRequest:
 			proc.processMilestoneRequest(p, data)
+		case MessageTypeMilestoneCone:
+			proc.processMilestoneCone(p, data)
 		}
 
 		task.Return(nil)
 	}, workerpool.WorkerCount(workerCount), workerpool.QueueSize(WorkerQueueSize))
 
+	proc.milestoneConeWP = workerpool.New(func(task workerpool.Task) {
+		proc.processMilestoneConeRequest(task.Param(0).(*Protocol), task.Param(1).([]byte))
+
+		task.Return(nil)
+	}, workerpool.WorkerCount(milestoneConeWorkerCount), workerpool.QueueSize(milestoneConeWorkerQueueSize))
+
 	return proc, nil
 }
 
+// punishes the given peer for sending invalid data by decreasing its score and dropping the connection.
+// the score decides whether the peer gets banned, or whether it is reconnected if it is a known peer.
+func (proc *MessageProcessor) punishPeer(p *Protocol, reason error) {
+	proc.peeringManager.UpdatePeerScore(p.PeerID, p2p.PeerScoreEventInvalidData)
+	_ = proc.peeringManager.DisconnectPeer(p.PeerID, reason)
+}
+
+// marks the request with the given key as answered by the given peer and updates the latency of the peer.
+func (proc *MessageProcessor) requestAnswered(p *Protocol, requestKey string) {
+	if latency, answered := p.requestAnswered(requestKey); answered {
+		proc.peeringManager.UpdatePeerLatency(p.PeerID, latency)
+	}
+}
+
 // Run runs the processor and blocks until the shutdown signal is triggered.
 func (proc *MessageProcessor) Run(ctx context.Context) {
 	proc.wp.Start()
+	proc.milestoneConeWP.Start()
 	<-ctx.Done()
 	proc.Shutdown()
 }
@@ -178,16 +231,26 @@ func (proc *MessageProcessor) Run(ctx context.Context) {
 // Shutdown signals the internal worker pool and object storage
 // to shut down and sets the shutdown flag.
 func (proc *MessageProcessor) Shutdown() {
+	// abort running traversals and waiting sends before waiting for the workers
+	proc.shutdownCtxCancel()
+
 	proc.shutdownMutex.Lock()
 	defer proc.shutdownMutex.Unlock()
 
 	proc.shutdown = true
+	proc.milestoneConeWP.StopAndWait()
 	proc.wp.StopAndWait()
 	proc.workUnits.Shutdown()
 }
 
 // Process submits the given message to the processor for processing.
 func (proc *MessageProcessor) Process(p *Protocol, msgType message.Type, data []byte) {
+	if msgType == MessageTypeMilestoneConeRequest {
+		// answering a milestone cone must not delay the processing of blocks
+		proc.milestoneConeWP.TrySubmit(p, data)
+		return
+	}
+
 	proc.wp.Submit(p, msgType, data)
 }
 
@@ -294,8 +357,8 @@ func (proc *MessageProcessor) processMilestoneRequest(p *Protocol, data []byte)
 	if err != nil {
 		proc.serverMetrics.InvalidRequests.Inc()
 
-		// drop the connection to the peer
-		_ = proc.peeringManager.DisconnectPeer(p.PeerID, errors.WithMessage(err, "processMilestoneRequest failed"))
+		// decrease the score of the peer and drop the connection
+		proc.punishPeer(p, errors.WithMessage(err, "processMilestoneRequest failed"))
 		return
 	}
 
@@ -329,7 +392,8 @@ func (proc *MessageProcessor) processMilestoneRequest(p *Protocol, data []byte)
 		return
 	}
 
-	p.Enqueue(msg)
+	// milestones must not be starved by bulk block responses
+	p.EnqueuePriority(msg)
 }
 
 func constructMilestoneBlock(protoParams *iotago.ProtocolParameters, cachedMilestone *storage.CachedMilestone) (*iotago.Block, error) {
@@ -373,8 +437,297 @@ func (proc *MessageProcessor) processBlockRequest(p *Protocol, data []byte) {
 	p.Enqueue(msg)
 }
 
+// processes the given batched block request by parsing it and then replying to the peer with the known blocks.
+func (proc *MessageProcessor) processBlockRequests(p *Protocol, data []byte) {
+	blockIDs, err := extractRequestedBlockIDs(data)
+	if err != nil {
+		proc.serverMetrics.InvalidRequests.Inc()
+
+		// decrease the score of the peer and drop the connection
+		proc.punishPeer(p, errors.WithMessage(err, "processBlockRequests failed"))
+		return
+	}
+
+	blocksData := make([][]byte, 0, len(blockIDs))
+	for _, blockID := range blockIDs {
+		cachedBlock := proc.storage.CachedBlockOrNil(blockID) // block +1
+		if cachedBlock == nil {
+			// can't reply if we don't have the requested block
+			continue
+		}
+		blocksData = append(blocksData, cachedBlock.Block().Data())
+		cachedBlock.Release(true) // block -1
+	}
+
+	msgs, err := newBlocksMessages(blocksData)
+	if err != nil {
+		// can't reply if serialization fails
+		return
+	}
+
+	// wait for the send queue instead of dropping parts of the cone
+	for _, msg := range msgs {
+		if !p.EnqueueWait(proc.shutdownCtx, msg) {
+			return
+		}
+	}
+}
+
+// processes the blocks of the given batched block response like separately received blocks.
+func (proc *MessageProcessor) processBlocks(p *Protocol, data []byte) {
+	blocksData, err := extractBlocks(data)
+	if err != nil {
+		proc.serverMetrics.InvalidBlocks.Inc()
+
+		// decrease the score of the peer and drop the connection
+		proc.punishPeer(p, errors.WithMessage(err, "processBlocks failed"))
+		return
+	}
+
+	for _, blockData := range blocksData {
+		p.Metrics.ReceivedBlocks.Inc()
+		proc.serverMetrics.Blocks.Inc()
+		proc.processBlockData(p, blockData, nil)
+	}
+}
+
+// processes the given milestone cone request by parsing it and then replying to the peer with
+// the milestone and all blocks referenced by it, up to milestoneConeMaxBytesLength and milestoneConeMaxBlocks.
+// The cone is sent while it is walked, so that it doesn't need to be kept in memory.
+// Further requests of the peer are ignored while its request is answered.
+func (proc *MessageProcessor) processMilestoneConeRequest(p *Protocol, data []byte) {
+	msIndex, err := extractRequestedMilestoneIndex(data)
+	if err != nil {
+		proc.serverMetrics.InvalidRequests.Inc()
+
+		// decrease the score of the peer and drop the connection
+		proc.punishPeer(p, errors.WithMessage(err, "processMilestoneConeRequest failed"))
+		return
+	}
+
+	if msIndex > proc.syncManager.ConfirmedMilestoneIndex() {
+		// can't reply if the cone of the milestone is not known yet
+		return
+	}
+
+	// only one milestone cone request per peer is answered at a time
+	if !p.answeringMilestoneCone.CAS(false, true) {
+		return
+	}
+	defer p.answeringMilestoneCone.Store(false)
+
+	cachedMilestone := proc.storage.CachedMilestoneByIndexOrNil(msIndex) // milestone +1
+	if cachedMilestone == nil {
+		// can't reply if we don't have the wanted milestone
+		return
+	}
+	milestoneParents := cachedMilestone.Milestone().Parents()
+
+	milestoneBlock, err := constructMilestoneBlock(proc.protocolManager.Current(), cachedMilestone) // milestone pass +1
+	if err != nil {
+		// can't reply if creating milestone block fails
+		return
+	}
+
+	milestoneBlockData, err := milestoneBlock.Serialize(serializer.DeSeriModeNoValidation, nil)
+	if err != nil {
+		// can't reply if serialization fails
+		return
+	}
+
+	// wait for the send queue instead of dropping parts of the cone
+	batcher := newMilestoneConeBatcher(msIndex, func(msg []byte) error {
+		if !p.EnqueueWait(proc.shutdownCtx, msg) {
+			return errMilestoneConeSendAborted
+		}
+		return nil
+	})
+
+	if err := batcher.add(milestoneBlockData); err != nil {
+		return
+	}
+
+	blocksCount := 1
+	blocksBytesLength := len(milestoneBlockData)
+
+	if err := proc.walkMilestoneCone(proc.shutdownCtx, msIndex, milestoneParents, func(blockData []byte) error {
+		if blocksCount >= milestoneConeMaxBlocks || blocksBytesLength+len(blockData) > milestoneConeMaxBytesLength {
+			// the remaining blocks of the cone are requested separately by the peer
+			return errMilestoneConeLimitReached
+		}
+
+		if err := batcher.add(blockData); err != nil {
+			return err
+		}
+
+		blocksCount++
+		blocksBytesLength += len(blockData)
+		return nil
+	}); err != nil && !errors.Is(err, errMilestoneConeLimitReached) {
+		if errors.Is(err, errMilestoneConeSendAborted) || errors.Is(err, common.ErrOperationAborted) {
+			// the peer or the node was shut down
+			return
+		}
+
+		// the cone is incomplete (e.g. pruned), the sent part is still finished
+		// so that the peer requests the remaining blocks separately.
+	}
+
+	// errors are ignored, since the peer will request the cone again.
+	_ = batcher.close()
+}
+
+// walks the cone of the milestone with the given index from the given parents of the milestone into the past
+// and passes the data of the blocks to the consumer.
+// Every block is passed after a block which references it, so that the receiver is able to check
+// that the blocks belong to the cone of the milestone while they arrive.
+// Blocks which are not found (e.g. pruned) are skipped together with their parents.
+func (proc *MessageProcessor) walkMilestoneCone(ctx context.Context, msIndex iotago.MilestoneIndex, milestoneParents iotago.BlockIDs, consumer func(blockData []byte) error) error {
+	visited := make(map[iotago.BlockID]struct{})
+	queue := append(iotago.BlockIDs{}, milestoneParents...)
+
+	for len(queue) > 0 {
+		if err := contextutils.ReturnErrIfCtxDone(ctx, common.ErrOperationAborted); err != nil {
+			return err
+		}
+
+		blockID := queue[0]
+		queue = queue[1:]
+
+		if _, alreadyVisited := visited[blockID]; alreadyVisited {
+			continue
+		}
+		visited[blockID] = struct{}{}
+
+		cachedBlock := proc.storage.CachedBlockOrNil(blockID) // block +1
+		if cachedBlock == nil {
+			// solid entry points and pruned blocks are not part of the cone
+			continue
+		}
+
+		// only the blocks referenced by the requested milestone belong to its cone
+		if referenced, at := cachedBlock.Metadata().ReferencedWithIndex(); !referenced || at != msIndex {
+			cachedBlock.Release(true) // block -1
+			continue
+		}
+
+		blockData := cachedBlock.Block().Data()
+		queue = append(queue, cachedBlock.Block().Parents()...)
+		cachedBlock.Release(true) // block -1
+
+		if err := consumer(blockData); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// processes the blocks of the given milestone cone response.
+// only the blocks which are reached by walking back from the parents of the requested milestone
+// are treated as requested, all other blocks are handled like separately received blocks.
+func (proc *MessageProcessor) processMilestoneCone(p *Protocol, data []byte) {
+	msIndex, final, blocksData, err := extractMilestoneCone(data)
+	if err != nil {
+		proc.serverMetrics.InvalidBlocks.Inc()
+
+		// decrease the score of the peer and drop the connection
+		proc.punishPeer(p, errors.WithMessage(err, "processMilestoneCone failed"))
+		return
+	}
+
+	proc.requestAnswered(p, NewMilestoneConeRequest(msIndex).MapKey())
+
+	for range blocksData {
+		p.Metrics.ReceivedBlocks.Inc()
+		proc.serverMetrics.Blocks.Inc()
+	}
+
+	cone, lastResponse := p.requestedMilestoneCone(msIndex, final)
+	if cone == nil {
+		// unrequested cones are handled like separately received blocks
+		for _, blockData := range blocksData {
+			proc.processBlockData(p, blockData, nil)
+		}
+		return
+	}
+
+	blocks := make([]*storage.Block, 0, len(blocksData))
+	for _, blockData := range blocksData {
+		block, err := storage.BlockFromBytes(blockData, serializer.DeSeriModeNoValidation, proc.protocolManager.Current())
+		if err != nil {
+			// invalid blocks are handled like separately received blocks, so that the peer is punished
+			proc.processBlockData(p, blockData, nil)
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+
+	var reachedBlocks []*storage.Block
+	if !cone.isStarted() {
+		if milestoneParents := proc.milestoneConeParents(msIndex, blocks); milestoneParents != nil {
+			reachedBlocks = append(reachedBlocks, cone.start(milestoneParents)...)
+		}
+	}
+
+	var unreachedBlocks []*storage.Block
+	for _, block := range blocks {
+		reached, accepted := cone.add(block)
+		if !accepted {
+			unreachedBlocks = append(unreachedBlocks, block)
+			continue
+		}
+		reachedBlocks = append(reachedBlocks, reached...)
+	}
+
+	if lastResponse {
+		unreachedBlocks = append(unreachedBlocks, cone.unreached()...)
+	}
+
+	if len(reachedBlocks) > 0 {
+		// the cone request is processed with the first reached blocks, the following blocks of the same cone
+		// are linked to a new request, so that they are still treated as requested.
+		coneRequest := proc.requestQueue.Received(NewMilestoneConeRequest(msIndex))
+		if coneRequest == nil {
+			coneRequest = NewMilestoneConeRequest(msIndex)
+		}
+
+		for _, block := range reachedBlocks {
+			proc.processBlockData(p, block.Data(), coneRequest)
+		}
+	}
+
+	for _, block := range unreachedBlocks {
+		proc.processBlockData(p, block.Data(), nil)
+	}
+}
+
+// returns the parents of the milestone with the given index, at which the walk through its cone starts.
+// the milestone is taken from the storage, or from the received blocks of the cone if its signature is valid.
+// returns nil if the milestone is unknown.
+func (proc *MessageProcessor) milestoneConeParents(msIndex iotago.MilestoneIndex, blocks []*storage.Block) iotago.BlockIDs {
+	if cachedMilestone := proc.storage.CachedMilestoneByIndexOrNil(msIndex); cachedMilestone != nil { // milestone +1
+		defer cachedMilestone.Release(true) // milestone -1
+
+		return cachedMilestone.Milestone().Parents()
+	}
+
+	for _, block := range blocks {
+		if !block.IsMilestone() || block.Milestone().Index != msIndex {
+			continue
+		}
+
+		if milestonePayload := proc.milestoneManager.VerifyMilestoneBlock(block.Block()); milestonePayload != nil {
+			return milestonePayload.Parents
+		}
+	}
+
+	return nil
+}
+
 // gets or creates a new WorkUnit for the given block data and then processes the WorkUnit.
-func (proc *MessageProcessor) processBlockData(p *Protocol, data []byte) {
+// coneRequest is the milestone cone request the block was received for, or nil.
+func (proc *MessageProcessor) processBlockData(p *Protocol, data []byte, coneRequest *Request) {
 	cachedWorkUnit, newlyAdded := proc.workUnitFor(data) // workUnit +1
 
 	// force release if not newly added, so the cache time is only active the first time the block is received.
@@ -382,31 +735,48 @@ func (proc *MessageProcessor) processBlockData(p *Protocol, data []byte) {
 
 	workUnit := cachedWorkUnit.WorkUnit()
 	workUnit.addReceivedFrom(p)
-	proc.processWorkUnit(workUnit, p)
+	proc.processWorkUnit(workUnit, p, coneRequest)
 }
 
 // tries to process the WorkUnit by first checking in what state it is.
 // if the WorkUnit is invalid (because the underlying block is invalid), the given peer is punished.
 // if the WorkUnit is already completed, and the block was requested, this function emits a BlockProcessed event.
+// if the block was received for a requested milestone cone, it is treated like a requested block.
 // it is safe to call this function for the same WorkUnit multiple times.
-func (proc *MessageProcessor) processWorkUnit(wu *WorkUnit, p *Protocol) {
+func (proc *MessageProcessor) processWorkUnit(wu *WorkUnit, p *Protocol, coneRequest *Request) {
 
 	processRequests := func(wu *WorkUnit, block *storage.Block, isMilestonePayload bool) Requests {
 
 		requests := Requests{}
 
+		blockID := block.BlockID()
+		proc.requestAnswered(p, string(blockID[:]))
+
 		// mark the block as received
-		request := proc.requestQueue.Received(block.BlockID())
+		request := proc.requestQueue.Received(blockID)
 		if request != nil {
 			requests = append(requests, request)
 		}
 
 		if isMilestonePayload {
+			proc.requestAnswered(p, NewMilestoneIndexRequest(block.Milestone().Index).MapKey())
+			proc.requestAnswered(p, NewMilestoneConeRequest(block.Milestone().Index).MapKey())
+
 			// mark the milestone as received
 			msRequest := proc.requestQueue.Received(block.Milestone().Index)
 			if msRequest != nil {
 				requests = append(requests, msRequest)
 			}
+
+			// peers that don't support milestone cone requests only send the milestone
+			msConeRequest := proc.requestQueue.Received(NewMilestoneConeRequest(block.Milestone().Index))
+			if msConeRequest != nil {
+				requests = append(requests, msConeRequest)
+			}
+		}
+
+		if coneRequest != nil {
+			requests = append(requests, coneRequest)
 		}
 
 		wu.requested = requests.HasRequest()
@@ -425,8 +795,8 @@ func (proc *MessageProcessor) processWorkUnit(wu *WorkUnit, p *Protocol) {
 
 		proc.serverMetrics.InvalidBlocks.Inc()
 
-		// drop the connection to the peer
-		_ = proc.peeringManager.DisconnectPeer(p.PeerID, errors.New("peer sent an invalid block"))
+		// decrease the score of the peer and drop the connection
+		proc.punishPeer(p, errors.New("peer sent an invalid block"))
 		return
 
 	case wu.Is(Hashed):
@@ -501,6 +871,11 @@ func (proc *MessageProcessor) processWorkUnit(wu *WorkUnit, p *Protocol) {
 		return
 	}
 
+	if !proc.storage.ContainsBlock(block.BlockID()) {
+		// the peer is rewarded for sending useful blocks
+		proc.peeringManager.UpdatePeerScore(p.PeerID, p2p.PeerScoreEventNewBlock)
+	}
+
 	proc.Events.BlockProcessed.Trigger(block, requests, p)
 }
 
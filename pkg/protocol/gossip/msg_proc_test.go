@@ -57,7 +57,7 @@ func TestMessageProcessorEmit(t *testing.T) {
 	service := gossip.NewService(protocolID, n, manager, serverMetrics)
 	go service.Start(ctx)
 
-	processor, err := gossip.NewMessageProcessor(te.Storage(), te.SyncManager(), gossip.NewRequestQueue(), manager, serverMetrics, te.ProtocolManager(), &gossip.Options{
+	processor, err := gossip.NewMessageProcessor(te.Storage(), te.SyncManager(), gossip.NewRequestQueue(), manager, serverMetrics, te.ProtocolManager(), te.MilestoneManager(), &gossip.Options{
 		WorkUnitCacheOpts: testsuite.TestProfileCaches.IncomingBlocksFilter,
 	})
 	require.NoError(t, err)
@@ -1,6 +1,7 @@
 package gossip
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -8,9 +9,12 @@ import (
 	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
 	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
 
 	"github.com/iotaledger/hive.go/events"
 	"github.com/iotaledger/hive.go/protocol"
+	"github.com/iotaledger/hive.go/protocol/message"
+	"github.com/iotaledger/hive.go/protocol/tlv"
 	"github.com/iotaledger/hornet/pkg/metrics"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
@@ -19,22 +23,39 @@ const (
 	// defines how far back a node's confirmed milestone index can be
 	// but still considered synchronized.
 	minCMISynchronizationThreshold = 2
+
+	// defines how long the blocks of a requested milestone cone are accepted from the peer.
+	milestoneConeRequestTimeout = 2 * time.Minute
 )
 
 // ProtocolEvents happening on a Protocol.
 type ProtocolEvents struct {
 	// Fired when the heartbeat message state on the peer has been updated.
 	HeartbeatUpdated *events.Event
+	// Fired when the handshake of the peer has been received.
+	HandshakeReceived *events.Event
 	// Fired when a message of the given type is sent.
 	// This exists solely because protocol.Protocol in hive.go doesn't
 	// emit events anymore for sent messages, as it is solely a parser.
 	Sent []*events.Event
+	// Fired when a batched blocks or milestone cone message is sent, with the amount of blocks it contained.
+	SentBlocks *events.Event
 	// Fired when an error occurs on the protocol.
 	Errors *events.Event
 }
 
 // NewProtocol creates a new gossip protocol instance associated to the given peer.
-func NewProtocol(peerID peer.ID, stream network.Stream, sendQueueSize int, readTimeout, writeTimeout time.Duration, serverMetrics *metrics.ServerMetrics) *Protocol {
+// The bandwidth of the protocol is limited by all the given bandwidth limiters.
+func NewProtocol(peerID peer.ID, stream network.Stream, sendQueueSize int, readTimeout, writeTimeout time.Duration, serverMetrics *metrics.ServerMetrics, bandwidthLimiters ...*BandwidthLimiter) *Protocol {
+	uploadLimiters := make([]*rate.Limiter, 0, len(bandwidthLimiters))
+	priorityUploadLimiters := make([]*rate.Limiter, 0, len(bandwidthLimiters))
+	downloadLimiters := make([]*rate.Limiter, 0, len(bandwidthLimiters))
+	for _, bandwidthLimiter := range bandwidthLimiters {
+		uploadLimiters = append(uploadLimiters, bandwidthLimiter.upload)
+		priorityUploadLimiters = append(priorityUploadLimiters, bandwidthLimiter.priorityUpload)
+		downloadLimiters = append(downloadLimiters, bandwidthLimiter.download)
+	}
+
 	defs := gossipMessageRegistry.Definitions()
 	sentEvents := make([]*events.Event, len(defs))
 	for i, def := range defs {
@@ -44,23 +65,48 @@ func NewProtocol(peerID peer.ID, stream network.Stream, sendQueueSize int, readT
 		sentEvents[i] = events.NewEvent(events.VoidCaller)
 	}
 
-	return &Protocol{
+	p := &Protocol{
 		Parser: protocol.New(gossipMessageRegistry),
 		PeerID: peerID,
 		Events: &ProtocolEvents{
-			HeartbeatUpdated: events.NewEvent(heartbeatCaller),
+			HeartbeatUpdated:  events.NewEvent(heartbeatCaller),
+			HandshakeReceived: events.NewEvent(handshakeCaller),
 			// we need this because protocol.Protocol doesn't emit
 			// events for sent messages anymore.
-			Sent:   sentEvents,
-			Errors: events.NewEvent(events.ErrorCaller),
+			Sent:       sentEvents,
+			SentBlocks: events.NewEvent(events.IntCaller),
+			Errors:     events.NewEvent(events.ErrorCaller),
 		},
-		Stream:         stream,
-		terminatedChan: make(chan struct{}),
-		SendQueue:      make(chan []byte, sendQueueSize),
-		readTimeout:    readTimeout,
-		writeTimeout:   writeTimeout,
-		ServerMetrics:  serverMetrics,
+		Stream:                  stream,
+		terminatedChan:          make(chan struct{}),
+		requestedMilestoneCones: make(map[iotago.MilestoneIndex]*milestoneCone),
+		sentRequests:            make(map[string]time.Time),
+		SendQueue:               make(chan []byte, sendQueueSize),
+		PrioritySendQueue:       make(chan []byte, sendQueueSize),
+		uploadLimiters:          uploadLimiters,
+		priorityUploadLimiters:  priorityUploadLimiters,
+		downloadLimiters:        downloadLimiters,
+		readTimeout:             readTimeout,
+		writeTimeout:            writeTimeout,
+		ServerMetrics:           serverMetrics,
+	}
+
+	// the download limits are applied to the parsed messages, so that the type of the message is known.
+	// the reading of the following messages is delayed until the received bytes are allowed by the download limits,
+	// except for priority messages.
+	for i, def := range defs {
+		if def == nil {
+			continue
+		}
+
+		_, priority := priorityMessageTypes[def.ID]
+		p.Parser.Events.Received[i].Attach(events.NewClosure(func(data []byte) {
+			// the next read fails if the protocol was terminated while waiting
+			_ = waitForTokens(p.terminatedChan, p.downloadLimiters, int(tlv.HeaderMessageDefinition.MaxBytesLength)+len(data), !priority)
+		}))
 	}
+
+	return p
 }
 
 // Protocol represents an instance of the gossip protocol.
@@ -81,8 +127,27 @@ type Protocol struct {
 	HeartbeatReceivedTime time.Time
 	// Time the last heartbeat was sent.
 	HeartbeatSentTime time.Time
+	// The handshake of the peer, nil if none was received yet.
+	handshake     *Handshake
+	handshakeLock sync.RWMutex
+	// The milestone cones requested from the peer by their index.
+	requestedMilestoneCones     map[iotago.MilestoneIndex]*milestoneCone
+	requestedMilestoneConesLock sync.Mutex
+	// Whether a milestone cone request of the peer is currently answered.
+	answeringMilestoneCone atomic.Bool
+	// The requests sent to the peer which were not answered yet and the time they were sent.
+	sentRequests map[string]time.Time
+	// The round-trip times and success rate of the requests sent to the peer.
+	requestStats     RequestStats
+	sentRequestsLock sync.Mutex
 	// The send queue into which to enqueue messages to send.
 	SendQueue chan []byte
+	// The send queue into which to enqueue messages which are sent before the messages of the SendQueue.
+	PrioritySendQueue chan []byte
+	// The token buckets limiting the bandwidth of the protocol.
+	uploadLimiters         []*rate.Limiter
+	priorityUploadLimiters []*rate.Limiter
+	downloadLimiters       []*rate.Limiter
 	// The metrics around this protocol instance.
 	Metrics      Metrics
 	sendMu       sync.Mutex
@@ -98,8 +163,14 @@ func (p *Protocol) Terminated() <-chan struct{} {
 }
 
 // Enqueue enqueues the given gossip protocol message to be sent to the peer.
+// Handshakes, heartbeats and milestone requests are enqueued into the PrioritySendQueue.
 // If it can't because the send queue is over capacity, the message gets dropped.
 func (p *Protocol) Enqueue(data []byte) {
+	if isPriorityMessage(data) {
+		p.EnqueuePriority(data)
+		return
+	}
+
 	select {
 	case p.SendQueue <- data:
 	default:
@@ -108,6 +179,37 @@ func (p *Protocol) Enqueue(data []byte) {
 	}
 }
 
+// EnqueueWait enqueues the given gossip protocol message to be sent to the peer.
+// If the send queue is over capacity, it waits until the message can be enqueued.
+// Returns false if the protocol was terminated or the context was canceled before.
+func (p *Protocol) EnqueueWait(ctx context.Context, data []byte) bool {
+	sendQueue := p.SendQueue
+	if isPriorityMessage(data) {
+		sendQueue = p.PrioritySendQueue
+	}
+
+	select {
+	case sendQueue <- data:
+		return true
+	case <-p.terminatedChan:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// EnqueuePriority enqueues the given gossip protocol message to be sent to the peer
+// before the messages of the SendQueue.
+// If it can't because the send queue is over capacity, the message gets dropped.
+func (p *Protocol) EnqueuePriority(data []byte) {
+	select {
+	case p.PrioritySendQueue <- data:
+	default:
+		p.ServerMetrics.DroppedPackets.Inc()
+		p.Metrics.DroppedPackets.Inc()
+	}
+}
+
 // Read reads from the stream into the given buffer.
 func (p *Protocol) Read(buf []byte) (int, error) {
 	readMessage := func(buf []byte) (int, error) {
@@ -121,16 +223,44 @@ func (p *Protocol) Read(buf []byte) (int, error) {
 	r, err := readMessage(buf)
 	if err != nil {
 		p.Events.Errors.Trigger(err)
+		return r, err
 	}
 
-	return r, err
+	p.Metrics.ReceivedBytes.Add(uint64(r))
+	p.Metrics.downloadMeter.add(r)
+	p.ServerMetrics.ReceivedBytes.Add(uint64(r))
+
+	return r, nil
 }
 
 // Send sends the given gossip message on the underlying Protocol.Stream.
+// The message is delayed until it is allowed by the upload limits,
+// priority messages are delayed by the reserved upload limits for priority messages instead.
 func (p *Protocol) Send(message []byte) error {
+	return p.send(message, isPriorityMessage(message))
+}
+
+// SendPriority sends the given gossip message on the underlying Protocol.Stream
+// after it is allowed by the reserved upload limits for priority messages.
+// The bandwidth used by the message is also taken from the upload limits, so that it delays the following messages.
+func (p *Protocol) SendPriority(message []byte) error {
+	return p.send(message, true)
+}
+
+func (p *Protocol) send(message []byte, priority bool) error {
 	p.sendMu.Lock()
 	defer p.sendMu.Unlock()
 
+	if priority {
+		if !waitForTokens(p.terminatedChan, p.priorityUploadLimiters, len(message), true) {
+			return ErrProtocolTerminated
+		}
+	}
+
+	if !waitForTokens(p.terminatedChan, p.uploadLimiters, len(message), !priority) {
+		return ErrProtocolTerminated
+	}
+
 	sendMessage := func(message []byte) error {
 		if err := p.Stream.SetWriteDeadline(time.Now().Add(p.writeTimeout)); err != nil {
 			return fmt.Errorf("unable to set write deadline: %w", err)
@@ -149,8 +279,18 @@ func (p *Protocol) Send(message []byte) error {
 		return err
 	}
 
+	p.Metrics.SentBytes.Add(uint64(len(message)))
+	p.Metrics.uploadMeter.add(len(message))
+	p.ServerMetrics.SentBytes.Add(uint64(len(message)))
+
 	// fire event handler for sent message
 	p.Events.Sent[message[0]].Trigger()
+
+	switch message[0] {
+	case byte(MessageTypeBlocks), byte(MessageTypeMilestoneCone):
+		p.Events.SentBlocks.Trigger(batchedBlocksCount(message))
+	}
+
 	return nil
 }
 
@@ -172,6 +312,15 @@ func (p *Protocol) SendHeartbeat(solidMsIndex iotago.MilestoneIndex, pruningMsIn
 	p.Enqueue(heartbeatData)
 }
 
+// SendHandshake sends a Handshake to the given peer.
+func (p *Protocol) SendHandshake(handshake *Handshake) {
+	handshakeData, err := newHandshakeMessage(handshake)
+	if err != nil {
+		return
+	}
+	p.Enqueue(handshakeData)
+}
+
 // SendBlockRequest sends a block request message to the given peer.
 func (p *Protocol) SendBlockRequest(requestedBlockID iotago.BlockID) {
 	blockRequestMessage, err := newBlockRequestMessage(requestedBlockID)
@@ -181,6 +330,16 @@ func (p *Protocol) SendBlockRequest(requestedBlockID iotago.BlockID) {
 	p.Enqueue(blockRequestMessage)
 }
 
+// SendBlockRequests sends a batched block request message to the given peer.
+// The peer must support MessageTypeBlockRequests.
+func (p *Protocol) SendBlockRequests(requestedBlockIDs iotago.BlockIDs) {
+	blockRequestsMessage, err := newBlockRequestsMessage(requestedBlockIDs)
+	if err != nil {
+		return
+	}
+	p.Enqueue(blockRequestsMessage)
+}
+
 // SendMilestoneRequest sends a milestone request to the given peer.
 func (p *Protocol) SendMilestoneRequest(index iotago.MilestoneIndex) {
 	milestoneRequestMessage, err := newMilestoneRequestMessage(index)
@@ -190,11 +349,161 @@ func (p *Protocol) SendMilestoneRequest(index iotago.MilestoneIndex) {
 	p.Enqueue(milestoneRequestMessage)
 }
 
+// SendMilestoneConeRequest sends a milestone cone request to the given peer.
+// The peer must support MessageTypeMilestoneConeRequest.
+func (p *Protocol) SendMilestoneConeRequest(index iotago.MilestoneIndex) {
+	milestoneConeRequestMessage, err := newMilestoneConeRequestMessage(index)
+	if err != nil {
+		return
+	}
+
+	p.requestedMilestoneConesLock.Lock()
+	now := time.Now()
+	for requestedIndex, cone := range p.requestedMilestoneCones {
+		if now.Sub(cone.requestTime) > milestoneConeRequestTimeout {
+			delete(p.requestedMilestoneCones, requestedIndex)
+		}
+	}
+	if _, exists := p.requestedMilestoneCones[index]; !exists {
+		p.requestedMilestoneCones[index] = newMilestoneCone(now)
+	}
+	p.requestedMilestoneConesLock.Unlock()
+
+	p.Enqueue(milestoneConeRequestMessage)
+}
+
+// requestedMilestoneCone returns the requested milestone cone a response of the peer belongs to,
+// and whether it is the last response accepted for the cone, after which the request is removed.
+// returns nil if the cone was not requested from the peer, the request timed out
+// or the maximum amount of responses for the cone was exceeded.
+func (p *Protocol) requestedMilestoneCone(index iotago.MilestoneIndex, final bool) (*milestoneCone, bool) {
+	p.requestedMilestoneConesLock.Lock()
+	defer p.requestedMilestoneConesLock.Unlock()
+
+	cone, exists := p.requestedMilestoneCones[index]
+	if !exists {
+		return nil, false
+	}
+
+	if time.Since(cone.requestTime) > milestoneConeRequestTimeout {
+		delete(p.requestedMilestoneCones, index)
+
+		return nil, false
+	}
+
+	cone.responses++
+	if final || cone.responses >= milestoneConeMaxResponses {
+		delete(p.requestedMilestoneCones, index)
+
+		return cone, true
+	}
+
+	return cone, false
+}
+
+// trackRequest remembers that the request with the given key was sent to the peer,
+// so that the peer can be scored on whether and how fast it answers.
+func (p *Protocol) trackRequest(requestKey string) {
+	p.sentRequestsLock.Lock()
+	defer p.sentRequestsLock.Unlock()
+
+	if _, exists := p.sentRequests[requestKey]; !exists {
+		p.sentRequests[requestKey] = time.Now()
+	}
+}
+
+// requestAnswered marks the request with the given key as answered.
+// Returns the time it took the peer to answer and whether the request was sent to the peer.
+func (p *Protocol) requestAnswered(requestKey string) (time.Duration, bool) {
+	p.sentRequestsLock.Lock()
+	defer p.sentRequestsLock.Unlock()
+
+	sentTime, exists := p.sentRequests[requestKey]
+	if !exists {
+		return 0, false
+	}
+	delete(p.sentRequests, requestKey)
+
+	roundTripTime := time.Since(sentTime)
+	p.requestStats.addAnswered(roundTripTime)
+
+	return roundTripTime, true
+}
+
+// unansweredRequests removes the requests which were not answered within the given timeout and returns their amount.
+func (p *Protocol) unansweredRequests(timeout time.Duration) int {
+	p.sentRequestsLock.Lock()
+	defer p.sentRequestsLock.Unlock()
+
+	var count int
+	for requestKey, sentTime := range p.sentRequests {
+		if time.Since(sentTime) > timeout {
+			delete(p.sentRequests, requestKey)
+			count++
+		}
+	}
+	p.requestStats.Unanswered += uint32(count)
+
+	return count
+}
+
+// RequestStats returns the round-trip times, success rate and outstanding requests of the requests sent to the peer.
+func (p *Protocol) RequestStats() RequestStats {
+	p.sentRequestsLock.Lock()
+	defer p.sentRequestsLock.Unlock()
+
+	requestStats := p.requestStats
+	requestStats.Outstanding = uint32(len(p.sentRequests))
+
+	return requestStats
+}
+
 // SendLatestMilestoneRequest sends a storage.Milestone request which requests the latest known milestone from the given peer.
 func (p *Protocol) SendLatestMilestoneRequest() {
 	p.SendMilestoneRequest(latestMilestoneRequestIndex)
 }
 
+// SetHandshake sets the handshake received from the peer.
+func (p *Protocol) SetHandshake(handshake *Handshake) {
+	p.handshakeLock.Lock()
+	p.handshake = handshake
+	p.handshakeLock.Unlock()
+
+	p.Events.HandshakeReceived.Trigger(handshake)
+}
+
+// Handshake returns the handshake received from the peer.
+// Returns nil if the peer didn't send a handshake (yet).
+func (p *Protocol) Handshake() *Handshake {
+	p.handshakeLock.RLock()
+	defer p.handshakeLock.RUnlock()
+	return p.handshake
+}
+
+// SupportsMessageType tells whether the underlying peer is able to process messages of the given type.
+// Peers that didn't send a handshake only support the message types that existed before the handshake was introduced.
+func (p *Protocol) SupportsMessageType(msgType message.Type) bool {
+	handshake := p.Handshake()
+	if handshake == nil {
+		for _, legacyType := range legacyMessageTypes {
+			if legacyType == msgType {
+				return true
+			}
+		}
+		return false
+	}
+	return handshake.SupportsMessageType(msgType)
+}
+
+// HasCapability tells whether the underlying peer advertised the given capability in its handshake.
+func (p *Protocol) HasCapability(capability Capabilities) bool {
+	handshake := p.Handshake()
+	if handshake == nil {
+		return false
+	}
+	return handshake.Capabilities.Has(capability)
+}
+
 // HasDataForMilestone tells whether the underlying peer given the latest heartbeat message, has the cone data for the given milestone.
 // Returns false if no heartbeat message was received yet.
 func (p *Protocol) HasDataForMilestone(index iotago.MilestoneIndex) bool {
@@ -233,9 +542,18 @@ func (p *Protocol) IsSynced(cmi iotago.MilestoneIndex) bool {
 
 // Info returns
 func (p *Protocol) Info() *Info {
+	requestStats := p.RequestStats()
+
 	return &Info{
 		Heartbeat: p.LatestHeartbeat,
 		Metrics:   p.Metrics.Snapshot(),
+		Requests: RequestStatsSnapshot{
+			RoundTripTimeMilliseconds: requestStats.RoundTripTime.Milliseconds(),
+			Answered:                  requestStats.Answered,
+			Unanswered:                requestStats.Unanswered,
+			Outstanding:               requestStats.Outstanding,
+			SuccessRate:               requestStats.SuccessRate(),
+		},
 	}
 }
 
@@ -265,6 +583,13 @@ type Metrics struct {
 	SentHeartbeats atomic.Uint32
 	// The number of dropped packets.
 	DroppedPackets atomic.Uint32
+	// The number of sent bytes.
+	SentBytes atomic.Uint64
+	// The number of received bytes.
+	ReceivedBytes atomic.Uint64
+	// The bytes sent and received in the last second.
+	uploadMeter   bandwidthMeter
+	downloadMeter bandwidthMeter
 }
 
 // Snapshot returns MetricsSnapshot of the Metrics.
@@ -281,6 +606,10 @@ func (m *Metrics) Snapshot() MetricsSnapshot {
 		SentMilestoneRequests:     m.SentMilestoneRequests.Load(),
 		SentHeartbeats:            m.SentHeartbeats.Load(),
 		DroppedPackets:            m.DroppedPackets.Load(),
+		SentBytes:                 m.SentBytes.Load(),
+		ReceivedBytes:             m.ReceivedBytes.Load(),
+		UploadBytesPerSecond:      m.uploadMeter.bytesPerSecond(),
+		DownloadBytesPerSecond:    m.downloadMeter.bytesPerSecond(),
 	}
 }
 
@@ -297,10 +626,15 @@ type MetricsSnapshot struct {
 	SentMilestoneRequests     uint32 `json:"sentMilestoneRequests"`
 	SentHeartbeats            uint32 `json:"sentHeartbeats"`
 	DroppedPackets            uint32 `json:"droppedPackets"`
+	SentBytes                 uint64 `json:"sentBytes"`
+	ReceivedBytes             uint64 `json:"receivedBytes"`
+	UploadBytesPerSecond      uint64 `json:"uploadBytesPerSecond"`
+	DownloadBytesPerSecond    uint64 `json:"downloadBytesPerSecond"`
 }
 
 // Info represents information about an ongoing gossip protocol.
 type Info struct {
-	Heartbeat *Heartbeat      `json:"heartbeat"`
-	Metrics   MetricsSnapshot `json:"metrics"`
+	Heartbeat *Heartbeat           `json:"heartbeat"`
+	Metrics   MetricsSnapshot      `json:"metrics"`
+	Requests  RequestStatsSnapshot `json:"requests"`
 }
@@ -0,0 +1,71 @@
+package gossip
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/protocol/tlv"
+	"github.com/iotaledger/hornet/pkg/metrics"
+	"github.com/iotaledger/hornet/pkg/tpkg"
+)
+
+func TestProtocolEnqueueWait(t *testing.T) {
+	proto := NewProtocol("", nil, 1, time.Second, time.Second, &metrics.ServerMetrics{})
+
+	msgs, err := newMilestoneConeMessages(1, [][]byte{{1, 2, 3}})
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+
+	// the full send queue drops messages
+	proto.Enqueue(msgs[0])
+	proto.Enqueue(msgs[0])
+	require.EqualValues(t, 1, proto.Metrics.DroppedPackets.Load())
+
+	// while waiting messages are only given up on cancellation
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.False(t, proto.EnqueueWait(ctx, msgs[0]))
+
+	enqueued := make(chan bool)
+	go func() {
+		enqueued <- proto.EnqueueWait(context.Background(), msgs[0])
+	}()
+
+	<-proto.SendQueue
+	require.True(t, <-enqueued)
+	require.EqualValues(t, 1, proto.Metrics.DroppedPackets.Load())
+}
+
+func TestProtocolDownloadLimit(t *testing.T) {
+	proto := NewProtocol("", nil, 1, time.Second, time.Second, &metrics.ServerMetrics{}, NewBandwidthLimiter(0, 1))
+
+	// the burst of the limiter is used up by the first message
+	proto.Parser.Events.Received[MessageTypeBlocks].Trigger(make([]byte, maxMessageBytesLength()-int(tlv.HeaderMessageDefinition.MaxBytesLength)))
+
+	// priority messages are not delayed
+	heartbeatMessage, err := newHeartbeatMessage(1, 0, 1, 1, 1)
+	require.NoError(t, err)
+
+	start := time.Now()
+	proto.Parser.Events.Received[MessageTypeHeartbeat].Trigger(heartbeatMessage[tlv.HeaderMessageDefinition.MaxBytesLength:])
+	require.Less(t, time.Since(start), time.Second)
+
+	// other messages wait for the download limits until the protocol is terminated
+	received := make(chan struct{})
+	go func() {
+		proto.Parser.Events.Received[MessageTypeBlock].Trigger(tpkg.RandBytes(1000))
+		close(received)
+	}()
+
+	select {
+	case <-received:
+		require.Fail(t, "block was not delayed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(proto.terminatedChan)
+	<-received
+}
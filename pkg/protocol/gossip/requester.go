@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	"github.com/libp2p/go-libp2p-core/peer"
+
 	"github.com/iotaledger/hornet/pkg/model/storage"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
@@ -91,48 +93,116 @@ func (r *Requester) RunRequestQueueDrainer(ctx context.Context) {
 		case <-r.drainSignal:
 
 			// drain request queue
-			for request := r.rQueue.Next(); request != nil; request = r.rQueue.Next() {
-
-				sendRequest := func(request *Request, proto *Protocol) {
-					switch request.RequestType {
-					case RequestTypeBlockID:
-						proto.SendBlockRequest(request.BlockID)
-					case RequestTypeMilestoneIndex:
-						proto.SendMilestoneRequest(request.MilestoneIndex)
-					default:
-						panic(ErrUnknownRequestType)
-					}
-				}
+			for requests := r.rQueue.NextBatch(MaxBlockRequestsBatchSize); len(requests) > 0; requests = r.rQueue.NextBatch(MaxBlockRequestsBatchSize) {
+				r.sendRequests(requests)
+			}
+		}
+	}
+}
 
-				requested := false
-				r.service.ForEach(func(proto *Protocol) bool {
-					// we only send a request block if the peer actually has the data
-					// (r.MilestoneIndex > PrunedMilestoneIndex && r.MilestoneIndex <= SolidMilestoneIndex)
-					if !proto.HasDataForMilestone(request.MilestoneIndex) {
-						return true
-					}
-
-					sendRequest(request, proto)
-					requested = true
-					return false
-				})
-
-				if !requested {
-					// we have no neighbor that has the data for sure,
-					// so we ask all peers that could have the data
-					// (r.MilestoneIndex > PrunedMilestoneIndex && r.MilestoneIndex <= LatestMilestoneIndex)
-					r.service.ForEach(func(proto *Protocol) bool {
-						// we only send a request block if the peer could have the data
-						if !proto.CouldHaveDataForMilestone(request.MilestoneIndex) {
-							return true
-						}
-
-						sendRequest(request, proto)
-						return true
-					})
-				}
+// sendRequests sends the given requests to the peers that have the data.
+// The peers that are expected to answer the fastest are preferred and retries are sent to other peers than before.
+// The requests already sent to a peer increase its expected answer time, so that the requests are spread across the peers.
+// Block requests to peers that support it are batched into a single message per peer.
+// Milestone cone requests are preferably sent to peers that support them, other peers only receive a milestone request.
+func (r *Requester) sendRequests(requests Requests) {
+
+	batchedBlockIDs := make(map[*Protocol]iotago.BlockIDs)
+
+	sendRequest := func(request *Request, proto *Protocol) {
+		switch request.RequestType {
+		case RequestTypeBlockID:
+			if proto.SupportsMessageType(MessageTypeBlockRequests) {
+				batchedBlockIDs[proto] = append(batchedBlockIDs[proto], request.BlockID)
+				return
+			}
+			proto.SendBlockRequest(request.BlockID)
+		case RequestTypeMilestoneIndex:
+			proto.SendMilestoneRequest(request.MilestoneIndex)
+		case RequestTypeMilestoneCone:
+			if proto.SupportsMessageType(MessageTypeMilestoneConeRequest) {
+				proto.SendMilestoneConeRequest(request.MilestoneIndex)
+				return
+			}
+			proto.SendMilestoneRequest(request.MilestoneIndex)
+		default:
+			panic(ErrUnknownRequestType)
+		}
+	}
+
+	// the scores of the peers are only fetched once per call.
+	// the latency of the peers is already part of the expected answer time, so it is left out of the score.
+	scores := make(map[*Protocol]float64)
+	peerScore := func(proto *Protocol) float64 {
+		score, exists := scores[proto]
+		if !exists {
+			protoScore := r.service.peeringManager.PeerScore(proto.PeerID)
+			score = protoScore.ValueWithoutLatency()
+			scores[proto] = score
+		}
+		return score
+	}
+
+	// peers that didn't answer a request yet are assumed to be as fast as the average
+	defaultRoundTripTime := time.Duration(r.rQueue.AvgLatency()) * time.Millisecond
+	if defaultRoundTripTime == 0 {
+		defaultRoundTripTime = defaultRequestRoundTripTime
+	}
+
+	for _, request := range requests {
+		var target *requestTarget
+		r.service.ForEach(func(proto *Protocol) bool {
+			// we only send a request block if the peer actually has the data
+			// (r.MilestoneIndex > PrunedMilestoneIndex && r.MilestoneIndex <= SolidMilestoneIndex)
+			if !proto.HasDataForMilestone(request.MilestoneIndex) {
+				return true
+			}
+
+			candidate := &requestTarget{
+				proto: proto,
+				// peers that are able to send the whole cone are preferred for milestone cone requests
+				supportsRequest:    request.RequestType != RequestTypeMilestoneCone || proto.SupportsMessageType(MessageTypeMilestoneConeRequest),
+				alreadyRequested:   request.wasRequestedFrom(proto.PeerID),
+				score:              peerScore(proto),
+				expectedAnswerTime: proto.RequestStats().ExpectedAnswerTime(defaultRoundTripTime),
+			}
+
+			if candidate.betterThan(target) {
+				target = candidate
 			}
+
+			return true
+		})
+
+		if target != nil {
+			// only the peers that claim to have the data are expected to answer
+			request.markRequestedFrom(target.proto.PeerID)
+			target.proto.trackRequest(request.MapKey())
+			sendRequest(request, target.proto)
+			continue
+		}
+
+		// we have no neighbor that has the data for sure,
+		// so we ask all peers that could have the data
+		// (r.MilestoneIndex > PrunedMilestoneIndex && r.MilestoneIndex <= LatestMilestoneIndex)
+		r.service.ForEach(func(proto *Protocol) bool {
+			// we only send a request block if the peer could have the data
+			if !proto.CouldHaveDataForMilestone(request.MilestoneIndex) {
+				return true
+			}
+
+			sendRequest(request, proto)
+			return true
+		})
+	}
+
+	// the batches can't exceed the maximum size, since there are not more requests than that
+	for proto, blockIDs := range batchedBlockIDs {
+		if len(blockIDs) == 1 {
+			proto.SendBlockRequest(blockIDs[0])
+			continue
 		}
+		proto.SendBlockRequests(blockIDs)
 	}
 }
 
@@ -153,6 +223,8 @@ reEnqueueLoop:
 				continue reEnqueueLoop
 			}
 
+			r.scoreUnansweredRequests()
+
 			// always fire the signal if something is in the queue, otherwise the sting request is not kicking in
 			if queued := r.rQueue.EnqueuePending(r.opts.DiscardRequestsOlderThan); queued > 0 {
 				select {
@@ -164,20 +236,40 @@ reEnqueueLoop:
 	}
 }
 
+// decreases the scores of the peers that didn't answer requests in time, at most once per interval.
+func (r *Requester) scoreUnansweredRequests() {
+	unanswered := make(map[peer.ID]int)
+	r.service.ForEach(func(proto *Protocol) bool {
+		if count := proto.unansweredRequests(r.opts.DiscardRequestsOlderThan); count > 0 {
+			unanswered[proto.PeerID] = count
+		}
+		return true
+	})
+
+	// the scores are updated outside the loop to not hold the lock of the service
+	for peerID, count := range unanswered {
+		r.service.peeringManager.UpdatePeerUnansweredRequests(peerID, count)
+	}
+}
+
 // adds the request to the request queue and signals the request drainer to drain it.
 func (r *Requester) enqueueAndSignal(request *Request) bool {
 	if !r.rQueue.Enqueue(request) {
 		return false
 	}
 
-	// signal requester
+	r.signalDrainer()
+	return true
+}
+
+// signals the request drainer to drain the request queue.
+func (r *Requester) signalDrainer() {
 	select {
 	case r.drainSignal <- struct{}{}:
 	default:
 		// if the signal queue is full, there's no need to block until it becomes empty
 		// as the requester will drain everything present in the queue
 	}
-	return true
 }
 
 // checks whether any back pressure function is signaling congestion.
@@ -200,6 +292,22 @@ func (r *Requester) AddBackPressureFunc(pressureFunc RequestBackPressureFunc) {
 	r.backPFuncs = append(r.backPFuncs, pressureFunc)
 }
 
+// blockIDRequest creates a request for the given block if it isn't a solid entry point
+// and is not contained in the database already, otherwise nil is returned.
+func (r *Requester) blockIDRequest(blockID iotago.BlockID, msIndex iotago.MilestoneIndex) *Request {
+	contains, err := r.storage.SolidEntryPointsContain(blockID)
+	if err != nil {
+		panic(err)
+	}
+	if contains {
+		return nil
+	}
+	if r.storage.ContainsBlock(blockID) {
+		return nil
+	}
+	return NewBlockIDRequest(blockID, msIndex)
+}
+
 // Request enqueues a request to the request queue for the given block if it isn't a solid entry point
 // and is not contained in the database already.
 func (r *Requester) Request(data interface{}, msIndex iotago.MilestoneIndex, preventDiscard ...bool) bool {
@@ -208,18 +316,10 @@ func (r *Requester) Request(data interface{}, msIndex iotago.MilestoneIndex, pre
 
 	switch value := data.(type) {
 	case iotago.BlockID:
-		blockID := value
-		contains, err := r.storage.SolidEntryPointsContain(blockID)
-		if err != nil {
-			panic(err)
-		}
-		if contains {
-			return false
-		}
-		if r.storage.ContainsBlock(blockID) {
+		request = r.blockIDRequest(value, msIndex)
+		if request == nil {
 			return false
 		}
-		request = NewBlockIDRequest(blockID, msIndex)
 
 	case iotago.MilestoneIndex:
 		msIndex := value
@@ -239,13 +339,49 @@ func (r *Requester) Request(data interface{}, msIndex iotago.MilestoneIndex, pre
 	return r.enqueueAndSignal(request)
 }
 
+// RequestMilestoneCone enqueues a request for all blocks referenced by the given milestone to the request queue.
+// Peers that don't support milestone cone requests are asked for the milestone only.
+func (r *Requester) RequestMilestoneCone(msIndex iotago.MilestoneIndex, preventDiscard ...bool) bool {
+	request := NewMilestoneConeRequest(msIndex)
+	if len(preventDiscard) > 0 {
+		request.PreventDiscard = preventDiscard[0]
+	}
+
+	return r.enqueueAndSignal(request)
+}
+
+// MilestoneConeRequestsSupported tells whether any connected peer supports milestone cone requests.
+func (r *Requester) MilestoneConeRequestsSupported() bool {
+	supported := false
+	r.service.ForEach(func(proto *Protocol) bool {
+		supported = proto.SupportsMessageType(MessageTypeMilestoneConeRequest)
+		return !supported
+	})
+	return supported
+}
+
 // RequestMultiple works like Request but takes multiple block IDs.
+// The requests are enqueued at once, so that the drainer can send them in batches.
 func (r *Requester) RequestMultiple(blockIDs iotago.BlockIDs, msIndex iotago.MilestoneIndex, preventDiscard ...bool) int {
-	requested := 0
+	requests := make(Requests, 0, len(blockIDs))
 	for _, blockID := range blockIDs {
-		if r.Request(blockID, msIndex, preventDiscard...) {
-			requested++
+		request := r.blockIDRequest(blockID, msIndex)
+		if request == nil {
+			continue
 		}
+		if len(preventDiscard) > 0 {
+			request.PreventDiscard = preventDiscard[0]
+		}
+		requests = append(requests, request)
+	}
+
+	if len(requests) == 0 {
+		return 0
+	}
+
+	requested := r.rQueue.EnqueueMultiple(requests)
+	if requested > 0 {
+		r.signalDrainer()
 	}
 	return requested
 }
@@ -264,9 +400,7 @@ func (r *Requester) RequestParents(cachedBlock *storage.CachedBlock, msIndex iot
 			return
 		}
 
-		for _, parent := range metadata.Parents() {
-			r.Request(parent, msIndex, preventDiscard...)
-		}
+		r.RequestMultiple(metadata.Parents(), msIndex, preventDiscard...)
 	})
 }
 
@@ -278,12 +412,5 @@ func (r *Requester) RequestMilestoneParents(cachedMilestone *storage.CachedMiles
 	msIndex := cachedMilestone.Milestone().Index()
 	parents := cachedMilestone.Milestone().Parents()
 
-	enqueued := false
-	for _, parent := range parents {
-		if r.Request(parent, msIndex, true) {
-			enqueued = true
-		}
-	}
-
-	return enqueued
+	return r.RequestMultiple(parents, msIndex, true) > 0
 }
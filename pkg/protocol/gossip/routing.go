@@ -0,0 +1,114 @@
+package gossip
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const (
+	// the round-trip time assumed for peers that didn't answer a request yet,
+	// if the request queue didn't measure a latency either.
+	defaultRequestRoundTripTime = 1 * time.Second
+
+	// the amount of outstanding requests a peer is assumed to answer within one round-trip time.
+	requestsPerRoundTrip = 32
+)
+
+// RequestStats contains the round-trip times and success rate of the requests sent to a peer.
+type RequestStats struct {
+	// The moving average of the round-trip time of the answered requests.
+	RoundTripTime time.Duration
+	// The amount of answered requests.
+	Answered uint32
+	// The amount of requests that were not answered in time.
+	Unanswered uint32
+	// The amount of requests that were sent to the peer and are not answered yet.
+	Outstanding uint32
+}
+
+// adds the round-trip time of an answered request to the moving average.
+func (s *RequestStats) addAnswered(roundTripTime time.Duration) {
+	s.Answered++
+	if s.RoundTripTime == 0 {
+		s.RoundTripTime = roundTripTime
+		return
+	}
+	s.RoundTripTime = (s.RoundTripTime*9 + roundTripTime) / 10
+}
+
+// SuccessRate returns the estimated probability that the peer answers a request.
+// Peers without answered or unanswered requests start with a success rate of 0.5.
+func (s RequestStats) SuccessRate() float64 {
+	return float64(s.Answered+1) / float64(s.Answered+s.Unanswered+2)
+}
+
+// ExpectedAnswerTime returns the expected time until the peer answers a request, taking retries due to
+// unanswered requests and the requests the peer has to answer before into account.
+// The given round-trip time is used if the peer didn't answer a request yet.
+func (s RequestStats) ExpectedAnswerTime(defaultRoundTripTime time.Duration) time.Duration {
+	roundTripTime := s.RoundTripTime
+	if s.Answered == 0 {
+		roundTripTime = defaultRoundTripTime
+	}
+	load := 1 + float64(s.Outstanding)/requestsPerRoundTrip
+	return time.Duration(float64(roundTripTime) * load / s.SuccessRate())
+}
+
+// RequestStatsSnapshot represents a snapshot of the request statistics of a peer.
+type RequestStatsSnapshot struct {
+	RoundTripTimeMilliseconds int64   `json:"roundTripTimeMs"`
+	Answered                  uint32  `json:"answered"`
+	Unanswered                uint32  `json:"unanswered"`
+	Outstanding               uint32  `json:"outstanding"`
+	SuccessRate               float64 `json:"successRate"`
+}
+
+// requestTarget is a peer that is able to answer a request.
+type requestTarget struct {
+	proto *Protocol
+	// whether the peer supports the type of the request, otherwise a fallback request is sent.
+	supportsRequest bool
+	// whether the request was already sent to the peer.
+	alreadyRequested bool
+	// the score of the peer without the latency penalty, the latency is covered by the expected answer time.
+	score float64
+	// the expected time until the peer answers the request.
+	expectedAnswerTime time.Duration
+}
+
+// betterThan tells whether the target should be preferred over the other one.
+// Targets that support the request are preferred, retries go to other peers than before,
+// peers with a negative score are avoided and the remaining peers are ordered by their expected answer time.
+func (t *requestTarget) betterThan(other *requestTarget) bool {
+	if other == nil {
+		return true
+	}
+	if t.supportsRequest != other.supportsRequest {
+		return t.supportsRequest
+	}
+	if t.alreadyRequested != other.alreadyRequested {
+		return !t.alreadyRequested
+	}
+	if (t.score < 0) != (other.score < 0) {
+		return t.score >= 0
+	}
+	if t.expectedAnswerTime != other.expectedAnswerTime {
+		return t.expectedAnswerTime < other.expectedAnswerTime
+	}
+	return t.score > other.score
+}
+
+// markRequestedFrom remembers that the request was sent to the given peer, so that retries go to other peers.
+func (r *Request) markRequestedFrom(peerID peer.ID) {
+	if r.requestedFrom == nil {
+		r.requestedFrom = make(map[peer.ID]struct{})
+	}
+	r.requestedFrom[peerID] = struct{}{}
+}
+
+// wasRequestedFrom tells whether the request was already sent to the given peer.
+func (r *Request) wasRequestedFrom(peerID peer.ID) bool {
+	_, requested := r.requestedFrom[peerID]
+	return requested
+}
@@ -0,0 +1,48 @@
+package gossip
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestTargetSelection(t *testing.T) {
+	stats := RequestStats{}
+	require.Equal(t, 0.5, stats.SuccessRate())
+	require.Equal(t, 2*time.Second, stats.ExpectedAnswerTime(time.Second))
+
+	stats.addAnswered(100 * time.Millisecond)
+	stats.addAnswered(200 * time.Millisecond)
+	require.Equal(t, 110*time.Millisecond, stats.RoundTripTime)
+	require.Equal(t, 0.75, stats.SuccessRate())
+
+	// unanswered requests increase the expected answer time
+	slowStats := stats
+	slowStats.Unanswered = 6
+	require.Greater(t, slowStats.ExpectedAnswerTime(time.Second), stats.ExpectedAnswerTime(time.Second))
+
+	// outstanding requests increase the expected answer time, so that the requests are spread across the peers
+	busyStats := stats
+	busyStats.Outstanding = requestsPerRoundTrip
+	require.InDelta(t, 2*stats.ExpectedAnswerTime(time.Second), busyStats.ExpectedAnswerTime(time.Second), float64(time.Microsecond))
+
+	fast := &requestTarget{supportsRequest: true, expectedAnswerTime: 100 * time.Millisecond}
+	slow := &requestTarget{supportsRequest: true, expectedAnswerTime: time.Second}
+	require.True(t, fast.betterThan(nil))
+	require.True(t, fast.betterThan(slow))
+	require.False(t, slow.betterThan(fast))
+
+	// retries go to peers that were not asked yet, even if they are slower
+	fast.alreadyRequested = true
+	require.True(t, slow.betterThan(fast))
+
+	// peers with a negative score are avoided
+	fast.alreadyRequested = false
+	slow.score = -10
+	require.True(t, fast.betterThan(slow))
+
+	// peers that support the request are preferred
+	fast.supportsRequest = false
+	require.True(t, slow.betterThan(fast))
+}
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/libp2p/go-libp2p-core/peer"
 	"go.uber.org/atomic"
 
 	"github.com/pkg/errors"
@@ -23,8 +24,12 @@ type RequestType int
 const (
 	RequestTypeBlockID RequestType = iota
 	RequestTypeMilestoneIndex
+	RequestTypeMilestoneCone
 )
 
+// milestoneConeRequestMapKeyPrefix distinguishes the keys of milestone cone requests from milestone index requests.
+const milestoneConeRequestMapKeyPrefix = "cone"
+
 func getRequestMapKey(data interface{}) string {
 	switch value := data.(type) {
 	case iotago.BlockID:
@@ -45,10 +50,14 @@ func getRequestMapKey(data interface{}) string {
 type RequestQueue interface {
 	// Next returns the next request to send, pops it from the queue and marks it as pending.
 	Next() *Request
+	// NextBatch returns up to maxCount requests to send, pops them from the queue and marks them as pending.
+	NextBatch(maxCount int) Requests
 	// Peek returns the next request to send without popping it from the queue.
 	Peek() *Request
 	// Enqueue enqueues the given request if it isn't already queued or pending.
 	Enqueue(*Request) (enqueued bool)
+	// EnqueueMultiple enqueues the given requests which aren't already queued or pending.
+	EnqueueMultiple(Requests) (enqueued int)
 	// IsQueued tells whether a given request for the given data is queued.
 	IsQueued(data interface{}) bool
 	// IsPending tells whether a given request was popped from the queue and is now pending.
@@ -114,6 +123,8 @@ type Request struct {
 	// the time at which this request was first enqueued.
 	// do not modify this time
 	EnqueueTime time.Time
+	// the peers the request was sent to, only accessed by the request queue drainer.
+	requestedFrom map[peer.ID]struct{}
 }
 
 // NewBlockIDRequest creates a new block request for a specific blockID.
@@ -126,12 +137,19 @@ func NewMilestoneIndexRequest(msIndex iotago.MilestoneIndex) *Request {
 	return &Request{RequestType: RequestTypeMilestoneIndex, MilestoneIndex: msIndex}
 }
 
+// NewMilestoneConeRequest creates a new request for all blocks referenced by a specific milestone.
+func NewMilestoneConeRequest(msIndex iotago.MilestoneIndex) *Request {
+	return &Request{RequestType: RequestTypeMilestoneCone, MilestoneIndex: msIndex}
+}
+
 func (r *Request) MapKey() string {
 	switch r.RequestType {
 	case RequestTypeBlockID:
 		return string(r.BlockID[:])
 	case RequestTypeMilestoneIndex:
 		return strconv.Itoa(int(r.MilestoneIndex))
+	case RequestTypeMilestoneCone:
+		return milestoneConeRequestMapKeyPrefix + strconv.Itoa(int(r.MilestoneIndex))
 	default:
 		panic(ErrUnknownRequestType)
 	}
@@ -178,10 +196,44 @@ func (pq *priorityqueue) Next() (r *Request) {
 	return next.(*Request)
 }
 
+func (pq *priorityqueue) NextBatch(maxCount int) Requests {
+	pq.Lock()
+	defer pq.Unlock()
+
+	var requests Requests
+	for len(requests) < maxCount && pq.Len() > 0 {
+		next := heap.Pop(pq)
+		if next == nil {
+			break
+		}
+		requests = append(requests, next.(*Request))
+	}
+
+	return requests
+}
+
 func (pq *priorityqueue) Enqueue(r *Request) bool {
 	pq.Lock()
 	defer pq.Unlock()
 
+	return pq.enqueueWithoutLocking(r)
+}
+
+func (pq *priorityqueue) EnqueueMultiple(requests Requests) int {
+	pq.Lock()
+	defer pq.Unlock()
+
+	enqueued := 0
+	for _, r := range requests {
+		if pq.enqueueWithoutLocking(r) {
+			enqueued++
+		}
+	}
+
+	return enqueued
+}
+
+func (pq *priorityqueue) enqueueWithoutLocking(r *Request) bool {
 	requestMapKey := r.MapKey()
 
 	if _, queued := pq.queued[requestMapKey]; queued {
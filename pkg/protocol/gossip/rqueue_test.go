@@ -169,3 +169,32 @@ func TestRequestQueue(t *testing.T) {
 		assert.Equal(t, req, r)
 	}
 }
+
+func TestRequestQueueBatch(t *testing.T) {
+	q := gossip.NewRequestQueue()
+
+	requests := gossip.Requests{
+		gossip.NewBlockIDRequest(tpkg.RandBlockID(), 10),
+		gossip.NewBlockIDRequest(tpkg.RandBlockID(), 5),
+		gossip.NewBlockIDRequest(tpkg.RandBlockID(), 7),
+	}
+
+	assert.Equal(t, len(requests), q.EnqueueMultiple(requests))
+	// already queued requests are not enqueued again
+	assert.Zero(t, q.EnqueueMultiple(requests[:1]))
+
+	batch := q.NextBatch(2)
+	assert.Len(t, batch, 2)
+	assert.Equal(t, requests[1], batch[0])
+	assert.Equal(t, requests[2], batch[1])
+
+	batch = q.NextBatch(2)
+	assert.Len(t, batch, 1)
+	assert.Equal(t, requests[0], batch[0])
+
+	assert.Empty(t, q.NextBatch(2))
+
+	queued, pending, _ := q.Size()
+	assert.Zero(t, queued)
+	assert.Equal(t, len(requests), pending)
+}
@@ -61,6 +61,7 @@ const (
 
 var (
 	ErrProtocolDoesNotExist = errors.New("stream/protocol does not exist")
+	ErrProtocolTerminated   = errors.New("stream/protocol was terminated")
 )
 
 const (
@@ -75,6 +76,7 @@ var defaultServiceOptions = []ServiceOption{
 	WithStreamReadTimeout(1 * time.Minute),
 	WithStreamWriteTimeout(10 * time.Second),
 	WithUnknownPeersLimit(0),
+	WithHandshakeFunc(func() *Handshake { return NewHandshake(0, 0) }),
 }
 
 // ServiceOptions define options for a Service.
@@ -91,6 +93,16 @@ type ServiceOptions struct {
 	streamWriteTimeout time.Duration
 	// The amount of unknown peers to allow to have a gossip stream with.
 	unknownPeersLimit int
+	// The protocol IDs of older gossip protocol versions without a handshake.
+	legacyProtocols []protocol.ID
+	// Creates the handshake which is sent when a stream is opened.
+	handshakeFunc HandshakeFunc
+	// The maximum amount of bytes per second sent to and received from all peers.
+	uploadLimit   int
+	downloadLimit int
+	// The maximum amount of bytes per second sent to and received from a single peer.
+	peerUploadLimit   int
+	peerDownloadLimit int
 }
 
 // applies the given ServiceOption.
@@ -143,6 +155,35 @@ func WithUnknownPeersLimit(limit int) ServiceOption {
 	}
 }
 
+// WithLegacyProtocols defines the protocol IDs of older gossip protocol versions
+// which are still accepted for peers that don't support the handshake.
+func WithLegacyProtocols(protocols ...protocol.ID) ServiceOption {
+	return func(opts *ServiceOptions) {
+		opts.legacyProtocols = protocols
+	}
+}
+
+// HandshakeFunc creates the handshake which is sent when a gossip stream is opened.
+type HandshakeFunc func() *Handshake
+
+// WithHandshakeFunc defines the function which creates the handshake sent to peers.
+func WithHandshakeFunc(handshakeFunc HandshakeFunc) ServiceOption {
+	return func(opts *ServiceOptions) {
+		opts.handshakeFunc = handshakeFunc
+	}
+}
+
+// WithBandwidthLimits defines the maximum amount of bytes per second sent to and received from all peers
+// and from a single peer. A limit of zero means that the bandwidth is not limited.
+func WithBandwidthLimits(uploadLimit int, downloadLimit int, peerUploadLimit int, peerDownloadLimit int) ServiceOption {
+	return func(opts *ServiceOptions) {
+		opts.uploadLimit = uploadLimit
+		opts.downloadLimit = downloadLimit
+		opts.peerUploadLimit = peerUploadLimit
+		opts.peerDownloadLimit = peerDownloadLimit
+	}
+}
+
 // ServiceOption is a function setting a ServiceOptions option.
 type ServiceOption func(opts *ServiceOptions)
 
@@ -166,6 +207,8 @@ type Service struct {
 	serverMetrics *metrics.ServerMetrics
 	// holds the service options.
 	opts *ServiceOptions
+	// limits the bandwidth of all gossip protocol streams.
+	bandwidthLimiter *BandwidthLimiter
 	// tells whether the service was shut down.
 	stopped *typeutils.AtomicBool
 	// the amount of unknown peers with which a gossip stream is ongoing.
@@ -217,6 +260,7 @@ func NewService(
 		peeringManager:      peeringManager,
 		serverMetrics:       serverMetrics,
 		opts:                srvOpts,
+		bandwidthLimiter:    NewBandwidthLimiter(srvOpts.uploadLimit, srvOpts.downloadLimit),
 		stopped:             typeutils.NewAtomicBool(),
 		unknownPeers:        map[peer.ID]struct{}{},
 		inboundStreamChan:   make(chan network.Stream, 10),
@@ -291,12 +335,16 @@ func (s *Service) Start(ctx context.Context) {
 	s.attachEvents()
 
 	// libp2p stream handler
-	s.host.SetStreamHandler(s.protocol, func(stream network.Stream) {
+	streamHandler := func(stream network.Stream) {
 		if s.stopped.IsSet() {
 			return
 		}
 		s.inboundStreamChan <- stream
-	})
+	}
+	s.host.SetStreamHandler(s.protocol, streamHandler)
+	for _, legacyProtocol := range s.opts.legacyProtocols {
+		s.host.SetStreamHandler(legacyProtocol, streamHandler)
+	}
 
 	// manage libp2p network events
 	s.host.Network().Notify((*netNotifiee)(s))
@@ -305,6 +353,9 @@ func (s *Service) Start(ctx context.Context) {
 
 	// libp2p stream handler
 	s.host.RemoveStreamHandler(s.protocol)
+	for _, legacyProtocol := range s.opts.legacyProtocols {
+		s.host.RemoveStreamHandler(legacyProtocol)
+	}
 
 	// de-register libp2p network events
 	s.host.Network().StopNotify((*netNotifiee)(s))
@@ -531,7 +582,8 @@ func (s *Service) openStream(peerID peer.ID) (network.Stream, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), s.opts.streamConnectTimeout)
 	defer cancel()
 
-	stream, err := s.host.NewStream(ctx, peerID, s.protocol)
+	// the current protocol version is preferred, older versions are used for peers that don't support it yet
+	stream, err := s.host.NewStream(ctx, peerID, append([]protocol.ID{s.protocol}, s.opts.legacyProtocols...)...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create gossip stream to %s: %w", peerID, err)
 	}
@@ -548,8 +600,16 @@ func (s *Service) registerProtocol(peerID peer.ID, stream network.Stream) {
 		return
 	}
 
-	proto := NewProtocol(peerID, stream, s.opts.sendQueueSize, s.opts.streamReadTimeout, s.opts.streamWriteTimeout, s.serverMetrics)
+	proto := NewProtocol(peerID, stream, s.opts.sendQueueSize, s.opts.streamReadTimeout, s.opts.streamWriteTimeout, s.serverMetrics,
+		s.bandwidthLimiter, NewBandwidthLimiter(s.opts.peerUploadLimit, s.opts.peerDownloadLimit))
 	s.streams[peerID] = proto
+
+	// the handshake is the first message on the stream,
+	// peers using an older protocol version would drop the stream because of the unknown message type.
+	if stream.Protocol() == s.protocol {
+		proto.SendHandshake(s.opts.handshakeFunc())
+	}
+
 	s.Events.ProtocolStarted.Trigger(proto)
 }
 
@@ -627,6 +687,19 @@ func (s *Service) forEach(f ProtocolForEachFunc) {
 	}
 }
 
+// tells whether the given protocol ID belongs to the current or an older gossip protocol version.
+func (s *Service) isGossipProtocol(protocolID protocol.ID) bool {
+	if protocolID == s.protocol {
+		return true
+	}
+	for _, legacyProtocol := range s.opts.legacyProtocols {
+		if protocolID == legacyProtocol {
+			return true
+		}
+	}
+	return false
+}
+
 // returns the protocol for the given peer or nil
 func (s *Service) proto(peerID peer.ID) *Protocol {
 	return s.streams[peerID]
@@ -725,7 +798,7 @@ func (m *netNotifiee) Connected(net network.Network, conn network.Conn)
 func (m *netNotifiee) Disconnected(net network.Network, conn network.Conn)            {}
 func (m *netNotifiee) OpenedStream(net network.Network, stream network.Stream)        {}
 func (m *netNotifiee) ClosedStream(net network.Network, stream network.Stream) {
-	if stream.Protocol() != m.protocol {
+	if !(*Service)(m).isGossipProtocol(stream.Protocol()) {
 		return
 	}
 	if m.stopped.IsSet() {
@@ -3,6 +3,8 @@ package gossip
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
+	"math"
 
 	"github.com/pkg/errors"
 
@@ -18,10 +20,15 @@ var (
 )
 
 const (
-	MessageTypeMilestoneRequest message.Type = 1
-	MessageTypeBlock            message.Type = 2
-	MessageTypeBlockRequest     message.Type = 3
-	MessageTypeHeartbeat        message.Type = 4
+	MessageTypeMilestoneRequest     message.Type = 1
+	MessageTypeBlock                message.Type = 2
+	MessageTypeBlockRequest         message.Type = 3
+	MessageTypeHeartbeat            message.Type = 4
+	MessageTypeHandshake            message.Type = 5
+	MessageTypeBlockRequests        message.Type = 6
+	MessageTypeBlocks               message.Type = 7
+	MessageTypeMilestoneConeRequest message.Type = 8
+	MessageTypeMilestoneCone        message.Type = 9
 )
 
 const (
@@ -34,6 +41,27 @@ const (
 	// heartbeatMilestoneIndexBytesLength defines the amount of bytes used for a milestone index within a heartbeat packet.
 	heartbeatMilestoneIndexBytesLength = 4
 
+	// MaxBlockRequestsBatchSize defines the maximum amount of block IDs in a batched block request.
+	MaxBlockRequestsBatchSize = 256
+
+	// blocksBatchMaxBytesLength defines the maximum amount of bytes of a batched block response.
+	blocksBatchMaxBytesLength = math.MaxUint16
+
+	// blocksBatchBlockLengthBytesLength defines the amount of bytes used for the length of a block within a batched block response.
+	blocksBatchBlockLengthBytesLength = serializer.UInt16ByteSize
+
+	// milestoneConeHeaderBytesLength defines the amount of bytes used for the header of a milestone cone response.
+	// milestone index + final flag
+	milestoneConeHeaderBytesLength = serializer.UInt32ByteSize + serializer.OneByte
+
+	// milestoneConeMaxBytesLength defines the maximum amount of bytes of the blocks sent in reply to a milestone cone request.
+	// The remaining blocks of bigger cones are requested separately by the solidifier of the requesting node.
+	milestoneConeMaxBytesLength = 16 * 1024 * 1024
+
+	// milestoneConeMaxBlocks defines the maximum amount of blocks sent in reply to a milestone cone request.
+	// The remaining blocks of bigger cones are requested separately by the solidifier of the requesting node.
+	milestoneConeMaxBlocks = 32 * 1024
+
 	// latestMilestoneRequestIndex defines the index to use to request the latest milestone via a milestone request message.
 	latestMilestoneRequestIndex = 0
 )
@@ -54,6 +82,38 @@ var (
 		VariableLength: false,
 	}
 
+	// blockRequestsMessageDefinition defines the batched block request packet.
+	// Contains the concatenated IDs of the requested blocks.
+	blockRequestsMessageDefinition = &message.Definition{
+		ID:             MessageTypeBlockRequests,
+		MaxBytesLength: requestedBlockIDMsgBytesLength * MaxBlockRequestsBatchSize,
+		VariableLength: true,
+	}
+
+	// blocksMessageDefinition defines the batched block response packet.
+	// Contains the length prefixed blocks.
+	blocksMessageDefinition = &message.Definition{
+		ID:             MessageTypeBlocks,
+		MaxBytesLength: blocksBatchMaxBytesLength,
+		VariableLength: true,
+	}
+
+	// milestoneConeRequestMessageDefinition defines the requested milestone cone packet.
+	// Contains only the index of the milestone whose cone is requested.
+	milestoneConeRequestMessageDefinition = &message.Definition{
+		ID:             MessageTypeMilestoneConeRequest,
+		MaxBytesLength: requestedMilestoneIndexMsgBytesLength,
+		VariableLength: false,
+	}
+
+	// milestoneConeMessageDefinition defines the milestone cone response packet.
+	// Contains the milestone index, a flag whether it is the last packet of the cone and the length prefixed blocks.
+	milestoneConeMessageDefinition = &message.Definition{
+		ID:             MessageTypeMilestoneCone,
+		MaxBytesLength: blocksBatchMaxBytesLength,
+		VariableLength: true,
+	}
+
 	// heartbeatMessageDefinition defines the heartbeat packet containing the current solid, pruned and latest milestone index,
 	// number of connected peers and number of synced peers.
 	heartbeatMessageDefinition = &message.Definition{
@@ -100,6 +160,176 @@ func newBlockRequestMessage(requestedBlockID iotago.BlockID) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// newBlockRequestsMessage creates a batched block request message.
+func newBlockRequestsMessage(requestedBlockIDs iotago.BlockIDs) ([]byte, error) {
+	if len(requestedBlockIDs) == 0 || len(requestedBlockIDs) > MaxBlockRequestsBatchSize {
+		return nil, fmt.Errorf("invalid amount of requested blocks: %d", len(requestedBlockIDs))
+	}
+
+	blockRequestsBytesLength := uint16(len(requestedBlockIDs) * requestedBlockIDMsgBytesLength)
+	buf := bytes.NewBuffer(make([]byte, 0, tlv.HeaderMessageDefinition.MaxBytesLength+blockRequestsBytesLength))
+	if err := tlv.WriteHeader(buf, MessageTypeBlockRequests, blockRequestsBytesLength); err != nil {
+		return nil, err
+	}
+
+	for _, requestedBlockID := range requestedBlockIDs {
+		if err := binary.Write(buf, binary.LittleEndian, requestedBlockID[:requestedBlockIDMsgBytesLength]); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// newBlocksMessages creates batched block response messages.
+// The blocks are split into as many messages as needed to not exceed the maximum message size.
+func newBlocksMessages(blocksData [][]byte) ([][]byte, error) {
+	return newBatchedBlocksMessages(MessageTypeBlocks, 0, nil, blocksData)
+}
+
+// newMilestoneConeMessages creates the milestone cone response messages for the given blocks of the cone.
+// The blocks are split into as many messages as needed to not exceed the maximum message size,
+// the last message is flagged as final. A cone without blocks results in a single final message.
+func newMilestoneConeMessages(msIndex iotago.MilestoneIndex, blocksData [][]byte) ([][]byte, error) {
+	var messages [][]byte
+
+	batcher := newMilestoneConeBatcher(msIndex, func(msg []byte) error {
+		messages = append(messages, msg)
+		return nil
+	})
+
+	for _, blockData := range blocksData {
+		if err := batcher.add(blockData); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := batcher.close(); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// newMilestoneConeBatcher creates a blocksBatcher for the milestone cone response messages of the given milestone.
+// A cone without blocks results in a single final message.
+func newMilestoneConeBatcher(msIndex iotago.MilestoneIndex, onMessage func(msg []byte) error) *blocksBatcher {
+	header := func(buf *bytes.Buffer, final bool) error {
+		if err := binary.Write(buf, binary.LittleEndian, msIndex); err != nil {
+			return err
+		}
+		return binary.Write(buf, binary.LittleEndian, final)
+	}
+
+	return newBlocksBatcher(MessageTypeMilestoneCone, milestoneConeHeaderBytesLength, header, onMessage)
+}
+
+// newBatchedBlocksMessages creates messages of the given type containing the length prefixed blocks.
+// Every message starts with a header of the given length written by writeHeader, which is told whether it is the last message.
+func newBatchedBlocksMessages(msgType message.Type, headerBytesLength int, writeHeader func(buf *bytes.Buffer, final bool) error, blocksData [][]byte) ([][]byte, error) {
+	var messages [][]byte
+
+	batcher := newBlocksBatcher(msgType, headerBytesLength, writeHeader, func(msg []byte) error {
+		messages = append(messages, msg)
+		return nil
+	})
+
+	for _, blockData := range blocksData {
+		if err := batcher.add(blockData); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := batcher.close(); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// blocksBatcher splits length prefixed blocks into messages of the given type while they are added,
+// so that the blocks don't need to be kept in memory until all of them are known.
+// Every message starts with a header of the given length written by writeHeader, which is told whether it is the last message.
+type blocksBatcher struct {
+	msgType           message.Type
+	headerBytesLength int
+	writeHeader       func(buf *bytes.Buffer, final bool) error
+	// called with every created message, the batching is aborted if it returns an error.
+	onMessage func(msg []byte) error
+
+	batch            [][]byte
+	batchBytesLength int
+}
+
+// newBlocksBatcher creates a new blocksBatcher.
+func newBlocksBatcher(msgType message.Type, headerBytesLength int, writeHeader func(buf *bytes.Buffer, final bool) error, onMessage func(msg []byte) error) *blocksBatcher {
+	return &blocksBatcher{
+		msgType:           msgType,
+		headerBytesLength: headerBytesLength,
+		writeHeader:       writeHeader,
+		onMessage:         onMessage,
+		batchBytesLength:  headerBytesLength,
+	}
+}
+
+// creates a message of the current batch and passes it to onMessage.
+// batches without blocks are only sent if they are final and have a header, so that the receiver learns about the end.
+func (b *blocksBatcher) flush(final bool) error {
+	if len(b.batch) == 0 && (!final || b.writeHeader == nil) {
+		return nil
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, int(tlv.HeaderMessageDefinition.MaxBytesLength)+b.batchBytesLength))
+	if err := tlv.WriteHeader(buf, b.msgType, uint16(b.batchBytesLength)); err != nil {
+		return err
+	}
+
+	if b.writeHeader != nil {
+		if err := b.writeHeader(buf, final); err != nil {
+			return err
+		}
+	}
+
+	for _, blockData := range b.batch {
+		if err := binary.Write(buf, binary.LittleEndian, uint16(len(blockData))); err != nil {
+			return err
+		}
+		if err := binary.Write(buf, binary.LittleEndian, blockData); err != nil {
+			return err
+		}
+	}
+
+	b.batch = nil
+	b.batchBytesLength = b.headerBytesLength
+
+	return b.onMessage(buf.Bytes())
+}
+
+// add adds the given block to the current batch.
+// If the block doesn't fit into the current batch, the batch is passed to onMessage first.
+func (b *blocksBatcher) add(blockData []byte) error {
+	blockBytesLength := blocksBatchBlockLengthBytesLength + len(blockData)
+	if b.headerBytesLength+blockBytesLength > blocksBatchMaxBytesLength {
+		return fmt.Errorf("block exceeds the maximum batch size: %d", len(blockData))
+	}
+
+	if b.batchBytesLength+blockBytesLength > blocksBatchMaxBytesLength {
+		if err := b.flush(false); err != nil {
+			return err
+		}
+	}
+
+	b.batch = append(b.batch, blockData)
+	b.batchBytesLength += blockBytesLength
+
+	return nil
+}
+
+// close passes the remaining blocks to onMessage as the final message.
+func (b *blocksBatcher) close() error {
+	return b.flush(true)
+}
+
 // newHeartbeatMessage creates a new heartbeat message.
 func newHeartbeatMessage(solidMilestoneIndex iotago.MilestoneIndex, prunedMilestoneIndex iotago.MilestoneIndex, latestMilestoneIndex iotago.MilestoneIndex, connectedPeers uint8, syncedPeers uint8) ([]byte, error) {
 	buf := bytes.NewBuffer(make([]byte, 0, tlv.HeaderMessageDefinition.MaxBytesLength+heartbeatMessageDefinition.MaxBytesLength))
@@ -130,6 +360,20 @@ func newHeartbeatMessage(solidMilestoneIndex iotago.MilestoneIndex, prunedMilest
 	return buf.Bytes(), nil
 }
 
+// newMilestoneConeRequestMessage creates a new milestone cone request message.
+func newMilestoneConeRequestMessage(requestedMilestoneIndex iotago.MilestoneIndex) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, tlv.HeaderMessageDefinition.MaxBytesLength+milestoneConeRequestMessageDefinition.MaxBytesLength))
+	if err := tlv.WriteHeader(buf, MessageTypeMilestoneConeRequest, milestoneConeRequestMessageDefinition.MaxBytesLength); err != nil {
+		return nil, err
+	}
+
+	if err := binary.Write(buf, binary.LittleEndian, requestedMilestoneIndex); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
 // newMilestoneRequestMessage creates a new milestone request message.
 func newMilestoneRequestMessage(requestedMilestoneIndex iotago.MilestoneIndex) ([]byte, error) {
 	buf := bytes.NewBuffer(make([]byte, 0, tlv.HeaderMessageDefinition.MaxBytesLength+milestoneRequestMessageDefinition.MaxBytesLength))
@@ -152,6 +396,75 @@ func extractRequestedMilestoneIndex(source []byte) (iotago.MilestoneIndex, error
 	return binary.LittleEndian.Uint32(source), nil
 }
 
+// extractRequestedBlockIDs extracts the requested block IDs from the given batched block request.
+func extractRequestedBlockIDs(source []byte) (iotago.BlockIDs, error) {
+	if len(source) == 0 || len(source)%requestedBlockIDMsgBytesLength != 0 || len(source) > requestedBlockIDMsgBytesLength*MaxBlockRequestsBatchSize {
+		return nil, ErrInvalidSourceLength
+	}
+
+	blockIDs := make(iotago.BlockIDs, len(source)/requestedBlockIDMsgBytesLength)
+	for i := range blockIDs {
+		copy(blockIDs[i][:], source[i*requestedBlockIDMsgBytesLength:])
+	}
+
+	return blockIDs, nil
+}
+
+// extractBlocks extracts the blocks from the given batched block response.
+func extractBlocks(source []byte) ([][]byte, error) {
+	var blocksData [][]byte
+
+	for offset := 0; offset < len(source); {
+		if len(source)-offset < blocksBatchBlockLengthBytesLength {
+			return nil, ErrInvalidSourceLength
+		}
+
+		blockBytesLength := int(binary.LittleEndian.Uint16(source[offset:]))
+		offset += blocksBatchBlockLengthBytesLength
+
+		if blockBytesLength == 0 || len(source)-offset < blockBytesLength {
+			return nil, ErrInvalidSourceLength
+		}
+
+		blocksData = append(blocksData, source[offset:offset+blockBytesLength])
+		offset += blockBytesLength
+	}
+
+	return blocksData, nil
+}
+
+// extractMilestoneCone extracts the milestone index, the final flag and the blocks from the given milestone cone response.
+func extractMilestoneCone(source []byte) (iotago.MilestoneIndex, bool, [][]byte, error) {
+	if len(source) < milestoneConeHeaderBytesLength {
+		return 0, false, nil, ErrInvalidSourceLength
+	}
+
+	msIndex := binary.LittleEndian.Uint32(source[:serializer.UInt32ByteSize])
+	final := source[serializer.UInt32ByteSize] != 0
+
+	blocksData, err := extractBlocks(source[milestoneConeHeaderBytesLength:])
+	if err != nil {
+		return 0, false, nil, err
+	}
+
+	return msIndex, final, blocksData, nil
+}
+
+// batchedBlocksCount returns the amount of blocks within the given batched blocks or milestone cone message.
+func batchedBlocksCount(data []byte) int {
+	headerBytesLength := int(tlv.HeaderMessageDefinition.MaxBytesLength)
+	if message.Type(data[0]) == MessageTypeMilestoneCone {
+		headerBytesLength += milestoneConeHeaderBytesLength
+	}
+
+	count := 0
+	for offset := headerBytesLength; offset+blocksBatchBlockLengthBytesLength <= len(data); count++ {
+		offset += blocksBatchBlockLengthBytesLength + int(binary.LittleEndian.Uint16(data[offset:]))
+	}
+
+	return count
+}
+
 // Heartbeat contains information about a nodes current solid and pruned milestone index
 // and its connected and synced peers count.
 type Heartbeat struct {
@@ -0,0 +1,89 @@
+package gossip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/protocol/tlv"
+	"github.com/iotaledger/hornet/pkg/tpkg"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func TestBlockRequestsMessage(t *testing.T) {
+	blockIDs := iotago.BlockIDs{tpkg.RandBlockID(), tpkg.RandBlockID(), tpkg.RandBlockID()}
+
+	msg, err := newBlockRequestsMessage(blockIDs)
+	require.NoError(t, err)
+
+	extractedBlockIDs, err := extractRequestedBlockIDs(msg[tlv.HeaderMessageDefinition.MaxBytesLength:])
+	require.NoError(t, err)
+	require.Equal(t, blockIDs, extractedBlockIDs)
+
+	_, err = extractRequestedBlockIDs(msg[tlv.HeaderMessageDefinition.MaxBytesLength+1:])
+	require.ErrorIs(t, err, ErrInvalidSourceLength)
+}
+
+func TestBlocksMessages(t *testing.T) {
+	// the blocks don't fit into a single message
+	var blocksData [][]byte
+	for i := 0; i < 5; i++ {
+		blocksData = append(blocksData, tpkg.RandBytes(20000))
+	}
+
+	msgs, err := newBlocksMessages(blocksData)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+
+	var extractedBlocksData [][]byte
+	for _, msg := range msgs {
+		require.LessOrEqual(t, len(msg), int(tlv.HeaderMessageDefinition.MaxBytesLength)+blocksBatchMaxBytesLength)
+
+		data, err := extractBlocks(msg[tlv.HeaderMessageDefinition.MaxBytesLength:])
+		require.NoError(t, err)
+		require.Equal(t, len(data), batchedBlocksCount(msg))
+		extractedBlocksData = append(extractedBlocksData, data...)
+	}
+	require.Equal(t, blocksData, extractedBlocksData)
+
+	_, err = extractBlocks(msgs[0][tlv.HeaderMessageDefinition.MaxBytesLength : len(msgs[0])-1])
+	require.ErrorIs(t, err, ErrInvalidSourceLength)
+}
+
+func TestMilestoneConeMessages(t *testing.T) {
+	msIndex := iotago.MilestoneIndex(1337)
+
+	var blocksData [][]byte
+	for i := 0; i < 5; i++ {
+		blocksData = append(blocksData, tpkg.RandBytes(20000))
+	}
+
+	msgs, err := newMilestoneConeMessages(msIndex, blocksData)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+
+	var extractedBlocksData [][]byte
+	for i, msg := range msgs {
+		require.LessOrEqual(t, len(msg), int(tlv.HeaderMessageDefinition.MaxBytesLength)+blocksBatchMaxBytesLength)
+
+		extractedIndex, final, data, err := extractMilestoneCone(msg[tlv.HeaderMessageDefinition.MaxBytesLength:])
+		require.NoError(t, err)
+		require.Equal(t, msIndex, extractedIndex)
+		require.Equal(t, i == len(msgs)-1, final)
+		require.Equal(t, len(data), batchedBlocksCount(msg))
+		extractedBlocksData = append(extractedBlocksData, data...)
+	}
+	require.Equal(t, blocksData, extractedBlocksData)
+
+	// an empty cone is answered with a single final message
+	msgs, err = newMilestoneConeMessages(msIndex, nil)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+
+	extractedIndex, final, data, err := extractMilestoneCone(msgs[0][tlv.HeaderMessageDefinition.MaxBytesLength:])
+	require.NoError(t, err)
+	require.Equal(t, msIndex, extractedIndex)
+	require.True(t, final)
+	require.Empty(t, data)
+	require.Zero(t, batchedBlocksCount(msgs[0]))
+}
@@ -354,6 +354,13 @@ func (w *WarpSyncMilestoneRequester) RequestMilestoneRange(ctx context.Context,
 
 		// milestone already exists
 		if onExistingMilestoneInRange != nil {
+			if msIndexToRequest > w.syncManager.ConfirmedMilestoneIndex() && w.requester.MilestoneConeRequestsSupported() {
+				// request the whole cone of the unconfirmed milestone at once instead of walking the cone
+				// and requesting the missing blocks one by one.
+				w.requester.RequestMilestoneCone(msIndexToRequest, w.preventDiscard)
+				continue
+			}
+
 			if err := onExistingMilestoneInRange(ctx, msIndexToRequest); err != nil && errors.Is(err, common.ErrOperationAborted) {
 				// do not proceed if the node was shut down
 				return 0
@@ -365,9 +372,10 @@ func (w *WarpSyncMilestoneRequester) RequestMilestoneRange(ctx context.Context,
 		return requested
 	}
 
-	// enqueue every milestone request to the request queue
+	// enqueue a request for the cone of every milestone to the request queue,
+	// peers that don't support milestone cone requests only send the milestone.
 	for _, msIndex := range msIndexes {
-		w.requester.Request(msIndex, msIndex)
+		w.requester.RequestMilestoneCone(msIndex)
 	}
 
 	return requested
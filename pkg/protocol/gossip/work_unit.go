@@ -98,15 +98,14 @@ func (wu *WorkUnit) addReceivedFrom(p *Protocol) {
 
 // punishes, respectively increases the invalid block metric of all peers
 // which sent the given underlying block of this WorkUnit.
-// it also closes the connection to these peers.
+// it also decreases the score of these peers and closes the connection to them.
 func (wu *WorkUnit) punish(reason error) {
 	wu.receivedFromLock.Lock()
 	defer wu.receivedFromLock.Unlock()
 	for _, p := range wu.receivedFrom {
 		wu.messageProcessor.serverMetrics.InvalidBlocks.Inc()
 
-		// drop the connection to the peer
-		_ = wu.messageProcessor.peeringManager.DisconnectPeer(p.PeerID, errors.WithMessagef(reason, "peer was punished"))
+		wu.messageProcessor.punishPeer(p, errors.WithMessagef(reason, "peer was punished"))
 	}
 }
 
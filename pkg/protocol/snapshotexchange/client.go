@@ -0,0 +1,683 @@
+package snapshotexchange
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/iotaledger/hive.go/contextutils"
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/hornet/pkg/snapshot"
+	iotago "github.com/iotaledger/iota.go/v3"
+	"github.com/iotaledger/iota.go/v3/keymanager"
+)
+
+const (
+	// the timeout for connecting to a known peer.
+	connectPeerTimeout = 10 * time.Second
+	// the timeout for requesting the advertisements or a manifest of a peer.
+	requestTimeout = 30 * time.Second
+	// the timeout for requesting a single chunk of a peer.
+	chunkRequestTimeout = 1 * time.Minute
+	// the amount of attempts to download a chunk before the download is aborted.
+	maxChunkAttempts = 5
+	// the amount of failed requests after which a peer is no longer used for a download.
+	maxPeerFailures = 3
+)
+
+var (
+	// ErrNoPeers is returned if no peers are available to download snapshot files from.
+	ErrNoPeers = errors.New("no peers available to download snapshot files from")
+	// ErrNoHealthyPeer is returned if all peers serving a snapshot file failed too often.
+	ErrNoHealthyPeer = errors.New("no healthy peer left to download the snapshot file from")
+	// ErrSnapshotHeaderMismatch is returned if the header of a downloaded snapshot file doesn't match the advertised milestone.
+	ErrSnapshotHeaderMismatch = errors.New("snapshot header does not match the advertised milestone")
+	// ErrInvalidSnapshotMilestones is returned if the milestones of a downloaded snapshot file are not signed by the coordinator
+	// or don't lead to the milestone in the snapshot header.
+	ErrInvalidSnapshotMilestones = errors.New("invalid snapshot milestones")
+)
+
+// sourceGroup holds the peers that advertised the same snapshot files.
+type sourceGroup struct {
+	full  *FileAdvertisement
+	delta *FileAdvertisement
+	peers []peer.ID
+}
+
+// ledgerIndex returns the ledger index after the snapshot files of the group were applied.
+func (g *sourceGroup) ledgerIndex() iotago.MilestoneIndex {
+	if g.delta != nil {
+		return g.delta.TargetMilestoneIndex
+	}
+	return g.full.TargetMilestoneIndex
+}
+
+// peerHealth tracks the failed requests of the peers serving a snapshot file.
+type peerHealth struct {
+	sync.Mutex
+
+	peers    []peer.ID
+	failures map[peer.ID]int
+}
+
+func newPeerHealth(peers []peer.ID) *peerHealth {
+	return &peerHealth{
+		peers:    peers,
+		failures: make(map[peer.ID]int),
+	}
+}
+
+// pick returns a healthy peer, the peers are distributed by the given seed.
+func (h *peerHealth) pick(seed int) (peer.ID, bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	for i := 0; i < len(h.peers); i++ {
+		peerID := h.peers[(seed+i)%len(h.peers)]
+		if h.failures[peerID] < maxPeerFailures {
+			return peerID, true
+		}
+	}
+
+	return "", false
+}
+
+func (h *peerHealth) recordFailure(peerID peer.ID) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.failures[peerID]++
+}
+
+// Client downloads snapshot files from peers that serve them via the snapshot exchange protocol.
+type Client struct {
+	// the logger used to log events.
+	*logger.WrappedLogger
+
+	host        host.Host
+	protocol    protocol.ID
+	knownPeers  []peer.AddrInfo
+	parallelism int
+
+	// used to verify the milestones of the downloaded snapshot files.
+	keyManager              *keymanager.KeyManager
+	milestonePublicKeyCount int
+
+	// connectPeers returns the peers to download from and a function to disconnect the peers that were connected for the download.
+	connectPeers func(ctx context.Context) ([]peer.ID, func())
+	// newStream opens a new stream of the snapshot exchange protocol to the given peer.
+	newStream func(ctx context.Context, peerID peer.ID) (io.ReadWriteCloser, error)
+}
+
+// NewClient creates a new Client.
+// Besides the already connected peers, the client connects to the given known peers to download snapshot files.
+// The milestones of the downloaded snapshot files are verified against the public keys of the given key manager.
+func NewClient(
+	log *logger.Logger,
+	host host.Host,
+	protocol protocol.ID,
+	keyManager *keymanager.KeyManager,
+	milestonePublicKeyCount int,
+	knownPeers []peer.AddrInfo,
+	parallelism int) *Client {
+
+	c := &Client{
+		WrappedLogger:           logger.NewWrappedLogger(log),
+		host:                    host,
+		protocol:                protocol,
+		knownPeers:              knownPeers,
+		parallelism:             parallelism,
+		keyManager:              keyManager,
+		milestonePublicKeyCount: milestonePublicKeyCount,
+	}
+	c.connectPeers = c.connectHostPeers
+	c.newStream = c.newHostStream
+
+	return c
+}
+
+// connectHostPeers connects to the known peers that are not connected yet.
+func (c *Client) connectHostPeers(ctx context.Context) ([]peer.ID, func()) {
+	connected := make(map[peer.ID]struct{})
+	for _, peerID := range c.host.Network().Peers() {
+		connected[peerID] = struct{}{}
+	}
+
+	var lock sync.Mutex
+	var newlyConnected []peer.ID
+
+	var wg sync.WaitGroup
+	for _, addrInfo := range c.knownPeers {
+		if _, exists := connected[addrInfo.ID]; exists || addrInfo.ID == c.host.ID() {
+			continue
+		}
+
+		wg.Add(1)
+		go func(addrInfo peer.AddrInfo) {
+			defer wg.Done()
+
+			connectCtx, connectCancel := context.WithTimeout(ctx, connectPeerTimeout)
+			defer connectCancel()
+
+			if err := c.host.Connect(connectCtx, addrInfo); err != nil {
+				c.LogDebugf("connecting to peer %s failed: %s", addrInfo.ID.ShortString(), err)
+				return
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			newlyConnected = append(newlyConnected, addrInfo.ID)
+		}(addrInfo)
+	}
+	wg.Wait()
+
+	peers := make([]peer.ID, 0, len(connected)+len(newlyConnected))
+	for peerID := range connected {
+		peers = append(peers, peerID)
+	}
+	peers = append(peers, newlyConnected...)
+
+	return peers, func() {
+		// the connections are managed by the peering manager once the node is running
+		for _, peerID := range newlyConnected {
+			_ = c.host.Network().ClosePeer(peerID)
+		}
+	}
+}
+
+func (c *Client) newHostStream(ctx context.Context, peerID peer.ID) (io.ReadWriteCloser, error) {
+	stream, err := c.host.NewStream(ctx, peerID, c.protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = stream.SetDeadline(deadline)
+	}
+
+	return stream, nil
+}
+
+// request sends a request to the given peer and passes the response to the response consumer.
+func (c *Client) request(ctx context.Context, timeout time.Duration, peerID peer.ID, request []byte, responseConsumer func(r io.Reader) error) error {
+	requestCtx, requestCancel := context.WithTimeout(ctx, timeout)
+	defer requestCancel()
+
+	stream, err := c.newStream(requestCtx, peerID)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stream.Close() }()
+
+	// close the stream if the context is done, so the blocking reads and writes are interrupted
+	go func() {
+		<-requestCtx.Done()
+		_ = stream.Close()
+	}()
+
+	if _, err := stream.Write(request); err != nil {
+		return err
+	}
+
+	return responseConsumer(bufio.NewReader(stream))
+}
+
+// requestAdvertisements requests the advertised snapshot files of a peer.
+func (c *Client) requestAdvertisements(ctx context.Context, peerID peer.ID) ([]*FileAdvertisement, error) {
+	var advertisements []*FileAdvertisement
+	if err := c.request(ctx, requestTimeout, peerID, []byte{requestTypeAdvertisement}, func(r io.Reader) error {
+		var err error
+		advertisements, err = readAdvertisements(r)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return advertisements, nil
+}
+
+// requestManifest requests the manifest of an advertised file from a peer and verifies it against the advertisement.
+func (c *Client) requestManifest(ctx context.Context, peerID peer.ID, adv *FileAdvertisement) (*Manifest, error) {
+	request := append([]byte{requestTypeManifest}, adv.ContentID[:]...)
+
+	var manifest *Manifest
+	if err := c.request(ctx, requestTimeout, peerID, request, func(r io.Reader) error {
+		var err error
+		manifest, err = readManifest(r, adv)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// requestChunk requests a chunk of a file from a peer and verifies it against the manifest.
+func (c *Client) requestChunk(ctx context.Context, peerID peer.ID, contentID ContentID, manifest *Manifest, index uint32) ([]byte, error) {
+	request := make([]byte, 1+ContentIDLength+4)
+	request[0] = requestTypeChunk
+	copy(request[1:], contentID[:])
+	binary.LittleEndian.PutUint32(request[1+ContentIDLength:], index)
+
+	_, expectedLength := manifest.chunkRange(index)
+
+	var chunk []byte
+	if err := c.request(ctx, chunkRequestTimeout, peerID, request, func(r io.Reader) error {
+		if err := readResponseStatus(r); err != nil {
+			return err
+		}
+
+		length, err := readUint32(r)
+		if err != nil {
+			return err
+		}
+		if int64(length) != expectedLength {
+			return fmt.Errorf("%w: invalid chunk length %d, expected %d", ErrInvalidResponse, length, expectedLength)
+		}
+
+		chunk = make([]byte, length)
+		_, err = io.ReadFull(r, chunk)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if blake2b.Sum256(chunk) != manifest.ChunkHashes[index] {
+		return nil, ErrChunkHashMismatch
+	}
+
+	return chunk, nil
+}
+
+// collectSourceGroups requests the advertisements of all peers and groups the peers that serve the same snapshot files.
+// The groups are sorted by the amount of peers serving them, and then by the ledger index of the snapshot files,
+// so a single peer advertising a newer snapshot doesn't win over the snapshot the majority of the peers agree on.
+// It also returns all peers serving a certain file.
+func (c *Client) collectSourceGroups(ctx context.Context, peers []peer.ID) ([]*sourceGroup, map[ContentID][]peer.ID) {
+
+	type groupKey struct {
+		full  ContentID
+		delta ContentID
+	}
+
+	var lock sync.Mutex
+	groups := make(map[groupKey]*sourceGroup)
+	providers := make(map[ContentID][]peer.ID)
+
+	var wg sync.WaitGroup
+	for _, peerID := range peers {
+		wg.Add(1)
+		go func(peerID peer.ID) {
+			defer wg.Done()
+
+			advertisements, err := c.requestAdvertisements(ctx, peerID)
+			if err != nil {
+				c.LogDebugf("requesting snapshot files of peer %s failed: %s", peerID.ShortString(), err)
+				return
+			}
+
+			var full, delta *FileAdvertisement
+			for _, adv := range advertisements {
+				switch adv.Type {
+				case snapshot.Full:
+					full = adv
+				case snapshot.Delta:
+					delta = adv
+				}
+			}
+
+			if full == nil {
+				return
+			}
+			if delta != nil && (delta.MilestoneID != full.MilestoneID || delta.TargetMilestoneIndex <= full.TargetMilestoneIndex) {
+				// the delta snapshot file does not fit the full snapshot file
+				delta = nil
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			key := groupKey{full: full.ContentID}
+			providers[full.ContentID] = append(providers[full.ContentID], peerID)
+			if delta != nil {
+				key.delta = delta.ContentID
+				providers[delta.ContentID] = append(providers[delta.ContentID], peerID)
+			}
+
+			group, exists := groups[key]
+			if !exists {
+				group = &sourceGroup{full: full, delta: delta}
+				groups[key] = group
+			}
+			group.peers = append(group.peers, peerID)
+		}(peerID)
+	}
+	wg.Wait()
+
+	results := make([]*sourceGroup, 0, len(groups))
+	for _, group := range groups {
+		results = append(results, group)
+	}
+
+	sortSourceGroups(results)
+
+	return results, providers
+}
+
+// sortSourceGroups sorts the groups by the amount of peers serving the files,
+// and prefers the latest snapshot index if the same amount of peers agree.
+func sortSourceGroups(groups []*sourceGroup) {
+	sort.Slice(groups, func(i int, j int) bool {
+		if len(groups[i].peers) != len(groups[j].peers) {
+			return len(groups[i].peers) > len(groups[j].peers)
+		}
+		return groups[i].ledgerIndex() > groups[j].ledgerIndex()
+	})
+}
+
+// DownloadSnapshotFiles downloads the snapshot files that are served by most of the peers of the node.
+// Every chunk is verified against the manifest of the file, the headers of the downloaded
+// files are verified against the advertised milestone, and the milestones in the files
+// are verified against the public keys of the coordinator.
+func (c *Client) DownloadSnapshotFiles(ctx context.Context, targetNetworkID iotago.NetworkID, fullPath string, deltaPath string) error {
+	peers, disconnect := c.connectPeers(ctx)
+	defer disconnect()
+
+	if len(peers) == 0 {
+		return ErrNoPeers
+	}
+
+	groups, providers := c.collectSourceGroups(ctx, peers)
+	for _, group := range groups {
+		c.LogInfof("downloading full snapshot file (milestone %d, %s) from %d peers", group.full.TargetMilestoneIndex, group.full.MilestoneID.ToHex(), len(providers[group.full.ContentID]))
+		if err := c.downloadFile(ctx, fullPath, targetNetworkID, group.full, providers[group.full.ContentID]); err != nil {
+			if errors.Is(err, snapshot.ErrSnapshotDownloadWasAborted) {
+				return err
+			}
+			c.LogWarn(err)
+			// as the full snapshot file failed to download, we commence further with the next group
+			continue
+		}
+
+		if group.delta != nil && len(deltaPath) > 0 {
+			c.LogInfof("downloading delta snapshot file (milestone %d) from %d peers", group.delta.TargetMilestoneIndex, len(providers[group.delta.ContentID]))
+			if err := c.downloadFile(ctx, deltaPath, targetNetworkID, group.delta, providers[group.delta.ContentID]); err != nil {
+				if errors.Is(err, snapshot.ErrSnapshotDownloadWasAborted) {
+					return err
+				}
+				// the full snapshot file can be used without the delta snapshot file.
+				c.LogWarn(err)
+			}
+		}
+		return nil
+	}
+
+	return snapshot.ErrSnapshotDownloadNoValidSource
+}
+
+// downloadFile downloads the advertised file from the given peers to the specified path.
+func (c *Client) downloadFile(ctx context.Context, path string, targetNetworkID iotago.NetworkID, adv *FileAdvertisement, peers []peer.ID) error {
+	health := newPeerHealth(peers)
+
+	var manifest *Manifest
+	for i := range peers {
+		peerID, ok := health.pick(i)
+		if !ok {
+			break
+		}
+
+		var err error
+		if manifest, err = c.requestManifest(ctx, peerID, adv); err == nil {
+			break
+		}
+		c.LogDebugf("requesting manifest %s of peer %s failed: %s", adv.ContentID.ToHex(), peerID.ShortString(), err)
+		health.recordFailure(peerID)
+	}
+	if manifest == nil {
+		return fmt.Errorf("download failed: unable to get the manifest of %s: %w", adv.ContentID.ToHex(), ErrNoHealthyPeer)
+	}
+
+	// a separate temporary file is used, so a resumable partial download of the HTTP targets is kept.
+	tempFilePath := path + ".peers.tmp"
+
+	file, err := os.OpenFile(tempFilePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+
+	var ok bool
+	defer func() {
+		if !ok {
+			_ = os.Remove(tempFilePath)
+		}
+	}()
+
+	if err := c.downloadChunks(ctx, file, adv.ContentID, manifest, health); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("download failed: %w", err)
+	}
+
+	// the progress indicator uses the same line so print a new line once it's finished downloading
+	fmt.Print("\n")
+
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("unable to close downloaded snapshot file: %w", err)
+	}
+
+	if err := verifyDownloadedFile(tempFilePath, targetNetworkID, adv); err != nil {
+		return err
+	}
+
+	if err := c.verifyMilestones(tempFilePath, adv); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tempFilePath, path); err != nil {
+		return fmt.Errorf("unable to rename downloaded snapshot file: %w", err)
+	}
+
+	ok = true
+	return nil
+}
+
+// downloadChunks downloads all chunks of the file in parallel and writes them to the given file.
+func (c *Client) downloadChunks(ctx context.Context, file *os.File, contentID ContentID, manifest *Manifest, health *peerHealth) error {
+	downloadCtx, downloadCancel := context.WithCancel(ctx)
+	defer downloadCancel()
+
+	counter := snapshot.NewWriteCounter(ctx, manifest.Length)
+
+	chunks := make(chan uint32, len(manifest.ChunkHashes))
+	for i := range manifest.ChunkHashes {
+		chunks <- uint32(i)
+	}
+	close(chunks)
+
+	var errOnce sync.Once
+	var downloadErr error
+
+	workers := c.parallelism
+	if workers > len(manifest.ChunkHashes) {
+		workers = len(manifest.ChunkHashes)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for index := range chunks {
+				if err := c.downloadChunk(downloadCtx, file, contentID, manifest, index, health, counter); err != nil {
+					errOnce.Do(func() {
+						downloadErr = err
+						downloadCancel()
+					})
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := contextutils.ReturnErrIfCtxDone(ctx, snapshot.ErrSnapshotDownloadWasAborted); err != nil {
+		return err
+	}
+
+	return downloadErr
+}
+
+// downloadChunk downloads a single chunk, it retries with other peers if the download fails.
+func (c *Client) downloadChunk(ctx context.Context, file *os.File, contentID ContentID, manifest *Manifest, index uint32, health *peerHealth, counter *snapshot.WriteCounter) error {
+	var lastErr error
+	for attempt := 0; attempt < maxChunkAttempts; attempt++ {
+		if err := contextutils.ReturnErrIfCtxDone(ctx, snapshot.ErrSnapshotDownloadWasAborted); err != nil {
+			return err
+		}
+
+		peerID, ok := health.pick(int(index) + attempt)
+		if !ok {
+			return ErrNoHealthyPeer
+		}
+
+		chunk, err := c.requestChunk(ctx, peerID, contentID, manifest, index)
+		if err != nil {
+			c.LogDebugf("requesting chunk %d of %s from peer %s failed: %s", index, contentID.ToHex(), peerID.ShortString(), err)
+			health.recordFailure(peerID)
+			lastErr = err
+			continue
+		}
+
+		offset, _ := manifest.chunkRange(index)
+		if _, err := file.WriteAt(chunk, offset); err != nil {
+			return err
+		}
+
+		_, err = counter.Write(chunk)
+		return err
+	}
+
+	return fmt.Errorf("chunk %d failed after %d attempts: %w", index, maxChunkAttempts, lastErr)
+}
+
+// verifyDownloadedFile checks that the header of the downloaded snapshot file matches the advertised milestone.
+func verifyDownloadedFile(filePath string, targetNetworkID iotago.NetworkID, adv *FileAdvertisement) error {
+	switch adv.Type {
+	case snapshot.Full:
+		header, err := snapshot.ReadFullSnapshotHeaderFromFile(filePath)
+		if err != nil {
+			return fmt.Errorf("unable to read downloaded full snapshot header: %w", err)
+		}
+
+		if header.TargetMilestoneIndex != adv.TargetMilestoneIndex || header.TargetMilestoneID != adv.MilestoneID || header.LedgerMilestoneIndex != adv.LedgerIndex {
+			return fmt.Errorf("%w: milestone %d (%s), advertised milestone %d (%s)", ErrSnapshotHeaderMismatch, header.TargetMilestoneIndex, header.TargetMilestoneID.ToHex(), adv.TargetMilestoneIndex, adv.MilestoneID.ToHex())
+		}
+
+		protoParams, err := header.ProtocolParameters()
+		if err != nil {
+			return err
+		}
+
+		if protoParams.NetworkID() != targetNetworkID {
+			return fmt.Errorf("full snapshot networkID does not match (%d != %d): %w", protoParams.NetworkID(), targetNetworkID, snapshot.ErrInvalidSnapshotAvailabilityState)
+		}
+
+	case snapshot.Delta:
+		header, err := snapshot.ReadDeltaSnapshotHeaderFromFile(filePath)
+		if err != nil {
+			return fmt.Errorf("unable to read downloaded delta snapshot header: %w", err)
+		}
+
+		if header.TargetMilestoneIndex != adv.TargetMilestoneIndex || header.FullSnapshotTargetMilestoneID != adv.MilestoneID {
+			return fmt.Errorf("%w: milestone %d (full snapshot %s), advertised milestone %d (full snapshot %s)", ErrSnapshotHeaderMismatch, header.TargetMilestoneIndex, header.FullSnapshotTargetMilestoneID.ToHex(), adv.TargetMilestoneIndex, adv.MilestoneID.ToHex())
+		}
+
+	default:
+		return fmt.Errorf("unknown snapshot type %d", adv.Type)
+	}
+
+	return nil
+}
+
+// verifyMilestones checks that the milestones of the downloaded snapshot file are signed by the coordinator
+// and form a chain that leads to the advertised milestone.
+// The advertisements are not signed, so this binds the snapshot file to the milestones issued by the coordinator.
+func (c *Client) verifyMilestones(filePath string, adv *FileAdvertisement) error {
+
+	var previous *iotago.Milestone
+	var previousID iotago.MilestoneID
+	var targetReached bool
+
+	if err := snapshot.StreamSnapshotMilestonesFromFile(filePath, func(milestonePayload *iotago.Milestone) error {
+		if err := milestonePayload.VerifySignatures(c.milestonePublicKeyCount, c.keyManager.PublicKeysSetForMilestoneIndex(milestonePayload.Index)); err != nil {
+			return fmt.Errorf("%w: milestone %d: %s", ErrInvalidSnapshotMilestones, milestonePayload.Index, err)
+		}
+
+		milestoneID, err := milestonePayload.ID()
+		if err != nil {
+			return err
+		}
+
+		switch adv.Type {
+		case snapshot.Full:
+			// the milestone diffs of a full snapshot file are stored backwards, starting at the ledger milestone.
+			switch {
+			case previous == nil:
+				if milestonePayload.Index != adv.LedgerIndex {
+					return fmt.Errorf("%w: first milestone %d is not the ledger milestone %d", ErrInvalidSnapshotMilestones, milestonePayload.Index, adv.LedgerIndex)
+				}
+			case milestonePayload.Index != previous.Index-1 || previous.PreviousMilestoneID != milestoneID:
+				return fmt.Errorf("%w: milestone %d does not precede milestone %d", ErrInvalidSnapshotMilestones, milestonePayload.Index, previous.Index)
+			}
+
+			if milestonePayload.Index == adv.TargetMilestoneIndex {
+				if milestoneID != adv.MilestoneID {
+					return fmt.Errorf("%w: milestone %d has ID %s instead of %s", ErrInvalidSnapshotMilestones, milestonePayload.Index, milestoneID.ToHex(), adv.MilestoneID.ToHex())
+				}
+				targetReached = true
+			}
+
+		case snapshot.Delta:
+			// the milestone diffs of a delta snapshot file are stored onwards, starting after the target milestone of the full snapshot.
+			switch {
+			case previous == nil:
+				if milestonePayload.PreviousMilestoneID != adv.MilestoneID {
+					return fmt.Errorf("%w: first milestone %d does not follow the full snapshot milestone %s", ErrInvalidSnapshotMilestones, milestonePayload.Index, adv.MilestoneID.ToHex())
+				}
+			case milestonePayload.Index != previous.Index+1 || milestonePayload.PreviousMilestoneID != previousID:
+				return fmt.Errorf("%w: milestone %d does not follow milestone %d", ErrInvalidSnapshotMilestones, milestonePayload.Index, previous.Index)
+			}
+
+			targetReached = milestonePayload.Index == adv.TargetMilestoneIndex
+		}
+
+		previous = milestonePayload
+		previousID = milestoneID
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("unable to verify the milestones of the downloaded snapshot file: %w", err)
+	}
+
+	if !targetReached {
+		return fmt.Errorf("%w: milestone %d is missing", ErrInvalidSnapshotMilestones, adv.TargetMilestoneIndex)
+	}
+
+	return nil
+}
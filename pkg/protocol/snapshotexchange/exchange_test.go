@@ -0,0 +1,289 @@
+package snapshotexchange
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"io"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/hornet/pkg/model/utxo"
+	"github.com/iotaledger/hornet/pkg/snapshot"
+	"github.com/iotaledger/hornet/pkg/tpkg"
+	iotago "github.com/iotaledger/iota.go/v3"
+	"github.com/iotaledger/iota.go/v3/keymanager"
+)
+
+const (
+	honestPeer  = peer.ID("honest")
+	corruptPeer = peer.ID("corrupt")
+	testChunk   = 1024
+)
+
+// corruptingWriter flips a byte in every large write, which only affects chunk responses.
+type corruptingWriter struct {
+	io.Writer
+}
+
+func (w *corruptingWriter) Write(p []byte) (int, error) {
+	if len(p) < testChunk {
+		return w.Writer.Write(p)
+	}
+
+	corrupted := make([]byte, len(p))
+	copy(corrupted, p)
+	corrupted[len(corrupted)-1] ^= 0xFF
+	return w.Writer.Write(corrupted)
+}
+
+// signedMilestones creates a chain of milestones, starting at the given index, signed by the given key.
+func signedMilestones(t *testing.T, cooKey ed25519.PrivateKey, startIndex iotago.MilestoneIndex, count int) []*iotago.Milestone {
+	keyManager := newTestKeyManager(cooKey)
+
+	var milestones []*iotago.Milestone
+	previousID := tpkg.RandMilestoneID()
+	for i := 0; i < count; i++ {
+		index := startIndex + iotago.MilestoneIndex(i)
+		milestonePayload := iotago.NewMilestone(index, tpkg.RandMilestoneTimestamp(), 2, previousID, iotago.BlockIDs{tpkg.RandBlockID()}, iotago.MilestoneMerkleProof{}, iotago.MilestoneMerkleProof{})
+
+		keyMapping := keyManager.MilestonePublicKeyMappingForMilestoneIndex(index, []ed25519.PrivateKey{cooKey}, 1)
+		pubKeys := make([]iotago.MilestonePublicKey, 0, len(keyMapping))
+		for pubKey := range keyMapping {
+			pubKeys = append(pubKeys, pubKey)
+		}
+		require.NoError(t, milestonePayload.Sign(pubKeys, iotago.InMemoryEd25519MilestoneSigner(keyMapping)))
+
+		milestoneID, err := milestonePayload.ID()
+		require.NoError(t, err)
+		previousID = milestoneID
+
+		milestones = append(milestones, milestonePayload)
+	}
+
+	return milestones
+}
+
+func newTestKeyManager(cooKey ed25519.PrivateKey) *keymanager.KeyManager {
+	keyManager := keymanager.New()
+	keyManager.AddKeyRange(cooKey.Public().(ed25519.PublicKey), 0, 0)
+
+	return keyManager
+}
+
+func writeFullSnapshotFile(t *testing.T, filePath string, cooKey ed25519.PrivateKey) *snapshot.FullSnapshotHeader {
+	targetIndex := tpkg.RandMilestoneIndex()
+
+	// the milestone diffs are stored backwards, from the ledger milestone down to the target milestone
+	milestones := signedMilestones(t, cooKey, targetIndex, 3)
+	targetMilestoneID, err := milestones[0].ID()
+	require.NoError(t, err)
+
+	header := &snapshot.FullSnapshotHeader{
+		Type:                       snapshot.Full,
+		Version:                    snapshot.SupportedFormatVersion,
+		GenesisMilestoneIndex:      0,
+		TargetMilestoneIndex:       targetIndex,
+		TargetMilestoneTimestamp:   tpkg.RandMilestoneTimestamp(),
+		TargetMilestoneID:          targetMilestoneID,
+		LedgerMilestoneIndex:       targetIndex + 2,
+		TreasuryOutput:             tpkg.RandTreasuryOutput(),
+		ProtocolParamsMilestoneOpt: tpkg.RandProtocolParamsMilestoneOpt(targetIndex),
+	}
+
+	outputCount := 100
+	outputProducer := func() (*utxo.Output, error) {
+		if outputCount == 0 {
+			return nil, nil
+		}
+		outputCount--
+		return tpkg.RandUTXOOutput(), nil
+	}
+
+	sepCount := 10
+	sepProducer := func() (iotago.BlockID, error) {
+		if sepCount == 0 {
+			return iotago.EmptyBlockID(), snapshot.ErrNoMoreSEPToProduce
+		}
+		sepCount--
+		return tpkg.RandBlockID(), nil
+	}
+
+	snapshotFile, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR, 0666)
+	require.NoError(t, err)
+	msDiffProducer := func() (*snapshot.MilestoneDiff, error) {
+		if len(milestones) == 0 {
+			return nil, nil
+		}
+		milestonePayload := milestones[len(milestones)-1]
+		milestones = milestones[:len(milestones)-1]
+		return &snapshot.MilestoneDiff{Milestone: milestonePayload}, nil
+	}
+
+	_, err = snapshot.StreamFullSnapshotDataTo(snapshotFile, header, outputProducer, msDiffProducer, sepProducer)
+	require.NoError(t, err)
+	require.NoError(t, snapshotFile.Close())
+
+	return header
+}
+
+// newTestClient creates a client that is connected to the given server via in-memory pipes.
+func newTestClient(server *Server, cooKey ed25519.PrivateKey, peers ...peer.ID) *Client {
+	client := NewClient(logger.NewNopLogger(), nil, "", newTestKeyManager(cooKey), 1, nil, 4)
+	client.connectPeers = func(_ context.Context) ([]peer.ID, func()) {
+		return peers, func() {}
+	}
+	client.newStream = func(_ context.Context, peerID peer.ID) (io.ReadWriteCloser, error) {
+		clientConn, serverConn := net.Pipe()
+		go func() {
+			defer func() { _ = serverConn.Close() }()
+
+			var writer io.Writer = serverConn
+			if peerID == corruptPeer {
+				writer = &corruptingWriter{Writer: serverConn}
+			}
+			_ = server.ServeRequest(struct {
+				io.Reader
+				io.Writer
+			}{serverConn, writer})
+		}()
+		return clientConn, nil
+	}
+	return client
+}
+
+func TestDownloadSnapshotFilesFromPeers(t *testing.T) {
+
+	_, cooKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	fullPath := filepath.Join(t.TempDir(), "full_snapshot.bin")
+	header := writeFullSnapshotFile(t, fullPath, cooKey)
+
+	protoParams, err := header.ProtocolParameters()
+	require.NoError(t, err)
+
+	originalData, err := os.ReadFile(fullPath)
+	require.NoError(t, err)
+	require.Greater(t, len(originalData), 4*testChunk)
+
+	server := NewServer(logger.NewNopLogger(), nil, "", fullPath, "", testChunk)
+
+	// files are only served after their manifest was computed
+	_, err = server.servedFile(fullPath)
+	require.ErrorIs(t, err, ErrFileNotPrepared)
+	server.PrepareFiles(context.Background())
+
+	t.Run("verified chunks", func(t *testing.T) {
+		targetPath := filepath.Join(t.TempDir(), "full_snapshot.bin")
+
+		// the chunks of the corrupted peer are rejected and downloaded from the honest peer instead
+		client := newTestClient(server, cooKey, corruptPeer, honestPeer)
+		require.NoError(t, client.DownloadSnapshotFiles(context.Background(), protoParams.NetworkID(), targetPath, ""))
+
+		downloadedData, err := os.ReadFile(targetPath)
+		require.NoError(t, err)
+		require.True(t, bytes.Equal(originalData, downloadedData))
+
+		_, err = os.Stat(targetPath + ".peers.tmp")
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("no valid source", func(t *testing.T) {
+		targetPath := filepath.Join(t.TempDir(), "full_snapshot.bin")
+
+		client := newTestClient(server, cooKey, corruptPeer)
+		require.ErrorIs(t, client.DownloadSnapshotFiles(context.Background(), protoParams.NetworkID(), targetPath, ""), snapshot.ErrSnapshotDownloadNoValidSource)
+
+		_, err := os.Stat(targetPath)
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("wrong network", func(t *testing.T) {
+		targetPath := filepath.Join(t.TempDir(), "full_snapshot.bin")
+
+		client := newTestClient(server, cooKey, honestPeer)
+		require.ErrorIs(t, client.DownloadSnapshotFiles(context.Background(), protoParams.NetworkID()+1, targetPath, ""), snapshot.ErrSnapshotDownloadNoValidSource)
+	})
+
+	t.Run("milestones not signed by the coordinator", func(t *testing.T) {
+		_, otherKey, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+
+		targetPath := filepath.Join(t.TempDir(), "full_snapshot.bin")
+
+		client := newTestClient(server, otherKey, honestPeer)
+		require.ErrorIs(t, client.DownloadSnapshotFiles(context.Background(), protoParams.NetworkID(), targetPath, ""), snapshot.ErrSnapshotDownloadNoValidSource)
+
+		file, err := server.servedFile(fullPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(targetPath, originalData, 0666))
+		require.ErrorIs(t, client.verifyMilestones(targetPath, file.advertisement), ErrInvalidSnapshotMilestones)
+		require.NoError(t, newTestClient(server, cooKey).verifyMilestones(targetPath, file.advertisement))
+	})
+
+	t.Run("source groups", func(t *testing.T) {
+		newGroup := func(ledgerIndex iotago.MilestoneIndex, peers ...peer.ID) *sourceGroup {
+			return &sourceGroup{full: &FileAdvertisement{TargetMilestoneIndex: ledgerIndex}, peers: peers}
+		}
+
+		// a single peer advertising a newer snapshot doesn't win over the majority
+		groups := []*sourceGroup{newGroup(20, "a"), newGroup(10, "b", "c"), newGroup(15, "d", "e")}
+		sortSourceGroups(groups)
+		require.Equal(t, []iotago.MilestoneIndex{15, 10, 20}, []iotago.MilestoneIndex{groups[0].ledgerIndex(), groups[1].ledgerIndex(), groups[2].ledgerIndex()})
+	})
+
+	t.Run("manifest", func(t *testing.T) {
+		file, err := server.servedFile(fullPath)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, writeManifest(&buf, file.manifest))
+		data := buf.Bytes()
+
+		_, err = readManifest(bytes.NewReader(data), file.advertisement)
+		require.NoError(t, err)
+
+		// a manifest with a modified chunk hash does not match the content ID anymore
+		data[len(data)-1] ^= 0xFF
+		_, err = readManifest(bytes.NewReader(data), file.advertisement)
+		require.ErrorIs(t, err, ErrManifestMismatch)
+	})
+
+	t.Run("hostile manifest", func(t *testing.T) {
+		file, err := server.servedFile(fullPath)
+		require.NoError(t, err)
+
+		writeHostileManifest := func(length uint64, chunkSize uint32, chunkCount uint32) []byte {
+			var buf bytes.Buffer
+			buf.WriteByte(responseStatusOK)
+			require.NoError(t, writeUint64(&buf, length))
+			require.NoError(t, writeUint32(&buf, chunkSize))
+			require.NoError(t, writeUint32(&buf, chunkCount))
+			return buf.Bytes()
+		}
+
+		// the peer claims a huge file, the manifest is rejected before the chunk hashes are allocated
+		hugeLength := uint64(math.MaxUint32) * maxChunkSize
+		_, err = readManifest(bytes.NewReader(writeHostileManifest(hugeLength, maxChunkSize, math.MaxUint32)), file.advertisement)
+		require.ErrorIs(t, err, ErrInvalidResponse)
+
+		// even if it was advertised that way
+		hugeAdv := *file.advertisement
+		hugeAdv.Length = hugeLength
+		hugeAdv.ChunkSize = maxChunkSize
+		_, err = readManifest(bytes.NewReader(writeHostileManifest(hugeLength, maxChunkSize, math.MaxUint32)), &hugeAdv)
+		require.ErrorIs(t, err, ErrInvalidResponse)
+
+		// the chunk size differs from the advertisement
+		_, err = readManifest(bytes.NewReader(writeHostileManifest(file.advertisement.Length, 1, uint32(file.advertisement.Length))), file.advertisement)
+		require.ErrorIs(t, err, ErrInvalidResponse)
+	})
+}
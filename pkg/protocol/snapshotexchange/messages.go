@@ -0,0 +1,343 @@
+package snapshotexchange
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/iotaledger/hornet/pkg/snapshot"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// ContentIDLength is the length of a ContentID.
+	ContentIDLength = blake2b.Size256
+	// ChunkHashLength is the length of the hash of a chunk.
+	ChunkHashLength = blake2b.Size256
+
+	// the maximum size of a chunk that is accepted from a peer.
+	maxChunkSize = 4 * 1024 * 1024
+	// the maximum amount of chunks a snapshot file may consist of.
+	maxChunkCount = 1 << 20
+	// the maximum amount of files a peer may advertise.
+	maxAdvertisedFiles = 2
+)
+
+// the types of the requests.
+const (
+	requestTypeAdvertisement byte = iota + 1
+	requestTypeManifest
+	requestTypeChunk
+)
+
+// the status of a response.
+const (
+	responseStatusOK byte = iota
+	responseStatusNotFound
+	responseStatusBusy
+	responseStatusBadRequest
+)
+
+var (
+	// ErrFileNotFound is returned if the peer does not serve the requested file (anymore).
+	ErrFileNotFound = errors.New("snapshot file not found on peer")
+	// ErrPeerBusy is returned if the peer has too many ongoing requests.
+	ErrPeerBusy = errors.New("peer is busy")
+	// ErrBadRequest is returned if the peer could not parse the request.
+	ErrBadRequest = errors.New("peer rejected the request")
+	// ErrInvalidResponse is returned if the response of a peer is malformed.
+	ErrInvalidResponse = errors.New("invalid response")
+	// ErrManifestMismatch is returned if a manifest does not hash to the requested content ID.
+	ErrManifestMismatch = errors.New("manifest does not match the content ID")
+	// ErrChunkHashMismatch is returned if a chunk does not match the hash in the manifest.
+	ErrChunkHashMismatch = errors.New("chunk does not match the manifest")
+	// ErrFileNotPrepared is returned if the manifest of a served file was not computed yet.
+	ErrFileNotPrepared = errors.New("manifest of the snapshot file not computed yet")
+)
+
+// ContentID identifies the content of a snapshot file.
+// It is the hash over the length, the chunk size and the hashes of all chunks of the file,
+// which allows to verify every chunk on its own.
+type ContentID [ContentIDLength]byte
+
+// ToHex returns the hex encoded ContentID.
+func (id ContentID) ToHex() string {
+	return iotago.EncodeHex(id[:])
+}
+
+// Manifest contains the hashes of the chunks of a snapshot file.
+type Manifest struct {
+	// The length of the file.
+	Length uint64
+	// The size of the chunks, the last chunk may be smaller.
+	ChunkSize uint32
+	// The hashes of all chunks.
+	ChunkHashes [][ChunkHashLength]byte
+}
+
+// ContentID computes the ContentID of the file described by the manifest.
+func (m *Manifest) ContentID() ContentID {
+	// the parameters are fixed and valid, so this can't fail
+	hash, _ := blake2b.New256(nil)
+
+	var header [12]byte
+	binary.LittleEndian.PutUint64(header[:8], m.Length)
+	binary.LittleEndian.PutUint32(header[8:], m.ChunkSize)
+	_, _ = hash.Write(header[:])
+
+	for i := range m.ChunkHashes {
+		_, _ = hash.Write(m.ChunkHashes[i][:])
+	}
+
+	var id ContentID
+	copy(id[:], hash.Sum(nil))
+	return id
+}
+
+// chunkRange returns the offset and the length of the chunk with the given index.
+func (m *Manifest) chunkRange(index uint32) (int64, int64) {
+	offset := int64(index) * int64(m.ChunkSize)
+	length := int64(m.ChunkSize)
+	if remaining := int64(m.Length) - offset; remaining < length {
+		length = remaining
+	}
+	return offset, length
+}
+
+// expectedChunkCount returns the amount of chunks a file with the given length consists of.
+func expectedChunkCount(length uint64, chunkSize uint32) uint64 {
+	return (length + uint64(chunkSize) - 1) / uint64(chunkSize)
+}
+
+// FileAdvertisement describes a snapshot file that is served by a peer.
+type FileAdvertisement struct {
+	// The type of the snapshot file.
+	Type snapshot.Type
+	// The index of the target milestone of the snapshot.
+	TargetMilestoneIndex iotago.MilestoneIndex
+	// The ID of the target milestone of the full snapshot.
+	// For delta snapshots this is the target milestone ID of the full snapshot it builds up from.
+	MilestoneID iotago.MilestoneID
+	// The ledger index after the snapshot file was applied.
+	LedgerIndex iotago.MilestoneIndex
+	// The length of the file.
+	Length uint64
+	// The size of the chunks the file is served in.
+	ChunkSize uint32
+	// The ContentID of the file.
+	ContentID ContentID
+}
+
+func writeUint32(w io.Writer, value uint32) error {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], value)
+	_, err := w.Write(buf[:])
+	return err
+}
+
+func readUint32(r io.Reader) (uint32, error) {
+	var buf [4]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(buf[:]), nil
+}
+
+func writeUint64(w io.Writer, value uint64) error {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], value)
+	_, err := w.Write(buf[:])
+	return err
+}
+
+func readUint64(r io.Reader) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
+
+func readByte(r io.Reader) (byte, error) {
+	var buf [1]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return 0, err
+	}
+	return buf[0], nil
+}
+
+// readResponseStatus reads the status of a response and converts it to an error.
+func readResponseStatus(r io.Reader) error {
+	status, err := readByte(r)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case responseStatusOK:
+		return nil
+	case responseStatusNotFound:
+		return ErrFileNotFound
+	case responseStatusBusy:
+		return ErrPeerBusy
+	case responseStatusBadRequest:
+		return ErrBadRequest
+	default:
+		return fmt.Errorf("%w: unknown response status %d", ErrInvalidResponse, status)
+	}
+}
+
+func writeAdvertisements(w io.Writer, advertisements []*FileAdvertisement) error {
+	if _, err := w.Write([]byte{responseStatusOK, byte(len(advertisements))}); err != nil {
+		return err
+	}
+
+	for _, adv := range advertisements {
+		if _, err := w.Write([]byte{byte(adv.Type)}); err != nil {
+			return err
+		}
+		if err := writeUint32(w, adv.TargetMilestoneIndex); err != nil {
+			return err
+		}
+		if _, err := w.Write(adv.MilestoneID[:]); err != nil {
+			return err
+		}
+		if err := writeUint32(w, adv.LedgerIndex); err != nil {
+			return err
+		}
+		if err := writeUint64(w, adv.Length); err != nil {
+			return err
+		}
+		if err := writeUint32(w, adv.ChunkSize); err != nil {
+			return err
+		}
+		if _, err := w.Write(adv.ContentID[:]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readAdvertisements(r io.Reader) ([]*FileAdvertisement, error) {
+	if err := readResponseStatus(r); err != nil {
+		return nil, err
+	}
+
+	count, err := readByte(r)
+	if err != nil {
+		return nil, err
+	}
+	if count > maxAdvertisedFiles {
+		return nil, fmt.Errorf("%w: too many advertised files (%d)", ErrInvalidResponse, count)
+	}
+
+	advertisements := make([]*FileAdvertisement, 0, count)
+	for i := 0; i < int(count); i++ {
+		adv := &FileAdvertisement{}
+
+		snapshotType, err := readByte(r)
+		if err != nil {
+			return nil, err
+		}
+		adv.Type = snapshot.Type(snapshotType)
+		if adv.Type != snapshot.Full && adv.Type != snapshot.Delta {
+			return nil, fmt.Errorf("%w: unknown snapshot type %d", ErrInvalidResponse, snapshotType)
+		}
+
+		if adv.TargetMilestoneIndex, err = readUint32(r); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(r, adv.MilestoneID[:]); err != nil {
+			return nil, err
+		}
+		if adv.LedgerIndex, err = readUint32(r); err != nil {
+			return nil, err
+		}
+		if adv.Length, err = readUint64(r); err != nil {
+			return nil, err
+		}
+		if adv.ChunkSize, err = readUint32(r); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(r, adv.ContentID[:]); err != nil {
+			return nil, err
+		}
+
+		if adv.Length == 0 || adv.ChunkSize == 0 || adv.ChunkSize > maxChunkSize || expectedChunkCount(adv.Length, adv.ChunkSize) > maxChunkCount {
+			return nil, fmt.Errorf("%w: invalid file dimensions (length: %d, chunk size: %d)", ErrInvalidResponse, adv.Length, adv.ChunkSize)
+		}
+
+		advertisements = append(advertisements, adv)
+	}
+
+	return advertisements, nil
+}
+
+func writeManifest(w io.Writer, manifest *Manifest) error {
+	if _, err := w.Write([]byte{responseStatusOK}); err != nil {
+		return err
+	}
+	if err := writeUint64(w, manifest.Length); err != nil {
+		return err
+	}
+	if err := writeUint32(w, manifest.ChunkSize); err != nil {
+		return err
+	}
+	if err := writeUint32(w, uint32(len(manifest.ChunkHashes))); err != nil {
+		return err
+	}
+	for i := range manifest.ChunkHashes {
+		if _, err := w.Write(manifest.ChunkHashes[i][:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readManifest reads a manifest and verifies it against the given advertisement.
+// The dimensions of the manifest are checked before the chunk hashes are read,
+// so a peer can't make us allocate more than the advertised file needs.
+func readManifest(r io.Reader, adv *FileAdvertisement) (*Manifest, error) {
+	if err := readResponseStatus(r); err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+
+	var err error
+	if manifest.Length, err = readUint64(r); err != nil {
+		return nil, err
+	}
+	if manifest.ChunkSize, err = readUint32(r); err != nil {
+		return nil, err
+	}
+	chunkCount, err := readUint32(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.Length != adv.Length || manifest.ChunkSize != adv.ChunkSize {
+		return nil, fmt.Errorf("%w: manifest dimensions (length: %d, chunk size: %d) differ from the advertisement (length: %d, chunk size: %d)", ErrInvalidResponse, manifest.Length, manifest.ChunkSize, adv.Length, adv.ChunkSize)
+	}
+
+	if manifest.ChunkSize == 0 || manifest.ChunkSize > maxChunkSize || chunkCount > maxChunkCount || uint64(chunkCount) != expectedChunkCount(manifest.Length, manifest.ChunkSize) {
+		return nil, fmt.Errorf("%w: invalid manifest dimensions (length: %d, chunk size: %d, chunks: %d)", ErrInvalidResponse, manifest.Length, manifest.ChunkSize, chunkCount)
+	}
+
+	manifest.ChunkHashes = make([][ChunkHashLength]byte, chunkCount)
+	for i := range manifest.ChunkHashes {
+		if _, err := io.ReadFull(r, manifest.ChunkHashes[i][:]); err != nil {
+			return nil, err
+		}
+	}
+
+	if manifest.ContentID() != adv.ContentID {
+		return nil, ErrManifestMismatch
+	}
+
+	return manifest, nil
+}
@@ -0,0 +1,408 @@
+package snapshotexchange
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/hornet/pkg/snapshot"
+)
+
+const (
+	// DefaultChunkSize is the default size of the chunks snapshot files are served in.
+	DefaultChunkSize = 1024 * 1024
+
+	// the maximum amount of requests that are served at the same time.
+	maxConcurrentRequests = 8
+	// the timeout for reading a request and writing the response.
+	serverStreamTimeout = 1 * time.Minute
+)
+
+// servedFile is a snapshot file of the node that is served to peers.
+type servedFile struct {
+	path          string
+	size          int64
+	modTime       time.Time
+	advertisement *FileAdvertisement
+	manifest      *Manifest
+}
+
+// unchanged checks whether the file on disk is still the one the manifest was computed for.
+func (f *servedFile) unchanged() bool {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return false
+	}
+	return info.Size() == f.size && info.ModTime().Equal(f.modTime)
+}
+
+// Server serves the snapshot files of the node to peers.
+type Server struct {
+	// the logger used to log events.
+	*logger.WrappedLogger
+
+	host      host.Host
+	protocol  protocol.ID
+	fullPath  string
+	deltaPath string
+	chunkSize uint32
+
+	// the manifests of the served files, keyed by path.
+	filesLock sync.RWMutex
+	files     map[string]*servedFile
+	// makes sure only one manifest is computed at a time.
+	prepareLock sync.Mutex
+
+	// limits the amount of concurrently served requests.
+	requestSemaphore chan struct{}
+}
+
+// NewServer creates a new Server that serves the snapshot files at the given paths.
+func NewServer(
+	log *logger.Logger,
+	host host.Host,
+	protocol protocol.ID,
+	fullPath string,
+	deltaPath string,
+	chunkSize uint32) *Server {
+
+	return &Server{
+		WrappedLogger:    logger.NewWrappedLogger(log),
+		host:             host,
+		protocol:         protocol,
+		fullPath:         fullPath,
+		deltaPath:        deltaPath,
+		chunkSize:        chunkSize,
+		files:            make(map[string]*servedFile),
+		requestSemaphore: make(chan struct{}, maxConcurrentRequests),
+	}
+}
+
+// Start registers the snapshot exchange protocol on the host.
+func (s *Server) Start() {
+	s.host.SetStreamHandler(s.protocol, s.handleStream)
+}
+
+// Stop removes the snapshot exchange protocol from the host.
+func (s *Server) Stop() {
+	s.host.RemoveStreamHandler(s.protocol)
+}
+
+func (s *Server) handleStream(stream network.Stream) {
+	defer func() { _ = stream.Close() }()
+
+	_ = stream.SetDeadline(time.Now().Add(serverStreamTimeout))
+
+	if err := s.ServeRequest(stream); err != nil {
+		s.LogDebugf("serving snapshot request of peer %s failed: %s", stream.Conn().RemotePeer().ShortString(), err)
+		_ = stream.Reset()
+	}
+}
+
+// ServeRequest reads a single request and writes the response.
+func (s *Server) ServeRequest(rw io.ReadWriter) error {
+	select {
+	case s.requestSemaphore <- struct{}{}:
+		defer func() { <-s.requestSemaphore }()
+	default:
+		_, err := rw.Write([]byte{responseStatusBusy})
+		return err
+	}
+
+	requestType, err := readByte(rw)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(rw)
+
+	switch requestType {
+	case requestTypeAdvertisement:
+		err = s.serveAdvertisements(writer)
+
+	case requestTypeManifest:
+		var contentID ContentID
+		if _, err = io.ReadFull(rw, contentID[:]); err != nil {
+			return err
+		}
+		err = s.serveManifest(writer, contentID)
+
+	case requestTypeChunk:
+		var contentID ContentID
+		if _, err = io.ReadFull(rw, contentID[:]); err != nil {
+			return err
+		}
+		var index uint32
+		if index, err = readUint32(rw); err != nil {
+			return err
+		}
+		err = s.serveChunk(writer, contentID, index)
+
+	default:
+		_, err = writer.Write([]byte{responseStatusBadRequest})
+	}
+	if err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+func (s *Server) serveAdvertisements(w io.Writer) error {
+	advertisements := make([]*FileAdvertisement, 0, maxAdvertisedFiles)
+
+	full, err := s.servedFile(s.fullPath)
+	if err != nil {
+		// the file is advertised again once its manifest was computed.
+		s.LogDebugf("full snapshot file can't be served: %s", err)
+		return writeAdvertisements(w, advertisements)
+	}
+	advertisements = append(advertisements, full.advertisement)
+
+	delta, err := s.servedFile(s.deltaPath)
+	if err == nil && delta.advertisement.MilestoneID == full.advertisement.MilestoneID {
+		// only advertise delta snapshot files that fit the full snapshot file.
+		advertisements = append(advertisements, delta.advertisement)
+	}
+
+	return writeAdvertisements(w, advertisements)
+}
+
+func (s *Server) serveManifest(w io.Writer, contentID ContentID) error {
+	file := s.servedFileByContentID(contentID)
+	if file == nil {
+		_, err := w.Write([]byte{responseStatusNotFound})
+		return err
+	}
+
+	return writeManifest(w, file.manifest)
+}
+
+func (s *Server) serveChunk(w io.Writer, contentID ContentID, index uint32) error {
+	file := s.servedFileByContentID(contentID)
+	if file == nil {
+		_, err := w.Write([]byte{responseStatusNotFound})
+		return err
+	}
+
+	if index >= uint32(len(file.manifest.ChunkHashes)) {
+		_, err := w.Write([]byte{responseStatusBadRequest})
+		return err
+	}
+
+	offset, length := file.manifest.chunkRange(index)
+
+	f, err := os.Open(file.path)
+	if err != nil {
+		_, err := w.Write([]byte{responseStatusNotFound})
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	// the requesting peer verifies the chunk against the manifest,
+	// so it doesn't matter if the file was replaced in the meantime.
+	chunk := make([]byte, length)
+	if _, err := f.ReadAt(chunk, offset); err != nil {
+		_, err := w.Write([]byte{responseStatusNotFound})
+		return err
+	}
+
+	if _, err := w.Write([]byte{responseStatusOK}); err != nil {
+		return err
+	}
+	if err := writeUint32(w, uint32(length)); err != nil {
+		return err
+	}
+	_, err = w.Write(chunk)
+	return err
+}
+
+// servedFileByContentID returns the served file with the given ContentID,
+// if it didn't change since the manifest was computed.
+func (s *Server) servedFileByContentID(contentID ContentID) *servedFile {
+	var file *servedFile
+
+	s.filesLock.RLock()
+	for _, f := range s.files {
+		if f.advertisement.ContentID == contentID {
+			file = f
+			break
+		}
+	}
+	s.filesLock.RUnlock()
+
+	if file == nil || !file.unchanged() {
+		return nil
+	}
+
+	return file
+}
+
+// servedFile returns the prepared file at the given path.
+// Returns an error if the manifest of the file was not computed yet or the file changed since then.
+func (s *Server) servedFile(path string) (*servedFile, error) {
+	s.filesLock.RLock()
+	file, exists := s.files[path]
+	s.filesLock.RUnlock()
+
+	if !exists || !file.unchanged() {
+		return nil, ErrFileNotPrepared
+	}
+
+	return file, nil
+}
+
+// prepareFile computes the manifest of the file at the given path if the file changed.
+// The manifest is computed without holding the lock of the served files, so requests are not blocked in the meantime.
+func (s *Server) prepareFile(path string) error {
+	s.prepareLock.Lock()
+	defer s.prepareLock.Unlock()
+
+	if _, err := s.servedFile(path); err == nil {
+		return nil
+	}
+
+	file, err := newServedFile(path, s.chunkSize)
+
+	s.filesLock.Lock()
+	defer s.filesLock.Unlock()
+
+	if err != nil {
+		delete(s.files, path)
+		return err
+	}
+	s.files[path] = file
+
+	return nil
+}
+
+// newServedFile reads the header of the snapshot file and computes its manifest.
+func newServedFile(path string, chunkSize uint32) (*servedFile, error) {
+	if len(path) == 0 {
+		return nil, os.ErrNotExist
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	advertisement, err := readFileAdvertisement(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := computeManifest(path, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.Length != uint64(info.Size()) {
+		return nil, fmt.Errorf("snapshot file %s changed while computing the manifest", path)
+	}
+
+	advertisement.Length = manifest.Length
+	advertisement.ChunkSize = manifest.ChunkSize
+	advertisement.ContentID = manifest.ContentID()
+
+	return &servedFile{
+		path:          path,
+		size:          info.Size(),
+		modTime:       info.ModTime(),
+		advertisement: advertisement,
+		manifest:      manifest,
+	}, nil
+}
+
+// readFileAdvertisement creates the advertisement of a snapshot file from its header.
+func readFileAdvertisement(path string) (*FileAdvertisement, error) {
+	snapshotType, err := snapshot.ReadSnapshotTypeFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch snapshotType {
+	case snapshot.Full:
+		header, err := snapshot.ReadFullSnapshotHeaderFromFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return &FileAdvertisement{
+			Type:                 snapshot.Full,
+			TargetMilestoneIndex: header.TargetMilestoneIndex,
+			MilestoneID:          header.TargetMilestoneID,
+			LedgerIndex:          header.LedgerMilestoneIndex,
+		}, nil
+
+	case snapshot.Delta:
+		header, err := snapshot.ReadDeltaSnapshotHeaderFromFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return &FileAdvertisement{
+			Type:                 snapshot.Delta,
+			TargetMilestoneIndex: header.TargetMilestoneIndex,
+			MilestoneID:          header.FullSnapshotTargetMilestoneID,
+			LedgerIndex:          header.TargetMilestoneIndex,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown snapshot type %d", snapshotType)
+	}
+}
+
+// computeManifest hashes the chunks of the file at the given path.
+func computeManifest(path string, chunkSize uint32) (*Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	manifest := &Manifest{ChunkSize: chunkSize}
+
+	reader := bufio.NewReaderSize(f, int(chunkSize))
+	chunk := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(reader, chunk)
+		if n > 0 {
+			manifest.ChunkHashes = append(manifest.ChunkHashes, blake2b.Sum256(chunk[:n]))
+			manifest.Length += uint64(n)
+		}
+		if err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, err
+		}
+	}
+
+	if manifest.Length == 0 {
+		return nil, fmt.Errorf("snapshot file %s is empty", path)
+	}
+
+	return manifest, nil
+}
+
+// PrepareFiles computes the manifests of the snapshot files that changed.
+// Only prepared files are served, so this needs to be called after the snapshot files were written.
+func (s *Server) PrepareFiles(ctx context.Context) {
+	for _, path := range []string{s.fullPath, s.deltaPath} {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.prepareFile(path); err != nil && !os.IsNotExist(err) {
+			s.LogDebugf("preparing snapshot file %s failed: %s", path, err)
+		}
+	}
+}
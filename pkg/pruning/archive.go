@@ -0,0 +1,72 @@
+package pruning
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hornet/pkg/model/utxo"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+// ArchivedBlock is a block of a milestone cone that is archived before it is pruned.
+type ArchivedBlock struct {
+	// The ID of the block.
+	BlockID iotago.BlockID
+	// The serialized block.
+	Data []byte
+	// The serialized metadata of the block.
+	Metadata []byte
+}
+
+// MilestoneCone contains the data of a milestone cone that is archived before it is pruned.
+type MilestoneCone struct {
+	// The index of the milestone.
+	Index iotago.MilestoneIndex
+	// The milestone payload.
+	Milestone *iotago.Milestone
+	// The blocks whose bodies are pruned with the milestone cone.
+	Blocks []*ArchivedBlock
+	// The ledger diff of the milestone, nil if it was already pruned.
+	Diff *utxo.MilestoneDiff
+}
+
+// ArchiveSink stores the milestone cones that are pruned from the database.
+type ArchiveSink interface {
+	// ArchiveMilestoneCone stores the given milestone cone.
+	// The cone is only pruned from the database if it was archived successfully.
+	ArchiveMilestoneCone(cone *MilestoneCone) error
+}
+
+// collectMilestoneCone collects the data of the milestone cone that is archived before it is pruned.
+// All blocks whose bodies are deleted together with the cone are part of it, even if they were referenced by an older milestone.
+func (p *Manager) collectMilestoneCone(milestoneIndex iotago.MilestoneIndex, milestonePayload *iotago.Milestone, blockIDsToDeleteMap map[iotago.BlockID]struct{}) (*MilestoneCone, error) {
+
+	cone := &MilestoneCone{
+		Index:     milestoneIndex,
+		Milestone: milestonePayload,
+		Blocks:    make([]*ArchivedBlock, 0, len(blockIDsToDeleteMap)),
+	}
+
+	for blockID := range blockIDsToDeleteMap {
+		cachedBlock := p.storage.CachedBlockOrNil(blockID) // block +1
+		if cachedBlock == nil {
+			// the block body was already pruned
+			continue
+		}
+
+		cone.Blocks = append(cone.Blocks, &ArchivedBlock{
+			BlockID:  blockID,
+			Data:     cachedBlock.Block().Data(),
+			Metadata: cachedBlock.Metadata().ObjectStorageValue(),
+		})
+		cachedBlock.Release(true) // block -1
+	}
+
+	diff, err := p.storage.UTXOManager().MilestoneDiff(milestoneIndex)
+	if err != nil && !errors.Is(err, kvstore.ErrKeyNotFound) {
+		return nil, err
+	}
+	cone.Diff = diff
+
+	return cone, nil
+}
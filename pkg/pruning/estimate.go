@@ -0,0 +1,281 @@
+package pruning
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/contextutils"
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hornet/pkg/common"
+	"github.com/iotaledger/hornet/pkg/dag"
+	storagepkg "github.com/iotaledger/hornet/pkg/model/storage"
+	"github.com/iotaledger/hornet/pkg/model/utxo"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+// PruningEstimate contains the impact of a pruning run that was not executed.
+type PruningEstimate struct {
+	// The target index of the tangle history.
+	TargetIndex iotago.MilestoneIndex
+	// The target indexes of the pruning classes.
+	ClassTargetIndexes map[storagepkg.PruningClass]iotago.MilestoneIndex
+	// The amount of milestones that would be removed.
+	Milestones int
+	// The amount of blocks in the milestone cones whose data would be removed.
+	Blocks int
+	// The amount of unreferenced blocks that would be removed.
+	UnreferencedBlocks int
+	// The amount of spent outputs that would be removed from the ledger.
+	Outputs int
+	// The amount of receipts that would be removed.
+	Receipts int
+	// The approximate amount of bytes that would be removed from the database.
+	Bytes uint64
+	// The solid entry points that would be created for the new end of the tangle history.
+	SolidEntryPoints []*storagepkg.SolidEntryPoint
+	// The estimated duration of the pruning run.
+	EstimatedDuration time.Duration
+}
+
+// coneSizeOfClasses returns the bytes of the given classes of a milestone cone.
+func coneSizeOfClasses(size *storagepkg.MilestoneConeSize, classes map[storagepkg.PruningClass]bool) uint64 {
+	var bytes uint64
+	if classes[storagepkg.PruningClassBlocks] {
+		bytes += size.Blocks
+	}
+	if classes[storagepkg.PruningClassMetadata] {
+		bytes += size.Metadata
+	}
+	if classes[storagepkg.PruningClassChildren] {
+		bytes += size.Children
+	}
+	if classes[storagepkg.PruningClassMilestones] {
+		bytes += size.Milestones
+	}
+	if classes[storagepkg.PruningClassLedgerDiffs] {
+		bytes += size.LedgerDiffs
+	}
+	return bytes
+}
+
+// averageMilestoneConeSize returns the average size of the known milestone cones, or nil if no size is known.
+func (p *Manager) averageMilestoneConeSize() (*storagepkg.MilestoneConeSize, error) {
+	average := &storagepkg.MilestoneConeSize{}
+
+	var count uint64
+	if err := p.storage.ForEachMilestoneConeSize(func(size *storagepkg.MilestoneConeSize) bool {
+		average.Blocks += size.Blocks
+		average.Metadata += size.Metadata
+		average.Children += size.Children
+		average.Milestones += size.Milestones
+		average.LedgerDiffs += size.LedgerDiffs
+		count++
+		return true
+	}); err != nil {
+		return nil, err
+	}
+
+	if count == 0 {
+		return nil, nil
+	}
+
+	average.Blocks /= count
+	average.Metadata /= count
+	average.Children /= count
+	average.Milestones /= count
+	average.LedgerDiffs /= count
+
+	return average, nil
+}
+
+// countUnreferencedBlocks returns the amount of unreferenced blocks of the given milestone that would be pruned.
+func (p *Manager) countUnreferencedBlocks(milestoneIndex iotago.MilestoneIndex) int {
+
+	blockIDs := make(map[iotago.BlockID]struct{})
+	for _, blockID := range p.storage.UnreferencedBlockIDs(milestoneIndex) {
+		cachedBlockMeta := p.storage.CachedBlockMetadataOrNil(blockID) // meta +1
+		if cachedBlockMeta == nil {
+			continue
+		}
+
+		if !cachedBlockMeta.Metadata().IsReferenced() {
+			blockIDs[blockID] = struct{}{}
+		}
+		cachedBlockMeta.Release(true) // meta -1
+	}
+
+	return len(blockIDs)
+}
+
+// estimatePruneDatabase walks the same milestone cones as pruneDatabase without modifying the database
+// and returns the impact the pruning run would have.
+// The pruning lock is not acquired, so the estimation can run while the database is pruned or a snapshot is created.
+// In that case the estimation is based on the state of the database before the running pruning finished.
+func (p *Manager) estimatePruneDatabase(ctx context.Context, targetIndex iotago.MilestoneIndex) (*PruningEstimate, error) {
+
+	timeStart := time.Now()
+
+	plan, err := p.planPruning(ctx, targetIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &PruningEstimate{
+		TargetIndex:        plan.targetIndex,
+		ClassTargetIndexes: plan.classTargetIndexes,
+	}
+
+	if plan.pruneTangleHistory {
+		if err := dag.ForEachSolidEntryPoint(
+			ctx,
+			p.storage,
+			plan.targetIndex,
+			// TODO
+			//p.solidEntryPointCheckThresholdPast,
+			15,
+			func(sep *storagepkg.SolidEntryPoint) bool {
+				estimate.SolidEntryPoints = append(estimate.SolidEntryPoints, sep)
+				return true
+			}); err != nil {
+			if errors.Is(err, common.ErrOperationAborted) {
+				return nil, ErrPruningAborted
+			}
+			return nil, err
+		}
+	}
+
+	// the sizes of the milestone cones that were not tracked are estimated with the average size
+	averageConeSize, err := p.averageMilestoneConeSize()
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.classPruningIndexes[storagepkg.PruningClassBlocks] < plan.classTargetIndexes[storagepkg.PruningClassBlocks] {
+		estimate.UnreferencedBlocks += p.countUnreferencedBlocks(plan.classPruningIndexes[storagepkg.PruningClassBlocks])
+	}
+
+	// the amount of milestones the pruning run iterates over
+	var milestonesCount int
+	for milestoneIndex := plan.startIndex; milestoneIndex <= plan.targetIndex; milestoneIndex++ {
+
+		if err := contextutils.ReturnErrIfCtxDone(ctx, ErrPruningAborted); err != nil {
+			return nil, err
+		}
+
+		dueClasses := plan.dueClasses(milestoneIndex)
+		if len(dueClasses) == 0 && milestoneIndex <= plan.pruningIndex {
+			continue
+		}
+		milestonesCount++
+
+		if dueClasses[storagepkg.PruningClassMilestones] {
+			estimate.Milestones++
+		}
+
+		if dueClasses[storagepkg.PruningClassBlocks] {
+			estimate.UnreferencedBlocks += p.countUnreferencedBlocks(milestoneIndex)
+		}
+
+		if dueClasses[storagepkg.PruningClassBlocks] || dueClasses[storagepkg.PruningClassMetadata] || dueClasses[storagepkg.PruningClassChildren] {
+			cachedMilestone := p.storage.CachedMilestoneByIndexOrNil(milestoneIndex) // milestone +1
+			if cachedMilestone != nil {
+				// the blocks of older milestones are not walked, their metadata was either pruned before
+				// or would be pruned by the run before this milestone is reached.
+				blockIDs, err := p.milestoneConeBlockIDs(ctx, cachedMilestone.Milestone().Milestone(), false)
+				cachedMilestone.Release(true) // milestone -1
+				if err != nil {
+					return nil, err
+				}
+				estimate.Blocks += len(blockIDs)
+			}
+		}
+
+		if dueClasses[storagepkg.PruningClassLedgerDiffs] {
+			diff, err := p.storage.UTXOManager().MilestoneDiff(milestoneIndex)
+			if err != nil && !errors.Is(err, kvstore.ErrKeyNotFound) {
+				return nil, err
+			}
+			if diff != nil {
+				estimate.Outputs += len(diff.Spents)
+			}
+		}
+
+		coneSize, err := p.storage.MilestoneConeSize(milestoneIndex)
+		if err != nil {
+			return nil, err
+		}
+		if coneSize == nil {
+			coneSize = averageConeSize
+		}
+		if coneSize != nil {
+			estimate.Bytes += coneSizeOfClasses(coneSize, dueClasses)
+		}
+	}
+
+	if receiptsTargetIndex := plan.classTargetIndexes[storagepkg.PruningClassReceipts]; plan.classPruningIndexes[storagepkg.PruningClassReceipts] < receiptsTargetIndex {
+		if err := p.storage.UTXOManager().ForEachReceiptTuple(func(rt *utxo.ReceiptTuple) bool {
+			if rt.MilestoneIndex <= receiptsTargetIndex {
+				estimate.Receipts++
+			}
+			return true
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	// the estimation walks the same cones, so its duration is a lower bound if no pruning run was measured yet
+	estimate.EstimatedDuration = time.Since(timeStart)
+	if averageDuration := p.averageMilestonePruningDuration(); averageDuration > 0 {
+		estimate.EstimatedDuration = time.Duration(milestonesCount) * averageDuration
+	}
+
+	return estimate, nil
+}
+
+// averageMilestonePruningDuration returns the moving average of the time it took to prune a milestone.
+func (p *Manager) averageMilestonePruningDuration() time.Duration {
+	p.statusLock.RLock()
+	defer p.statusLock.RUnlock()
+	return p.milestonePruningDuration
+}
+
+// updateMilestonePruningDuration adds the duration of a pruned milestone to the moving average.
+func (p *Manager) updateMilestonePruningDuration(duration time.Duration) {
+	p.statusLock.Lock()
+	defer p.statusLock.Unlock()
+
+	if p.milestonePruningDuration == 0 {
+		p.milestonePruningDuration = duration
+		return
+	}
+	p.milestonePruningDuration = (p.milestonePruningDuration*9 + duration) / 10
+}
+
+// EstimatePruneDatabaseByDepth returns the impact of pruning the database by depth without pruning it.
+func (p *Manager) EstimatePruneDatabaseByDepth(ctx context.Context, depth iotago.MilestoneIndex) (*PruningEstimate, error) {
+	confirmedMilestoneIndex := p.syncManager.ConfirmedMilestoneIndex()
+
+	if confirmedMilestoneIndex <= depth {
+		// Not enough history
+		return nil, ErrNotEnoughHistory
+	}
+
+	return p.estimatePruneDatabase(ctx, confirmedMilestoneIndex-depth)
+}
+
+// EstimatePruneDatabaseByTargetIndex returns the impact of pruning the database up to the target index without pruning it.
+func (p *Manager) EstimatePruneDatabaseByTargetIndex(ctx context.Context, targetIndex iotago.MilestoneIndex) (*PruningEstimate, error) {
+	return p.estimatePruneDatabase(ctx, targetIndex)
+}
+
+// EstimatePruneDatabaseBySize returns the impact of pruning the database to the target size without pruning it.
+func (p *Manager) EstimatePruneDatabaseBySize(ctx context.Context, targetSizeBytes int64) (*PruningEstimate, error) {
+	targetIndex, err := p.calcTargetIndexBySize(targetSizeBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.estimatePruneDatabase(ctx, targetIndex)
+}
@@ -34,6 +34,15 @@ var (
 	ErrExistingDeltaSnapshotWrongFullSnapshotTargetMilestoneID = errors.New("existing delta ledger snapshot has wrong full snapshot target milestone ID")
 )
 
+// the classes whose data is pruned per milestone.
+var milestonePruningClasses = []storagepkg.PruningClass{
+	storagepkg.PruningClassBlocks,
+	storagepkg.PruningClassMetadata,
+	storagepkg.PruningClassChildren,
+	storagepkg.PruningClassMilestones,
+	storagepkg.PruningClassLedgerDiffs,
+}
+
 type getMinimumTangleHistoryFunc func() iotago.MilestoneIndex
 
 // Manager handles pruning of the database.
@@ -55,11 +64,17 @@ type Manager struct {
 	pruningSizeThresholdPercentage       float64
 	pruningSizeCooldownTime              time.Duration
 	pruneReceipts                        bool
+	// the amount of milestones the data of a class is kept for, if it should be kept longer than the tangle history.
+	classRetentions map[storagepkg.PruningClass]syncmanager.MilestoneIndexDelta
+	// the sink the milestone cones are archived to before they are pruned, nil if they are not archived.
+	archiveSink ArchiveSink
 
 	snapshotLock          syncutils.Mutex
 	statusLock            syncutils.RWMutex
 	isPruning             bool
 	lastPruningBySizeTime time.Time
+	// the moving average of the time it took to prune a milestone, used to estimate the duration of a pruning run.
+	milestonePruningDuration time.Duration
 
 	Events *Events
 }
@@ -78,7 +93,9 @@ func NewPruningManager(
 	pruningSizeTargetSizeBytes int64,
 	pruningSizeThresholdPercentage float64,
 	pruningSizeCooldownTime time.Duration,
-	pruneReceipts bool) *Manager {
+	pruneReceipts bool,
+	classRetentions map[storagepkg.PruningClass]syncmanager.MilestoneIndexDelta,
+	archiveSink ArchiveSink) *Manager {
 
 	return &Manager{
 		WrappedLogger:                        logger.NewWrappedLogger(log),
@@ -95,6 +112,8 @@ func NewPruningManager(
 		pruningSizeThresholdPercentage:       pruningSizeThresholdPercentage,
 		pruningSizeCooldownTime:              pruningSizeCooldownTime,
 		pruneReceipts:                        pruneReceipts,
+		classRetentions:                      classRetentions,
+		archiveSink:                          archiveSink,
 		Events: &Events{
 			PruningMilestoneIndexChanged: events.NewEvent(storagepkg.MilestoneIndexCaller),
 			PruningMetricsUpdated:        events.NewEvent(PruningMetricsCaller),
@@ -161,12 +180,88 @@ func (p *Manager) calcTargetIndexBySize(targetSizeBytes ...int64) (iotago.Milest
 		return 0, common.ErrSnapshotInfoNotFound
 	}
 
-	milestoneRange := p.syncManager.ConfirmedMilestoneIndex() - snapshotInfo.PruningIndex()
 	prunedDatabaseSizeBytes := float64(targetDatabaseSizeBytes) * ((100.0 - p.pruningSizeThresholdPercentage) / 100.0)
-	diffPercentage := prunedDatabaseSizeBytes / float64(currentDatabaseSizeBytes)
-	milestoneDiff := syncmanager.MilestoneIndexDelta(math.Ceil(float64(milestoneRange) * diffPercentage))
+	freePercentage := 1.0 - prunedDatabaseSizeBytes/float64(currentDatabaseSizeBytes)
+
+	return p.calcTargetIndexByConeSizes(snapshotInfo.PruningIndex(), p.syncManager.ConfirmedMilestoneIndex(), freePercentage)
+}
+
+// calcTargetIndexByConeSizes returns the target index that frees the given percentage of the bytes
+// that were written for the milestone cones between the pruning index and the confirmed milestone index.
+// The sizes of milestone cones that are unknown, e.g. because they were confirmed before the sizes were tracked,
+// are estimated with the average size of the known milestone cones.
+func (p *Manager) calcTargetIndexByConeSizes(pruningIndex iotago.MilestoneIndex, confirmedMilestoneIndex iotago.MilestoneIndex, freePercentage float64) (iotago.MilestoneIndex, error) {
+
+	if confirmedMilestoneIndex <= pruningIndex || freePercentage <= 0 {
+		return 0, ErrNoPruningNeeded
+	}
+
+	// only the sums of the known sizes are kept in memory, the sizes are looked up again while walking the range.
+	var knownConeSizesCount uint64
+	knownClassBytes := make(map[storagepkg.PruningClass]uint64, len(milestonePruningClasses))
+	var knownBytes uint64
+	if err := p.storage.ForEachMilestoneConeSize(func(coneSize *storagepkg.MilestoneConeSize) bool {
+		if coneSize.Index <= pruningIndex || coneSize.Index > confirmedMilestoneIndex {
+			return true
+		}
+		knownConeSizesCount++
+		knownBytes += coneSize.Total()
+		for _, class := range milestonePruningClasses {
+			knownClassBytes[class] += coneSize.BytesOf(class)
+		}
+		return true
+	}); err != nil {
+		return 0, err
+	}
+
+	milestoneRange := confirmedMilestoneIndex - pruningIndex
+
+	if knownConeSizesCount == 0 {
+		// no sizes known, assume the data is uniformly distributed across the milestone range
+		milestoneDiff := syncmanager.MilestoneIndexDelta(math.Ceil(float64(milestoneRange) * (1.0 - freePercentage)))
+		return confirmedMilestoneIndex - milestoneDiff, nil
+	}
+
+	averageClassBytes := make(map[storagepkg.PruningClass]uint64, len(milestonePruningClasses))
+	var averageBytes uint64
+	for _, class := range milestonePruningClasses {
+		averageClassBytes[class] = knownClassBytes[class] / knownConeSizesCount
+		averageBytes += averageClassBytes[class]
+	}
+	totalBytes := knownBytes + (uint64(milestoneRange)-knownConeSizesCount)*averageBytes
+
+	// the tracked sizes don't include the overhead and the compression of the database,
+	// so the same percentage of the tracked bytes is freed instead of the absolute amount.
+	bytesToFree := uint64(math.Ceil(float64(totalBytes) * freePercentage))
 
-	return p.syncManager.ConfirmedMilestoneIndex() - milestoneDiff, nil
+	// the data of classes with a longer retention is not freed by pruning the tangle history.
+	retainedClassTargetIndexes := p.classTargetIndexes(confirmedMilestoneIndex)
+
+	var freedBytes uint64
+	for msIndex := pruningIndex + 1; msIndex < confirmedMilestoneIndex; msIndex++ {
+		coneSize, err := p.storage.MilestoneConeSize(msIndex)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, class := range milestonePruningClasses {
+			if msIndex > retainedClassTargetIndexes[class] {
+				continue
+			}
+
+			if coneSize != nil {
+				freedBytes += coneSize.BytesOf(class)
+			} else {
+				freedBytes += averageClassBytes[class]
+			}
+		}
+
+		if freedBytes >= bytesToFree {
+			return msIndex, nil
+		}
+	}
+
+	return confirmedMilestoneIndex, nil
 }
 
 // pruneUnreferencedBlocks prunes all unreferenced blocks from the database for the given milestone
@@ -196,26 +291,19 @@ func (p *Manager) pruneUnreferencedBlocks(targetIndex iotago.MilestoneIndex) (bl
 		blockIDsToDeleteMap[blockID] = struct{}{}
 	}
 
-	blocksCountDeleted = p.pruneBlocks(blockIDsToDeleteMap)
+	// unreferenced blocks are removed completely together with the block bodies
+	blocksCountDeleted = p.pruneBlocks(blockIDsToDeleteMap, map[storagepkg.PruningClass]bool{
+		storagepkg.PruningClassBlocks:   true,
+		storagepkg.PruningClassMetadata: true,
+		storagepkg.PruningClassChildren: true,
+	})
 	p.storage.DeleteUnreferencedBlocks(targetIndex)
 
 	return blocksCountDeleted, len(blockIDsToDeleteMap)
 }
 
-// pruneMilestone prunes the milestone metadata and the ledger diffs from the database for the given milestone
-func (p *Manager) pruneMilestone(milestoneIndex iotago.MilestoneIndex, receiptMigratedAtIndex ...iotago.MilestoneIndex) error {
-
-	if err := p.storage.UTXOManager().PruneMilestoneIndexWithoutLocking(milestoneIndex, p.pruneReceipts, receiptMigratedAtIndex...); err != nil {
-		return err
-	}
-
-	p.storage.DeleteMilestone(milestoneIndex)
-
-	return nil
-}
-
-// pruneBlocks removes all the associated data of the given block IDs from the database
-func (p *Manager) pruneBlocks(blockIDsToDeleteMap map[iotago.BlockID]struct{}) int {
+// pruneBlocks removes the data of the due classes of the given block IDs from the database
+func (p *Manager) pruneBlocks(blockIDsToDeleteMap map[iotago.BlockID]struct{}, dueClasses map[storagepkg.PruningClass]bool) int {
 
 	for blockID := range blockIDsToDeleteMap {
 
@@ -225,6 +313,10 @@ func (p *Manager) pruneBlocks(blockIDsToDeleteMap map[iotago.BlockID]struct{}) i
 		}
 
 		cachedBlockMeta.ConsumeMetadata(func(metadata *storagepkg.BlockMetadata) { // meta -1
+			if !dueClasses[storagepkg.PruningClassChildren] {
+				return
+			}
+
 			// Delete the reference in the parents
 			for _, parent := range metadata.Parents() {
 				p.storage.DeleteChild(parent, blockID)
@@ -235,28 +327,94 @@ func (p *Manager) pruneBlocks(blockIDsToDeleteMap map[iotago.BlockID]struct{}) i
 			// and the references will be deleted together with the children blocks when they are pruned.
 		})
 
-		p.storage.DeleteBlock(blockID)
+		switch {
+		case dueClasses[storagepkg.PruningClassBlocks] && dueClasses[storagepkg.PruningClassMetadata]:
+			p.storage.DeleteBlock(blockID)
+		case dueClasses[storagepkg.PruningClassBlocks]:
+			p.storage.DeleteBlockBody(blockID)
+		case dueClasses[storagepkg.PruningClassMetadata]:
+			p.storage.DeleteBlockMetadata(blockID)
+		}
 	}
 
 	return len(blockIDsToDeleteMap)
 }
 
-func (p *Manager) pruneDatabase(ctx context.Context, targetIndex iotago.MilestoneIndex) (iotago.MilestoneIndex, error) {
+// classTargetIndexes returns the target indexes of the pruning classes for the given target index of the tangle history.
+// Classes with a longer retention are pruned up to a lower index.
+func (p *Manager) classTargetIndexes(targetIndex iotago.MilestoneIndex) map[storagepkg.PruningClass]iotago.MilestoneIndex {
+
+	confirmedMilestoneIndex := p.syncManager.ConfirmedMilestoneIndex()
+
+	targetIndexes := make(map[storagepkg.PruningClass]iotago.MilestoneIndex, len(storagepkg.PruningClasses))
+	for _, class := range storagepkg.PruningClasses {
+		classTargetIndex := targetIndex
+
+		if retention := p.classRetentions[class]; retention > 0 {
+			classTargetIndex = 0
+			if confirmedMilestoneIndex > retention {
+				classTargetIndex = confirmedMilestoneIndex - retention
+			}
+			if classTargetIndex > targetIndex {
+				classTargetIndex = targetIndex
+			}
+		}
+
+		targetIndexes[class] = classTargetIndex
+	}
+
+	if !p.pruneReceipts {
+		// receipts are never pruned
+		targetIndexes[storagepkg.PruningClassReceipts] = 0
+	}
+
+	return targetIndexes
+}
+
+// pruningPlan describes which data is removed by a pruning run.
+type pruningPlan struct {
+	// the target index of the tangle history.
+	targetIndex iotago.MilestoneIndex
+	// the pruning index of the tangle history before the pruning run.
+	pruningIndex iotago.MilestoneIndex
+	// whether the tangle history is pruned, otherwise only the classes with a longer retention are pruned.
+	pruneTangleHistory bool
+	// the current and the target pruning indexes of the classes.
+	classPruningIndexes map[storagepkg.PruningClass]iotago.MilestoneIndex
+	classTargetIndexes  map[storagepkg.PruningClass]iotago.MilestoneIndex
+	// the first milestone that is pruned.
+	startIndex iotago.MilestoneIndex
+}
+
+// dueClasses returns the classes whose data of the given milestone needs to be pruned.
+func (plan *pruningPlan) dueClasses(milestoneIndex iotago.MilestoneIndex) map[storagepkg.PruningClass]bool {
+	dueClasses := make(map[storagepkg.PruningClass]bool, len(milestonePruningClasses))
+	for _, class := range milestonePruningClasses {
+		if plan.classPruningIndexes[class] < milestoneIndex && milestoneIndex <= plan.classTargetIndexes[class] {
+			dueClasses[class] = true
+		}
+	}
+	return dueClasses
+}
+
+// planPruning checks whether the database can be pruned up to the given target index and
+// determines which data needs to be pruned.
+func (p *Manager) planPruning(ctx context.Context, targetIndex iotago.MilestoneIndex) (*pruningPlan, error) {
 
 	if err := contextutils.ReturnErrIfCtxDone(ctx, common.ErrOperationAborted); err != nil {
 		// do not prune the database if the node was shut down
-		return 0, err
+		return nil, err
 	}
 
 	if p.tangleDatabase.CompactionRunning() || p.utxoDatabase.CompactionRunning() {
-		return 0, ErrDatabaseCompactionRunning
+		return nil, ErrDatabaseCompactionRunning
 	}
 
 	targetIndexMax := p.getMinimumTangleHistory()
 
 	snapshotInfo := p.storage.SnapshotInfo()
 	if snapshotInfo == nil {
-		return 0, errors.Wrap(common.ErrCritical, common.ErrSnapshotInfoNotFound.Error())
+		return nil, errors.Wrap(common.ErrCritical, common.ErrSnapshotInfoNotFound.Error())
 	}
 
 	//lint:ignore SA5011 nil pointer is already checked before with a panic
@@ -264,7 +422,7 @@ func (p *Manager) pruneDatabase(ctx context.Context, targetIndex iotago.Mileston
 	if snapshotInfo.SnapshotIndex() < p.additionalPruningThreshold+1 {
 		// Not enough history
 		//return 0, errors.Wrapf(ErrNotEnoughHistory, "minimum index: %d, target index: %d", p.solidEntryPointCheckThresholdPast+p.additionalPruningThreshold+1, targetIndex)
-		return 0, errors.Wrapf(ErrNotEnoughHistory, "minimum index: %d, target index: %d", p.additionalPruningThreshold+1, targetIndex)
+		return nil, errors.Wrapf(ErrNotEnoughHistory, "minimum index: %d, target index: %d", p.additionalPruningThreshold+1, targetIndex)
 	}
 
 	// TODO
@@ -273,143 +431,270 @@ func (p *Manager) pruneDatabase(ctx context.Context, targetIndex iotago.Mileston
 		targetIndex = targetIndexMax
 	}
 
-	if snapshotInfo.PruningIndex() >= targetIndex {
+	pruningIndex := snapshotInfo.PruningIndex()
+
+	var errTangleHistory error
+	switch {
+	case pruningIndex >= targetIndex:
 		// no pruning needed
-		return 0, errors.Wrapf(ErrNoPruningNeeded, "pruning index: %d, target index: %d", snapshotInfo.PruningIndex(), targetIndex)
+		errTangleHistory = errors.Wrapf(ErrNoPruningNeeded, "pruning index: %d, target index: %d", pruningIndex, targetIndex)
+	case snapshotInfo.EntryPointIndex()+p.additionalPruningThreshold+1 > targetIndex:
+		// we prune in "additionalPruningThreshold" steps to recalculate the solidEntryPoints
+		errTangleHistory = errors.Wrapf(ErrNotEnoughHistory, "minimum index: %d, target index: %d", snapshotInfo.EntryPointIndex()+p.additionalPruningThreshold+1, targetIndex)
 	}
 
-	if snapshotInfo.EntryPointIndex()+p.additionalPruningThreshold+1 > targetIndex {
-		// we prune in "additionalPruningThreshold" steps to recalculate the solidEntryPoints
-		return 0, errors.Wrapf(ErrNotEnoughHistory, "minimum index: %d, target index: %d", snapshotInfo.EntryPointIndex()+p.additionalPruningThreshold+1, targetIndex)
+	pruneTangleHistory := errTangleHistory == nil
+	if !pruneTangleHistory {
+		// the tangle history is not pruned, but the classes with a longer retention may still be behind
+		targetIndex = pruningIndex
 	}
 
-	p.setIsPruning(true)
-	defer p.setIsPruning(false)
+	plan := &pruningPlan{
+		targetIndex:         targetIndex,
+		pruningIndex:        pruningIndex,
+		pruneTangleHistory:  pruneTangleHistory,
+		classPruningIndexes: make(map[storagepkg.PruningClass]iotago.MilestoneIndex, len(storagepkg.PruningClasses)),
+		classTargetIndexes:  p.classTargetIndexes(targetIndex),
+	}
 
-	// calculate solid entry points for the new end of the tangle history
-	var solidEntryPoints []*storagepkg.SolidEntryPoint
-	err := dag.ForEachSolidEntryPoint(
+	for _, class := range storagepkg.PruningClasses {
+		plan.classPruningIndexes[class] = snapshotInfo.PruningIndexOf(class)
+	}
+
+	// the milestones are pruned starting from the lowest pruning index of all classes that need to be pruned
+	plan.startIndex = pruningIndex + 1
+	for _, class := range milestonePruningClasses {
+		if plan.classPruningIndexes[class] < plan.classTargetIndexes[class] && plan.classPruningIndexes[class]+1 < plan.startIndex {
+			plan.startIndex = plan.classPruningIndexes[class] + 1
+		}
+	}
+
+	classesPruningNeeded := false
+	for class, classTargetIndex := range plan.classTargetIndexes {
+		if plan.classPruningIndexes[class] < classTargetIndex {
+			classesPruningNeeded = true
+			break
+		}
+	}
+
+	if !pruneTangleHistory && !classesPruningNeeded {
+		return nil, errTangleHistory
+	}
+
+	return plan, nil
+}
+
+// milestoneConeBlockIDs returns the IDs of the blocks in the cone of the given milestone whose data needs to be pruned.
+// If walkOlderCones is set, the walk continues into the blocks that were referenced by older milestones.
+func (p *Manager) milestoneConeBlockIDs(ctx context.Context, milestonePayload *iotago.Milestone, walkOlderCones bool) (map[iotago.BlockID]struct{}, error) {
+
+	milestoneIndex := milestonePayload.Index
+	blockIDs := make(map[iotago.BlockID]struct{})
+
+	if err := dag.TraverseParents(
 		ctx,
 		p.storage,
-		targetIndex,
-		// TODO
-		//p.solidEntryPointCheckThresholdPast,
-		15,
-		func(sep *storagepkg.SolidEntryPoint) bool {
-			solidEntryPoints = append(solidEntryPoints, sep)
-			return true
-		})
+		milestonePayload.Parents,
+		// traversal stops if no more blocks pass the given condition
+		// Caution: condition func is not in DFS order
+		func(cachedBlockMeta *storagepkg.CachedMetadata) (bool, error) { // meta +1
+			defer cachedBlockMeta.Release(true) // meta -1
+
+			// everything that was referenced by that milestone can be pruned (even blocks of older milestones),
+			// but the cones of older milestones are not walked again if their metadata is kept longer.
+			referenced, referencedIndex := cachedBlockMeta.Metadata().ReferencedWithIndex()
+			return !referenced || referencedIndex >= milestoneIndex || walkOlderCones, nil
+		},
+		// consumer
+		func(cachedBlockMeta *storagepkg.CachedMetadata) error { // meta +1
+			defer cachedBlockMeta.Release(true) // meta -1
+			blockIDs[cachedBlockMeta.Metadata().BlockID()] = struct{}{}
+			return nil
+		},
+		// called on missing parents
+		func(parentBlockID iotago.BlockID) error { return nil },
+		// called on solid entry points
+		// Ignore solid entry points (snapshot milestone included)
+		nil,
+		// the pruning target index is also a solid entry point => traverse it anyways
+		true); err != nil {
+		return nil, err
+	}
+
+	return blockIDs, nil
+}
+
+func (p *Manager) pruneDatabase(ctx context.Context, targetIndex iotago.MilestoneIndex) (iotago.MilestoneIndex, error) {
+
+	plan, err := p.planPruning(ctx, targetIndex)
 	if err != nil {
-		if errors.Is(err, common.ErrOperationAborted) {
-			return 0, ErrPruningAborted
-		}
 		return 0, err
 	}
 
-	// temporarily add the new solid entry points and keep the old ones
-	p.storage.WriteLockSolidEntryPoints()
-	for _, sep := range solidEntryPoints {
-		p.storage.SolidEntryPointsAddWithoutLocking(sep.BlockID, sep.Index)
-	}
-	if err = p.storage.StoreSolidEntryPointsWithoutLocking(); err != nil {
-		p.LogPanic(err)
-	}
-	p.storage.WriteUnlockSolidEntryPoints()
+	targetIndex = plan.targetIndex
+	pruningIndex := plan.pruningIndex
+	pruneTangleHistory := plan.pruneTangleHistory
+	classPruningIndexes := plan.classPruningIndexes
+	classTargetIndexes := plan.classTargetIndexes
 
-	// we have to set the new solid entry point index.
-	// this way we can cleanly prune even if the pruning was aborted last time
-	if err = p.storage.SetEntryPointIndex(targetIndex); err != nil {
-		p.LogPanic(err)
+	p.setIsPruning(true)
+	defer p.setIsPruning(false)
+
+	var solidEntryPoints []*storagepkg.SolidEntryPoint
+	if pruneTangleHistory {
+		// calculate solid entry points for the new end of the tangle history
+		err := dag.ForEachSolidEntryPoint(
+			ctx,
+			p.storage,
+			targetIndex,
+			// TODO
+			//p.solidEntryPointCheckThresholdPast,
+			15,
+			func(sep *storagepkg.SolidEntryPoint) bool {
+				solidEntryPoints = append(solidEntryPoints, sep)
+				return true
+			})
+		if err != nil {
+			if errors.Is(err, common.ErrOperationAborted) {
+				return 0, ErrPruningAborted
+			}
+			return 0, err
+		}
+
+		// temporarily add the new solid entry points and keep the old ones
+		p.storage.WriteLockSolidEntryPoints()
+		for _, sep := range solidEntryPoints {
+			p.storage.SolidEntryPointsAddWithoutLocking(sep.BlockID, sep.Index)
+		}
+		if err = p.storage.StoreSolidEntryPointsWithoutLocking(); err != nil {
+			p.LogPanic(err)
+		}
+		p.storage.WriteUnlockSolidEntryPoints()
+
+		// we have to set the new solid entry point index.
+		// this way we can cleanly prune even if the pruning was aborted last time
+		if err = p.storage.SetEntryPointIndex(targetIndex); err != nil {
+			p.LogPanic(err)
+		}
 	}
 
-	// unreferenced blocks have to be pruned for PruningIndex as well, since this could be CMI at startup of the node
-	p.pruneUnreferencedBlocks(snapshotInfo.PruningIndex())
+	if classPruningIndexes[storagepkg.PruningClassBlocks] < classTargetIndexes[storagepkg.PruningClassBlocks] {
+		// unreferenced blocks have to be pruned for PruningIndex as well, since this could be CMI at startup of the node
+		p.pruneUnreferencedBlocks(classPruningIndexes[storagepkg.PruningClassBlocks])
+	}
 
 	// Iterate through all milestones that have to be pruned
-	for milestoneIndex := snapshotInfo.PruningIndex() + 1; milestoneIndex <= targetIndex; milestoneIndex++ {
+	for milestoneIndex := plan.startIndex; milestoneIndex <= targetIndex; milestoneIndex++ {
 
 		if err := contextutils.ReturnErrIfCtxDone(ctx, ErrPruningAborted); err != nil {
 			// stop pruning if node was shutdown
 			return 0, err
 		}
 
+		// the classes whose data of this milestone needs to be pruned
+		dueClasses := plan.dueClasses(milestoneIndex)
+		if len(dueClasses) == 0 && milestoneIndex <= pruningIndex {
+			continue
+		}
+		pruneMilestoneCone := dueClasses[storagepkg.PruningClassBlocks] || dueClasses[storagepkg.PruningClassMetadata] || dueClasses[storagepkg.PruningClassChildren]
+
 		p.LogInfof("Pruning milestone (%d)...", milestoneIndex)
 
 		timeStart := time.Now()
-		blocksCountDeleted, blocksCountChecked := p.pruneUnreferencedBlocks(milestoneIndex)
-		timePruneUnreferencedBlocks := time.Now()
-
-		// get all parents of that milestone
-		cachedMilestone := p.storage.CachedMilestoneByIndexOrNil(milestoneIndex) // milestone +1
-		if cachedMilestone == nil {
-			// Milestone not found, pruning impossible
-			p.LogWarnf("Pruning milestone (%d) failed! Milestone not found!", milestoneIndex)
-			continue
+		var blocksCountDeleted, blocksCountChecked int
+		if dueClasses[storagepkg.PruningClassBlocks] {
+			blocksCountDeleted, blocksCountChecked = p.pruneUnreferencedBlocks(milestoneIndex)
 		}
+		timePruneUnreferencedBlocks := time.Now()
 
 		blockIDsToDeleteMap := make(map[iotago.BlockID]struct{})
 
-		if err := dag.TraverseParents(
-			ctx,
-			p.storage,
-			cachedMilestone.Milestone().Parents(),
-			// traversal stops if no more blocks pass the given condition
-			// Caution: condition func is not in DFS order
-			func(cachedBlockMeta *storagepkg.CachedMetadata) (bool, error) { // meta +1
-				defer cachedBlockMeta.Release(true) // meta -1
-				// everything that was referenced by that milestone can be pruned (even blocks of older milestones)
-				return true, nil
-			},
-			// consumer
-			func(cachedBlockMeta *storagepkg.CachedMetadata) error { // meta +1
-				defer cachedBlockMeta.Release(true) // meta -1
-				blockIDsToDeleteMap[cachedBlockMeta.Metadata().BlockID()] = struct{}{}
-				return nil
-			},
-			// called on missing parents
-			func(parentBlockID iotago.BlockID) error { return nil },
-			// called on solid entry points
-			// Ignore solid entry points (snapshot milestone included)
-			nil,
-			// the pruning target index is also a solid entry point => traverse it anyways
-			true); err != nil {
+		if pruneMilestoneCone {
+			// get all parents of that milestone
+			cachedMilestone := p.storage.CachedMilestoneByIndexOrNil(milestoneIndex) // milestone +1
+			if cachedMilestone == nil {
+				// Milestone not found, pruning impossible
+				p.LogWarnf("Pruning milestone (%d) failed! Milestone not found!", milestoneIndex)
+				continue
+			}
+
+			blockIDsToDeleteMap, err = p.milestoneConeBlockIDs(ctx, cachedMilestone.Milestone().Milestone(), dueClasses[storagepkg.PruningClassMetadata])
+			if err != nil {
+				cachedMilestone.Release(true) // milestone -1
+				p.LogWarnf("Pruning milestone (%d) failed! %s", milestoneIndex, err)
+				continue
+			}
+
+			if p.archiveSink != nil && dueClasses[storagepkg.PruningClassBlocks] {
+				// the cone is archived before the block bodies are deleted
+				cone, err := p.collectMilestoneCone(milestoneIndex, cachedMilestone.Milestone().Milestone(), blockIDsToDeleteMap)
+				if err == nil {
+					err = p.archiveSink.ArchiveMilestoneCone(cone)
+				}
+				if err != nil {
+					cachedMilestone.Release(true) // milestone -1
+					// stop pruning, otherwise the cone would be lost
+					return 0, errors.Wrapf(err, "archiving milestone (%d) failed", milestoneIndex)
+				}
+			}
+
 			cachedMilestone.Release(true) // milestone -1
-			p.LogWarnf("Pruning milestone (%d) failed! %s", milestoneIndex, err)
-			continue
 		}
 		timeTraverseMilestoneCone := time.Now()
 
-		// check whether milestone contained receipt and delete it accordingly
-		var migratedAtIndex []iotago.MilestoneIndex
-
-		opts, err := cachedMilestone.Milestone().Milestone().Opts.Set()
-		if err == nil && opts != nil {
-			if r := opts.Receipt(); r != nil {
-				migratedAtIndex = append(migratedAtIndex, r.MigratedAt)
+		if dueClasses[storagepkg.PruningClassLedgerDiffs] {
+			// the receipts are pruned separately
+			if err := p.storage.UTXOManager().PruneMilestoneIndexWithoutLocking(milestoneIndex, false); err != nil {
+				p.LogWarnf("Pruning milestone (%d) failed! %s", milestoneIndex, err)
 			}
 		}
-
-		cachedMilestone.Release(true) // milestone -1
-
-		if err := p.pruneMilestone(milestoneIndex, migratedAtIndex...); err != nil {
-			p.LogWarnf("Pruning milestone (%d) failed! %s", milestoneIndex, err)
+		if dueClasses[storagepkg.PruningClassMilestones] {
+			p.storage.DeleteMilestone(milestoneIndex)
 		}
 		timePruneMilestone := time.Now()
 
 		blocksCountChecked += len(blockIDsToDeleteMap)
-		blocksCountDeleted += p.pruneBlocks(blockIDsToDeleteMap)
+		blocksCountDeleted += p.pruneBlocks(blockIDsToDeleteMap, dueClasses)
 		timePruneBlocks := time.Now()
 
-		if err = p.storage.SetPruningIndex(milestoneIndex); err != nil {
+		pruningIndexChanged := milestoneIndex > pruningIndex
+		if pruningIndexChanged {
+			if err := p.storage.SetPruningIndex(milestoneIndex); err != nil {
+				p.LogPanic(err)
+			}
+		}
+
+		dueClassPruningIndexes := make(map[storagepkg.PruningClass]iotago.MilestoneIndex, len(dueClasses))
+		for class := range dueClasses {
+			classPruningIndexes[class] = milestoneIndex
+			dueClassPruningIndexes[class] = milestoneIndex
+		}
+		if err := p.storage.SetPruningIndexesOfClasses(dueClassPruningIndexes); err != nil {
 			p.LogPanic(err)
 		}
+
+		// the size of the milestone cone is kept until all of its data was pruned
+		coneFullyPruned := true
+		for _, class := range milestonePruningClasses {
+			if classPruningIndexes[class] < milestoneIndex {
+				coneFullyPruned = false
+				break
+			}
+		}
+		if coneFullyPruned {
+			p.storage.DeleteMilestoneConeSize(milestoneIndex)
+		}
 		timeSetSnapshotInfo := time.Now()
 
 		p.LogInfof("Pruning milestone (%d) took %v. Pruned %d/%d blocks. ", milestoneIndex, time.Since(timeStart).Truncate(time.Millisecond), blocksCountDeleted, blocksCountChecked)
 
-		p.Events.PruningMilestoneIndexChanged.Trigger(milestoneIndex)
+		if pruningIndexChanged {
+			p.Events.PruningMilestoneIndexChanged.Trigger(milestoneIndex)
+		}
 		timePruningMilestoneIndexChanged := time.Now()
 
+		p.updateMilestonePruningDuration(time.Since(timeStart))
+
 		p.Events.PruningMetricsUpdated.Trigger(&PruningMetrics{
 			DurationPruneUnreferencedBlocks:      timePruneUnreferencedBlocks.Sub(timeStart),
 			DurationTraverseMilestoneCone:        timeTraverseMilestoneCone.Sub(timePruneUnreferencedBlocks),
@@ -421,19 +706,36 @@ func (p *Manager) pruneDatabase(ctx context.Context, targetIndex iotago.Mileston
 		})
 	}
 
-	// finally set the new solid entry points and remove the old ones
-	p.storage.WriteLockSolidEntryPoints()
-	p.storage.ResetSolidEntryPointsWithoutLocking()
-	for _, sep := range solidEntryPoints {
-		p.storage.SolidEntryPointsAddWithoutLocking(sep.BlockID, sep.Index)
+	if pruneTangleHistory {
+		// finally set the new solid entry points and remove the old ones
+		p.storage.WriteLockSolidEntryPoints()
+		p.storage.ResetSolidEntryPointsWithoutLocking()
+		for _, sep := range solidEntryPoints {
+			p.storage.SolidEntryPointsAddWithoutLocking(sep.BlockID, sep.Index)
+		}
+		if err := p.storage.StoreSolidEntryPointsWithoutLocking(); err != nil {
+			p.LogPanic(err)
+		}
+		p.storage.WriteUnlockSolidEntryPoints()
 	}
-	if err = p.storage.StoreSolidEntryPointsWithoutLocking(); err != nil {
-		p.LogPanic(err)
+
+	// the receipts and the protocol parameters are not stored per milestone, so they are pruned at once
+	if receiptsTargetIndex := classTargetIndexes[storagepkg.PruningClassReceipts]; classPruningIndexes[storagepkg.PruningClassReceipts] < receiptsTargetIndex {
+		if err := p.storage.UTXOManager().PruneReceiptsWithoutLocking(receiptsTargetIndex); err != nil {
+			return 0, err
+		}
+		if err := p.storage.SetPruningIndexesOfClasses(map[storagepkg.PruningClass]iotago.MilestoneIndex{storagepkg.PruningClassReceipts: receiptsTargetIndex}); err != nil {
+			p.LogPanic(err)
+		}
 	}
-	p.storage.WriteUnlockSolidEntryPoints()
 
-	if err := p.storage.PruneProtocolParameterMilestoneOptions(targetIndex); err != nil {
-		return 0, err
+	if protocolParamsTargetIndex := classTargetIndexes[storagepkg.PruningClassProtocolParams]; classPruningIndexes[storagepkg.PruningClassProtocolParams] < protocolParamsTargetIndex {
+		if err := p.storage.PruneProtocolParameterMilestoneOptions(protocolParamsTargetIndex); err != nil {
+			return 0, err
+		}
+		if err := p.storage.SetPruningIndexesOfClasses(map[storagepkg.PruningClass]iotago.MilestoneIndex{storagepkg.PruningClassProtocolParams: protocolParamsTargetIndex}); err != nil {
+			p.LogPanic(err)
+		}
 	}
 
 	return targetIndex, nil
@@ -0,0 +1,149 @@
+package pruning
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	storagepkg "github.com/iotaledger/hornet/pkg/model/storage"
+	"github.com/iotaledger/hornet/pkg/model/syncmanager"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func TestCalcTargetIndexByConeSizes(t *testing.T) {
+
+	uniformConeSize := func(index iotago.MilestoneIndex) *storagepkg.MilestoneConeSize {
+		return &storagepkg.MilestoneConeSize{Index: index, Blocks: 50, LedgerDiffs: 50}
+	}
+
+	tests := []struct {
+		name            string
+		pruningIndex    iotago.MilestoneIndex
+		confirmedIndex  iotago.MilestoneIndex
+		freePercentage  float64
+		classRetentions map[storagepkg.PruningClass]syncmanager.MilestoneIndexDelta
+		// returns the size of the milestone cone, or nil if it is unknown.
+		coneSize    func(index iotago.MilestoneIndex) *storagepkg.MilestoneConeSize
+		targetIndex iotago.MilestoneIndex
+		err         error
+	}{
+		{
+			name:           "no sizes known",
+			confirmedIndex: 100,
+			freePercentage: 0.3,
+			coneSize:       func(iotago.MilestoneIndex) *storagepkg.MilestoneConeSize { return nil },
+			targetIndex:    30,
+		},
+		{
+			name:           "uniform sizes",
+			confirmedIndex: 100,
+			freePercentage: 0.5,
+			coneSize:       uniformConeSize,
+			targetIndex:    50,
+		},
+		{
+			name:           "traffic spike",
+			confirmedIndex: 100,
+			freePercentage: 0.5,
+			coneSize: func(index iotago.MilestoneIndex) *storagepkg.MilestoneConeSize {
+				if index <= 10 {
+					return &storagepkg.MilestoneConeSize{Index: index, Blocks: 1000}
+				}
+				return &storagepkg.MilestoneConeSize{Index: index, Blocks: 10}
+			},
+			// 10900 bytes in total, the first 6 milestones free 6000 bytes
+			targetIndex: 6,
+		},
+		{
+			name:           "unknown sizes are estimated with the average",
+			confirmedIndex: 100,
+			freePercentage: 0.25,
+			coneSize: func(index iotago.MilestoneIndex) *storagepkg.MilestoneConeSize {
+				if index <= 50 {
+					return nil
+				}
+				return uniformConeSize(index)
+			},
+			targetIndex: 25,
+		},
+		{
+			name:           "sizes below the pruning index are ignored",
+			pruningIndex:   50,
+			confirmedIndex: 100,
+			freePercentage: 0.5,
+			coneSize: func(index iotago.MilestoneIndex) *storagepkg.MilestoneConeSize {
+				if index <= 50 {
+					return &storagepkg.MilestoneConeSize{Index: index, Blocks: 1000}
+				}
+				return uniformConeSize(index)
+			},
+			targetIndex: 75,
+		},
+		{
+			name:           "retained classes are not freed",
+			confirmedIndex: 100,
+			freePercentage: 0.25,
+			classRetentions: map[storagepkg.PruningClass]syncmanager.MilestoneIndexDelta{
+				storagepkg.PruningClassLedgerDiffs: 100,
+			},
+			coneSize:    uniformConeSize,
+			targetIndex: 50,
+		},
+		{
+			name:           "not enough bytes to free",
+			confirmedIndex: 100,
+			freePercentage: 0.9,
+			classRetentions: map[storagepkg.PruningClass]syncmanager.MilestoneIndexDelta{
+				storagepkg.PruningClassLedgerDiffs: 100,
+			},
+			coneSize:    uniformConeSize,
+			targetIndex: 100,
+		},
+		{
+			name:           "nothing to free",
+			confirmedIndex: 100,
+			freePercentage: 0,
+			coneSize:       uniformConeSize,
+			err:            ErrNoPruningNeeded,
+		},
+		{
+			name:           "no history",
+			pruningIndex:   100,
+			confirmedIndex: 100,
+			freePercentage: 0.5,
+			coneSize:       uniformConeSize,
+			err:            ErrNoPruningNeeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dbStorage, err := storagepkg.New(mapdb.NewMapDB(), mapdb.NewMapDB())
+			require.NoError(t, err)
+
+			for index := iotago.MilestoneIndex(1); index <= test.confirmedIndex; index++ {
+				if coneSize := test.coneSize(index); coneSize != nil {
+					require.NoError(t, dbStorage.StoreMilestoneConeSize(coneSize))
+				}
+			}
+
+			syncManager, err := syncmanager.New(test.confirmedIndex, nil)
+			require.NoError(t, err)
+
+			p := &Manager{
+				storage:         dbStorage,
+				syncManager:     syncManager,
+				classRetentions: test.classRetentions,
+			}
+
+			targetIndex, err := p.calcTargetIndexByConeSizes(test.pruningIndex, test.confirmedIndex, test.freePercentage)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.targetIndex, targetIndex)
+		})
+	}
+}
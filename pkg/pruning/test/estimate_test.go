@@ -0,0 +1,97 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/hornet/pkg/database"
+	"github.com/iotaledger/hornet/pkg/model/storage"
+	"github.com/iotaledger/hornet/pkg/pruning"
+	"github.com/iotaledger/hornet/pkg/testsuite"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	ProtocolVersion = 2
+	BelowMaxDepth   = 5
+	MinPoWScore     = 10
+)
+
+func countBlocks(dbStorage *storage.Storage) int {
+	var count int
+	dbStorage.ForEachBlockID(func(_ iotago.BlockID) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+func countMilestones(dbStorage *storage.Storage) int {
+	var count int
+	dbStorage.ForEachMilestoneIndex(func(_ iotago.MilestoneIndex) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+func TestEstimateMatchesPruning(t *testing.T) {
+
+	te := testsuite.SetupTestEnvironment(t, &iotago.Ed25519Address{}, 0, ProtocolVersion, BelowMaxDepth, MinPoWScore, false)
+	defer te.CleanupTestEnvironment(true)
+
+	_, _ = te.BuildTangle(10, BelowMaxDepth, 30, 10, 30,
+		nil,
+		func(blockIDs iotago.BlockIDs, blockIDsPerMilestones []iotago.BlockIDs) iotago.BlockIDs {
+			return iotago.BlockIDs{blockIDs[len(blockIDs)-1]}
+		},
+		nil,
+	)
+
+	confirmedMilestoneIndex := te.SyncManager().ConfirmedMilestoneIndex()
+	require.NoError(t, te.Storage().SetInitialSnapshotInfo(0, confirmedMilestoneIndex, 0, 0, time.Now()))
+
+	newDatabase := func() *database.Database {
+		return database.New(te.TempDir, mapdb.NewMapDB(), database.EngineMapDB, nil, nil, false, nil)
+	}
+
+	pruningManager := pruning.NewPruningManager(
+		logger.NewNopLogger(),
+		te.Storage(),
+		te.SyncManager(),
+		newDatabase(),
+		newDatabase(),
+		func() iotago.MilestoneIndex { return confirmedMilestoneIndex },
+		false,
+		0,
+		false,
+		0,
+		0,
+		0,
+		false,
+		nil,
+		nil,
+	)
+
+	targetIndex := confirmedMilestoneIndex - 10
+
+	estimate, err := pruningManager.EstimatePruneDatabaseByTargetIndex(context.Background(), targetIndex)
+	require.NoError(t, err)
+	require.Equal(t, targetIndex, estimate.TargetIndex)
+
+	blocksBefore := countBlocks(te.Storage())
+	milestonesBefore := countMilestones(te.Storage())
+
+	prunedIndex, err := pruningManager.PruneDatabaseByTargetIndex(context.Background(), targetIndex)
+	require.NoError(t, err)
+	require.Equal(t, targetIndex, prunedIndex)
+
+	// every block is only counted once, even though the walks of the estimation reach the cones of older milestones
+	require.Equal(t, blocksBefore-countBlocks(te.Storage()), estimate.Blocks+estimate.UnreferencedBlocks)
+	require.Equal(t, milestonesBefore-countMilestones(te.Storage()), estimate.Milestones)
+}
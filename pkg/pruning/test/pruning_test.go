@@ -0,0 +1,106 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/hornet/pkg/database"
+	"github.com/iotaledger/hornet/pkg/model/storage"
+	"github.com/iotaledger/hornet/pkg/model/syncmanager"
+	"github.com/iotaledger/hornet/pkg/pruning"
+	"github.com/iotaledger/hornet/pkg/testsuite"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func hasMilestoneDiff(dbStorage *storage.Storage, msIndex iotago.MilestoneIndex) bool {
+	diff, err := dbStorage.UTXOManager().MilestoneDiff(msIndex)
+	return err == nil && diff != nil
+}
+
+func hasMilestone(dbStorage *storage.Storage, msIndex iotago.MilestoneIndex) bool {
+	cachedMilestone := dbStorage.CachedMilestoneByIndexOrNil(msIndex) // milestone +1
+	if cachedMilestone == nil {
+		return false
+	}
+	cachedMilestone.Release(true) // milestone -1
+	return true
+}
+
+func TestPruningClassRetention(t *testing.T) {
+
+	te := testsuite.SetupTestEnvironment(t, &iotago.Ed25519Address{}, 0, ProtocolVersion, BelowMaxDepth, MinPoWScore, false)
+	defer te.CleanupTestEnvironment(true)
+
+	_, _ = te.BuildTangle(10, BelowMaxDepth, 30, 10, 30,
+		nil,
+		func(blockIDs iotago.BlockIDs, blockIDsPerMilestones []iotago.BlockIDs) iotago.BlockIDs {
+			return iotago.BlockIDs{blockIDs[len(blockIDs)-1]}
+		},
+		nil,
+	)
+
+	confirmedMilestoneIndex := te.SyncManager().ConfirmedMilestoneIndex()
+	require.NoError(t, te.Storage().SetInitialSnapshotInfo(0, confirmedMilestoneIndex, 0, 0, time.Now()))
+
+	newPruningManager := func(ledgerDiffsRetention syncmanager.MilestoneIndexDelta) *pruning.Manager {
+		newDatabase := func() *database.Database {
+			return database.New(te.TempDir, mapdb.NewMapDB(), database.EngineMapDB, nil, nil, false, nil)
+		}
+
+		return pruning.NewPruningManager(
+			logger.NewNopLogger(),
+			te.Storage(),
+			te.SyncManager(),
+			newDatabase(),
+			newDatabase(),
+			func() iotago.MilestoneIndex { return confirmedMilestoneIndex },
+			false,
+			0,
+			false,
+			0,
+			0,
+			0,
+			false,
+			map[storage.PruningClass]syncmanager.MilestoneIndexDelta{
+				storage.PruningClassLedgerDiffs: ledgerDiffsRetention,
+			},
+			nil,
+		)
+	}
+
+	targetIndex := confirmedMilestoneIndex - 10
+	ledgerDiffsTargetIndex := confirmedMilestoneIndex - 15
+
+	// the ledger diffs are kept longer than the tangle history
+	prunedIndex, err := newPruningManager(15).PruneDatabaseByTargetIndex(context.Background(), targetIndex)
+	require.NoError(t, err)
+	require.Equal(t, targetIndex, prunedIndex)
+
+	snapshotInfo := te.Storage().SnapshotInfo()
+	require.Equal(t, targetIndex, snapshotInfo.PruningIndex())
+	require.Equal(t, targetIndex, snapshotInfo.PruningIndexOf(storage.PruningClassMilestones))
+	require.Equal(t, ledgerDiffsTargetIndex, snapshotInfo.PruningIndexOf(storage.PruningClassLedgerDiffs))
+
+	for msIndex := iotago.MilestoneIndex(1); msIndex <= confirmedMilestoneIndex; msIndex++ {
+		require.Equal(t, msIndex > targetIndex, hasMilestone(te.Storage(), msIndex), "milestone %d", msIndex)
+		require.Equal(t, msIndex > ledgerDiffsTargetIndex, hasMilestoneDiff(te.Storage(), msIndex), "milestone diff %d", msIndex)
+	}
+
+	// the ledger diffs catch up with the tangle history once their retention allows it,
+	// even though the tangle history doesn't need to be pruned.
+	_, err = newPruningManager(10).PruneDatabaseByTargetIndex(context.Background(), targetIndex)
+	require.NoError(t, err)
+
+	snapshotInfo = te.Storage().SnapshotInfo()
+	require.Equal(t, targetIndex, snapshotInfo.PruningIndex())
+	require.Equal(t, targetIndex, snapshotInfo.PruningIndexOf(storage.PruningClassLedgerDiffs))
+
+	for msIndex := iotago.MilestoneIndex(1); msIndex <= confirmedMilestoneIndex; msIndex++ {
+		require.Equal(t, msIndex > targetIndex, hasMilestoneDiff(te.Storage(), msIndex), "milestone diff %d", msIndex)
+	}
+}
@@ -2,32 +2,195 @@ package restapi
 
 import (
 	"fmt"
+	"net"
+	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
+const (
+	// the key under which the selected backend of a request is stored in the echo context.
+	proxyBackendContextKey = "proxyBackend"
+)
+
+var (
+	// ErrNoHealthyProxyBackend defines the error if none of the backends of a route is healthy.
+	ErrNoHealthyProxyBackend = echo.NewHTTPError(http.StatusServiceUnavailable, "no healthy backend available")
+)
+
+// BalancingStrategy defines how the requests of a route are distributed between its backends.
+type BalancingStrategy string
+
+const (
+	// BalancingStrategyRoundRobin sends the requests to the healthy backends in turn.
+	BalancingStrategyRoundRobin BalancingStrategy = "roundRobin"
+	// BalancingStrategyLeastConnections sends the requests to the healthy backend with the fewest active requests.
+	BalancingStrategyLeastConnections BalancingStrategy = "leastConnections"
+)
+
+// ParseBalancingStrategy parses the given name of a balancing strategy.
+func ParseBalancingStrategy(name string) (BalancingStrategy, error) {
+	switch strategy := BalancingStrategy(name); strategy {
+	case BalancingStrategyRoundRobin, BalancingStrategyLeastConnections:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown balancing strategy: %s", name)
+	}
+}
+
+// ProxyBackendStatus is the status of a backend of a proxied route.
+type ProxyBackendStatus struct {
+	// The address of the backend.
+	Address string `json:"address"`
+	// Whether the backend receives requests.
+	Healthy bool `json:"healthy"`
+	// The amount of requests the backend currently handles.
+	ActiveConnections int64 `json:"activeConnections"`
+	// The amount of failed health checks since the last successful one.
+	FailedHealthChecks int `json:"failedHealthChecks"`
+	// The time of the last health check as unix timestamp, zero if it was never checked.
+	LastHealthCheck int64 `json:"lastHealthCheck"`
+}
+
+// ProxyRouteStatus is the status of the backends of a proxied route.
+type ProxyRouteStatus struct {
+	// The route prefix.
+	Route string `json:"route"`
+	// The backends the requests of the route are sent to.
+	Backends []*ProxyBackendStatus `json:"backends"`
+}
+
+// proxyBackend is a target the requests of a route can be sent to.
+type proxyBackend struct {
+	target *middleware.ProxyTarget
+	// the amount of requests the backend currently handles, accessed atomically.
+	activeConnections int64
+
+	// the following fields are protected by the balancer mutex.
+	healthy            bool
+	failedHealthChecks int
+	lastHealthCheck    time.Time
+	// the time the backend became unhealthy, zero if it is healthy.
+	unhealthySince time.Time
+}
+
+func (b *proxyBackend) address() string {
+	return b.target.URL.Host
+}
+
+// marks the backend as unhealthy at the given time.
+// the balancer mutex must be held.
+func (b *proxyBackend) markUnhealthy(now time.Time) {
+	b.healthy = false
+	if b.unhealthySince.IsZero() {
+		b.unhealthySince = now
+	}
+}
+
+func (b *proxyBackend) status() *ProxyBackendStatus {
+	var lastHealthCheck int64
+	if !b.lastHealthCheck.IsZero() {
+		lastHealthCheck = b.lastHealthCheck.Unix()
+	}
+
+	return &ProxyBackendStatus{
+		Address:            b.address(),
+		Healthy:            b.healthy,
+		ActiveConnections:  atomic.LoadInt64(&b.activeConnections),
+		FailedHealthChecks: b.failedHealthChecks,
+		LastHealthCheck:    lastHealthCheck,
+	}
+}
+
+// proxyRoute holds the backends of a route prefix.
+type proxyRoute struct {
+	backends []*proxyBackend
+	// the index of the next backend for round robin, accessed atomically.
+	nextIndex uint64
+}
+
+// returns the backend with the given address.
+func (r *proxyRoute) backend(address string) *proxyBackend {
+	for _, backend := range r.backends {
+		if backend.address() == address {
+			return backend
+		}
+	}
+	return nil
+}
+
+// returns the healthy backend which should handle the next request.
+// the balancer mutex must be held.
+func (r *proxyRoute) selectBackend(strategy BalancingStrategy) *proxyBackend {
+	healthy := make([]*proxyBackend, 0, len(r.backends))
+	for _, backend := range r.backends {
+		if backend.healthy {
+			healthy = append(healthy, backend)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	if strategy == BalancingStrategyLeastConnections {
+		selected := healthy[0]
+		for _, backend := range healthy[1:] {
+			if atomic.LoadInt64(&backend.activeConnections) < atomic.LoadInt64(&selected.activeConnections) {
+				selected = backend
+			}
+		}
+		return selected
+	}
+
+	return healthy[(atomic.AddUint64(&r.nextIndex, 1)-1)%uint64(len(healthy))]
+}
+
 type DynamicProxy struct {
 	group    *echo.Group
 	balancer *balancer
 }
 
 type balancer struct {
-	mutex   sync.RWMutex
-	prefix  string
-	targets map[string]*middleware.ProxyTarget
+	mutex    sync.RWMutex
+	prefix   string
+	strategy BalancingStrategy
+	targets  map[string]*proxyRoute
 }
 
+// AddTarget adds the given target as backend of the route with the name of the target.
 func (b *balancer) AddTarget(target *middleware.ProxyTarget) bool {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
-	b.targets[target.Name] = target
-	return false
+
+	route, exists := b.targets[target.Name]
+	if !exists {
+		route = &proxyRoute{}
+		b.targets[target.Name] = route
+	}
+
+	if backend := route.backend(target.URL.Host); backend != nil {
+		// the backend registered again, so it is alive
+		backend.healthy = true
+		backend.unhealthySince = time.Time{}
+		return false
+	}
+
+	route.backends = append(route.backends, &proxyBackend{
+		target:  target,
+		healthy: true,
+	})
+	return true
 }
 
+// RemoveTarget removes the route with the given prefix and all its backends.
 func (b *balancer) RemoveTarget(prefix string) bool {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
@@ -35,19 +198,97 @@ func (b *balancer) RemoveTarget(prefix string) bool {
 	return true
 }
 
+// removes the backend with the given address from the route with the given prefix.
+// the route is removed if it has no backends left.
+func (b *balancer) removeBackend(prefix string, address string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	route, exists := b.targets[prefix]
+	if !exists {
+		return false
+	}
+
+	removed := false
+	backends := make([]*proxyBackend, 0, len(route.backends))
+	for _, backend := range route.backends {
+		if backend.address() == address {
+			removed = true
+			continue
+		}
+		backends = append(backends, backend)
+	}
+	route.backends = backends
+
+	if len(route.backends) == 0 {
+		delete(b.targets, prefix)
+	}
+
+	return removed
+}
+
+// Next returns the target of the backend which was selected for the request.
 func (b *balancer) Next(c echo.Context) *middleware.ProxyTarget {
+	backend, ok := c.Get(proxyBackendContextKey).(*proxyBackend)
+	if !ok {
+		return nil
+	}
+	return backend.target
+}
+
+// returns the route with the longest prefix matching the given request.
+// the mutex must be held.
+func (b *balancer) routeWithoutLocking(c echo.Context) *proxyRoute {
+	name := strings.TrimPrefix(b.uriFromRequest(c), "/")
+
+	var matchingPrefix string
+	var matchingRoute *proxyRoute
+	for prefix, route := range b.targets {
+		if strings.HasPrefix(name, prefix) && (matchingRoute == nil || len(prefix) > len(matchingPrefix)) {
+			matchingPrefix = prefix
+			matchingRoute = route
+		}
+	}
+
+	return matchingRoute
+}
+
+// acquireBackend selects the backend which handles the request and counts the request as active connection.
+// Returns nil if no route matches the request and ErrNoHealthyProxyBackend if the route has no healthy backends.
+func (b *balancer) acquireBackend(c echo.Context) (*proxyBackend, error) {
 	b.mutex.RLock()
 	defer b.mutex.RUnlock()
 
-	uri := b.uriFromRequest(c)
+	route := b.routeWithoutLocking(c)
+	if route == nil {
+		return nil, nil
+	}
+
+	backend := route.selectBackend(b.strategy)
+	if backend == nil {
+		return nil, ErrNoHealthyProxyBackend
+	}
+	atomic.AddInt64(&backend.activeConnections, 1)
 
-	name := strings.TrimPrefix(uri, "/")
-	for k, v := range b.targets {
-		if strings.HasPrefix(name, k) {
-			return v
+	return backend, nil
+}
+
+// releaseBackend marks the request handled by the given backend as finished.
+func (b *balancer) releaseBackend(backend *proxyBackend) {
+	atomic.AddInt64(&backend.activeConnections, -1)
+}
+
+// marks all backends with the given address as unhealthy.
+func (b *balancer) markUnhealthy(address string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	for _, route := range b.targets {
+		if backend := route.backend(address); backend != nil {
+			backend.markUnhealthy(now)
 		}
 	}
-	return nil
 }
 
 func (b *balancer) uriFromRequest(c echo.Context) string {
@@ -85,10 +326,26 @@ func (b *balancer) AddTargetHostAndPort(prefix string, host string, port uint32)
 	return nil
 }
 
-func NewDynamicProxy(e *echo.Echo, prefix string) *DynamicProxy {
+// proxyTransport marks backends as unhealthy if requests to them fail,
+// so that they don't receive further requests until the next successful health check.
+type proxyTransport struct {
+	balancer *balancer
+}
+
+func (t *proxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil && req.Context().Err() == nil {
+		// the request failed, but it was not canceled by the client
+		t.balancer.markUnhealthy(req.URL.Host)
+	}
+	return resp, err
+}
+
+func NewDynamicProxy(e *echo.Echo, prefix string, strategy BalancingStrategy) *DynamicProxy {
 	balancer := &balancer{
-		prefix:  prefix,
-		targets: map[string]*middleware.ProxyTarget{},
+		prefix:   prefix,
+		strategy: strategy,
+		targets:  map[string]*proxyRoute{},
 	}
 
 	proxy := &DynamicProxy{
@@ -102,16 +359,36 @@ func (p *DynamicProxy) middleware(prefix string) echo.MiddlewareFunc {
 	config := middleware.DefaultProxyConfig
 	config.Skipper = p.balancer.skipper
 	config.Balancer = p.balancer
+	config.Transport = &proxyTransport{balancer: p.balancer}
 	config.Rewrite = map[string]string{
 		fmt.Sprintf("^%s/%s/*", p.balancer.prefix, prefix): "/$1",
 	}
-	return middleware.ProxyWithConfig(config)
+	proxyMiddleware := middleware.ProxyWithConfig(config)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		proxyHandler := proxyMiddleware(next)
+
+		return func(c echo.Context) error {
+			backend, err := p.balancer.acquireBackend(c)
+			if err != nil {
+				return err
+			}
+
+			if backend != nil {
+				defer p.balancer.releaseBackend(backend)
+				c.Set(proxyBackendContextKey, backend)
+			}
+
+			return proxyHandler(c)
+		}
+	}
 }
 
 func (p *DynamicProxy) AddGroup(prefix string) *echo.Group {
 	return p.group.Group("/" + prefix)
 }
 
+// AddReverseProxy adds the given host and port as backend of the route with the given prefix.
 func (p *DynamicProxy) AddReverseProxy(prefix string, host string, port uint32) error {
 	if err := p.balancer.AddTargetHostAndPort(prefix, host, port); err != nil {
 		return err
@@ -120,6 +397,136 @@ func (p *DynamicProxy) AddReverseProxy(prefix string, host string, port uint32)
 	return nil
 }
 
+// RemoveReverseProxy removes the route with the given prefix and all its backends.
 func (p *DynamicProxy) RemoveReverseProxy(prefix string) {
 	p.balancer.RemoveTarget(prefix)
 }
+
+// RemoveReverseProxyBackend removes the given host and port from the backends of the route with the given prefix.
+// The route is removed if it has no backends left.
+func (p *DynamicProxy) RemoveReverseProxyBackend(prefix string, host string, port uint32) bool {
+	return p.balancer.removeBackend(prefix, fmt.Sprintf("%s:%d", host, port))
+}
+
+// HasReverseProxy tells whether the route with the given prefix has any backends.
+func (p *DynamicProxy) HasReverseProxy(prefix string) bool {
+	p.balancer.mutex.RLock()
+	defer p.balancer.mutex.RUnlock()
+
+	_, exists := p.balancer.targets[prefix]
+	return exists
+}
+
+// Status returns the status of the backends of all routes, sorted by route.
+func (p *DynamicProxy) Status() []*ProxyRouteStatus {
+	p.balancer.mutex.RLock()
+	defer p.balancer.mutex.RUnlock()
+
+	routesStatus := make([]*ProxyRouteStatus, 0, len(p.balancer.targets))
+	for prefix, route := range p.balancer.targets {
+		routeStatus := &ProxyRouteStatus{
+			Route:    prefix,
+			Backends: make([]*ProxyBackendStatus, 0, len(route.backends)),
+		}
+		for _, backend := range route.backends {
+			routeStatus.Backends = append(routeStatus.Backends, backend.status())
+		}
+		routesStatus = append(routesStatus, routeStatus)
+	}
+
+	sort.Slice(routesStatus, func(i, j int) bool {
+		return routesStatus[i].Route < routesStatus[j].Route
+	})
+
+	return routesStatus
+}
+
+// CheckHealth connects to all backends to check whether they are alive.
+// Backends which are unhealthy for longer than removeUnhealthyAfter are removed,
+// routes without backends are removed as well. Removed backends are not checked anymore,
+// they have to be added again once they are alive. Returns the removed backends.
+func (p *DynamicProxy) CheckHealth(timeout time.Duration, removeUnhealthyAfter time.Duration) []*ProxyRouteStatus {
+	p.balancer.mutex.RLock()
+	addresses := make(map[string]struct{})
+	for _, route := range p.balancer.targets {
+		for _, backend := range route.backends {
+			addresses[backend.address()] = struct{}{}
+		}
+	}
+	p.balancer.mutex.RUnlock()
+
+	// backends shared by several routes are only checked once
+	var resultsLock sync.Mutex
+	results := make(map[string]error, len(addresses))
+
+	var wg sync.WaitGroup
+	for address := range addresses {
+		wg.Add(1)
+		go func(address string) {
+			defer wg.Done()
+
+			conn, err := net.DialTimeout("tcp", address, timeout)
+			if err == nil {
+				_ = conn.Close()
+			}
+
+			resultsLock.Lock()
+			defer resultsLock.Unlock()
+			results[address] = err
+		}(address)
+	}
+	wg.Wait()
+
+	p.balancer.mutex.Lock()
+	defer p.balancer.mutex.Unlock()
+
+	now := time.Now()
+	var removed []*ProxyRouteStatus
+	for prefix, route := range p.balancer.targets {
+		backends := make([]*proxyBackend, 0, len(route.backends))
+		var removedBackends []*ProxyBackendStatus
+
+		for _, backend := range route.backends {
+			err, checked := results[backend.address()]
+			if !checked {
+				// the backend was added during the health check
+				backends = append(backends, backend)
+				continue
+			}
+
+			backend.lastHealthCheck = now
+			if err == nil {
+				backend.healthy = true
+				backend.failedHealthChecks = 0
+				backend.unhealthySince = time.Time{}
+				backends = append(backends, backend)
+				continue
+			}
+
+			backend.failedHealthChecks++
+			backend.markUnhealthy(now)
+
+			if now.Sub(backend.unhealthySince) >= removeUnhealthyAfter {
+				removedBackends = append(removedBackends, backend.status())
+				continue
+			}
+			backends = append(backends, backend)
+		}
+
+		if len(removedBackends) == 0 {
+			continue
+		}
+
+		removed = append(removed, &ProxyRouteStatus{
+			Route:    prefix,
+			Backends: removedBackends,
+		})
+
+		route.backends = backends
+		if len(route.backends) == 0 {
+			delete(p.balancer.targets, prefix)
+		}
+	}
+
+	return removed
+}
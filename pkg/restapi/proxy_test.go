@@ -0,0 +1,135 @@
+package restapi
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/require"
+)
+
+// starts a backend which answers all requests with its name and adds it to the given route of the proxy.
+func addTestBackend(t *testing.T, proxy *DynamicProxy, route string, name string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(name))
+	}))
+	t.Cleanup(server.Close)
+
+	host, portStr, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	port, err := strconv.ParseUint(portStr, 10, 32)
+	require.NoError(t, err)
+
+	require.NoError(t, proxy.AddReverseProxy(route, host, uint32(port)))
+
+	return server
+}
+
+func proxyRequest(e *echo.Echo, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestDynamicProxyBalancing(t *testing.T) {
+	e := echo.New()
+	proxy := NewDynamicProxy(e, "/api", BalancingStrategyRoundRobin)
+
+	addTestBackend(t, proxy, "indexer/v1", "first")
+	second := addTestBackend(t, proxy, "indexer/v1", "second")
+
+	// the requests are distributed between both backends
+	responses := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		rec := proxyRequest(e, "/api/indexer/v1/outputs")
+		require.Equal(t, http.StatusOK, rec.Code)
+		responses[rec.Body.String()]++
+	}
+	require.Equal(t, map[string]int{"first": 2, "second": 2}, responses)
+
+	status := proxy.Status()
+	require.Len(t, status, 1)
+	require.Equal(t, "indexer/v1", status[0].Route)
+	require.Len(t, status[0].Backends, 2)
+
+	// the stopped backend doesn't receive requests anymore
+	second.Close()
+	require.Empty(t, proxy.CheckHealth(time.Second, time.Hour))
+
+	for i := 0; i < 4; i++ {
+		rec := proxyRequest(e, "/api/indexer/v1/outputs")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "first", rec.Body.String())
+	}
+
+	// the backend is removed after it was unhealthy for too long
+	removed := proxy.CheckHealth(time.Second, 0)
+	require.Len(t, removed, 1)
+	require.Equal(t, "indexer/v1", removed[0].Route)
+	require.Len(t, removed[0].Backends, 1)
+	require.Equal(t, second.Listener.Addr().String(), removed[0].Backends[0].Address)
+	require.True(t, proxy.HasReverseProxy("indexer/v1"))
+
+	status = proxy.Status()
+	require.Len(t, status[0].Backends, 1)
+	require.True(t, status[0].Backends[0].Healthy)
+	require.NotZero(t, status[0].Backends[0].LastHealthCheck)
+}
+
+func TestDynamicProxyUnhealthyRoute(t *testing.T) {
+	e := echo.New()
+	proxy := NewDynamicProxy(e, "/api", BalancingStrategyRoundRobin)
+
+	server := addTestBackend(t, proxy, "mqtt/v1", "mqtt")
+	server.Close()
+
+	// the failed request marks the backend as unhealthy
+	require.Equal(t, http.StatusBadGateway, proxyRequest(e, "/api/mqtt/v1").Code)
+	require.Equal(t, http.StatusServiceUnavailable, proxyRequest(e, "/api/mqtt/v1").Code)
+
+	// the route is removed together with its last backend
+	require.Len(t, proxy.CheckHealth(time.Second, 0), 1)
+	require.False(t, proxy.HasReverseProxy("mqtt/v1"))
+	require.Empty(t, proxy.Status())
+}
+
+func TestDynamicProxyRemoveBackend(t *testing.T) {
+	e := echo.New()
+	proxy := NewDynamicProxy(e, "/api", BalancingStrategyRoundRobin)
+
+	require.NoError(t, proxy.AddReverseProxy("indexer/v1", "localhost", 9091))
+	require.NoError(t, proxy.AddReverseProxy("indexer/v1", "localhost", 9092))
+
+	require.True(t, proxy.RemoveReverseProxyBackend("indexer/v1", "localhost", 9091))
+	require.False(t, proxy.RemoveReverseProxyBackend("indexer/v1", "localhost", 9091))
+	require.True(t, proxy.HasReverseProxy("indexer/v1"))
+
+	require.True(t, proxy.RemoveReverseProxyBackend("indexer/v1", "localhost", 9092))
+	require.False(t, proxy.HasReverseProxy("indexer/v1"))
+}
+
+func TestLeastConnections(t *testing.T) {
+	route := &proxyRoute{}
+	for i := 0; i < 3; i++ {
+		route.backends = append(route.backends, &proxyBackend{
+			target:            &middleware.ProxyTarget{Name: fmt.Sprintf("backend%d", i)},
+			healthy:           true,
+			activeConnections: int64(3 - i),
+		})
+	}
+
+	require.Equal(t, route.backends[2], route.selectBackend(BalancingStrategyLeastConnections))
+
+	// unhealthy backends are skipped
+	route.backends[2].healthy = false
+	require.Equal(t, route.backends[1], route.selectBackend(BalancingStrategyLeastConnections))
+
+	_, err := ParseBalancingStrategy("random")
+	require.Error(t, err)
+}
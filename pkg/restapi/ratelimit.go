@@ -0,0 +1,213 @@
+package restapi
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	jwtlib "github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/time/rate"
+
+	"github.com/iotaledger/hornet/pkg/jwt"
+	"github.com/iotaledger/hornet/pkg/metrics"
+)
+
+var (
+	// ErrTooManyRequests defines the error if a client exceeded its rate limit.
+	ErrTooManyRequests = echo.NewHTTPError(http.StatusTooManyRequests, "too many requests")
+)
+
+// RateLimitClass is a class of requests with its own rate limit.
+type RateLimitClass int
+
+const (
+	// RateLimitClassReads are all requests except block submissions.
+	RateLimitClassReads RateLimitClass = iota
+	// RateLimitClassBlocks are block submissions.
+	RateLimitClassBlocks
+	// RateLimitClassPoW are block submissions for which the node does the PoW.
+	RateLimitClassPoW
+)
+
+// RateLimit defines the amount of requests a client is allowed to send.
+type RateLimit struct {
+	// The amount of requests per second, zero means that the requests are not limited.
+	RequestsPerSecond float64
+	// The amount of requests which can be sent at once.
+	Burst int
+}
+
+// rateLimitClient holds the token bucket of a client for a class of requests.
+type rateLimitClient struct {
+	key      rateLimitClientKey
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimitClientKey identifies the token bucket of a client for a class of requests.
+type rateLimitClientKey struct {
+	class  RateLimitClass
+	client string
+}
+
+// RateLimiter limits the amount of requests per client, clients are identified by their JWT or their IP.
+type RateLimiter struct {
+	limits           map[RateLimitClass]RateLimit
+	maxClients       int
+	ipv6PrefixLength int
+	metrics          *metrics.RestAPIMetrics
+
+	clientsLock sync.Mutex
+	clients     map[rateLimitClientKey]*list.Element
+	// the token buckets of the clients, ordered from the most to the least recently seen.
+	clientsList *list.List
+}
+
+// NewRateLimiter creates a new RateLimiter with the given limits.
+// Classes without a limit are not limited.
+// At most maxClients token buckets are kept, the ones of the least recently seen clients are removed first.
+// IPv6 clients are identified by the prefix of their IP with the given length,
+// since a single client usually gets a whole subnet assigned.
+func NewRateLimiter(limits map[RateLimitClass]RateLimit, maxClients int, ipv6PrefixLength int, restAPIMetrics *metrics.RestAPIMetrics) *RateLimiter {
+	return &RateLimiter{
+		limits:           limits,
+		maxClients:       maxClients,
+		ipv6PrefixLength: ipv6PrefixLength,
+		metrics:          restAPIMetrics,
+		clients:          make(map[rateLimitClientKey]*list.Element),
+		clientsList:      list.New(),
+	}
+}
+
+// ClientID returns the identity of the client which sent the request.
+// Requests with a verified JWT are identified by the subject and the ID of the token, all others by their IP.
+// IPv6 clients are identified by the prefix of their IP with the given length.
+func ClientID(c echo.Context, ipv6PrefixLength int) string {
+	if token, ok := c.Get("jwt").(*jwtlib.Token); ok {
+		if claims, ok := token.Claims.(*jwt.AuthClaims); ok {
+			return fmt.Sprintf("jwt:%s:%s", claims.Subject, claims.Id)
+		}
+	}
+
+	realIP := c.RealIP()
+
+	ip := net.ParseIP(realIP)
+	if ip == nil || ip.To4() != nil {
+		return fmt.Sprintf("ip:%s", realIP)
+	}
+
+	return fmt.Sprintf("ip:%s/%d", ip.Mask(net.CIDRMask(ipv6PrefixLength, 8*net.IPv6len)), ipv6PrefixLength)
+}
+
+// Allow takes a request of the given class from the token bucket of the client.
+// If the client exceeded its rate limit, the Retry-After header is set and ErrTooManyRequests is returned.
+func (r *RateLimiter) Allow(c echo.Context, class RateLimitClass) error {
+	limit, exists := r.limits[class]
+	if !exists || limit.RequestsPerSecond <= 0 {
+		return nil
+	}
+
+	key := rateLimitClientKey{class: class, client: ClientID(c, r.ipv6PrefixLength)}
+
+	r.clientsLock.Lock()
+	client := r.clientWithoutLocking(key, limit)
+	client.lastSeen = time.Now()
+	reservation := client.limiter.ReserveN(client.lastSeen, 1)
+	r.clientsLock.Unlock()
+
+	if !reservation.OK() {
+		// the burst is zero, so the class is blocked entirely
+		r.rateLimited(class)
+		return ErrTooManyRequests
+	}
+
+	delay := reservation.Delay()
+	if delay == 0 {
+		return nil
+	}
+
+	// the request is rejected, so the tokens are given back
+	reservation.Cancel()
+	r.rateLimited(class)
+
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+	return ErrTooManyRequests
+}
+
+// returns the token bucket of the given client and marks it as the most recently seen one.
+// if the client has no token bucket yet, a new one is created and the least recently seen clients
+// are removed if there are too many.
+// clientsLock must be held.
+func (r *RateLimiter) clientWithoutLocking(key rateLimitClientKey, limit RateLimit) *rateLimitClient {
+	if element, exists := r.clients[key]; exists {
+		r.clientsList.MoveToFront(element)
+		return element.Value.(*rateLimitClient)
+	}
+
+	for r.maxClients > 0 && r.clientsList.Len() >= r.maxClients {
+		r.removeClientWithoutLocking(r.clientsList.Back())
+	}
+
+	client := &rateLimitClient{
+		key:     key,
+		limiter: rate.NewLimiter(rate.Limit(limit.RequestsPerSecond), limit.Burst),
+	}
+	r.clients[key] = r.clientsList.PushFront(client)
+
+	return client
+}
+
+// removes the token bucket of the given client.
+// clientsLock must be held.
+func (r *RateLimiter) removeClientWithoutLocking(element *list.Element) {
+	r.clientsList.Remove(element)
+	delete(r.clients, element.Value.(*rateLimitClient).key)
+}
+
+// counts the rejected requests of the given class.
+func (r *RateLimiter) rateLimited(class RateLimitClass) {
+	if r.metrics == nil {
+		return
+	}
+
+	switch class {
+	case RateLimitClassReads:
+		r.metrics.RateLimitedReadsCounter.Inc()
+	case RateLimitClassBlocks:
+		r.metrics.RateLimitedBlocksCounter.Inc()
+	case RateLimitClassPoW:
+		r.metrics.RateLimitedPoWCounter.Inc()
+	}
+}
+
+// Middleware returns a middleware which limits the requests of the class returned by the given function.
+func (r *RateLimiter) Middleware(classify func(c echo.Context) RateLimitClass) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if err := r.Allow(c, classify(c)); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// Cleanup removes the token buckets of the clients which didn't send a request for the given duration.
+func (r *RateLimiter) Cleanup(idleTimeout time.Duration) {
+	r.clientsLock.Lock()
+	defer r.clientsLock.Unlock()
+
+	// the least recently seen clients are at the back of the list
+	for element := r.clientsList.Back(); element != nil; element = r.clientsList.Back() {
+		if time.Since(element.Value.(*rateLimitClient).lastSeen) <= idleTimeout {
+			break
+		}
+		r.removeClientWithoutLocking(element)
+	}
+}
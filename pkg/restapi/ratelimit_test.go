@@ -0,0 +1,80 @@
+package restapi_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hornet/pkg/metrics"
+	"github.com/iotaledger/hornet/pkg/restapi"
+)
+
+func newContext(e *echo.Echo, remoteAddr string) echo.Context {
+	req := httptest.NewRequest(http.MethodGet, "/api/core/v2/info", nil)
+	req.RemoteAddr = remoteAddr
+	return e.NewContext(req, httptest.NewRecorder())
+}
+
+func TestRateLimiter(t *testing.T) {
+	e := echo.New()
+	restAPIMetrics := &metrics.RestAPIMetrics{}
+
+	rateLimiter := restapi.NewRateLimiter(map[restapi.RateLimitClass]restapi.RateLimit{
+		restapi.RateLimitClassReads: {RequestsPerSecond: 1, Burst: 2},
+	}, 2, 64, restAPIMetrics)
+
+	// the burst is allowed, afterwards the client has to wait
+	require.NoError(t, rateLimiter.Allow(newContext(e, "10.0.0.1:1234"), restapi.RateLimitClassReads))
+	require.NoError(t, rateLimiter.Allow(newContext(e, "10.0.0.1:1234"), restapi.RateLimitClassReads))
+
+	c := newContext(e, "10.0.0.1:1234")
+	err := rateLimiter.Allow(c, restapi.RateLimitClassReads)
+	require.True(t, errors.Is(err, restapi.ErrTooManyRequests))
+	require.Equal(t, "1", c.Response().Header().Get("Retry-After"))
+	require.Equal(t, uint32(1), restAPIMetrics.RateLimitedReadsCounter.Load())
+
+	// other clients have their own buckets
+	require.NoError(t, rateLimiter.Allow(newContext(e, "10.0.0.2:1234"), restapi.RateLimitClassReads))
+
+	// the least recently seen client is forgotten if too many clients are tracked
+	require.NoError(t, rateLimiter.Allow(newContext(e, "10.0.0.3:1234"), restapi.RateLimitClassReads))
+	require.NoError(t, rateLimiter.Allow(newContext(e, "10.0.0.1:1234"), restapi.RateLimitClassReads))
+
+	c = newContext(e, "10.0.0.3:1234")
+	require.NoError(t, rateLimiter.Allow(c, restapi.RateLimitClassReads))
+	require.True(t, errors.Is(rateLimiter.Allow(c, restapi.RateLimitClassReads), restapi.ErrTooManyRequests))
+
+	// classes without a limit are not limited
+	for i := 0; i < 10; i++ {
+		require.NoError(t, rateLimiter.Allow(newContext(e, "10.0.0.1:1234"), restapi.RateLimitClassBlocks))
+	}
+
+	// idle clients start with a full bucket again
+	time.Sleep(time.Millisecond)
+	rateLimiter.Cleanup(0)
+	require.NoError(t, rateLimiter.Allow(newContext(e, "10.0.0.1:1234"), restapi.RateLimitClassReads))
+}
+
+func TestRateLimiterIPv6Prefix(t *testing.T) {
+	e := echo.New()
+
+	rateLimiter := restapi.NewRateLimiter(map[restapi.RateLimitClass]restapi.RateLimit{
+		restapi.RateLimitClassReads: {RequestsPerSecond: 1, Burst: 1},
+	}, 10, 64, &metrics.RestAPIMetrics{})
+
+	// IPv6 clients with the same /64 prefix share their bucket
+	require.Equal(t, "ip:2001:db8:1:2::/64", restapi.ClientID(newContext(e, "[2001:db8:1:2::1]:1234"), 64))
+	require.NoError(t, rateLimiter.Allow(newContext(e, "[2001:db8:1:2::1]:1234"), restapi.RateLimitClassReads))
+	require.True(t, errors.Is(rateLimiter.Allow(newContext(e, "[2001:db8:1:2:ffff::1]:1234"), restapi.RateLimitClassReads), restapi.ErrTooManyRequests))
+
+	// other prefixes have their own buckets
+	require.NoError(t, rateLimiter.Allow(newContext(e, "[2001:db8:1:3::1]:1234"), restapi.RateLimitClassReads))
+
+	// IPv4 clients are identified by their full IP
+	require.Equal(t, "ip:10.0.0.1", restapi.ClientID(newContext(e, "10.0.0.1:1234"), 64))
+}
@@ -36,8 +36,47 @@ const (
 	// ParameterPeerID is used to identify a peer.
 	ParameterPeerID = "peerID"
 
+	// ParameterAddress is used to identify an address.
+	ParameterAddress = "address"
+
+	// ParameterAliasID is used to identify an alias by its ID.
+	ParameterAliasID = "aliasID"
+
+	// ParameterNFTID is used to identify a nft by its ID.
+	ParameterNFTID = "nftID"
+
+	// ParameterFoundryID is used to identify a foundry by its ID.
+	ParameterFoundryID = "foundryID"
+
+	// ParameterSnapshotJobID is used to identify a snapshot job by its ID.
+	ParameterSnapshotJobID = "jobID"
+
 	// QueryParameterOutputType is used to filter for a certain output type.
 	QueryParameterOutputType = "type"
+
+	// QueryParameterTopics is used to filter for certain event topics.
+	QueryParameterTopics = "topics"
+
+	// QueryParameterPageSize is used to define the page size for the results.
+	QueryParameterPageSize = "pageSize"
+
+	// QueryParameterCursor is used to pass the offset we want to start the next results from.
+	QueryParameterCursor = "cursor"
+
+	// QueryParameterStartIndex is used to filter for results starting at the given milestone index.
+	QueryParameterStartIndex = "startIndex"
+
+	// QueryParameterEndIndex is used to filter for results up to and including the given milestone index.
+	QueryParameterEndIndex = "endIndex"
+
+	// QueryParameterStartTimestamp is used to filter for results starting at the given unix timestamp.
+	QueryParameterStartTimestamp = "startTimestamp"
+
+	// QueryParameterEndTimestamp is used to filter for results up to and including the given unix timestamp.
+	QueryParameterEndTimestamp = "endTimestamp"
+
+	// QueryParameterAtIndex is used to query the ledger state as it was at the given milestone index.
+	QueryParameterAtIndex = "atIndex"
 )
 
 var (
@@ -184,6 +223,72 @@ func ParsePeerIDParam(c echo.Context) (peer.ID, error) {
 	return peerID, nil
 }
 
+func ParseBech32AddressParam(c echo.Context, prefix iotago.NetworkPrefix) (iotago.Address, error) {
+	addressParam := strings.ToLower(c.Param(ParameterAddress))
+
+	hrp, bech32Address, err := iotago.ParseBech32(addressParam)
+	if err != nil {
+		return nil, errors.WithMessagef(ErrInvalidParameter, "invalid address: %s, error: %s", addressParam, err)
+	}
+
+	if hrp != prefix {
+		return nil, errors.WithMessagef(ErrInvalidParameter, "invalid bech32 address, expected prefix: %s", prefix)
+	}
+
+	return bech32Address, nil
+}
+
+func ParseAliasIDParam(c echo.Context) (*iotago.AliasID, error) {
+	aliasIDParam := strings.ToLower(c.Param(ParameterAliasID))
+
+	aliasIDBytes, err := iotago.DecodeHex(aliasIDParam)
+	if err != nil {
+		return nil, errors.WithMessagef(ErrInvalidParameter, "invalid alias ID: %s, error: %s", aliasIDParam, err)
+	}
+
+	if len(aliasIDBytes) != iotago.AliasIDLength {
+		return nil, errors.WithMessagef(ErrInvalidParameter, "invalid alias ID: %s, invalid length: %d", aliasIDParam, len(aliasIDBytes))
+	}
+
+	var aliasID iotago.AliasID
+	copy(aliasID[:], aliasIDBytes)
+	return &aliasID, nil
+}
+
+func ParseNFTIDParam(c echo.Context) (*iotago.NFTID, error) {
+	nftIDParam := strings.ToLower(c.Param(ParameterNFTID))
+
+	nftIDBytes, err := iotago.DecodeHex(nftIDParam)
+	if err != nil {
+		return nil, errors.WithMessagef(ErrInvalidParameter, "invalid NFT ID: %s, error: %s", nftIDParam, err)
+	}
+
+	if len(nftIDBytes) != iotago.NFTIDLength {
+		return nil, errors.WithMessagef(ErrInvalidParameter, "invalid NFT ID: %s, invalid length: %d", nftIDParam, len(nftIDBytes))
+	}
+
+	var nftID iotago.NFTID
+	copy(nftID[:], nftIDBytes)
+	return &nftID, nil
+}
+
+func ParseFoundryIDParam(c echo.Context) (*iotago.FoundryID, error) {
+	foundryIDParam := strings.ToLower(c.Param(ParameterFoundryID))
+
+	foundryIDBytes, err := iotago.DecodeHex(foundryIDParam)
+	if err != nil {
+		return nil, errors.WithMessagef(ErrInvalidParameter, "invalid foundry ID: %s, error: %s", foundryIDParam, err)
+	}
+
+	if len(foundryIDBytes) != iotago.FoundryIDLength {
+		return nil, errors.WithMessagef(ErrInvalidParameter, "invalid foundry ID: %s, invalid length: %d", foundryIDParam, len(foundryIDBytes))
+	}
+
+	var foundryID iotago.FoundryID
+	copy(foundryID[:], foundryIDBytes)
+	return &foundryID, nil
+}
+
 func ParseOutputTypeQueryParam(c echo.Context) (*iotago.OutputType, error) {
 	typeParam := strings.ToLower(c.QueryParam(QueryParameterOutputType))
 	var filteredType *iotago.OutputType
@@ -203,3 +308,50 @@ func ParseOutputTypeQueryParam(c echo.Context) (*iotago.OutputType, error) {
 	}
 	return filteredType, nil
 }
+
+func parseUint32QueryParam(c echo.Context, paramName string) (uint32, error) {
+	param := c.QueryParam(paramName)
+	if len(param) == 0 {
+		return 0, nil
+	}
+
+	value, err := strconv.ParseUint(param, 10, 32)
+	if err != nil {
+		return 0, errors.WithMessagef(ErrInvalidParameter, "invalid value for query parameter \"%s\": %s, error: %s", paramName, param, err)
+	}
+
+	return uint32(value), nil
+}
+
+// ParseMilestoneIndexQueryParam parses the milestone index query parameter with the given name.
+// It returns 0 if the query parameter was not given.
+func ParseMilestoneIndexQueryParam(c echo.Context, paramName string) (iotago.MilestoneIndex, error) {
+	return parseUint32QueryParam(c, paramName)
+}
+
+// ParseUnixTimestampQueryParam parses the unix timestamp query parameter with the given name.
+// It returns 0 if the query parameter was not given.
+func ParseUnixTimestampQueryParam(c echo.Context, paramName string) (uint32, error) {
+	return parseUint32QueryParam(c, paramName)
+}
+
+// ParseCursorQueryParam parses the cursor query parameter.
+// It returns 0 if the query parameter was not given.
+func ParseCursorQueryParam(c echo.Context) (uint32, error) {
+	return parseUint32QueryParam(c, QueryParameterCursor)
+}
+
+// ParsePageSizeQueryParam parses the page size query parameter.
+// It returns maxPageSize if the query parameter was not given or exceeds maxPageSize.
+func ParsePageSizeQueryParam(c echo.Context, maxPageSize int) (int, error) {
+	pageSize, err := parseUint32QueryParam(c, QueryParameterPageSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if pageSize == 0 || int(pageSize) > maxPageSize {
+		return maxPageSize, nil
+	}
+
+	return int(pageSize), nil
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dustin/go-humanize"
@@ -24,7 +25,10 @@ const (
 
 // WriteCounter counts the number of bytes written to it. It implements to the io.Writer interface
 // and we can pass this into io.TeeReader() which will report progress on each write cycle.
+// It is safe to use the WriteCounter from multiple goroutines.
 type WriteCounter struct {
+	sync.Mutex
+
 	// context that is done when the node is shutting down.
 	ctx      context.Context
 	Expected uint64
@@ -43,6 +47,9 @@ func NewWriteCounter(ctx context.Context, expected uint64) *WriteCounter {
 }
 
 func (wc *WriteCounter) Write(p []byte) (int, error) {
+	wc.Lock()
+	defer wc.Unlock()
+
 	n := len(p)
 	wc.total += uint64(n)
 
@@ -55,6 +62,7 @@ func (wc *WriteCounter) Write(p []byte) (int, error) {
 }
 
 // PrintProgress prints the current progress.
+// The lock of the WriteCounter must be held by the caller.
 func (wc *WriteCounter) PrintProgress() {
 	if time.Since(wc.lastProgressTime) < 1*time.Second {
 		return
@@ -81,7 +89,37 @@ type DownloadTarget struct {
 	Delta string `usage:"URL of the delta snapshot file" json:"delta"`
 }
 
-func (s *Importer) filterTargets(targetNetworkID uint64, targets []*DownloadTarget) []*DownloadTarget {
+// PeerDownloader downloads snapshot files from the peers of the node.
+type PeerDownloader interface {
+	// DownloadSnapshotFiles downloads the latest snapshot files served by the peers to the given paths.
+	DownloadSnapshotFiles(ctx context.Context, targetNetworkID iotago.NetworkID, fullPath string, deltaPath string) error
+}
+
+// downloadTargetGroup is a group of download targets that serve the snapshot files for the same milestones.
+type downloadTargetGroup struct {
+	targets []*DownloadTarget
+	index   iotago.MilestoneIndex
+}
+
+func (g *downloadTargetGroup) fullURLs() []string {
+	urls := make([]string, 0, len(g.targets))
+	for _, target := range g.targets {
+		urls = append(urls, target.Full)
+	}
+	return urls
+}
+
+func (g *downloadTargetGroup) deltaURLs() []string {
+	urls := make([]string, 0, len(g.targets))
+	for _, target := range g.targets {
+		if len(target.Delta) > 0 {
+			urls = append(urls, target.Delta)
+		}
+	}
+	return urls
+}
+
+func (s *Importer) filterTargets(targetNetworkID uint64, targets []*DownloadTarget) []*downloadTargetGroup {
 
 	// check if the remote snapshot files fit the network ID and if delta fits the full snapshot.
 	checkTargetConsistency := func(targetNetworkID uint64, fullHeader *FullSnapshotHeader, deltaHeader *DeltaSnapshotHeader) error {
@@ -110,12 +148,14 @@ func (s *Importer) filterTargets(targetNetworkID uint64, targets []*DownloadTarg
 		return nil
 	}
 
-	type downloadTargetWithIndex struct {
-		target *DownloadTarget
-		index  iotago.MilestoneIndex
+	// targets that serve snapshot files for the same full snapshot and ledger index are grouped,
+	// so they can be used as mirrors for the download.
+	type downloadTargetGroupKey struct {
+		fullTargetMilestoneID iotago.MilestoneID
+		index                 iotago.MilestoneIndex
 	}
 
-	filteredTargets := []*downloadTargetWithIndex{}
+	groups := make(map[downloadTargetGroupKey]*downloadTargetGroup)
 
 	// search the latest snapshot by scanning all target headers
 	for _, target := range targets {
@@ -133,6 +173,7 @@ func (s *Importer) filterTargets(targetNetworkID uint64, targets []*DownloadTarg
 		}); err != nil {
 			// as the full snapshot URL failed to download, we commence further with our targets
 			s.LogDebugf("downloading full snapshot header from %s failed: %s", target.Full, err)
+			s.recordTargetFailure(target.Full)
 			continue
 		}
 
@@ -166,40 +207,60 @@ func (s *Importer) filterTargets(targetNetworkID uint64, targets []*DownloadTarg
 			target.Delta = ""
 		}
 
-		filteredTargets = append(filteredTargets, &downloadTargetWithIndex{
-			target: target,
-			index:  getSnapshotFilesLedgerIndex(fullHeader, deltaHeader),
-		})
+		if deltaHeader == nil {
+			// the delta snapshot file is not available on the target.
+			target.Delta = ""
+		}
+
+		key := downloadTargetGroupKey{
+			fullTargetMilestoneID: fullHeader.TargetMilestoneID,
+			index:                 getSnapshotFilesLedgerIndex(fullHeader, deltaHeader),
+		}
+
+		group, exists := groups[key]
+		if !exists {
+			group = &downloadTargetGroup{index: key.index}
+			groups[key] = group
+		}
+		group.targets = append(group.targets, target)
+	}
+
+	results := make([]*downloadTargetGroup, 0, len(groups))
+	for _, group := range groups {
+		results = append(results, group)
 	}
 
 	// sort by snapshot index, latest index first
-	sort.Slice(filteredTargets, func(i int, j int) bool {
-		return filteredTargets[i].index > filteredTargets[j].index
+	sort.Slice(results, func(i int, j int) bool {
+		return results[i].index > results[j].index
 	})
 
-	results := make([]*DownloadTarget, len(filteredTargets))
-	for i := 0; i < len(filteredTargets); i++ {
-		results[i] = filteredTargets[i].target
-	}
-
 	return results
 }
 
 // DownloadSnapshotFiles tries to download snapshots files from the given targets.
+// Targets that serve the snapshot files for the same milestones are used as mirrors.
 func (s *Importer) DownloadSnapshotFiles(ctx context.Context, targetNetworkID uint64, fullPath string, deltaPath string, targets []*DownloadTarget) error {
 
-	for _, target := range s.filterTargets(targetNetworkID, targets) {
+	for _, group := range s.filterTargets(targetNetworkID, targets) {
 
-		s.LogInfof("downloading full snapshot file from %s", target.Full)
-		if err := s.downloadFile(ctx, fullPath, target.Full); err != nil {
+		fullURLs := group.fullURLs()
+		s.LogInfof("downloading full snapshot file from %s", strings.Join(fullURLs, ", "))
+		if err := s.downloadFileFromMirrors(ctx, fullPath, fullURLs); err != nil {
+			if errors.Is(err, ErrSnapshotDownloadWasAborted) {
+				return err
+			}
 			s.LogWarn(err)
 			// as the full snapshot URL failed to download, we commence further with our targets
 			continue
 		}
 
-		if len(target.Delta) > 0 {
-			s.LogInfof("downloading delta snapshot file from %s", target.Delta)
-			if err := s.downloadFile(ctx, deltaPath, target.Delta); err != nil {
+		if deltaURLs := group.deltaURLs(); len(deltaURLs) > 0 {
+			s.LogInfof("downloading delta snapshot file from %s", strings.Join(deltaURLs, ", "))
+			if err := s.downloadFileFromMirrors(ctx, deltaPath, deltaURLs); err != nil {
+				if errors.Is(err, ErrSnapshotDownloadWasAborted) {
+					return err
+				}
 				// it is valid that no delta snapshot file is available on the target.
 				s.LogWarn(err)
 			}
@@ -230,7 +291,13 @@ func (s *Importer) downloadHeader(url string, headerConsumer func(readCloser io.
 		return fmt.Errorf("download failed, server returned status code %d", resp.StatusCode)
 	}
 
-	return headerConsumer(resp.Body)
+	reader, closeReader, err := newSnapshotHeaderReader(resp.Body)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	defer closeReader()
+
+	return headerConsumer(io.NopCloser(reader))
 }
 
 // downloads a snapshot file from the given url to the specified path.
@@ -254,6 +321,10 @@ func (s *Importer) downloadFile(ctx context.Context, path string, url string) er
 	}
 
 	tempFileName := path + ".tmp"
+
+	// the file is downloaded at once, a partial chunked download can't be resumed anymore.
+	removeDownloadState(tempFileName)
+
 	out, err := os.Create(tempFileName)
 	if err != nil {
 		return err
@@ -281,10 +352,37 @@ func (s *Importer) downloadFile(ctx context.Context, path string, url string) er
 		return fmt.Errorf("unable to close downloaded snapshot file: %w", err)
 	}
 
-	if err = os.Rename(tempFileName, path); err != nil {
-		return fmt.Errorf("unable to rename downloaded snapshot file: %w", err)
+	if err = s.finishDownloadedFile(tempFileName, path); err != nil {
+		return err
 	}
 
 	ok = true
 	return nil
 }
+
+// verifies and decompresses a downloaded compressed snapshot file, or moves an uncompressed one to the specified path.
+func (s *Importer) finishDownloadedFile(tempFilePath string, path string) error {
+	compressedInfo, err := ReadCompressedSnapshotInfoFromFile(tempFilePath)
+	if err != nil {
+		return fmt.Errorf("unable to read downloaded snapshot file: %w", err)
+	}
+
+	if compressedInfo != nil {
+		// compressed snapshot files are verified and stored uncompressed,
+		// because the node needs to be able to append to the snapshot files later on.
+		s.LogInfof("verifying and decompressing downloaded snapshot file (%d bytes uncompressed, checksum %s)", compressedInfo.UncompressedLength, iotago.EncodeHex(compressedInfo.Checksum[:]))
+		if _, err = DecompressSnapshotFile(tempFilePath, path); err != nil {
+			// the downloaded data is corrupted, it needs to be downloaded again.
+			_ = os.Remove(tempFilePath)
+			return fmt.Errorf("unable to decompress downloaded snapshot file: %w", err)
+		}
+
+		return os.Remove(tempFilePath)
+	}
+
+	if err = os.Rename(tempFilePath, path); err != nil {
+		return fmt.Errorf("unable to rename downloaded snapshot file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,666 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/ioutils"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	timeoutDownloadSnapshotProbe = 5 * time.Second
+	timeoutDownloadSnapshotChunk = 2 * time.Minute
+
+	// the maximum amount of attempts to download a single chunk before the download is aborted.
+	maxDownloadChunkAttempts = 5
+	// the amount of consecutive failures after which a mirror is not used anymore for the current download.
+	maxDownloadMirrorFailures = 3
+	// the throughput that is assumed for download targets without any successful download yet.
+	defaultDownloadTargetThroughput = 1024 * 1024
+)
+
+var (
+	// ErrSnapshotDownloadRangesNotSupported is returned if a download target doesn't support HTTP range requests.
+	ErrSnapshotDownloadRangesNotSupported = errors.New("download target does not support range requests")
+	// ErrSnapshotDownloadNoHealthyMirror is returned if all mirrors of a snapshot file failed too often.
+	ErrSnapshotDownloadNoHealthyMirror = errors.New("no healthy mirror left to download the snapshot file")
+)
+
+// downloadTargetHealth keeps track of the download performance of a host.
+// It is used to prefer fast and reliable download targets.
+type downloadTargetHealth struct {
+	bytes    int64
+	duration time.Duration
+	failures int
+}
+
+// score returns the health score of the target, which is the throughput weighted by the amount of failures.
+func (h *downloadTargetHealth) score() float64 {
+	throughput := float64(defaultDownloadTargetThroughput)
+	if h.bytes > 0 && h.duration > 0 {
+		throughput = float64(h.bytes) / h.duration.Seconds()
+	}
+
+	return throughput / float64(1+h.failures*h.failures)
+}
+
+// downloadTargetHost returns the key the health of a download target is tracked by.
+// The full and delta snapshot files are usually served by the same host.
+func downloadTargetHost(targetURL string) string {
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil || parsedURL.Host == "" {
+		return targetURL
+	}
+
+	return parsedURL.Host
+}
+
+func (s *Importer) targetHealthWithoutLocking(targetURL string) *downloadTargetHealth {
+	host := downloadTargetHost(targetURL)
+
+	health, exists := s.targetHealth[host]
+	if !exists {
+		health = &downloadTargetHealth{}
+		s.targetHealth[host] = health
+	}
+
+	return health
+}
+
+// targetScore returns the health score of the host of the given URL.
+func (s *Importer) targetScore(targetURL string) float64 {
+	s.targetHealthLock.Lock()
+	defer s.targetHealthLock.Unlock()
+
+	return s.targetHealthWithoutLocking(targetURL).score()
+}
+
+func (s *Importer) recordTargetSuccess(targetURL string, bytes int64, duration time.Duration) {
+	s.targetHealthLock.Lock()
+	defer s.targetHealthLock.Unlock()
+
+	health := s.targetHealthWithoutLocking(targetURL)
+	health.bytes += bytes
+	health.duration += duration
+}
+
+func (s *Importer) recordTargetFailure(targetURL string) {
+	s.targetHealthLock.Lock()
+	defer s.targetHealthLock.Unlock()
+
+	health := s.targetHealthWithoutLocking(targetURL)
+	health.failures++
+}
+
+// sortURLsByScore sorts the given URLs by the health score of their hosts, best first.
+func (s *Importer) sortURLsByScore(urls []string) {
+	sort.SliceStable(urls, func(i int, j int) bool {
+		return s.targetScore(urls[i]) > s.targetScore(urls[j])
+	})
+}
+
+// downloadProbe contains the information about a snapshot file on a download target.
+type downloadProbe struct {
+	url string
+	// the length of the file.
+	length int64
+	// the ETag of the file, if provided by the target.
+	etag string
+	// the Last-Modified date of the file, if provided by the target.
+	lastModified string
+	// the trailer of the file, if the file is a compressed snapshot.
+	compressedInfo *CompressedSnapshotInfo
+}
+
+// probeDownloadTarget requests the trailer of the snapshot file with a range request,
+// which returns the length of the file and the checksum in case of a compressed snapshot.
+func (s *Importer) probeDownloadTarget(ctx context.Context, targetURL string) (*downloadProbe, error) {
+	probeCtx, probeCtxCancel := context.WithTimeout(ctx, timeoutDownloadSnapshotProbe)
+	defer probeCtxCancel()
+
+	req, err := http.NewRequestWithContext(probeCtx, http.MethodGet, targetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=-%d", compressedSnapshotTrailerLength))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		return nil, ErrSnapshotDownloadRangesNotSupported
+	default:
+		return nil, fmt.Errorf("server returned status code %d", resp.StatusCode)
+	}
+
+	_, _, length, err := parseContentRange(resp.Header.Get("Content-Range"))
+	if err != nil {
+		return nil, err
+	}
+
+	trailer, err := io.ReadAll(io.LimitReader(resp.Body, compressedSnapshotTrailerLength))
+	if err != nil {
+		return nil, err
+	}
+
+	probe := &downloadProbe{
+		url:          targetURL,
+		length:       length,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	if compressedInfo, err := parseCompressedSnapshotTrailer(trailer, length); err == nil {
+		probe.compressedInfo = compressedInfo
+	}
+
+	return probe, nil
+}
+
+// isMirrorOf checks if the probed file is the same as the other probed file.
+// Only compressed snapshots can be identified by their checksum,
+// uncompressed snapshots of the same milestone may differ between targets.
+func (p *downloadProbe) isMirrorOf(other *downloadProbe) bool {
+	if p.compressedInfo == nil || other.compressedInfo == nil {
+		return false
+	}
+
+	return p.length == other.length && p.compressedInfo.Checksum == other.compressedInfo.Checksum
+}
+
+// isResumable checks if the content of the probed file can be identified,
+// which is the case for compressed snapshots or if the target provides a validator.
+func (p *downloadProbe) isResumable() bool {
+	return p.compressedInfo != nil || p.etag != "" || p.lastModified != ""
+}
+
+// identity returns a string that identifies the content of the probed file.
+// It is used to check if a partial download can be resumed.
+func (p *downloadProbe) identity() string {
+	if p.compressedInfo != nil {
+		return iotago.EncodeHex(p.compressedInfo.Checksum[:])
+	}
+
+	return fmt.Sprintf("%s#%d#%s#%s", p.url, p.length, p.etag, p.lastModified)
+}
+
+// parseContentRange parses a "Content-Range: bytes <start>-<end>/<length>" header.
+func parseContentRange(contentRange string) (int64, int64, int64, error) {
+	rangeAndLength := strings.TrimPrefix(contentRange, "bytes ")
+	if rangeAndLength == contentRange {
+		return 0, 0, 0, fmt.Errorf("invalid content range: %s", contentRange)
+	}
+
+	parts := strings.Split(rangeAndLength, "/")
+	if len(parts) != 2 {
+		return 0, 0, 0, fmt.Errorf("invalid content range: %s", contentRange)
+	}
+
+	bounds := strings.Split(parts[0], "-")
+	if len(bounds) != 2 {
+		return 0, 0, 0, fmt.Errorf("invalid content range: %s", contentRange)
+	}
+
+	start, err := strconv.ParseInt(bounds[0], 10, 64)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid content range: %s", contentRange)
+	}
+
+	end, err := strconv.ParseInt(bounds[1], 10, 64)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid content range: %s", contentRange)
+	}
+
+	length, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid content range: %s", contentRange)
+	}
+
+	return start, end, length, nil
+}
+
+// downloadState is stored next to a partially downloaded snapshot file, so the download can be resumed.
+type downloadState struct {
+	// the identity of the downloaded content.
+	Identity string `json:"identity"`
+	// the length of the downloaded file.
+	Length int64 `json:"length"`
+	// the size of the chunks the file is downloaded in.
+	ChunkSize int64 `json:"chunkSize"`
+	// the indexes of the chunks that were already downloaded.
+	CompletedChunks []int `json:"completedChunks"`
+}
+
+func downloadStateFilePath(tempFilePath string) string {
+	return tempFilePath + ".state"
+}
+
+// loadDownloadState loads the state of a partial download.
+// Nil is returned if no resumable state exists for the given content.
+func loadDownloadState(tempFilePath string, identity string, length int64, chunkSize int64) *downloadState {
+	state := &downloadState{}
+	if err := ioutils.ReadJSONFromFile(downloadStateFilePath(tempFilePath), state); err != nil {
+		return nil
+	}
+
+	if state.Identity != identity || state.Length != length || state.ChunkSize != chunkSize {
+		return nil
+	}
+
+	fileInfo, err := os.Stat(tempFilePath)
+	if err != nil || fileInfo.Size() != length {
+		return nil
+	}
+
+	return state
+}
+
+func storeDownloadState(tempFilePath string, state *downloadState) error {
+	return ioutils.WriteJSONToFile(downloadStateFilePath(tempFilePath), state, 0666)
+}
+
+func removeDownloadState(tempFilePath string) {
+	// we don't need to check the error, maybe the file doesn't exist
+	_ = os.Remove(downloadStateFilePath(tempFilePath))
+}
+
+// offsetWriter writes to the file starting at the given offset.
+type offsetWriter struct {
+	file   *os.File
+	offset int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.file.WriteAt(p, w.offset)
+	w.offset += int64(n)
+
+	return n, err
+}
+
+// downloadMirror is a download target of a snapshot file that is used for a chunked download.
+type downloadMirror struct {
+	url      string
+	inflight int
+	failures int
+}
+
+// chunkedDownload downloads a snapshot file in chunks from one or more mirrors in parallel.
+type chunkedDownload struct {
+	importer *Importer
+	file     *os.File
+	filePath string
+	state    *downloadState
+	counter  *WriteCounter
+
+	mirrorsLock sync.Mutex
+	mirrors     []*downloadMirror
+
+	stateLock sync.Mutex
+}
+
+// selectMirror returns the mirror with the best health score with respect to the currently running requests.
+func (d *chunkedDownload) selectMirror() (*downloadMirror, error) {
+	d.mirrorsLock.Lock()
+	defer d.mirrorsLock.Unlock()
+
+	var best *downloadMirror
+	var bestScore float64
+	for _, mirror := range d.mirrors {
+		if mirror.failures >= maxDownloadMirrorFailures {
+			continue
+		}
+
+		score := d.importer.targetScore(mirror.url) / float64(1+mirror.inflight)
+		if best == nil || score > bestScore {
+			best = mirror
+			bestScore = score
+		}
+	}
+
+	if best == nil {
+		return nil, ErrSnapshotDownloadNoHealthyMirror
+	}
+	best.inflight++
+
+	return best, nil
+}
+
+func (d *chunkedDownload) releaseMirror(mirror *downloadMirror, success bool) {
+	d.mirrorsLock.Lock()
+	defer d.mirrorsLock.Unlock()
+
+	mirror.inflight--
+	if success {
+		mirror.failures = 0
+		return
+	}
+	mirror.failures++
+}
+
+func (d *chunkedDownload) chunkBounds(chunkIndex int) (int64, int64) {
+	start := int64(chunkIndex) * d.state.ChunkSize
+	end := start + d.state.ChunkSize - 1
+	if end >= d.state.Length {
+		end = d.state.Length - 1
+	}
+
+	return start, end
+}
+
+// downloadChunk downloads a single chunk from the given mirror and writes it to the file.
+func (d *chunkedDownload) downloadChunk(ctx context.Context, mirror *downloadMirror, chunkIndex int) error {
+	start, end := d.chunkBounds(chunkIndex)
+
+	chunkCtx, chunkCtxCancel := context.WithTimeout(ctx, timeoutDownloadSnapshotChunk)
+	defer chunkCtxCancel()
+
+	req, err := http.NewRequestWithContext(chunkCtx, http.MethodGet, mirror.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	ts := time.Now()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("server returned status code %d", resp.StatusCode)
+	}
+
+	rangeStart, rangeEnd, length, err := parseContentRange(resp.Header.Get("Content-Range"))
+	if err != nil {
+		return err
+	}
+	if rangeStart != start || rangeEnd != end || length != d.state.Length {
+		return fmt.Errorf("server returned wrong content range: %s", resp.Header.Get("Content-Range"))
+	}
+
+	written, err := io.Copy(&offsetWriter{file: d.file, offset: start}, io.TeeReader(io.LimitReader(resp.Body, end-start+1), d.counter))
+	if err != nil {
+		return err
+	}
+	if written != end-start+1 {
+		return fmt.Errorf("chunk incomplete: %d/%d bytes", written, end-start+1)
+	}
+
+	d.importer.recordTargetSuccess(mirror.url, written, time.Since(ts))
+
+	return nil
+}
+
+// completeChunk marks the chunk as completed and stores the state, so the download can be resumed.
+func (d *chunkedDownload) completeChunk(chunkIndex int) error {
+	d.stateLock.Lock()
+	defer d.stateLock.Unlock()
+
+	d.state.CompletedChunks = append(d.state.CompletedChunks, chunkIndex)
+
+	// the data of the chunk needs to be persisted before the state is updated.
+	if err := d.file.Sync(); err != nil {
+		return err
+	}
+
+	return storeDownloadState(d.filePath, d.state)
+}
+
+// run downloads all missing chunks with the given amount of parallel requests.
+func (d *chunkedDownload) run(ctx context.Context, parallelism int) error {
+	chunksCount := int((d.state.Length + d.state.ChunkSize - 1) / d.state.ChunkSize)
+
+	completedChunks := make(map[int]struct{}, len(d.state.CompletedChunks))
+	for _, chunkIndex := range d.state.CompletedChunks {
+		completedChunks[chunkIndex] = struct{}{}
+	}
+
+	pendingChunks := make(chan int, chunksCount)
+	for chunkIndex := 0; chunkIndex < chunksCount; chunkIndex++ {
+		if _, completed := completedChunks[chunkIndex]; !completed {
+			pendingChunks <- chunkIndex
+		}
+	}
+
+	remainingChunks := len(pendingChunks)
+	if remainingChunks == 0 {
+		return nil
+	}
+
+	downloadCtx, downloadCtxCancel := context.WithCancel(ctx)
+	defer downloadCtxCancel()
+
+	var downloadErr error
+	var downloadLock sync.Mutex
+	chunkAttempts := make(map[int]int)
+
+	// marks the chunk as done, schedules a retry or aborts the whole download.
+	handleChunkResult := func(chunkIndex int, err error) {
+		downloadLock.Lock()
+		defer downloadLock.Unlock()
+
+		if err == nil {
+			remainingChunks--
+			if remainingChunks == 0 {
+				close(pendingChunks)
+			}
+			return
+		}
+
+		chunkAttempts[chunkIndex]++
+		if chunkAttempts[chunkIndex] >= maxDownloadChunkAttempts || errors.Is(err, ErrSnapshotDownloadNoHealthyMirror) || errors.Is(err, ErrSnapshotDownloadWasAborted) {
+			if downloadErr == nil {
+				downloadErr = err
+			}
+			downloadCtxCancel()
+			return
+		}
+
+		// retry the chunk, probably on another mirror
+		pendingChunks <- chunkIndex
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-downloadCtx.Done():
+					return
+
+				case chunkIndex, ok := <-pendingChunks:
+					if !ok {
+						return
+					}
+
+					mirror, err := d.selectMirror()
+					if err != nil {
+						handleChunkResult(chunkIndex, err)
+						continue
+					}
+
+					err = d.downloadChunk(downloadCtx, mirror, chunkIndex)
+					d.releaseMirror(mirror, err == nil)
+					if err != nil {
+						if ctx.Err() != nil {
+							handleChunkResult(chunkIndex, ErrSnapshotDownloadWasAborted)
+							continue
+						}
+
+						d.importer.recordTargetFailure(mirror.url)
+						d.importer.LogWarnf("downloading chunk %d of snapshot file from %s failed: %s", chunkIndex, mirror.url, err)
+						handleChunkResult(chunkIndex, err)
+						continue
+					}
+
+					handleChunkResult(chunkIndex, d.completeChunk(chunkIndex))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if downloadErr != nil {
+		return downloadErr
+	}
+
+	return ctx.Err()
+}
+
+// downloadFileFromMirrors downloads a snapshot file from the given URLs, which should serve the same file.
+// If the targets support range requests, the file is downloaded in chunks in parallel
+// from all targets that serve exactly the same file, and the download can be resumed after a failure.
+// Otherwise the file is downloaded from the first target that succeeds.
+func (s *Importer) downloadFileFromMirrors(ctx context.Context, path string, urls []string) error {
+	s.sortURLsByScore(urls)
+
+	var probes []*downloadProbe
+	for _, targetURL := range urls {
+		probe, err := s.probeDownloadTarget(ctx, targetURL)
+		if err != nil {
+			if !errors.Is(err, ErrSnapshotDownloadRangesNotSupported) {
+				s.recordTargetFailure(targetURL)
+			}
+			s.LogDebugf("probing snapshot file on %s failed: %s", targetURL, err)
+			continue
+		}
+		probes = append(probes, probe)
+	}
+
+	if len(probes) == 0 {
+		// none of the targets supports range requests, download the whole file at once
+		var err error
+		for _, targetURL := range urls {
+			if err = s.downloadFile(ctx, path, targetURL); err == nil {
+				return nil
+			}
+			s.recordTargetFailure(targetURL)
+			s.LogWarn(err)
+		}
+		return err
+	}
+
+	var err error
+	for i, primary := range probes {
+		mirrors := []*downloadMirror{{url: primary.url}}
+		for j, probe := range probes {
+			if i != j && probe.isMirrorOf(primary) {
+				mirrors = append(mirrors, &downloadMirror{url: probe.url})
+			}
+		}
+
+		if err = s.downloadFileInChunks(ctx, path, primary, mirrors); err == nil {
+			return nil
+		}
+		if errors.Is(err, ErrSnapshotDownloadWasAborted) {
+			return err
+		}
+		s.LogWarn(err)
+
+		if len(mirrors) == len(probes) {
+			// all targets were already used as mirrors
+			break
+		}
+	}
+
+	return err
+}
+
+// downloadFileInChunks downloads the probed snapshot file from the given mirrors to the specified path.
+func (s *Importer) downloadFileInChunks(ctx context.Context, path string, probe *downloadProbe, mirrors []*downloadMirror) error {
+	tempFilePath := path + ".tmp"
+
+	// the content of a target without any validator can't be identified,
+	// so a partial download is never resumed from it.
+	var state *downloadState
+	if probe.isResumable() {
+		state = loadDownloadState(tempFilePath, probe.identity(), probe.length, s.downloadChunkSize)
+	}
+	if state == nil {
+		removeDownloadState(tempFilePath)
+		state = &downloadState{
+			Identity:  probe.identity(),
+			Length:    probe.length,
+			ChunkSize: s.downloadChunkSize,
+		}
+	}
+
+	file, err := os.OpenFile(tempFilePath, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+
+	if len(state.CompletedChunks) == 0 {
+		if err := file.Truncate(probe.length); err != nil {
+			_ = file.Close()
+			return err
+		}
+	}
+
+	mirrorURLs := make([]string, len(mirrors))
+	for i, mirror := range mirrors {
+		mirrorURLs[i] = mirror.url
+	}
+
+	alreadyDownloaded := int64(len(state.CompletedChunks)) * state.ChunkSize
+	if alreadyDownloaded > probe.length {
+		alreadyDownloaded = probe.length
+	}
+	if alreadyDownloaded > 0 {
+		s.LogInfof("resuming snapshot file download (%d/%d bytes) from %s", alreadyDownloaded, probe.length, strings.Join(mirrorURLs, ", "))
+	} else {
+		s.LogInfof("downloading snapshot file (%d bytes) from %s", probe.length, strings.Join(mirrorURLs, ", "))
+	}
+
+	counter := NewWriteCounter(ctx, uint64(probe.length))
+	counter.total = uint64(alreadyDownloaded)
+	counter.last = counter.total
+
+	download := &chunkedDownload{
+		importer: s,
+		file:     file,
+		filePath: tempFilePath,
+		state:    state,
+		counter:  counter,
+		mirrors:  mirrors,
+	}
+
+	// the partial download is kept on failure, so it can be resumed later.
+	if err := download.run(ctx, s.downloadParallelism); err != nil {
+		_ = file.Close()
+		fmt.Print("\n")
+		return fmt.Errorf("download failed: %w", err)
+	}
+
+	// the progress indicator uses the same line so print a new line once it's finished downloading
+	fmt.Print("\n")
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("unable to close downloaded snapshot file: %w", err)
+	}
+	removeDownloadState(tempFilePath)
+
+	return s.finishDownloadedFile(tempFilePath, path)
+}
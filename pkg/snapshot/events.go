@@ -9,8 +9,14 @@ func SnapshotMetricsCaller(handler interface{}, params ...interface{}) {
 	handler.(func(metrics *SnapshotMetrics))(params[0].(*SnapshotMetrics))
 }
 
+// SnapshotProgressCaller is used to signal the progress of a snapshot creation.
+func SnapshotProgressCaller(handler interface{}, params ...interface{}) {
+	handler.(func(progress *SnapshotProgress))(params[0].(*SnapshotProgress))
+}
+
 type Events struct {
 	SnapshotMilestoneIndexChanged         *events.Event
 	SnapshotMetricsUpdated                *events.Event
+	SnapshotProgressUpdated               *events.Event
 	HandledConfirmedMilestoneIndexChanged *events.Event
 }
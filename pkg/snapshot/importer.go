@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -19,11 +20,19 @@ type Importer struct {
 	// the logger used to log events.
 	*logger.WrappedLogger
 
-	storage           *storage.Storage
-	snapshotFullPath  string
-	snapshotDeltaPath string
-	targetNetworkName string
-	downloadTargets   []*DownloadTarget
+	storage             *storage.Storage
+	snapshotFullPath    string
+	snapshotDeltaPath   string
+	targetNetworkName   string
+	downloadTargets     []*DownloadTarget
+	downloadParallelism int
+	downloadChunkSize   int64
+	// used to download the snapshot files from peers if no download target is available.
+	peerDownloader PeerDownloader
+
+	// the health of the download targets, keyed by host.
+	targetHealthLock sync.Mutex
+	targetHealth     map[string]*downloadTargetHealth
 }
 
 // NewSnapshotImporter creates a new snapshot manager instance.
@@ -33,15 +42,22 @@ func NewSnapshotImporter(
 	snapshotFullPath string,
 	snapshotDeltaPath string,
 	targetNetworkName string,
-	downloadTargets []*DownloadTarget) *Importer {
+	downloadTargets []*DownloadTarget,
+	downloadParallelism int,
+	downloadChunkSize int64,
+	peerDownloader PeerDownloader) *Importer {
 
 	return &Importer{
-		WrappedLogger:     logger.NewWrappedLogger(log),
-		storage:           storage,
-		snapshotFullPath:  snapshotFullPath,
-		snapshotDeltaPath: snapshotDeltaPath,
-		targetNetworkName: targetNetworkName,
-		downloadTargets:   downloadTargets,
+		WrappedLogger:       logger.NewWrappedLogger(log),
+		storage:             storage,
+		snapshotFullPath:    snapshotFullPath,
+		snapshotDeltaPath:   snapshotDeltaPath,
+		targetNetworkName:   targetNetworkName,
+		downloadTargets:     downloadTargets,
+		downloadParallelism: downloadParallelism,
+		downloadChunkSize:   downloadChunkSize,
+		peerDownloader:      peerDownloader,
+		targetHealth:        make(map[string]*downloadTargetHealth),
 	}
 }
 
@@ -70,6 +86,16 @@ func (s *Importer) ImportSnapshots(ctx context.Context) error {
 		return errors.New("no snapshot files available after snapshot download")
 	}
 
+	if err = s.decompressSnapshotFile(s.snapshotFullPath); err != nil {
+		return err
+	}
+
+	if snapAvail == snapshotAvailBoth {
+		if err = s.decompressSnapshotFile(s.snapshotDeltaPath); err != nil {
+			return err
+		}
+	}
+
 	if err = s.LoadFullSnapshotFromFile(ctx, s.snapshotFullPath, targetNetworkID); err != nil {
 		_ = s.storage.MarkDatabasesCorrupted()
 		return err
@@ -119,6 +145,26 @@ func (s *Importer) checkSnapshotFilesAvailability(fullPath string, deltaPath str
 	}
 }
 
+// replaces a compressed snapshot file with its verified uncompressed data,
+// because the node needs to be able to append to the snapshot files later on.
+func (s *Importer) decompressSnapshotFile(filePath string) error {
+	compressedInfo, err := ReadCompressedSnapshotInfoFromFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	if compressedInfo == nil {
+		return nil
+	}
+
+	s.LogInfof("verifying and decompressing snapshot file %s (%d bytes uncompressed, checksum %s)", filePath, compressedInfo.UncompressedLength, iotago.EncodeHex(compressedInfo.Checksum[:]))
+	if _, err := DecompressSnapshotFile(filePath, filePath); err != nil {
+		return fmt.Errorf("unable to decompress snapshot file %s: %w", filePath, err)
+	}
+
+	return nil
+}
+
 // ensures that the folders to both paths exists and then downloads the appropriate snapshot files.
 func (s *Importer) downloadSnapshotFiles(ctx context.Context, targetNetworkID uint64, fullPath string, deltaPath string) error {
 	fullPathDir := filepath.Dir(fullPath)
@@ -132,18 +178,33 @@ func (s *Importer) downloadSnapshotFiles(ctx context.Context, targetNetworkID ui
 		return fmt.Errorf("could not create snapshot dir '%s': %w", fullPath, err)
 	}
 
-	if len(s.downloadTargets) == 0 {
+	if len(s.downloadTargets) == 0 && s.peerDownloader == nil {
 		return ErrNoSnapshotDownloadURL
 	}
 
-	targetsJSON, err := json.MarshalIndent(s.downloadTargets, "", "   ")
-	if err != nil {
-		return fmt.Errorf("unable to marshal targets into formatted JSON: %w", err)
+	if len(s.downloadTargets) > 0 {
+		targetsJSON, err := json.MarshalIndent(s.downloadTargets, "", "   ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal targets into formatted JSON: %w", err)
+		}
+		s.LogInfof("downloading snapshot files from one of the provided sources %s", string(targetsJSON))
+
+		err = s.DownloadSnapshotFiles(ctx, targetNetworkID, fullPath, deltaPath, s.downloadTargets)
+		if err == nil {
+			s.LogInfo("snapshot download finished")
+			return nil
+		}
+
+		if s.peerDownloader == nil || errors.Is(err, ErrSnapshotDownloadWasAborted) {
+			return fmt.Errorf("unable to download snapshot files: %w", err)
+		}
+		s.LogWarnf("unable to download snapshot files from the provided sources: %s", err)
 	}
-	s.LogInfof("downloading snapshot files from one of the provided sources %s", string(targetsJSON))
 
-	if err := s.DownloadSnapshotFiles(ctx, targetNetworkID, fullPath, deltaPath, s.downloadTargets); err != nil {
-		return fmt.Errorf("unable to download snapshot files: %w", err)
+	// the download targets are not configured or not reachable, try to get the snapshot files from peers instead.
+	s.LogInfo("downloading snapshot files from peers")
+	if err := s.peerDownloader.DownloadSnapshotFiles(ctx, targetNetworkID, fullPath, deltaPath); err != nil {
+		return fmt.Errorf("unable to download snapshot files from peers: %w", err)
 	}
 
 	s.LogInfo("snapshot download finished")
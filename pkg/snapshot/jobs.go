@@ -0,0 +1,263 @@
+package snapshot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/events"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// the maximum amount of jobs that are waiting to be processed.
+	maxQueuedJobs = 16
+	// the maximum amount of finished jobs that are kept to be queried.
+	maxFinishedJobs = 32
+)
+
+var (
+	// ErrJobNotFound is returned if a snapshot job with the given ID does not exist.
+	ErrJobNotFound = errors.New("snapshot job not found")
+	// ErrJobQueueFull is returned if too many snapshot jobs are waiting to be processed.
+	ErrJobQueueFull = errors.New("snapshot job queue is full")
+	// ErrJobAlreadyFinished is returned if a finished snapshot job should be canceled.
+	ErrJobAlreadyFinished = errors.New("snapshot job already finished")
+)
+
+// JobState is the state of a snapshot job.
+type JobState string
+
+const (
+	// JobStateQueued means the job is waiting to be processed.
+	JobStateQueued JobState = "queued"
+	// JobStateRunning means the snapshot file is being created.
+	JobStateRunning JobState = "running"
+	// JobStateFinished means the snapshot file was created successfully.
+	JobStateFinished JobState = "finished"
+	// JobStateFailed means the creation of the snapshot file failed.
+	JobStateFailed JobState = "failed"
+	// JobStateCanceled means the job was canceled.
+	JobStateCanceled JobState = "canceled"
+)
+
+// Job is a scheduled creation of a snapshot file.
+type Job struct {
+	// The ID of the job.
+	ID string
+	// The type of the snapshot.
+	Type Type
+	// The target index of the snapshot.
+	TargetIndex iotago.MilestoneIndex
+	// The path of the snapshot file.
+	FilePath string
+	// The state of the job.
+	State JobState
+	// The progress of the snapshot creation.
+	Progress SnapshotProgress
+	// The error if the job failed.
+	Error error
+	// The time the job was added.
+	CreatedAt time.Time
+	// The time the job was started.
+	StartedAt time.Time
+	// The time the job was finished, failed or canceled.
+	FinishedAt time.Time
+
+	cancel context.CancelFunc
+}
+
+// Finished returns whether the job is in a final state.
+func (j *Job) Finished() bool {
+	return j.State == JobStateFinished || j.State == JobStateFailed || j.State == JobStateCanceled
+}
+
+// JobManager processes snapshot jobs one after another.
+type JobManager struct {
+	snapshotManager *Manager
+
+	jobsLock sync.RWMutex
+	jobs     map[string]*Job
+	// the IDs of the finished jobs, the oldest first.
+	finishedJobIDs []string
+
+	queue chan *Job
+}
+
+// NewJobManager creates a new JobManager.
+func NewJobManager(snapshotManager *Manager) *JobManager {
+	return &JobManager{
+		snapshotManager: snapshotManager,
+		jobs:            make(map[string]*Job),
+		queue:           make(chan *Job, maxQueuedJobs),
+	}
+}
+
+// Add schedules the creation of a snapshot file and returns a copy of the job.
+// The limits of the snapshot are checked before the job is added, so invalid jobs are rejected right away.
+func (m *JobManager) Add(snapshotType Type, targetIndex iotago.MilestoneIndex, filePath string) (*Job, error) {
+	if err := m.snapshotManager.CheckSnapshotFile(snapshotType, targetIndex, filePath); err != nil {
+		return nil, err
+	}
+
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+
+	job := &Job{
+		ID:          hex.EncodeToString(id[:]),
+		Type:        snapshotType,
+		TargetIndex: targetIndex,
+		FilePath:    filePath,
+		State:       JobStateQueued,
+		Progress: SnapshotProgress{
+			Type:        snapshotType,
+			TargetIndex: targetIndex,
+			FilePath:    filePath,
+		},
+		CreatedAt: time.Now(),
+	}
+
+	m.jobsLock.Lock()
+	defer m.jobsLock.Unlock()
+
+	select {
+	case m.queue <- job:
+	default:
+		return nil, ErrJobQueueFull
+	}
+	m.jobs[job.ID] = job
+
+	jobCopy := *job
+	return &jobCopy, nil
+}
+
+// Job returns a copy of the job with the given ID.
+func (m *JobManager) Job(id string) (*Job, error) {
+	m.jobsLock.RLock()
+	defer m.jobsLock.RUnlock()
+
+	job, exists := m.jobs[id]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+
+	jobCopy := *job
+	return &jobCopy, nil
+}
+
+// Cancel cancels the job with the given ID and returns a copy of the job.
+// A running snapshot creation is aborted asynchronously.
+func (m *JobManager) Cancel(id string) (*Job, error) {
+	m.jobsLock.Lock()
+	defer m.jobsLock.Unlock()
+
+	job, exists := m.jobs[id]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+
+	switch job.State {
+	case JobStateQueued:
+		// the job is skipped as soon as it is taken from the queue
+		m.finishJobWithoutLocking(job, JobStateCanceled, nil)
+	case JobStateRunning:
+		job.cancel()
+	default:
+		return nil, ErrJobAlreadyFinished
+	}
+
+	jobCopy := *job
+	return &jobCopy, nil
+}
+
+// Run processes the queued jobs until the given context is done.
+func (m *JobManager) Run(ctx context.Context) {
+	onSnapshotProgressUpdated := events.NewClosure(func(progress *SnapshotProgress) {
+		m.jobsLock.Lock()
+		defer m.jobsLock.Unlock()
+
+		for _, job := range m.jobs {
+			// the snapshot lock ensures that only one snapshot is created at a time,
+			// but the automatic snapshots of the node trigger progress events as well.
+			if job.State == JobStateRunning && job.Type == progress.Type && job.FilePath == progress.FilePath {
+				job.Progress = *progress
+			}
+		}
+	})
+
+	m.snapshotManager.Events.SnapshotProgressUpdated.Attach(onSnapshotProgressUpdated)
+	defer m.snapshotManager.Events.SnapshotProgressUpdated.Detach(onSnapshotProgressUpdated)
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.cancelRemainingJobs()
+			return
+
+		case job := <-m.queue:
+			m.processJob(ctx, job)
+		}
+	}
+}
+
+func (m *JobManager) processJob(ctx context.Context, job *Job) {
+	jobCtx, jobCancel := context.WithCancel(ctx)
+	defer jobCancel()
+
+	m.jobsLock.Lock()
+	if job.State != JobStateQueued {
+		// the job was canceled while it was queued
+		m.jobsLock.Unlock()
+		return
+	}
+	job.State = JobStateRunning
+	job.StartedAt = time.Now()
+	job.Progress.StartTime = job.StartedAt
+	job.cancel = jobCancel
+	m.jobsLock.Unlock()
+
+	err := m.snapshotManager.CreateSnapshotFile(jobCtx, job.Type, job.TargetIndex, job.FilePath)
+
+	m.jobsLock.Lock()
+	defer m.jobsLock.Unlock()
+
+	switch {
+	case err == nil:
+		m.finishJobWithoutLocking(job, JobStateFinished, nil)
+	case errors.Is(err, ErrSnapshotCreationWasAborted) || jobCtx.Err() != nil:
+		m.finishJobWithoutLocking(job, JobStateCanceled, err)
+	default:
+		m.finishJobWithoutLocking(job, JobStateFailed, err)
+	}
+}
+
+// cancelRemainingJobs marks all queued jobs as canceled.
+func (m *JobManager) cancelRemainingJobs() {
+	m.jobsLock.Lock()
+	defer m.jobsLock.Unlock()
+
+	for _, job := range m.jobs {
+		if job.State == JobStateQueued {
+			m.finishJobWithoutLocking(job, JobStateCanceled, nil)
+		}
+	}
+}
+
+// finishJobWithoutLocking sets the final state of the job and removes the oldest finished jobs.
+func (m *JobManager) finishJobWithoutLocking(job *Job, state JobState, err error) {
+	job.State = state
+	job.Error = err
+	job.FinishedAt = time.Now()
+
+	m.finishedJobIDs = append(m.finishedJobIDs, job.ID)
+	for len(m.finishedJobIDs) > maxFinishedJobs {
+		delete(m.jobs, m.finishedJobIDs[0])
+		m.finishedJobIDs = m.finishedJobIDs[1:]
+	}
+}
@@ -110,6 +110,7 @@ func NewSnapshotManager(
 			SnapshotMilestoneIndexChanged:         events.NewEvent(storagepkg.MilestoneIndexCaller),
 			HandledConfirmedMilestoneIndexChanged: events.NewEvent(storagepkg.MilestoneIndexCaller),
 			SnapshotMetricsUpdated:                events.NewEvent(SnapshotMetricsCaller),
+			SnapshotProgressUpdated:               events.NewEvent(SnapshotProgressCaller),
 		},
 	}
 }
@@ -187,6 +188,23 @@ func checkSnapshotLimits(
 	return nil
 }
 
+// checkDeltaSnapshotHistory checks whether the milestone diffs that are needed for a delta snapshot file
+// with the given target index on top of the given full snapshot are still available in the database.
+// If the delta snapshot file already exists, only the milestone diffs after its target index are needed.
+func checkDeltaSnapshotHistory(snapshotInfo *storagepkg.SnapshotInfo, fullHeader *FullSnapshotHeader, targetIndex iotago.MilestoneIndex, deltaSnapshotFileExists bool) error {
+
+	if targetIndex <= fullHeader.TargetMilestoneIndex {
+		return errors.Wrapf(ErrTargetIndexTooOld, "target index must be newer than the full snapshot target index: %d", fullHeader.TargetMilestoneIndex)
+	}
+
+	if ledgerDiffsPruningIndex := snapshotInfo.PruningIndexOf(storagepkg.PruningClassLedgerDiffs); !deltaSnapshotFileExists && fullHeader.TargetMilestoneIndex < ledgerDiffsPruningIndex {
+		// the milestone diffs from the full snapshot target index onwards are needed
+		return errors.Wrapf(ErrNotEnoughHistory, "milestone diffs after the full snapshot target index (%d) were already pruned (pruning index: %d)", fullHeader.TargetMilestoneIndex, ledgerDiffsPruningIndex)
+	}
+
+	return nil
+}
+
 func (s *Manager) setIsSnapshotting(value bool) {
 	s.statusLock.Lock()
 	s.statusIsSnapshotting = value
@@ -197,7 +215,67 @@ func (s *Manager) setIsSnapshotting(value bool) {
 func (s *Manager) CreateFullSnapshot(ctx context.Context, targetIndex iotago.MilestoneIndex, filePath string, writeToDatabase bool) error {
 	s.snapshotLock.Lock()
 	defer s.snapshotLock.Unlock()
-	return s.createFullSnapshotWithoutLocking(ctx, targetIndex, filePath, writeToDatabase)
+	return s.createFullSnapshotWithoutLocking(ctx, targetIndex, filePath, writeToDatabase, false)
+}
+
+// CheckSnapshotFile checks whether a full or delta snapshot file for the given target milestone index
+// can be created at the given path with the history that is currently available in the database.
+// The limits are checked again when the snapshot file is created, since the database may be pruned in the meantime.
+func (s *Manager) CheckSnapshotFile(snapshotType Type, targetIndex iotago.MilestoneIndex, filePath string) error {
+
+	snapshotInfo := s.storage.SnapshotInfo()
+	if snapshotInfo == nil {
+		return errors.Wrap(common.ErrCritical, common.ErrSnapshotInfoNotFound.Error())
+	}
+
+	switch snapshotType {
+	case Full:
+	case Delta:
+		if filePath == s.snapshotDeltaPath {
+			return errors.Wrap(ErrSnapshotCreationFailed, "the delta snapshot file of the node can't be overwritten")
+		}
+	default:
+		return errors.Wrapf(ErrSnapshotCreationFailed, "unknown snapshot type: %d", snapshotType)
+	}
+
+	if err := checkSnapshotLimits(
+		snapshotInfo,
+		s.syncManager.ConfirmedMilestoneIndex(),
+		targetIndex,
+		s.solidEntryPointCheckThresholdPast,
+		s.solidEntryPointCheckThresholdFuture,
+		false); err != nil {
+		return err
+	}
+
+	if snapshotType == Full {
+		return nil
+	}
+
+	fullHeader, err := s.readSnapshotHeaderFromFullSnapshotFile()
+	if err != nil {
+		return err
+	}
+
+	return checkDeltaSnapshotHistory(snapshotInfo, fullHeader, targetIndex, false)
+}
+
+// CreateSnapshotFile creates a full or delta snapshot file for the given target milestone index at the given path.
+// The snapshot state in the database is not modified, so the target index can be any milestone index
+// that still has enough history in the database.
+func (s *Manager) CreateSnapshotFile(ctx context.Context, snapshotType Type, targetIndex iotago.MilestoneIndex, filePath string) error {
+	s.snapshotLock.Lock()
+	defer s.snapshotLock.Unlock()
+
+	if err := s.CheckSnapshotFile(snapshotType, targetIndex, filePath); err != nil {
+		return err
+	}
+
+	if snapshotType == Delta {
+		return s.createDeltaSnapshotWithoutLocking(ctx, targetIndex, filePath, false)
+	}
+
+	return s.createFullSnapshotWithoutLocking(ctx, targetIndex, filePath, false, true)
 }
 
 // optimalSnapshotType returns the optimal snapshot type
@@ -280,9 +358,9 @@ func (s *Manager) HandleNewConfirmedMilestoneEvent(ctx context.Context, confirme
 
 		switch snapshotType {
 		case Full:
-			err = s.createFullSnapshotWithoutLocking(ctx, confirmedMilestoneIndex-s.snapshotDepth, s.snapshotTypeFilePath(snapshotType), true)
+			err = s.createFullSnapshotWithoutLocking(ctx, confirmedMilestoneIndex-s.snapshotDepth, s.snapshotTypeFilePath(snapshotType), true, false)
 		case Delta:
-			err = s.createDeltaSnapshotWithoutLocking(ctx, confirmedMilestoneIndex-s.snapshotDepth)
+			err = s.createDeltaSnapshotWithoutLocking(ctx, confirmedMilestoneIndex-s.snapshotDepth, s.snapshotDeltaPath, true)
 		}
 
 		if err != nil {
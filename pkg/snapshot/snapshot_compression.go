@@ -0,0 +1,503 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/iotaledger/hive.go/ioutils"
+)
+
+// A compressed snapshot file consists of the zstd compressed data of an uncompressed snapshot file,
+// followed by a trailer that contains the length and the checksum of the uncompressed data.
+// The trailer is stored in a zstd skippable frame, so the file can also be decompressed with the zstd command line tool.
+//
+// The trailer has the following layout:
+//	skippable frame magic (4 bytes) | frame size (4 bytes) | trailer magic (4 bytes) | trailer version (1 byte) |
+//	uncompressed length (8 bytes) | blake2b-256 checksum of the uncompressed data (32 bytes)
+
+const (
+	// the magic number at the start of every zstd frame.
+	zstdFrameMagic uint32 = 0xFD2FB528
+	// the magic number of the zstd skippable frame that contains the trailer.
+	zstdSkippableFrameMagic uint32 = 0x184D2A5E
+
+	// CompressedSnapshotTrailerVersion defines the supported version of the compressed snapshot trailer.
+	CompressedSnapshotTrailerVersion byte = 1
+
+	// the length of the trailer without the skippable frame header.
+	compressedSnapshotTrailerPayloadLength = 4 + 1 + 8 + blake2b.Size256
+	// the length of the trailer including the skippable frame header.
+	compressedSnapshotTrailerLength = 4 + 4 + compressedSnapshotTrailerPayloadLength
+)
+
+var (
+	// the magic bytes that identify the trailer of a compressed snapshot file.
+	compressedSnapshotTrailerMagic = [4]byte{'H', 'S', 'N', 'P'}
+)
+
+var (
+	// ErrInvalidCompressedSnapshot is returned if a compressed snapshot file has no valid trailer.
+	ErrInvalidCompressedSnapshot = errors.New("invalid compressed snapshot file")
+	// ErrSnapshotChecksumMismatch is returned if the checksum of the decompressed data doesn't match the trailer of a compressed snapshot file.
+	ErrSnapshotChecksumMismatch = errors.New("snapshot file checksum mismatch")
+	// ErrSnapshotAlreadyCompressed is returned if a compressed snapshot file should be compressed again.
+	ErrSnapshotAlreadyCompressed = errors.New("snapshot file is already compressed")
+)
+
+// CompressedSnapshotInfo contains the information of the trailer of a compressed snapshot file.
+type CompressedSnapshotInfo struct {
+	// The length of the compressed snapshot file including the trailer.
+	CompressedLength int64
+	// The length of the uncompressed snapshot data.
+	UncompressedLength uint64
+	// The blake2b-256 checksum of the uncompressed snapshot data.
+	Checksum [blake2b.Size256]byte
+}
+
+// dataLength returns the length of the compressed data without the trailer.
+func (i *CompressedSnapshotInfo) dataLength() int64 {
+	return i.CompressedLength - compressedSnapshotTrailerLength
+}
+
+func isZstdFrameMagic(magic []byte) bool {
+	return len(magic) == 4 && binary.LittleEndian.Uint32(magic) == zstdFrameMagic
+}
+
+// isCompressedSnapshot checks if the data of the given reader starts with a zstd frame and seeks back to the start.
+func isCompressedSnapshot(readSeeker io.ReadSeeker) (bool, error) {
+	if _, err := readSeeker.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+
+	magic := make([]byte, 4)
+	n, err := io.ReadFull(readSeeker, magic)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	if _, err := readSeeker.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+
+	return isZstdFrameMagic(magic[:n]), nil
+}
+
+// readCompressedSnapshotTrailer reads the trailer at the end of a compressed snapshot and seeks back to the start.
+func readCompressedSnapshotTrailer(readSeeker io.ReadSeeker) (*CompressedSnapshotInfo, error) {
+	compressedLength, err := readSeeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	if compressedLength < compressedSnapshotTrailerLength+4 {
+		return nil, errors.Wrap(ErrInvalidCompressedSnapshot, "file too short")
+	}
+
+	if _, err := readSeeker.Seek(-compressedSnapshotTrailerLength, io.SeekEnd); err != nil {
+		return nil, err
+	}
+
+	trailer := make([]byte, compressedSnapshotTrailerLength)
+	if _, err := io.ReadFull(readSeeker, trailer); err != nil {
+		return nil, fmt.Errorf("unable to read compressed snapshot trailer: %w", err)
+	}
+
+	if _, err := readSeeker.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	return parseCompressedSnapshotTrailer(trailer, compressedLength)
+}
+
+// parseCompressedSnapshotTrailer parses the trailer of a compressed snapshot with the given total length.
+func parseCompressedSnapshotTrailer(trailer []byte, compressedLength int64) (*CompressedSnapshotInfo, error) {
+	if len(trailer) != compressedSnapshotTrailerLength {
+		return nil, errors.Wrap(ErrInvalidCompressedSnapshot, "invalid trailer length")
+	}
+
+	if binary.LittleEndian.Uint32(trailer[0:4]) != zstdSkippableFrameMagic ||
+		binary.LittleEndian.Uint32(trailer[4:8]) != compressedSnapshotTrailerPayloadLength ||
+		!bytes.Equal(trailer[8:12], compressedSnapshotTrailerMagic[:]) {
+		return nil, errors.Wrap(ErrInvalidCompressedSnapshot, "trailer not found")
+	}
+
+	if trailer[12] != CompressedSnapshotTrailerVersion {
+		return nil, errors.Wrapf(ErrInvalidCompressedSnapshot, "unsupported trailer version: %d", trailer[12])
+	}
+
+	info := &CompressedSnapshotInfo{
+		CompressedLength:   compressedLength,
+		UncompressedLength: binary.LittleEndian.Uint64(trailer[13:21]),
+	}
+	copy(info.Checksum[:], trailer[21:])
+
+	return info, nil
+}
+
+// writeCompressedSnapshotTrailer writes the trailer of a compressed snapshot.
+func writeCompressedSnapshotTrailer(writer io.Writer, uncompressedLength uint64, checksum []byte) error {
+	trailer := make([]byte, compressedSnapshotTrailerLength)
+	binary.LittleEndian.PutUint32(trailer[0:4], zstdSkippableFrameMagic)
+	binary.LittleEndian.PutUint32(trailer[4:8], compressedSnapshotTrailerPayloadLength)
+	copy(trailer[8:12], compressedSnapshotTrailerMagic[:])
+	trailer[12] = CompressedSnapshotTrailerVersion
+	binary.LittleEndian.PutUint64(trailer[13:21], uncompressedLength)
+	copy(trailer[21:], checksum)
+
+	if _, err := writer.Write(trailer); err != nil {
+		return fmt.Errorf("unable to write compressed snapshot trailer: %w", err)
+	}
+
+	return nil
+}
+
+// compressedSnapshotReader decompresses the data of a compressed snapshot file.
+// Seeking forward skips the decompressed data, seeking backwards restarts the decompression from the beginning.
+type compressedSnapshotReader struct {
+	source  io.ReadSeeker
+	info    *CompressedSnapshotInfo
+	decoder *zstd.Decoder
+	pos     int64
+}
+
+func newCompressedSnapshotReader(source io.ReadSeeker, info *CompressedSnapshotInfo) (*compressedSnapshotReader, error) {
+	if _, err := source.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	decoder, err := zstd.NewReader(io.LimitReader(source, info.dataLength()), zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+
+	return &compressedSnapshotReader{
+		source:  source,
+		info:    info,
+		decoder: decoder,
+	}, nil
+}
+
+func (r *compressedSnapshotReader) Read(p []byte) (int, error) {
+	n, err := r.decoder.Read(p)
+	r.pos += int64(n)
+
+	return n, err
+}
+
+func (r *compressedSnapshotReader) Seek(offset int64, whence int) (int64, error) {
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = r.pos + offset
+	case io.SeekEnd:
+		target = int64(r.info.UncompressedLength) + offset
+	default:
+		return r.pos, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	if target < 0 || target > int64(r.info.UncompressedLength) {
+		return r.pos, fmt.Errorf("invalid seek position in compressed snapshot: %d", target)
+	}
+
+	if target < r.pos {
+		if _, err := r.source.Seek(0, io.SeekStart); err != nil {
+			return r.pos, err
+		}
+		if err := r.decoder.Reset(io.LimitReader(r.source, r.info.dataLength())); err != nil {
+			return r.pos, err
+		}
+		r.pos = 0
+	}
+
+	if _, err := io.CopyN(io.Discard, r, target-r.pos); err != nil {
+		return r.pos, err
+	}
+
+	return r.pos, nil
+}
+
+func (r *compressedSnapshotReader) Close() {
+	r.decoder.Close()
+}
+
+// verifyCompressedSnapshot decompresses the whole data of a compressed snapshot
+// and checks the length and the checksum against the trailer.
+func verifyCompressedSnapshot(source io.ReadSeeker, info *CompressedSnapshotInfo) error {
+	reader, err := newCompressedSnapshotReader(source, info)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	hasher, _ := blake2b.New256(nil)
+	uncompressedLength, err := io.Copy(hasher, reader)
+	if err != nil {
+		return fmt.Errorf("unable to decompress snapshot: %w", err)
+	}
+
+	if uint64(uncompressedLength) != info.UncompressedLength {
+		return errors.Wrapf(ErrSnapshotChecksumMismatch, "uncompressed length %d != %d", uncompressedLength, info.UncompressedLength)
+	}
+
+	if !bytes.Equal(hasher.Sum(nil), info.Checksum[:]) {
+		return ErrSnapshotChecksumMismatch
+	}
+
+	_, err = source.Seek(0, io.SeekStart)
+	return err
+}
+
+// newSnapshotDataReader returns a reader for the uncompressed snapshot data of the given reader.
+// If the data is compressed, the checksum is verified before the reader is returned,
+// so corrupted snapshots are rejected before any data is consumed.
+// The returned function must be called to release the resources of the reader.
+func newSnapshotDataReader(source io.ReadSeeker) (io.ReadSeeker, func(), error) {
+	compressed, err := isCompressedSnapshot(source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !compressed {
+		return source, func() {}, nil
+	}
+
+	info, err := readCompressedSnapshotTrailer(source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := verifyCompressedSnapshot(source, info); err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := newCompressedSnapshotReader(source, info)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reader, reader.Close, nil
+}
+
+// newSnapshotHeaderReader returns a reader for the uncompressed snapshot data of the given reader,
+// which doesn't need to be seekable (e.g. a HTTP response body).
+// The checksum is not verified, so it should only be used to read snapshot headers.
+// The returned function must be called to release the resources of the reader.
+func newSnapshotHeaderReader(source io.Reader) (io.Reader, func(), error) {
+	bufferedSource := bufio.NewReader(source)
+
+	magic, err := bufferedSource.Peek(4)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, err
+	}
+
+	if !isZstdFrameMagic(magic) {
+		return bufferedSource, func() {}, nil
+	}
+
+	decoder, err := zstd.NewReader(bufferedSource, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return decoder, decoder.Close, nil
+}
+
+// ReadCompressedSnapshotInfoFromFile reads the trailer of the given compressed snapshot file.
+// Nil is returned if the file is not compressed.
+func ReadCompressedSnapshotInfoFromFile(filePath string) (*CompressedSnapshotInfo, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open snapshot file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	compressed, err := isCompressedSnapshot(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if !compressed {
+		return nil, nil
+	}
+
+	return readCompressedSnapshotTrailer(file)
+}
+
+// VerifyCompressedSnapshotFile checks the checksum of the given compressed snapshot file.
+// Nil is returned if the file is not compressed, since uncompressed snapshot files contain no checksum.
+func VerifyCompressedSnapshotFile(filePath string) (*CompressedSnapshotInfo, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open snapshot file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	compressed, err := isCompressedSnapshot(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if !compressed {
+		return nil, nil
+	}
+
+	info, err := readCompressedSnapshotTrailer(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyCompressedSnapshot(file, info); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// CompressSnapshotFile compresses the given snapshot file and writes it to the target path
+// together with a trailer that contains the checksum of the uncompressed data.
+func CompressSnapshotFile(sourcePath string, targetPath string) (*CompressedSnapshotInfo, error) {
+	sourceFile, err := os.Open(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open snapshot file: %w", err)
+	}
+	defer func() { _ = sourceFile.Close() }()
+
+	compressed, err := isCompressedSnapshot(sourceFile)
+	if err != nil {
+		return nil, err
+	}
+	if compressed {
+		return nil, ErrSnapshotAlreadyCompressed
+	}
+
+	// make sure the file is a valid snapshot file
+	if _, err := ReadSnapshotType(sourceFile); err != nil {
+		return nil, err
+	}
+
+	targetFile, tempFilePath, err := ioutils.CreateTempFile(targetPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var ok bool
+	defer func() {
+		if !ok {
+			_ = targetFile.Close()
+			_ = os.Remove(tempFilePath)
+		}
+	}()
+
+	encoder, err := zstd.NewWriter(targetFile, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+	if err != nil {
+		return nil, err
+	}
+
+	hasher, _ := blake2b.New256(nil)
+	uncompressedLength, err := io.Copy(encoder, io.TeeReader(sourceFile, hasher))
+	if err != nil {
+		_ = encoder.Close()
+		return nil, fmt.Errorf("unable to compress snapshot file: %w", err)
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("unable to compress snapshot file: %w", err)
+	}
+
+	if err := writeCompressedSnapshotTrailer(targetFile, uint64(uncompressedLength), hasher.Sum(nil)); err != nil {
+		return nil, err
+	}
+
+	compressedLength, err := targetFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ioutils.CloseFileAndRename(targetFile, tempFilePath, targetPath); err != nil {
+		return nil, err
+	}
+	ok = true
+
+	info := &CompressedSnapshotInfo{
+		CompressedLength:   compressedLength,
+		UncompressedLength: uint64(uncompressedLength),
+	}
+	copy(info.Checksum[:], hasher.Sum(nil))
+
+	return info, nil
+}
+
+// DecompressSnapshotFile verifies the checksum of the given compressed snapshot file
+// and writes the uncompressed snapshot to the target path.
+// The source and the target path may be the same.
+func DecompressSnapshotFile(sourcePath string, targetPath string) (*CompressedSnapshotInfo, error) {
+	sourceFile, err := os.Open(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open snapshot file: %w", err)
+	}
+	defer func() { _ = sourceFile.Close() }()
+
+	compressed, err := isCompressedSnapshot(sourceFile)
+	if err != nil {
+		return nil, err
+	}
+	if !compressed {
+		return nil, errors.Wrap(ErrInvalidCompressedSnapshot, "snapshot file is not compressed")
+	}
+
+	info, err := readCompressedSnapshotTrailer(sourceFile)
+	if err != nil {
+		return nil, err
+	}
+
+	targetFile, tempFilePath, err := ioutils.CreateTempFile(targetPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var ok bool
+	defer func() {
+		if !ok {
+			_ = targetFile.Close()
+			_ = os.Remove(tempFilePath)
+		}
+	}()
+
+	reader, err := newCompressedSnapshotReader(sourceFile, info)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	// the checksum is calculated while decompressing, the target file is only renamed if it matches.
+	hasher, _ := blake2b.New256(nil)
+	uncompressedLength, err := io.Copy(io.MultiWriter(targetFile, hasher), reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress snapshot file: %w", err)
+	}
+
+	if uint64(uncompressedLength) != info.UncompressedLength {
+		return nil, errors.Wrapf(ErrSnapshotChecksumMismatch, "uncompressed length %d != %d", uncompressedLength, info.UncompressedLength)
+	}
+
+	if !bytes.Equal(hasher.Sum(nil), info.Checksum[:]) {
+		return nil, ErrSnapshotChecksumMismatch
+	}
+
+	if err := ioutils.CloseFileAndRename(targetFile, tempFilePath, targetPath); err != nil {
+		return nil, err
+	}
+	ok = true
+
+	return info, nil
+}
@@ -62,6 +62,14 @@ var snapshotNames = map[Type]string{
 	Delta: "delta",
 }
 
+// String returns the name of the snapshot type.
+func (t Type) String() string {
+	if name, exists := snapshotNames[t]; exists {
+		return name
+	}
+	return fmt.Sprintf("unknown (%d)", byte(t))
+}
+
 // ReadWriteTruncateSeeker is the interface used to read, write and truncate a file.
 type ReadWriteTruncateSeeker interface {
 	io.ReadWriteSeeker
@@ -267,39 +275,51 @@ func ReadMilestoneDiff(reader io.ReadSeeker, protocolStorage *storage.ProtocolSt
 // automatically seek to the end of the MilestoneDiff.
 func ReadMilestoneDiffProtocolParameters(reader io.ReadSeeker, protocolStorage *storage.ProtocolStorage) (int64, error) {
 
+	msDiffLength, milestonePayload, err := readMilestoneDiffMilestone(reader)
+	if err != nil {
+		return 0, err
+	}
+
+	if milestonePayload.Opts.MustSet().ProtocolParams() != nil {
+		if err := protocolStorage.StoreProtocolParametersMilestoneOption(milestonePayload.Opts.MustSet().ProtocolParams()); err != nil {
+			return 0, fmt.Errorf("unable to store protocol parameters milestone option: %w", err)
+		}
+	}
+
+	return msDiffLength, nil
+}
+
+// reads the milestone of a MilestoneDiff from the given reader.
+// automatically seek to the end of the MilestoneDiff.
+func readMilestoneDiffMilestone(reader io.ReadSeeker) (int64, *iotago.Milestone, error) {
+
 	var msDiffLength uint32
 	if err := binary.Read(reader, binary.LittleEndian, &msDiffLength); err != nil {
-		return 0, fmt.Errorf("unable to read LS ms-diff length: %w", err)
+		return 0, nil, fmt.Errorf("unable to read LS ms-diff length: %w", err)
 	}
 
 	var msLength uint32
 	if err := binary.Read(reader, binary.LittleEndian, &msLength); err != nil {
-		return 0, fmt.Errorf("unable to read LS ms-diff ms length: %w", err)
+		return 0, nil, fmt.Errorf("unable to read LS ms-diff ms length: %w", err)
 	}
 
 	msBytes := make([]byte, msLength)
 	milestonePayload := &iotago.Milestone{}
 	if _, err := io.ReadFull(reader, msBytes); err != nil {
-		return 0, fmt.Errorf("unable to read LS ms-diff ms: %w", err)
+		return 0, nil, fmt.Errorf("unable to read LS ms-diff ms: %w", err)
 	}
 
 	if _, err := milestonePayload.Deserialize(msBytes, serializer.DeSeriModePerformValidation, nil); err != nil {
-		return 0, fmt.Errorf("unable to deserialize LS ms-diff ms: %w", err)
-	}
-
-	if milestonePayload.Opts.MustSet().ProtocolParams() != nil {
-		if err := protocolStorage.StoreProtocolParametersMilestoneOption(milestonePayload.Opts.MustSet().ProtocolParams()); err != nil {
-			return 0, fmt.Errorf("unable to store protocol parameters milestone option: %w", err)
-		}
+		return 0, nil, fmt.Errorf("unable to deserialize LS ms-diff ms: %w", err)
 	}
 
 	// seek to the end of the MilestoneDiff
 	// msDiffLength - msDiffLengthSize - msLengthSize - msLength
 	if _, err := reader.Seek(int64(msDiffLength-serializer.UInt32ByteSize-serializer.UInt32ByteSize-msLength), io.SeekCurrent); err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
-	return int64(msDiffLength), nil
+	return int64(msDiffLength), milestonePayload, nil
 }
 
 // ProtocolStorageGetterFunc returns a ProtocolStorage.
@@ -338,6 +358,9 @@ type SEPProducerFunc func() (iotago.BlockID, error)
 // A returned error signals to cancel further reading.
 type SEPConsumerFunc func(iotago.BlockID, iotago.MilestoneIndex) error
 
+// MilestoneConsumerFunc consumes the milestone of a milestone diff.
+type MilestoneConsumerFunc func(milestonePayload *iotago.Milestone) error
+
 // ProtocolParamsMilestoneOptConsumerFunc consumes the given ProtocolParamsMilestoneOpt.
 // A returned error signals to cancel further reading.
 type ProtocolParamsMilestoneOptConsumerFunc func(*iotago.ProtocolParamsMilestoneOpt) error
@@ -1147,7 +1170,8 @@ func ReadSnapshotType(readSeeker io.ReadSeeker) (Type, error) {
 	}
 }
 
-// ReadSnapshotHeaderFromFile reads the snapshot type of the given snapshot file.
+// ReadSnapshotTypeFromFile reads the snapshot type of the given snapshot file.
+// Compressed snapshot files are supported as well.
 func ReadSnapshotTypeFromFile(filePath string) (Type, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -1155,10 +1179,32 @@ func ReadSnapshotTypeFromFile(filePath string) (Type, error) {
 	}
 	defer func() { _ = file.Close() }()
 
-	return ReadSnapshotType(file)
+	compressed, err := isCompressedSnapshot(file)
+	if err != nil {
+		return Full, err
+	}
+
+	if !compressed {
+		return ReadSnapshotType(file)
+	}
+
+	info, err := readCompressedSnapshotTrailer(file)
+	if err != nil {
+		return Full, err
+	}
+
+	// only the start of the file is decompressed, the checksum is verified when the data is consumed.
+	reader, err := newCompressedSnapshotReader(file, info)
+	if err != nil {
+		return Full, err
+	}
+	defer reader.Close()
+
+	return ReadSnapshotType(reader)
 }
 
 // StreamFullSnapshotDataFrom consumes a full snapshot from the given reader.
+// If the snapshot is compressed, the checksum is verified before any data is consumed.
 func StreamFullSnapshotDataFrom(
 	reader io.ReadSeeker,
 	headerConsumer FullHeaderConsumerFunc,
@@ -1168,6 +1214,12 @@ func StreamFullSnapshotDataFrom(
 	sepConsumer SEPConsumerFunc,
 	protoParamsMsOptionsConsumer ProtocolParamsMilestoneOptConsumerFunc) error {
 
+	reader, closeReader, err := newSnapshotDataReader(reader)
+	if err != nil {
+		return err
+	}
+	defer closeReader()
+
 	fullHeader, err := ReadFullSnapshotHeader(reader)
 	if err != nil {
 		return err
@@ -1281,6 +1333,7 @@ func StreamFullSnapshotDataFrom(
 }
 
 // StreamDeltaSnapshotDataFrom consumes a delta snapshot from the given reader.
+// If the snapshot is compressed, the checksum is verified before any data is consumed.
 func StreamDeltaSnapshotDataFrom(
 	reader io.ReadSeeker,
 	protocolStorageGetter ProtocolStorageGetterFunc,
@@ -1294,6 +1347,12 @@ func StreamDeltaSnapshotDataFrom(
 		return err
 	}
 
+	reader, closeReader, err := newSnapshotDataReader(reader)
+	if err != nil {
+		return err
+	}
+	defer closeReader()
+
 	deltaHeader, err := ReadDeltaSnapshotHeader(reader)
 	if err != nil {
 		return err
@@ -1367,7 +1426,73 @@ func readSpent(reader io.ReadSeeker, protoParams *iotago.ProtocolParameters, msI
 	return utxo.SpentFromSnapshotReader(reader, protoParams, msIndexSpent, msTimestampSpent)
 }
 
+// StreamSnapshotMilestonesFromFile consumes the milestones of the milestone diffs of the given snapshot file,
+// in the order they are stored in the file, without reading the outputs of the milestone diffs.
+// If the snapshot is compressed, the checksum is verified before any data is consumed.
+func StreamSnapshotMilestonesFromFile(filePath string, milestoneConsumer MilestoneConsumerFunc) error {
+	snapshotType, err := ReadSnapshotTypeFromFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("unable to open snapshot file to read milestones: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	reader, closeReader, err := newSnapshotDataReader(file)
+	if err != nil {
+		return err
+	}
+	defer closeReader()
+
+	var msDiffCount uint32
+	switch snapshotType {
+	case Full:
+		fullHeader, err := ReadFullSnapshotHeader(reader)
+		if err != nil {
+			return err
+		}
+
+		protoParams, err := fullHeader.ProtocolParameters()
+		if err != nil {
+			return err
+		}
+		protoParams.RentStructure.VByteCost = 0
+
+		// the outputs have no length prefix, so they need to be read to get to the milestone diffs.
+		for i := uint64(0); i < fullHeader.OutputCount; i++ {
+			if _, err := ReadOutput(reader, protoParams); err != nil {
+				return fmt.Errorf("at pos %d: %w", i, err)
+			}
+		}
+		msDiffCount = fullHeader.MilestoneDiffCount
+
+	case Delta:
+		deltaHeader, err := ReadDeltaSnapshotHeader(reader)
+		if err != nil {
+			return err
+		}
+		msDiffCount = deltaHeader.MilestoneDiffCount
+	}
+
+	for i := uint32(0); i < msDiffCount; i++ {
+		_, milestonePayload, err := readMilestoneDiffMilestone(reader)
+		if err != nil {
+			return fmt.Errorf("at pos %d: %w", i, err)
+		}
+
+		if err := milestoneConsumer(milestonePayload); err != nil {
+			return fmt.Errorf("milestone consumer error at pos %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
 // ReadSnapshotHeaderFromFile reads the header of the given snapshot file.
+// Compressed snapshot files are supported as well.
 func ReadSnapshotHeaderFromFile(filePath string, headerConsumer func(readCloser io.ReadCloser) error) error {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -1375,7 +1500,13 @@ func ReadSnapshotHeaderFromFile(filePath string, headerConsumer func(readCloser
 	}
 	defer func() { _ = file.Close() }()
 
-	return headerConsumer(file)
+	reader, closeReader, err := newSnapshotHeaderReader(file)
+	if err != nil {
+		return err
+	}
+	defer closeReader()
+
+	return headerConsumer(io.NopCloser(reader))
 }
 
 // ReadFullSnapshotHeaderFromFile reads the header of the given full snapshot file.
@@ -344,3 +344,53 @@ func LoadSnapshotFilesToStorage(ctx context.Context, dbStorage *storage.Storage,
 
 	return fullSnapshotHeader, deltaSnapshotHeader, nil
 }
+
+// FullSnapshotLedgerStateCommitment computes the commitment of the ledger state at the target milestone index
+// of the given full snapshot file, without loading the snapshot into a database.
+// The result can be compared with the ledger state commitment of a node at the same milestone index.
+func FullSnapshotLedgerStateCommitment(filePath string) (iotago.MilestoneIndex, *utxo.LedgerStateCommitment, error) {
+	lsFile, err := os.Open(filePath)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to open %s snapshot file: %w", snapshotNames[Full], err)
+	}
+	defer func() { _ = lsFile.Close() }()
+
+	var ledgerIndex iotago.MilestoneIndex
+	commitment := utxo.NewLedgerStateCommitment()
+
+	if err := StreamFullSnapshotDataFrom(
+		lsFile,
+		func(header *FullSnapshotHeader) error {
+			ledgerIndex = header.LedgerMilestoneIndex
+			return nil
+		},
+		func(_ *utxo.TreasuryOutput) error { return nil },
+		func(output *utxo.Output) error {
+			commitment.AddOutput(output)
+			return nil
+		},
+		func(msDiff *MilestoneDiff) error {
+			// the milestone diffs in the full snapshot file are in backwards order
+			// and are rolled back to get to the ledger state at the target milestone index.
+			if msDiff.Milestone.Index != ledgerIndex {
+				return errors.Wrapf(ErrWrongMilestoneDiffIndex, "ledgerIndex: %d, msDiffIndex: %d", ledgerIndex, msDiff.Milestone.Index)
+			}
+
+			for _, output := range msDiff.Created {
+				commitment.RemoveOutput(output)
+			}
+			for _, spent := range msDiff.Consumed {
+				commitment.AddOutput(spent.Output())
+			}
+			ledgerIndex--
+
+			return nil
+		},
+		func(_ iotago.BlockID, _ iotago.MilestoneIndex) error { return nil },
+		func(_ *iotago.ProtocolParamsMilestoneOpt) error { return nil },
+	); err != nil {
+		return 0, nil, fmt.Errorf("unable to read %s snapshot file: %w", snapshotNames[Full], err)
+	}
+
+	return ledgerIndex, commitment, nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/iotaledger/hive.go/contextutils"
+	"github.com/iotaledger/hive.go/events"
 	"github.com/iotaledger/hive.go/ioutils"
 	"github.com/iotaledger/hive.go/kvstore"
 	"github.com/iotaledger/hive.go/serializer/v2"
@@ -39,6 +40,9 @@ const (
 	// milestone diffs that are stored in the full snapshot.
 	// These are used to reconstruct pending protocol parameter updates.
 	AdditionalMilestoneDiffRange syncmanager.MilestoneIndexDelta = 30
+
+	// the minimum interval between two progress events of a snapshot creation.
+	snapshotProgressInterval = 1 * time.Second
 )
 
 // MilestoneRetrieverFunc is a function which returns the milestone for the given index.
@@ -54,6 +58,162 @@ type MergeInfo struct {
 	MergedSnapshotHeader *FullSnapshotHeader
 }
 
+// SnapshotProgress holds the progress of a snapshot file creation.
+type SnapshotProgress struct {
+	// The type of the snapshot.
+	Type Type
+	// The target index of the snapshot.
+	TargetIndex iotago.MilestoneIndex
+	// The path of the snapshot file.
+	FilePath string
+	// The time the creation was started.
+	StartTime time.Time
+	// The amount of outputs written to the snapshot file.
+	OutputsWritten uint64
+	// The amount of outputs of the snapshot file, zero if unknown.
+	OutputsTotal uint64
+	// The amount of milestone diffs written to the snapshot file.
+	MilestoneDiffsWritten uint32
+	// The amount of milestone diffs of the snapshot file.
+	MilestoneDiffsTotal uint32
+	// The amount of solid entry points written to the snapshot file.
+	SolidEntryPointsWritten uint16
+	// Whether all data was written to the snapshot file.
+	Done bool
+}
+
+// Fraction returns the completed fraction of the snapshot creation between 0 and 1.
+// The solid entry points are not taken into account, since their amount is not known in advance.
+func (p *SnapshotProgress) Fraction() float64 {
+	if p.Done {
+		return 1
+	}
+
+	total := p.OutputsTotal + uint64(p.MilestoneDiffsTotal)
+	if total == 0 {
+		return 0
+	}
+
+	written := p.OutputsWritten + uint64(p.MilestoneDiffsWritten)
+	if written >= total {
+		return 1
+	}
+
+	return float64(written) / float64(total)
+}
+
+// EstimatedTimeRemaining returns the estimated remaining duration of the snapshot creation.
+// It returns false if no estimation is possible yet.
+func (p *SnapshotProgress) EstimatedTimeRemaining() (time.Duration, bool) {
+	fraction := p.Fraction()
+	if fraction == 0 {
+		return 0, false
+	}
+
+	elapsed := time.Since(p.StartTime)
+	return time.Duration(float64(elapsed) * (1 - fraction) / fraction), true
+}
+
+// snapshotProgressReporter counts the elements written to a snapshot file and triggers progress events.
+// The producers of a snapshot file are called sequentially, so no locking is needed.
+type snapshotProgressReporter struct {
+	ctx        context.Context
+	event      *events.Event
+	progress   SnapshotProgress
+	lastUpdate time.Time
+}
+
+func newSnapshotProgressReporter(ctx context.Context, event *events.Event, snapshotType Type, targetIndex iotago.MilestoneIndex, filePath string) *snapshotProgressReporter {
+	return &snapshotProgressReporter{
+		ctx:   ctx,
+		event: event,
+		progress: SnapshotProgress{
+			Type:        snapshotType,
+			TargetIndex: targetIndex,
+			FilePath:    filePath,
+			StartTime:   time.Now(),
+		},
+	}
+}
+
+// update triggers a progress event, if the last one is older than the progress interval.
+func (r *snapshotProgressReporter) update(force bool) {
+	if !force && time.Since(r.lastUpdate) < snapshotProgressInterval {
+		return
+	}
+	r.lastUpdate = time.Now()
+
+	progress := r.progress
+	r.event.Trigger(&progress)
+}
+
+// done triggers the final progress event.
+func (r *snapshotProgressReporter) done() {
+	r.progress.Done = true
+	r.update(true)
+}
+
+// the producers below abort the snapshot creation if the context is done.
+// the remaining elements of the wrapped producer are drained in the background,
+// so the goroutine feeding the producer can terminate.
+
+func (r *snapshotProgressReporter) outputProducer(producer OutputProducerFunc) OutputProducerFunc {
+	return func() (*utxo.Output, error) {
+		if err := contextutils.ReturnErrIfCtxDone(r.ctx, ErrSnapshotCreationWasAborted); err != nil {
+			go func() {
+				for output, err := producer(); output != nil && err == nil; output, err = producer() {
+				}
+			}()
+			return nil, err
+		}
+
+		output, err := producer()
+		if output != nil {
+			r.progress.OutputsWritten++
+			r.update(false)
+		}
+		return output, err
+	}
+}
+
+func (r *snapshotProgressReporter) msDiffProducer(producer MilestoneDiffProducerFunc) MilestoneDiffProducerFunc {
+	return func() (*MilestoneDiff, error) {
+		if err := contextutils.ReturnErrIfCtxDone(r.ctx, ErrSnapshotCreationWasAborted); err != nil {
+			go func() {
+				for msDiff, err := producer(); msDiff != nil && err == nil; msDiff, err = producer() {
+				}
+			}()
+			return nil, err
+		}
+
+		msDiff, err := producer()
+		if msDiff != nil {
+			r.progress.MilestoneDiffsWritten++
+			r.update(false)
+		}
+		return msDiff, err
+	}
+}
+
+func (r *snapshotProgressReporter) sepProducer(producer SEPProducerFunc) SEPProducerFunc {
+	return func() (iotago.BlockID, error) {
+		if err := contextutils.ReturnErrIfCtxDone(r.ctx, ErrSnapshotCreationWasAborted); err != nil {
+			go func() {
+				for _, err := producer(); err == nil; _, err = producer() {
+				}
+			}()
+			return iotago.EmptyBlockID(), err
+		}
+
+		sep, err := producer()
+		if err == nil {
+			r.progress.SolidEntryPointsWritten++
+			r.update(false)
+		}
+		return sep, err
+	}
+}
+
 // returns a function which tries to read from the given producer and error channels up on each invocation.
 func producerFromChannels(prodChan <-chan interface{}, errChan <-chan error) func() (interface{}, error) {
 	return func() (interface{}, error) {
@@ -279,11 +439,13 @@ func (s *Manager) readSnapshotHeaderFromFullSnapshotFile() (*FullSnapshotHeader,
 }
 
 // creates a snapshot file by streaming data from the database into a snapshot file.
+// if countOutputs is set, the unspent outputs are counted in advance to report the total amount in the progress events.
 func (s *Manager) createFullSnapshotWithoutLocking(
 	ctx context.Context,
 	targetIndex iotago.MilestoneIndex,
 	filePath string,
-	writeToDatabase bool) error {
+	writeToDatabase bool,
+	countOutputs bool) error {
 
 	s.LogInfof("creating %s snapshot for targetIndex %d", snapshotNames[Full], targetIndex)
 	ts := time.Now()
@@ -291,6 +453,18 @@ func (s *Manager) createFullSnapshotWithoutLocking(
 	s.setIsSnapshotting(true)
 	defer s.setIsSnapshotting(false)
 
+	// the unspent outputs are counted without holding the ledger lock, so the confirmation of milestones is not blocked.
+	// the total is only used to report the progress, so it doesn't matter if the ledger changes before the lock is acquired.
+	var outputsTotal uint64
+	if countOutputs {
+		if err := s.utxoManager.ForEachUnspentOutputID(func(_ iotago.OutputID) bool {
+			outputsTotal++
+			return ctx.Err() == nil
+		}, utxo.ReadLockLedger(false)); err != nil {
+			return fmt.Errorf("unable to count unspent outputs: %w", err)
+		}
+	}
+
 	timeStart := time.Now()
 
 	s.utxoManager.ReadLockLedger()
@@ -362,24 +536,33 @@ func (s *Manager) createFullSnapshotWithoutLocking(
 		SEPCount:                   0,
 	}
 
+	// a full snapshot contains the ledger UTXOs as of the CMI and the milestone diffs from
+	// the CMI back to target index - AdditionalMilestoneDiffRange (excluding the last index)
+	// the "AdditionalMilestoneDiffRange" milestone diffs are needed to reconstruct pending protocol parameter updates.
+	progressReporter := newSnapshotProgressReporter(ctx, s.Events.SnapshotProgressUpdated, Full, targetIndex, filePath)
+	if msDiffsTargetIndex := targetIndex - AdditionalMilestoneDiffRange; ledgerIndex > msDiffsTargetIndex {
+		progressReporter.progress.MilestoneDiffsTotal = ledgerIndex - msDiffsTargetIndex
+	}
+	progressReporter.progress.OutputsTotal = outputsTotal
+	progressReporter.update(true)
+
 	snapshotFile, tempFilePath, err := ioutils.CreateTempFile(filePath)
 	if err != nil {
 		return err
 	}
 
-	// a full snapshot contains the ledger UTXOs as of the CMI and the milestone diffs from
-	// the CMI back to target index - AdditionalMilestoneDiffRange (excluding the last index)
-	// the "AdditionalMilestoneDiffRange" milestone diffs are needed to reconstruct pending protocol parameter updates.
-	utxoProducer := NewCMIUTXOProducer(s.utxoManager)
-	milestoneDiffProducer := NewMsDiffsProducer(MilestoneRetrieverFromStorage(s.storage), s.utxoManager, MsDiffDirectionBackwards, fullHeader.LedgerMilestoneIndex, targetIndex-AdditionalMilestoneDiffRange)
-	sepProducer := NewSEPsProducer(ctx, s.storage, targetIndex, s.solidEntryPointCheckThresholdPast)
+	utxoProducer := progressReporter.outputProducer(NewCMIUTXOProducer(s.utxoManager))
+	milestoneDiffProducer := progressReporter.msDiffProducer(NewMsDiffsProducer(MilestoneRetrieverFromStorage(s.storage), s.utxoManager, MsDiffDirectionBackwards, fullHeader.LedgerMilestoneIndex, targetIndex-AdditionalMilestoneDiffRange))
+	sepProducer := progressReporter.sepProducer(NewSEPsProducer(ctx, s.storage, targetIndex, s.solidEntryPointCheckThresholdPast))
 
 	// stream data into snapshot file
 	snapshotMetrics, err := StreamFullSnapshotDataTo(snapshotFile, fullHeader, utxoProducer, milestoneDiffProducer, sepProducer)
 	if err != nil {
 		_ = snapshotFile.Close()
+		_ = os.Remove(tempFilePath)
 		return fmt.Errorf("couldn't generate %s snapshot file: %w", snapshotNames[Full], err)
 	}
+	progressReporter.done()
 
 	timeStreamSnapshotData := time.Now()
 
@@ -428,7 +611,9 @@ func (s *Manager) createFullSnapshotWithoutLocking(
 }
 
 // creates a snapshot file by streaming data from the database into a snapshot file.
-func (s *Manager) createDeltaSnapshotWithoutLocking(ctx context.Context, targetIndex iotago.MilestoneIndex) error {
+// the delta snapshot file at the configured path is extended, other delta snapshot files are created
+// from the target index of the full snapshot file onwards.
+func (s *Manager) createDeltaSnapshotWithoutLocking(ctx context.Context, targetIndex iotago.MilestoneIndex, filePath string, writeToDatabase bool) error {
 
 	s.LogInfof("creating %s snapshot for targetIndex %d", snapshotNames[Delta], targetIndex)
 	ts := time.Now()
@@ -460,7 +645,7 @@ func (s *Manager) createDeltaSnapshotWithoutLocking(ctx context.Context, targetI
 		s.solidEntryPointCheckThresholdPast,
 		s.solidEntryPointCheckThresholdFuture,
 		// if we write the snapshot state to the database, the newly generated snapshot index must be greater than the last snapshot index
-		true); err != nil {
+		writeToDatabase); err != nil {
 		return err
 	}
 
@@ -493,9 +678,14 @@ func (s *Manager) createDeltaSnapshotWithoutLocking(ctx context.Context, targetI
 	}
 
 	_, err = os.Stat(s.snapshotDeltaPath)
-	deltaSnapshotFileExists := !os.IsNotExist(err)
+	deltaSnapshotFileExists := !os.IsNotExist(err) && filePath == s.snapshotDeltaPath
+
+	if err := checkDeltaSnapshotHistory(snapshotInfo, fullHeader, targetIndex, deltaSnapshotFileExists); err != nil {
+		return err
+	}
 
-	sepProducer := NewSEPsProducer(ctx, s.storage, targetIndex, s.solidEntryPointCheckThresholdPast)
+	progressReporter := newSnapshotProgressReporter(ctx, s.Events.SnapshotProgressUpdated, Delta, targetIndex, filePath)
+	sepProducer := progressReporter.sepProducer(NewSEPsProducer(ctx, s.storage, targetIndex, s.solidEntryPointCheckThresholdPast))
 
 	var snapshotMetrics *SnapshotMetrics
 	var snapshotFile *os.File
@@ -510,7 +700,9 @@ func (s *Manager) createDeltaSnapshotWithoutLocking(ctx context.Context, targetI
 		}
 
 		// we stream the diff from the old delta header target index to the new target index
-		milestoneDiffProducer := NewMsDiffsProducer(MilestoneRetrieverFromStorage(s.storage), s.utxoManager, MsDiffDirectionOnwards, oldDeltaHeader.TargetMilestoneIndex, targetIndex)
+		progressReporter.progress.MilestoneDiffsTotal = targetIndex - oldDeltaHeader.TargetMilestoneIndex
+		progressReporter.update(true)
+		milestoneDiffProducer := progressReporter.msDiffProducer(NewMsDiffsProducer(MilestoneRetrieverFromStorage(s.storage), s.utxoManager, MsDiffDirectionOnwards, oldDeltaHeader.TargetMilestoneIndex, targetIndex))
 
 		tempFilePath = s.snapshotDeltaPath + "_tmp"
 		if err := os.Rename(s.snapshotDeltaPath, tempFilePath); err != nil {
@@ -526,9 +718,11 @@ func (s *Manager) createDeltaSnapshotWithoutLocking(ctx context.Context, targetI
 
 	} else {
 		// we stream the diff from the full header target index to the new target index
-		milestoneDiffProducer := NewMsDiffsProducer(MilestoneRetrieverFromStorage(s.storage), s.utxoManager, MsDiffDirectionOnwards, fullHeader.TargetMilestoneIndex, targetIndex)
+		progressReporter.progress.MilestoneDiffsTotal = targetIndex - fullHeader.TargetMilestoneIndex
+		progressReporter.update(true)
+		milestoneDiffProducer := progressReporter.msDiffProducer(NewMsDiffsProducer(MilestoneRetrieverFromStorage(s.storage), s.utxoManager, MsDiffDirectionOnwards, fullHeader.TargetMilestoneIndex, targetIndex))
 
-		snapshotFile, tempFilePath, err = ioutils.CreateTempFile(s.snapshotDeltaPath)
+		snapshotFile, tempFilePath, err = ioutils.CreateTempFile(filePath)
 		if err != nil {
 			return err
 		}
@@ -537,34 +731,38 @@ func (s *Manager) createDeltaSnapshotWithoutLocking(ctx context.Context, targetI
 	}
 
 	if err != nil {
+		// an existing delta snapshot file was already partially overwritten, so it is removed as well.
 		_ = snapshotFile.Close()
+		_ = os.Remove(tempFilePath)
 		return fmt.Errorf("couldn't generate %s snapshot file: %w", snapshotNames[Delta], err)
 	}
+	progressReporter.done()
 
 	timeStreamSnapshotData := time.Now()
 
 	// finalize file
-	if err := ioutils.CloseFileAndRename(snapshotFile, tempFilePath, s.snapshotDeltaPath); err != nil {
+	if err := ioutils.CloseFileAndRename(snapshotFile, tempFilePath, filePath); err != nil {
 		return err
 	}
 
 	timeSetSnapshotInfo := timeStreamSnapshotData
 	timeSnapshotMilestoneIndexChanged := timeStreamSnapshotData
+	if writeToDatabase {
+		// since we write to the database, the targetIndex should exist
+		targetMsTimestamp, err := s.storage.MilestoneTimestampByIndex(targetIndex)
+		if err != nil {
+			return errors.Wrapf(common.ErrCritical, "target milestone (%d) not found", targetIndex)
+		}
 
-	// since we write to the database, the targetIndex should exist
-	targetMsTimestamp, err := s.storage.MilestoneTimestampByIndex(targetIndex)
-	if err != nil {
-		return errors.Wrapf(common.ErrCritical, "target milestone (%d) not found", targetIndex)
-	}
+		if err = s.storage.SetSnapshotIndex(targetIndex, targetMsTimestamp); err != nil {
+			s.LogPanic(err)
+		}
 
-	if err = s.storage.SetSnapshotIndex(targetIndex, targetMsTimestamp); err != nil {
-		s.LogPanic(err)
+		timeSetSnapshotInfo = time.Now()
+		s.Events.SnapshotMilestoneIndexChanged.Trigger(targetIndex)
+		timeSnapshotMilestoneIndexChanged = time.Now()
 	}
 
-	timeSetSnapshotInfo = time.Now()
-	s.Events.SnapshotMilestoneIndexChanged.Trigger(targetIndex)
-	timeSnapshotMilestoneIndexChanged = time.Now()
-
 	snapshotMetrics.DurationReadLockLedger = timeReadLockLedger.Sub(timeStart)
 	snapshotMetrics.DurationInit = timeInit.Sub(timeReadLockLedger)
 	snapshotMetrics.DurationSetSnapshotInfo = timeSetSnapshotInfo.Sub(timeStreamSnapshotData)
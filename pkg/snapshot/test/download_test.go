@@ -0,0 +1,183 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/hornet/pkg/snapshot"
+)
+
+// newSnapshotFileServer serves the given file with support for range requests.
+// Chunk requests fail if the given function returns true.
+func newSnapshotFileServer(data []byte, failChunk func(chunkRequests int64) bool) (*httptest.Server, *int64) {
+	var chunkRequests int64
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rangeHeader := r.Header.Get("Range"); rangeHeader != "" && !strings.HasPrefix(rangeHeader, "bytes=-") {
+			if failChunk(atomic.AddInt64(&chunkRequests, 1)) {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+		http.ServeContent(w, r, "full_snapshot.bin", time.Time{}, bytes.NewReader(data))
+	})), &chunkRequests
+}
+
+// newUncompressedSnapshotFileServer serves the given file with support for range requests and the given ETag.
+// Chunk requests fail while broken is set.
+func newUncompressedSnapshotFileServer(data []byte, etag string, broken *int32) (*httptest.Server, *int64) {
+	var chunkRequests int64
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rangeHeader := r.Header.Get("Range"); rangeHeader != "" && !strings.HasPrefix(rangeHeader, "bytes=-") {
+			if atomic.AddInt64(&chunkRequests, 1) > 1 && atomic.LoadInt32(broken) == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		http.ServeContent(w, r, "full_snapshot.bin", time.Time{}, bytes.NewReader(data))
+	})), &chunkRequests
+}
+
+func TestDownloadSnapshotFilesFromMirrors(t *testing.T) {
+
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "full_snapshot.bin")
+	compressedFilePath := filepath.Join(tempDir, "full_snapshot.bin.zst")
+
+	originFullHeader := randFullSnapshotHeader(1000, 10, 10)
+	protoParams, err := originFullHeader.ProtocolParameters()
+	require.NoError(t, err)
+
+	outputIterFunc, _ := newOutputsGenerator(originFullHeader.OutputCount)
+	msDiffIterFunc, _ := newMsDiffGenerator(originFullHeader.TargetMilestoneIndex, originFullHeader.MilestoneDiffCount, snapshot.MsDiffDirectionOnwards)
+	sepIterFunc, _ := newSEPGenerator(originFullHeader.SEPCount)
+
+	snapshotFile, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR, 0666)
+	require.NoError(t, err)
+	_, err = snapshot.StreamFullSnapshotDataTo(snapshotFile, originFullHeader, outputIterFunc, msDiffIterFunc, sepIterFunc)
+	require.NoError(t, err)
+	require.NoError(t, snapshotFile.Close())
+
+	_, err = snapshot.CompressSnapshotFile(filePath, compressedFilePath)
+	require.NoError(t, err)
+
+	originalData, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	compressedData, err := os.ReadFile(compressedFilePath)
+	require.NoError(t, err)
+
+	const chunkSize = 4096
+	chunksCount := int64((len(compressedData) + chunkSize - 1) / chunkSize)
+
+	t.Run("mirrors", func(t *testing.T) {
+		targetPath := filepath.Join(t.TempDir(), "full_snapshot.bin")
+
+		healthyServer, healthyRequests := newSnapshotFileServer(compressedData, func(int64) bool { return false })
+		defer healthyServer.Close()
+
+		// every second chunk request to the flaky server fails
+		flakyServer, flakyRequests := newSnapshotFileServer(compressedData, func(chunkRequests int64) bool { return chunkRequests%2 == 0 })
+		defer flakyServer.Close()
+
+		importer := snapshot.NewSnapshotImporter(logger.NewNopLogger(), nil, targetPath, "", protoParams.NetworkName, nil, 4, chunkSize, nil)
+		require.NoError(t, importer.DownloadSnapshotFiles(context.Background(), protoParams.NetworkID(), targetPath, "", []*snapshot.DownloadTarget{
+			{Full: flakyServer.URL},
+			{Full: healthyServer.URL},
+		}))
+
+		// both targets serve the same compressed snapshot, so the chunks are distributed over both of them
+		require.Greater(t, atomic.LoadInt64(healthyRequests), int64(0))
+		require.Greater(t, atomic.LoadInt64(flakyRequests), int64(0))
+
+		downloadedData, err := os.ReadFile(targetPath)
+		require.NoError(t, err)
+		require.Equal(t, originalData, downloadedData)
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		targetPath := filepath.Join(t.TempDir(), "full_snapshot.bin")
+
+		// the server stops working after half of the chunks
+		brokenServer, _ := newSnapshotFileServer(compressedData, func(chunkRequests int64) bool { return chunkRequests > chunksCount/2 })
+
+		importer := snapshot.NewSnapshotImporter(logger.NewNopLogger(), nil, targetPath, "", protoParams.NetworkName, nil, 1, chunkSize, nil)
+		require.Error(t, importer.DownloadSnapshotFiles(context.Background(), protoParams.NetworkID(), targetPath, "", []*snapshot.DownloadTarget{
+			{Full: brokenServer.URL},
+		}))
+		brokenServer.Close()
+
+		_, err := os.Stat(targetPath)
+		require.True(t, os.IsNotExist(err))
+
+		healthyServer, healthyRequests := newSnapshotFileServer(compressedData, func(int64) bool { return false })
+		defer healthyServer.Close()
+
+		require.NoError(t, importer.DownloadSnapshotFiles(context.Background(), protoParams.NetworkID(), targetPath, "", []*snapshot.DownloadTarget{
+			{Full: healthyServer.URL},
+		}))
+
+		// only the missing chunks were downloaded
+		require.Less(t, atomic.LoadInt64(healthyRequests), chunksCount)
+
+		downloadedData, err := os.ReadFile(targetPath)
+		require.NoError(t, err)
+		require.Equal(t, originalData, downloadedData)
+	})
+
+	for _, test := range []struct {
+		name      string
+		etag      string
+		resumable bool
+	}{
+		{name: "resume uncompressed", etag: `"v1"`, resumable: true},
+		{name: "restart without validator", etag: "", resumable: false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			targetPath := filepath.Join(t.TempDir(), "full_snapshot.bin")
+			originalChunksCount := int64((len(originalData) + chunkSize - 1) / chunkSize)
+
+			// the server stops working after the first chunk
+			broken := int32(1)
+			server, chunkRequests := newUncompressedSnapshotFileServer(originalData, test.etag, &broken)
+			defer server.Close()
+
+			importer := snapshot.NewSnapshotImporter(logger.NewNopLogger(), nil, targetPath, "", protoParams.NetworkName, nil, 1, chunkSize, nil)
+			require.Error(t, importer.DownloadSnapshotFiles(context.Background(), protoParams.NetworkID(), targetPath, "", []*snapshot.DownloadTarget{
+				{Full: server.URL},
+			}))
+
+			atomic.StoreInt32(&broken, 0)
+			atomic.StoreInt64(chunkRequests, 0)
+
+			require.NoError(t, importer.DownloadSnapshotFiles(context.Background(), protoParams.NetworkID(), targetPath, "", []*snapshot.DownloadTarget{
+				{Full: server.URL},
+			}))
+
+			if test.resumable {
+				// the first chunk was not downloaded again
+				require.Less(t, atomic.LoadInt64(chunkRequests), originalChunksCount)
+			} else {
+				require.Equal(t, originalChunksCount, atomic.LoadInt64(chunkRequests))
+			}
+
+			downloadedData, err := os.ReadFile(targetPath)
+			require.NoError(t, err)
+			require.Equal(t, originalData, downloadedData)
+		})
+	}
+}
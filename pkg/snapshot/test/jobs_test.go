@@ -0,0 +1,118 @@
+package snapshot_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/hornet/pkg/snapshot"
+	"github.com/iotaledger/hornet/pkg/testsuite"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	ProtocolVersion = 2
+	BelowMaxDepth   = 5
+	MinPoWScore     = 10
+)
+
+func TestSnapshotJobs(t *testing.T) {
+
+	te := testsuite.SetupTestEnvironment(t, &iotago.Ed25519Address{}, 0, ProtocolVersion, BelowMaxDepth, MinPoWScore, false)
+	defer te.CleanupTestEnvironment(true)
+
+	// a full snapshot contains the milestone diffs of AdditionalMilestoneDiffRange milestones before the target index
+	_, _ = te.BuildTangle(10, BelowMaxDepth, 50, 10, 30,
+		nil,
+		func(blockIDs iotago.BlockIDs, blockIDsPerMilestones []iotago.BlockIDs) iotago.BlockIDs {
+			return iotago.BlockIDs{blockIDs[len(blockIDs)-1]}
+		},
+		nil,
+	)
+
+	confirmedMilestoneIndex := te.SyncManager().ConfirmedMilestoneIndex()
+	require.NoError(t, te.Storage().SetInitialSnapshotInfo(0, confirmedMilestoneIndex, 0, 0, time.Now()))
+
+	tempDir := t.TempDir()
+	snapshotManager := snapshot.NewSnapshotManager(
+		logger.NewNopLogger(),
+		te.Storage(),
+		te.SyncManager(),
+		te.UTXOManager(),
+		te.ProtocolManager(),
+		filepath.Join(tempDir, "full_snapshot.bin"),
+		filepath.Join(tempDir, "delta_snapshot.bin"),
+		0,
+		0,
+		5,
+		5,
+		0,
+		0,
+		0,
+	)
+
+	jobManager := snapshot.NewJobManager(snapshotManager)
+
+	// jobs that can't be processed are rejected right away
+	_, err := jobManager.Add(snapshot.Full, confirmedMilestoneIndex, filepath.Join(tempDir, "too_new.bin"))
+	require.ErrorIs(t, err, snapshot.ErrTargetIndexTooNew)
+
+	_, err = jobManager.Add(snapshot.Full, 1, filepath.Join(tempDir, "too_old.bin"))
+	require.ErrorIs(t, err, snapshot.ErrTargetIndexTooOld)
+
+	_, err = jobManager.Add(snapshot.Delta, confirmedMilestoneIndex-10, filepath.Join(tempDir, "delta_snapshot.bin"))
+	require.ErrorIs(t, err, snapshot.ErrSnapshotCreationFailed)
+
+	// the delta snapshot needs the full snapshot file of the node
+	_, err = jobManager.Add(snapshot.Delta, confirmedMilestoneIndex-10, filepath.Join(tempDir, "delta.bin"))
+	require.Error(t, err)
+
+	fullJob, err := jobManager.Add(snapshot.Full, confirmedMilestoneIndex-10, filepath.Join(tempDir, "full.bin"))
+	require.NoError(t, err)
+	require.Equal(t, snapshot.JobStateQueued, fullJob.State)
+
+	canceledJob, err := jobManager.Add(snapshot.Full, confirmedMilestoneIndex-8, filepath.Join(tempDir, "canceled.bin"))
+	require.NoError(t, err)
+
+	canceledJob, err = jobManager.Cancel(canceledJob.ID)
+	require.NoError(t, err)
+	require.Equal(t, snapshot.JobStateCanceled, canceledJob.State)
+
+	_, err = jobManager.Cancel(canceledJob.ID)
+	require.ErrorIs(t, err, snapshot.ErrJobAlreadyFinished)
+
+	_, err = jobManager.Job("unknown")
+	require.ErrorIs(t, err, snapshot.ErrJobNotFound)
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+	go jobManager.Run(ctx)
+
+	require.Eventually(t, func() bool {
+		job, err := jobManager.Job(fullJob.ID)
+		require.NoError(t, err)
+		return job.Finished()
+	}, 10*time.Second, 10*time.Millisecond)
+
+	fullJob, err = jobManager.Job(fullJob.ID)
+	require.NoError(t, err)
+	require.Equal(t, snapshot.JobStateFinished, fullJob.State, "error: %v", fullJob.Error)
+	require.Equal(t, 1.0, fullJob.Progress.Fraction())
+	require.Greater(t, fullJob.Progress.OutputsTotal, uint64(0))
+
+	header, err := snapshot.ReadFullSnapshotHeaderFromFile(fullJob.FilePath)
+	require.NoError(t, err)
+	require.Equal(t, confirmedMilestoneIndex-10, header.TargetMilestoneIndex)
+
+	// the canceled job was skipped
+	canceledJob, err = jobManager.Job(canceledJob.ID)
+	require.NoError(t, err)
+	require.Equal(t, snapshot.JobStateCanceled, canceledJob.State)
+	_, err = os.Stat(canceledJob.FilePath)
+	require.True(t, os.IsNotExist(err))
+}
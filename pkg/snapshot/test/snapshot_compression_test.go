@@ -0,0 +1,123 @@
+package snapshot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hornet/pkg/snapshot"
+	"github.com/iotaledger/hornet/pkg/tpkg"
+)
+
+func TestCompressedFullSnapshot(t *testing.T) {
+
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "full_snapshot.bin")
+	compressedFilePath := filepath.Join(tempDir, "full_snapshot.bin.zst")
+	decompressedFilePath := filepath.Join(tempDir, "full_snapshot_decompressed.bin")
+
+	originFullHeader := randFullSnapshotHeader(1000, 50, 150)
+
+	outputIterFunc, outputGenRetriever := newOutputsGenerator(originFullHeader.OutputCount)
+	msDiffIterFunc, msDiffGenRetriever := newMsDiffGenerator(originFullHeader.TargetMilestoneIndex, originFullHeader.MilestoneDiffCount, snapshot.MsDiffDirectionOnwards)
+	sepIterFunc, sepGenRetriever := newSEPGenerator(originFullHeader.SEPCount)
+
+	snapshotFile, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR, 0666)
+	require.NoError(t, err)
+	_, err = snapshot.StreamFullSnapshotDataTo(snapshotFile, originFullHeader, outputIterFunc, msDiffIterFunc, sepIterFunc)
+	require.NoError(t, err)
+	require.NoError(t, snapshotFile.Close())
+
+	compressedInfo, err := snapshot.CompressSnapshotFile(filePath, compressedFilePath)
+	require.NoError(t, err)
+
+	_, err = snapshot.CompressSnapshotFile(compressedFilePath, filepath.Join(tempDir, "twice.bin.zst"))
+	require.ErrorIs(t, err, snapshot.ErrSnapshotAlreadyCompressed)
+
+	// uncompressed files have no trailer
+	info, err := snapshot.ReadCompressedSnapshotInfoFromFile(filePath)
+	require.NoError(t, err)
+	require.Nil(t, info)
+
+	info, err = snapshot.VerifyCompressedSnapshotFile(compressedFilePath)
+	require.NoError(t, err)
+	require.Equal(t, compressedInfo, info)
+
+	snapshotType, err := snapshot.ReadSnapshotTypeFromFile(compressedFilePath)
+	require.NoError(t, err)
+	require.Equal(t, snapshot.Full, snapshotType)
+
+	fullHeader, err := snapshot.ReadFullSnapshotHeaderFromFile(compressedFilePath)
+	require.NoError(t, err)
+	require.Equal(t, originFullHeader.TargetMilestoneIndex, fullHeader.TargetMilestoneIndex)
+
+	// the compressed data is streamed like an uncompressed snapshot
+	outputConsumerFunc, outputCollRetriever := newOutputCollector()
+	msDiffConsumerFunc, msDiffCollRetriever := newMsDiffCollector()
+	sepConsumerFunc, sepsCollRetriever := newSEPCollector()
+
+	compressedFile, err := os.Open(compressedFilePath)
+	require.NoError(t, err)
+	require.NoError(t, snapshot.StreamFullSnapshotDataFrom(
+		compressedFile,
+		fullHeaderEqualFunc(t, originFullHeader),
+		unspentTreasuryOutputEqualFunc(t, originFullHeader.TreasuryOutput),
+		outputConsumerFunc,
+		msDiffConsumerFunc,
+		sepConsumerFunc,
+		newProtocolParamsMilestoneOptConsumerFunc(),
+	))
+	require.NoError(t, compressedFile.Close())
+
+	tpkg.EqualOutputs(t, outputGenRetriever(), outputCollRetriever())
+	msDiffGen := msDiffGenRetriever()
+	msDiffCon := msDiffCollRetriever()
+	require.Len(t, msDiffCon, len(msDiffGen))
+	for i := range msDiffGen {
+		equalMilestoneDiff(t, msDiffGen[i], msDiffCon[i])
+	}
+	require.EqualValues(t, sepGenRetriever(), sepsCollRetriever())
+
+	// decompressing restores the original file
+	_, err = snapshot.DecompressSnapshotFile(compressedFilePath, decompressedFilePath)
+	require.NoError(t, err)
+
+	originalData, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	decompressedData, err := os.ReadFile(decompressedFilePath)
+	require.NoError(t, err)
+	require.Equal(t, originalData, decompressedData)
+
+	// a corrupted checksum is detected before any data is consumed
+	compressedData, err := os.ReadFile(compressedFilePath)
+	require.NoError(t, err)
+	compressedData[len(compressedData)-1] ^= 0xFF
+	require.NoError(t, os.WriteFile(compressedFilePath, compressedData, 0666))
+
+	_, err = snapshot.VerifyCompressedSnapshotFile(compressedFilePath)
+	require.ErrorIs(t, err, snapshot.ErrSnapshotChecksumMismatch)
+
+	outputConsumerFunc, outputCollRetriever = newOutputCollector()
+	msDiffConsumerFunc, _ = newMsDiffCollector()
+	sepConsumerFunc, _ = newSEPCollector()
+
+	compressedFile, err = os.Open(compressedFilePath)
+	require.NoError(t, err)
+	defer func() { _ = compressedFile.Close() }()
+
+	require.ErrorIs(t, snapshot.StreamFullSnapshotDataFrom(
+		compressedFile,
+		fullHeaderEqualFunc(t, originFullHeader),
+		unspentTreasuryOutputEqualFunc(t, originFullHeader.TreasuryOutput),
+		outputConsumerFunc,
+		msDiffConsumerFunc,
+		sepConsumerFunc,
+		newProtocolParamsMilestoneOptConsumerFunc(),
+	), snapshot.ErrSnapshotChecksumMismatch)
+	require.Empty(t, outputCollRetriever())
+
+	_, err = snapshot.DecompressSnapshotFile(compressedFilePath, decompressedFilePath)
+	require.ErrorIs(t, err, snapshot.ErrSnapshotChecksumMismatch)
+}
@@ -3,6 +3,7 @@ package snapshot_test
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -243,3 +244,26 @@ func TestSnapshotMsDiffProducerAndConsumer(t *testing.T) {
 	})
 	require.NoError(t, err)
 }
+
+func TestSnapshotProgress(t *testing.T) {
+	progress := &snapshot.SnapshotProgress{
+		StartTime:           time.Now().Add(-10 * time.Second),
+		OutputsTotal:        900,
+		MilestoneDiffsTotal: 100,
+	}
+
+	_, ok := progress.EstimatedTimeRemaining()
+	require.False(t, ok)
+	require.Zero(t, progress.Fraction())
+
+	progress.OutputsWritten = 200
+	progress.MilestoneDiffsWritten = 50
+	require.InDelta(t, 0.25, progress.Fraction(), 0.0001)
+
+	remaining, ok := progress.EstimatedTimeRemaining()
+	require.True(t, ok)
+	require.InDelta(t, float64(30*time.Second), float64(remaining), float64(time.Second))
+
+	progress.Done = true
+	require.Equal(t, 1.0, progress.Fraction())
+}
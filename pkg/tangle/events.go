@@ -2,6 +2,7 @@ package tangle
 
 import (
 	"github.com/iotaledger/hive.go/events"
+	"github.com/iotaledger/hornet/pkg/model/storage"
 	"github.com/iotaledger/hornet/pkg/model/utxo"
 	"github.com/iotaledger/hornet/pkg/whiteflag"
 	iotago "github.com/iotaledger/iota.go/v3"
@@ -38,6 +39,11 @@ func ReferencedBlocksCountUpdatedCaller(handler interface{}, params ...interface
 	handler.(func(msIndex iotago.MilestoneIndex, referencedBlocksCount int))(params[0].(iotago.MilestoneIndex), params[1].(int))
 }
 
+// MilestoneConeSizeCaller is used to signal the size of a confirmed milestone cone.
+func MilestoneConeSizeCaller(handler interface{}, params ...interface{}) {
+	handler.(func(size *storage.MilestoneConeSize))(params[0].(*storage.MilestoneConeSize))
+}
+
 type Events struct {
 	// block events
 	ReceivedNewBlock          *events.Event
@@ -71,4 +77,6 @@ type Events struct {
 	TreasuryMutated *events.Event
 	// Hint: Ledger is not locked
 	NewReceipt *events.Event
+	// Hint: Ledger is not locked
+	MilestoneConeSizeStored *events.Event
 }
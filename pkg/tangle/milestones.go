@@ -37,3 +37,25 @@ func (t *Tangle) processValidMilestone(blockID iotago.BlockID, cachedMilestone *
 		}
 	}
 }
+
+// storeMilestoneConeSize adds the sizes of the milestone and the referenced blocks to the size of the milestone cone and stores it.
+// The sizes are used to calculate the pruning target index if the database is pruned by size.
+func (t *Tangle) storeMilestoneConeSize(coneSize *storage.MilestoneConeSize, milestone *storage.Milestone, referencedBlockIDs iotago.BlockIDs, blocksMemcache *storage.BlocksMemcache) {
+	coneSize.AddMilestone(milestone)
+
+	for _, blockID := range referencedBlockIDs {
+		cachedBlock, err := blocksMemcache.CachedBlock(blockID) // block +1
+		if err != nil || cachedBlock == nil {
+			continue
+		}
+		coneSize.AddBlock(cachedBlock.Block(), cachedBlock.Metadata())
+		cachedBlock.Release(true) // block -1
+	}
+
+	if err := t.storage.StoreMilestoneConeSize(coneSize); err != nil {
+		t.LogWarnf("storing the size of milestone cone %d failed: %s", coneSize.Index, err)
+		return
+	}
+
+	t.Events.MilestoneConeSizeStored.Trigger(coneSize)
+}
@@ -331,6 +331,9 @@ func (t *Tangle) solidifyMilestone(newMilestoneIndex iotago.MilestoneIndex, forc
 	var newReceipt *iotago.ReceiptMilestoneOpt
 	var newConfirmation *whiteflag.Confirmation
 
+	// the approximate amount of bytes written to the database for the milestone cone
+	coneSize := &storage.MilestoneConeSize{Index: milestoneIndexToSolidify}
+
 	snapshotInfo := t.storage.SnapshotInfo()
 	if snapshotInfo == nil {
 		t.LogPanic(common.ErrSnapshotInfoNotFound)
@@ -397,6 +400,7 @@ func (t *Tangle) solidifyMilestone(newMilestoneIndex iotago.MilestoneIndex, forc
 		},
 		// Hint: Ledger is not locked
 		func(index iotago.MilestoneIndex, newOutputs utxo.Outputs, newSpents utxo.Spents) {
+			coneSize.AddLedgerChanges(newOutputs, newSpents)
 			t.Events.LedgerUpdated.Trigger(index, newOutputs, newSpents)
 		},
 		// Hint: Ledger is not locked
@@ -418,6 +422,8 @@ func (t *Tangle) solidifyMilestone(newMilestoneIndex iotago.MilestoneIndex, forc
 
 	if newConfirmation != nil {
 		t.Events.ReferencedBlocksCountUpdated.Trigger(milestoneIndexToSolidify, len(newConfirmation.Mutations.ReferencedBlocks))
+
+		t.storeMilestoneConeSize(coneSize, cachedMilestoneToSolidify.Milestone(), newConfirmation.Mutations.ReferencedBlocks.BlockIDs(), blocksMemcache)
 	}
 
 	t.LogInfof("Milestone confirmed (%d): txsReferenced: %v, txsValue: %v, txsZeroValue: %v, txsConflicting: %v, collect: %v, total: %v",
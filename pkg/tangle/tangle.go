@@ -173,6 +173,7 @@ func New(
 			LedgerUpdated:                  events.NewEvent(LedgerUpdatedCaller),
 			TreasuryMutated:                events.NewEvent(TreasuryMutationCaller),
 			NewReceipt:                     events.NewEvent(ReceiptCaller),
+			MilestoneConeSizeStored:        events.NewEvent(MilestoneConeSizeCaller),
 		},
 	}
 	t.futureConeSolidifier = NewFutureConeSolidifier(t.storage, t.markBlockAsSolid)
@@ -0,0 +1,183 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultReloadInterval is the interval in which the certificate files are checked for changes.
+	DefaultReloadInterval = 1 * time.Minute
+)
+
+var (
+	// ErrNoClientCertificate is returned if a client didn't present a certificate.
+	ErrNoClientCertificate = errors.New("no client certificate")
+)
+
+// ServerTLS holds the certificate of a TLS server and optionally the CAs the client certificates must be signed by.
+// The files are reloaded if they changed, so that certificates can be renewed without restarting the node.
+type ServerTLS struct {
+	certFilePath     string
+	keyFilePath      string
+	clientCAFilePath string
+
+	lock         sync.RWMutex
+	certificate  *tls.Certificate
+	clientCAs    *x509.CertPool
+	fileModTimes map[string]time.Time
+}
+
+// NewServerTLS loads the certificate and the key of a TLS server from the given files.
+// If the path of the client CA file is not empty, clients must present a certificate signed by one of its CAs.
+func NewServerTLS(certFilePath string, keyFilePath string, clientCAFilePath string) (*ServerTLS, error) {
+	if len(certFilePath) == 0 || len(keyFilePath) == 0 {
+		return nil, errors.New("certificate and key file paths must not be empty")
+	}
+
+	s := &ServerTLS{
+		certFilePath:     certFilePath,
+		keyFilePath:      keyFilePath,
+		clientCAFilePath: clientCAFilePath,
+		fileModTimes:     make(map[string]time.Time),
+	}
+
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// returns the paths of the files used by the server.
+func (s *ServerTLS) filePaths() []string {
+	filePaths := []string{s.certFilePath, s.keyFilePath}
+	if len(s.clientCAFilePath) > 0 {
+		filePaths = append(filePaths, s.clientCAFilePath)
+	}
+	return filePaths
+}
+
+// returns the modification times of the files used by the server.
+func (s *ServerTLS) readFileModTimes() (map[string]time.Time, error) {
+	fileModTimes := make(map[string]time.Time)
+	for _, filePath := range s.filePaths() {
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to check TLS file %s", filePath)
+		}
+		fileModTimes[filePath] = info.ModTime()
+	}
+	return fileModTimes, nil
+}
+
+// Reload loads the files again if any of them changed since they were loaded.
+// Returns whether the files were reloaded. If loading fails, the previous certificates are kept.
+func (s *ServerTLS) Reload() (bool, error) {
+	fileModTimes, err := s.readFileModTimes()
+	if err != nil {
+		return false, err
+	}
+
+	s.lock.RLock()
+	changed := false
+	for filePath, modTime := range fileModTimes {
+		if !modTime.Equal(s.fileModTimes[filePath]) {
+			changed = true
+			break
+		}
+	}
+	s.lock.RUnlock()
+
+	if !changed {
+		return false, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(s.certFilePath, s.keyFilePath)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to load TLS certificate")
+	}
+
+	var clientCAs *x509.CertPool
+	if len(s.clientCAFilePath) > 0 {
+		clientCAData, err := os.ReadFile(s.clientCAFilePath)
+		if err != nil {
+			return false, errors.Wrap(err, "unable to read client CA file")
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(clientCAData) {
+			return false, errors.Errorf("no valid certificates found in client CA file %s", s.clientCAFilePath)
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.certificate = &certificate
+	s.clientCAs = clientCAs
+	s.fileModTimes = fileModTimes
+
+	return true, nil
+}
+
+// ClientAuthEnabled tells whether clients must present a certificate.
+func (s *ServerTLS) ClientAuthEnabled() bool {
+	return len(s.clientCAFilePath) > 0
+}
+
+func (s *ServerTLS) getCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.certificate, nil
+}
+
+// verifies the certificate chain presented by a client against the current client CAs.
+// this is also called for resumed sessions, so clients are rejected as soon as their CA is not trusted anymore.
+func (s *ServerTLS) verifyClientCertificate(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return ErrNoClientCertificate
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	s.lock.RLock()
+	clientCAs := s.clientCAs
+	s.lock.RUnlock()
+
+	if _, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         clientCAs,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return errors.Wrap(err, "invalid client certificate")
+	}
+
+	return nil
+}
+
+// Config returns the TLS config of the server, which always uses the latest loaded certificates.
+func (s *ServerTLS) Config() *tls.Config {
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: s.getCertificate,
+	}
+
+	if s.ClientAuthEnabled() {
+		// the chain is verified by VerifyConnection, so that the client CAs can be reloaded.
+		// unlike VerifyPeerCertificate, it is also called if a session is resumed.
+		config.ClientAuth = tls.RequireAnyClientCert
+		config.VerifyConnection = s.verifyClientCertificate
+	}
+
+	return config
+}
@@ -0,0 +1,264 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// creates a certificate signed by the given parent, or a self-signed CA if parent is nil.
+func newTestCertificate(t *testing.T, commonName string, parent *testCertificate, extKeyUsage x509.ExtKeyUsage) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{extKeyUsage},
+	}
+
+	signerCert, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCertificate{cert: cert, key: key, der: der}
+}
+
+func (c *testCertificate) writeFiles(t *testing.T, certFilePath string, keyFilePath string) {
+	require.NoError(t, os.WriteFile(certFilePath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0600))
+
+	if len(keyFilePath) == 0 {
+		return
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyFilePath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+}
+
+// returns the connection state of a client which presented the given certificates.
+func clientConnectionState(certs ...*testCertificate) tls.ConnectionState {
+	cs := tls.ConnectionState{}
+	for _, cert := range certs {
+		cs.PeerCertificates = append(cs.PeerCertificates, cert.cert)
+	}
+	return cs
+}
+
+func TestServerTLSReload(t *testing.T) {
+	dir := t.TempDir()
+	certFilePath := filepath.Join(dir, "server.crt")
+	keyFilePath := filepath.Join(dir, "server.key")
+
+	ca := newTestCertificate(t, "ca", nil, x509.ExtKeyUsageServerAuth)
+	first := newTestCertificate(t, "first", ca, x509.ExtKeyUsageServerAuth)
+	first.writeFiles(t, certFilePath, keyFilePath)
+
+	serverTLS, err := NewServerTLS(certFilePath, keyFilePath, "")
+	require.NoError(t, err)
+	require.False(t, serverTLS.ClientAuthEnabled())
+
+	config := serverTLS.Config()
+	require.Equal(t, tls.NoClientCert, config.ClientAuth)
+
+	cert, err := config.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, first.der, cert.Certificate[0])
+
+	// nothing changed
+	reloaded, err := serverTLS.Reload()
+	require.NoError(t, err)
+	require.False(t, reloaded)
+
+	second := newTestCertificate(t, "second", ca, x509.ExtKeyUsageServerAuth)
+	second.writeFiles(t, certFilePath, keyFilePath)
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFilePath, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFilePath, modTime, modTime))
+
+	reloaded, err = serverTLS.Reload()
+	require.NoError(t, err)
+	require.True(t, reloaded)
+
+	// the existing config uses the new certificate
+	cert, err = config.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, second.der, cert.Certificate[0])
+
+	// the previous certificate is kept if the new files are invalid
+	require.NoError(t, os.WriteFile(keyFilePath, []byte("invalid"), 0600))
+	modTime = modTime.Add(time.Minute)
+	require.NoError(t, os.Chtimes(keyFilePath, modTime, modTime))
+
+	_, err = serverTLS.Reload()
+	require.Error(t, err)
+
+	cert, err = config.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, second.der, cert.Certificate[0])
+}
+
+func TestServerTLSClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	certFilePath := filepath.Join(dir, "server.crt")
+	keyFilePath := filepath.Join(dir, "server.key")
+	clientCAFilePath := filepath.Join(dir, "client-ca.crt")
+
+	serverCA := newTestCertificate(t, "server-ca", nil, x509.ExtKeyUsageServerAuth)
+	newTestCertificate(t, "server", serverCA, x509.ExtKeyUsageServerAuth).writeFiles(t, certFilePath, keyFilePath)
+
+	clientCA := newTestCertificate(t, "client-ca", nil, x509.ExtKeyUsageClientAuth)
+	clientCA.writeFiles(t, clientCAFilePath, "")
+
+	serverTLS, err := NewServerTLS(certFilePath, keyFilePath, clientCAFilePath)
+	require.NoError(t, err)
+	require.True(t, serverTLS.ClientAuthEnabled())
+
+	config := serverTLS.Config()
+	require.NotNil(t, config.VerifyConnection)
+
+	client := newTestCertificate(t, "client", clientCA, x509.ExtKeyUsageClientAuth)
+	require.NoError(t, config.VerifyConnection(clientConnectionState(client)))
+
+	// no certificate
+	require.ErrorIs(t, config.VerifyConnection(clientConnectionState()), ErrNoClientCertificate)
+
+	// signed by an unknown CA
+	otherCA := newTestCertificate(t, "other-ca", nil, x509.ExtKeyUsageClientAuth)
+	other := newTestCertificate(t, "other", otherCA, x509.ExtKeyUsageClientAuth)
+	require.Error(t, config.VerifyConnection(clientConnectionState(other)))
+
+	// not meant for client authentication
+	server := newTestCertificate(t, "server", clientCA, x509.ExtKeyUsageServerAuth)
+	require.Error(t, config.VerifyConnection(clientConnectionState(server)))
+
+	// the client CA is replaced, so the previous clients are rejected
+	otherCA.writeFiles(t, clientCAFilePath, "")
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(clientCAFilePath, modTime, modTime))
+
+	reloaded, err := serverTLS.Reload()
+	require.NoError(t, err)
+	require.True(t, reloaded)
+
+	require.Error(t, config.VerifyConnection(clientConnectionState(client)))
+	require.NoError(t, config.VerifyConnection(clientConnectionState(other)))
+}
+
+func TestServerTLSSessionResumption(t *testing.T) {
+	dir := t.TempDir()
+	certFilePath := filepath.Join(dir, "server.crt")
+	keyFilePath := filepath.Join(dir, "server.key")
+	clientCAFilePath := filepath.Join(dir, "client-ca.crt")
+
+	serverCA := newTestCertificate(t, "server-ca", nil, x509.ExtKeyUsageServerAuth)
+	newTestCertificate(t, "server", serverCA, x509.ExtKeyUsageServerAuth).writeFiles(t, certFilePath, keyFilePath)
+
+	clientCA := newTestCertificate(t, "client-ca", nil, x509.ExtKeyUsageClientAuth)
+	clientCA.writeFiles(t, clientCAFilePath, "")
+
+	serverTLS, err := NewServerTLS(certFilePath, keyFilePath, clientCAFilePath)
+	require.NoError(t, err)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", serverTLS.Config())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if err := conn.(*tls.Conn).Handshake(); err != nil {
+					return
+				}
+				_, _ = conn.Write([]byte{1})
+			}()
+		}
+	}()
+
+	client := newTestCertificate(t, "client", clientCA, x509.ExtKeyUsageClientAuth)
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(serverCA.cert)
+
+	clientConfig := &tls.Config{
+		RootCAs:            rootCAs,
+		ServerName:         "localhost",
+		Certificates:       []tls.Certificate{{Certificate: [][]byte{client.der}, PrivateKey: client.key}},
+		ClientSessionCache: tls.NewLRUClientSessionCache(1),
+	}
+
+	// returns whether the server accepted the connection and whether the session was resumed.
+	connect := func() (bool, bool) {
+		conn, err := tls.Dial("tcp", listener.Addr().String(), clientConfig)
+		if err != nil {
+			return false, false
+		}
+		defer conn.Close()
+
+		// the server rejects client certificates after the handshake completed on the client side
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		if _, err := conn.Read(make([]byte, 1)); err != nil {
+			return false, conn.ConnectionState().DidResume
+		}
+		return true, conn.ConnectionState().DidResume
+	}
+
+	accepted, _ := connect()
+	require.True(t, accepted)
+
+	accepted, resumed := connect()
+	require.True(t, accepted)
+	require.True(t, resumed)
+
+	// the client CA is replaced, so resumed sessions of the previous clients are rejected as well
+	newTestCertificate(t, "other-ca", nil, x509.ExtKeyUsageClientAuth).writeFiles(t, clientCAFilePath, "")
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(clientCAFilePath, modTime, modTime))
+
+	reloaded, err := serverTLS.Reload()
+	require.NoError(t, err)
+	require.True(t, reloaded)
+
+	accepted, resumed = connect()
+	require.False(t, accepted)
+	require.True(t, resumed)
+}
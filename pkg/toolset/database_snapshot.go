@@ -88,7 +88,7 @@ func databaseSnapshot(args []string) error {
 		fmt.Printf("metadata:\n")
 	}
 
-	if err := printFullSnapshotHeaderInfo("", *snapshotPathTargetFlag, fullHeader); err != nil {
+	if err := printFullSnapshotHeaderInfo("", *snapshotPathTargetFlag, fullHeader, nil, nil); err != nil {
 		return err
 	}
 
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/libp2p/go-libp2p-core/peer"
 	flag "github.com/spf13/pflag"
@@ -18,18 +20,25 @@ func generateJWTApiToken(args []string) error {
 
 	fs := configuration.NewUnsortedFlagSet("", flag.ContinueOnError)
 	databasePathFlag := fs.String(FlagToolDatabasePath, DefaultValueP2PDatabasePath, "the path to the p2p database folder")
-	apiJWTSaltFlag := fs.String(FlagToolSalt, DefaultValueAPIJWTTokenSalt, "salt used inside the JWT tokens for the REST API")
+	apiJWTSaltFlag := fs.String(FlagToolSalt, DefaultValueAPIJWTTokenSalt, fmt.Sprintf("salt used inside the JWT tokens for the REST API or INX (default for INX tokens: %s)", DefaultValueINXJWTTokenSalt))
+	scopesFlag := fs.StringSlice(FlagToolJWTScopes, []string{jwt.ScopeAll}, "the scopes the JWT token grants access to, must be specified for INX tokens (e.g. read,submit-blocks,peers:write,control:* or inx:read,inx:routes:indexer/*)")
+	expiryFlag := fs.Duration(FlagToolJWTExpiry, 0, "the duration after which the JWT token expires (0 = never)")
+	inxFlag := fs.Bool(FlagToolJWTINX, false, "issue a JWT token for INX extensions instead of the REST API")
 	outputJSONFlag := fs.Bool(FlagToolOutputJSON, false, FlagToolDescriptionOutputJSON)
 
 	fs.Usage = func() {
 		_, _ = fmt.Fprintf(os.Stderr, "Usage of %s:\n", ToolJWTApi)
 		fs.PrintDefaults()
-		println(fmt.Sprintf("\nexample: %s --%s %s --%s %s",
+		println(fmt.Sprintf("\nexample: %s --%s %s --%s %s --%s %s --%s %s",
 			ToolJWTApi,
 			FlagToolDatabasePath,
 			DefaultValueP2PDatabasePath,
 			FlagToolSalt,
-			DefaultValueAPIJWTTokenSalt))
+			DefaultValueAPIJWTTokenSalt,
+			FlagToolJWTScopes,
+			"read",
+			FlagToolJWTExpiry,
+			"720h"))
 	}
 
 	if err := parseFlagSet(fs, args); err != nil {
@@ -42,11 +51,37 @@ func generateJWTApiToken(args []string) error {
 	if len(*apiJWTSaltFlag) == 0 {
 		return fmt.Errorf("'%s' not specified", FlagToolSalt)
 	}
+	if len(*scopesFlag) == 0 {
+		return fmt.Errorf("'%s' not specified", FlagToolJWTScopes)
+	}
+	if *inxFlag {
+		// INX tokens should only grant access to what the extension needs, so the default of all scopes is not used
+		if !fs.Changed(FlagToolJWTScopes) {
+			return fmt.Errorf("'%s' must be specified for INX tokens", FlagToolJWTScopes)
+		}
+		for _, scope := range *scopesFlag {
+			if !strings.HasPrefix(scope, jwt.ScopeINXPrefix) {
+				return fmt.Errorf("scope '%s' is not an INX scope, INX scopes start with '%s'", scope, jwt.ScopeINXPrefix)
+			}
+		}
+	} else {
+		for _, scope := range *scopesFlag {
+			if strings.HasPrefix(scope, jwt.ScopeINXPrefix) {
+				return fmt.Errorf("scope '%s' is an INX scope, use '--%s' to issue INX tokens", scope, FlagToolJWTINX)
+			}
+		}
+	}
+	if *expiryFlag < 0 {
+		return fmt.Errorf("'%s' must not be negative", FlagToolJWTExpiry)
+	}
 
 	databasePath := *databasePathFlag
 	privKeyFilePath := filepath.Join(databasePath, p2p.PrivKeyFileName)
 
 	salt := *apiJWTSaltFlag
+	if *inxFlag && !fs.Changed(FlagToolSalt) {
+		salt = DefaultValueINXJWTTokenSalt
+	}
 
 	_, err := os.Stat(privKeyFilePath)
 	switch {
@@ -71,7 +106,7 @@ func generateJWTApiToken(args []string) error {
 		return fmt.Errorf("unable to get peer identity from public key: %w", err)
 	}
 
-	// API tokens do not expire.
+	// the expiry is set per token.
 	jwtAuth, err := jwt.NewJWTAuth(salt,
 		0,
 		peerID.String(),
@@ -81,7 +116,7 @@ func generateJWTApiToken(args []string) error {
 		return fmt.Errorf("JWT auth initialization failed: %w", err)
 	}
 
-	jwtToken, err := jwtAuth.IssueJWT()
+	jwtToken, claims, err := jwtAuth.IssueScopedJWT(*scopesFlag, *expiryFlag)
 	if err != nil {
 		return fmt.Errorf("issuing JWT token failed: %w", err)
 	}
@@ -89,14 +124,25 @@ func generateJWTApiToken(args []string) error {
 	if *outputJSONFlag {
 
 		result := struct {
-			JWT string `json:"jwt"`
+			JWT       string   `json:"jwt"`
+			TokenID   string   `json:"tokenId"`
+			Scopes    []string `json:"scopes"`
+			ExpiresAt int64    `json:"expiresAt,omitempty"`
 		}{
-			JWT: jwtToken,
+			JWT:       jwtToken,
+			TokenID:   claims.Id,
+			Scopes:    claims.Scopes,
+			ExpiresAt: claims.ExpiresAt,
 		}
 
 		return printJSON(result)
 	}
 
 	fmt.Println("Your API JWT token: ", jwtToken)
+	fmt.Println("Token ID:           ", claims.Id)
+	fmt.Println("Scopes:             ", strings.Join(claims.Scopes, ","))
+	if claims.ExpiresAt != 0 {
+		fmt.Println("Expires at:         ", time.Unix(claims.ExpiresAt, 0).Format(time.RFC3339))
+	}
 	return nil
 }
@@ -0,0 +1,82 @@
+package toolset
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/iotaledger/hive.go/configuration"
+	"github.com/iotaledger/hornet/pkg/snapshot"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func snapshotCompress(args []string) error {
+
+	fs := configuration.NewUnsortedFlagSet("", flag.ContinueOnError)
+	snapshotPathFlag := fs.String(FlagToolSnapshotPath, "", "the path to the snapshot file")
+	snapshotPathTargetFlag := fs.String(FlagToolSnapshotPathTarget, "", "the path to the target snapshot file")
+	decompressFlag := fs.Bool(FlagToolSnapCompressDecompress, false, "verify and decompress a compressed snapshot file instead")
+	outputJSONFlag := fs.Bool(FlagToolOutputJSON, false, FlagToolDescriptionOutputJSON)
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage of %s:\n", ToolSnapCompress)
+		fs.PrintDefaults()
+		println(fmt.Sprintf("\nexample: %s --%s %s --%s %s",
+			ToolSnapCompress,
+			FlagToolSnapshotPath,
+			"snapshots/mainnet/full_snapshot.bin",
+			FlagToolSnapshotPathTarget,
+			"full_snapshot.bin.zst"))
+	}
+
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+
+	if len(*snapshotPathFlag) == 0 {
+		return fmt.Errorf("'%s' not specified", FlagToolSnapshotPath)
+	}
+	if len(*snapshotPathTargetFlag) == 0 {
+		return fmt.Errorf("'%s' not specified", FlagToolSnapshotPathTarget)
+	}
+
+	sourcePath, targetPath := *snapshotPathFlag, *snapshotPathTargetFlag
+
+	ts := time.Now()
+
+	var compressedInfo *snapshot.CompressedSnapshotInfo
+	var err error
+	if *decompressFlag {
+		if !*outputJSONFlag {
+			fmt.Println("decompressing snapshot file...")
+		}
+		compressedInfo, err = snapshot.DecompressSnapshotFile(sourcePath, targetPath)
+	} else {
+		if !*outputJSONFlag {
+			fmt.Println("compressing snapshot file...")
+		}
+		compressedInfo, err = snapshot.CompressSnapshotFile(sourcePath, targetPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *outputJSONFlag {
+		return printJSON(newCompressedSnapshotInfo(compressedInfo))
+	}
+
+	fmt.Printf(`    >
+        - Compressed size:   %d bytes
+        - Uncompressed size: %d bytes
+        - Checksum:          %s`+"\n\n",
+		compressedInfo.CompressedLength,
+		compressedInfo.UncompressedLength,
+		iotago.EncodeHex(compressedInfo.Checksum[:]),
+	)
+
+	fmt.Printf("successfully created snapshot file '%s', took %v\n", targetPath, time.Since(ts).Truncate(time.Millisecond))
+
+	return nil
+}
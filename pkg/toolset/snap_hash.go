@@ -15,6 +15,7 @@ import (
 	"github.com/iotaledger/hornet/pkg/database"
 	"github.com/iotaledger/hornet/pkg/model/storage"
 	"github.com/iotaledger/hornet/pkg/snapshot"
+	iotago "github.com/iotaledger/iota.go/v3"
 )
 
 func snapshotHash(args []string) error {
@@ -46,6 +47,22 @@ func snapshotHash(args []string) error {
 	fullPath := *fullSnapshotPathFlag
 	deltaPath := *deltaSnapshotPathFlag
 
+	// compressed snapshot files are verified against the checksum in their trailer.
+	for _, filePath := range []string{fullPath, deltaPath} {
+		if len(filePath) == 0 {
+			continue
+		}
+
+		compressedInfo, err := snapshot.VerifyCompressedSnapshotFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		if compressedInfo != nil && !*outputJSONFlag {
+			fmt.Printf("verified checksum of compressed snapshot file %s: %s\n", filePath, iotago.EncodeHex(compressedInfo.Checksum[:]))
+		}
+	}
+
 	targetEngine, err := database.DatabaseEngineAllowed(database.EnginePebble)
 	if err != nil {
 		return err
@@ -35,6 +35,12 @@ func snapshotInfo(args []string) error {
 
 	filePath := *snapshotPathFlag
 
+	// compressed snapshot files are verified against the checksum in their trailer.
+	compressedInfo, err := snapshot.VerifyCompressedSnapshotFile(filePath)
+	if err != nil {
+		return err
+	}
+
 	snapshotType, err := snapshot.ReadSnapshotTypeFromFile(filePath)
 	if err != nil {
 		return err
@@ -46,14 +52,22 @@ func snapshotInfo(args []string) error {
 		if err != nil {
 			return err
 		}
-		return printFullSnapshotHeaderInfo("", filePath, fullHeader)
+
+		// the commitment is computed for the ledger state at the target milestone index,
+		// which is the state a node has after loading the snapshot.
+		_, ledgerStateCommitment, err := snapshot.FullSnapshotLedgerStateCommitment(filePath)
+		if err != nil {
+			return err
+		}
+
+		return printFullSnapshotHeaderInfo("", filePath, fullHeader, ledgerStateCommitment, compressedInfo)
 
 	case snapshot.Delta:
 		deltaHeader, err := snapshot.ReadDeltaSnapshotHeaderFromFile(filePath)
 		if err != nil {
 			return err
 		}
-		return printDeltaSnapshotHeaderInfo("", filePath, deltaHeader)
+		return printDeltaSnapshotHeaderInfo("", filePath, deltaHeader, compressedInfo)
 
 	default:
 		return fmt.Errorf("unknown snapshot type: %d", snapshotType)
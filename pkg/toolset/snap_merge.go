@@ -65,9 +65,9 @@ func snapshotMerge(args []string) error {
 		fmt.Printf("metadata:\n")
 	}
 
-	_ = printFullSnapshotHeaderInfo("full", fullPath, mergeInfo.FullSnapshotHeader)
-	_ = printDeltaSnapshotHeaderInfo("delta", deltaPath, mergeInfo.DeltaSnapshotHeader)
-	_ = printFullSnapshotHeaderInfo("merged", targetPath, mergeInfo.MergedSnapshotHeader)
+	_ = printFullSnapshotHeaderInfo("full", fullPath, mergeInfo.FullSnapshotHeader, nil, nil)
+	_ = printDeltaSnapshotHeaderInfo("delta", deltaPath, mergeInfo.DeltaSnapshotHeader, nil)
+	_ = printFullSnapshotHeaderInfo("merged", targetPath, mergeInfo.MergedSnapshotHeader, nil, nil)
 
 	if !*outputJSONFlag {
 		fmt.Printf("successfully created merged full snapshot '%s', took %v\n", targetPath, time.Since(ts).Truncate(time.Millisecond))
@@ -76,8 +76,28 @@ func snapshotMerge(args []string) error {
 	return nil
 }
 
+// compressedSnapshotInfo is the printed information about the trailer of a compressed snapshot file.
+type compressedSnapshotInfo struct {
+	CompressedSize   int64  `json:"compressedSize"`
+	UncompressedSize uint64 `json:"uncompressedSize"`
+	Checksum         string `json:"checksum"`
+}
+
+func newCompressedSnapshotInfo(info *snapshot.CompressedSnapshotInfo) *compressedSnapshotInfo {
+	if info == nil {
+		return nil
+	}
+
+	return &compressedSnapshotInfo{
+		CompressedSize:   info.CompressedLength,
+		UncompressedSize: info.UncompressedLength,
+		Checksum:         iotago.EncodeHex(info.Checksum[:]),
+	}
+}
+
 // prints information about the given full snapshot file header.
-func printFullSnapshotHeaderInfo(name string, path string, fullHeader *snapshot.FullSnapshotHeader) error {
+// the ledger state commitment and the compression info are optional and only printed if given.
+func printFullSnapshotHeaderInfo(name string, path string, fullHeader *snapshot.FullSnapshotHeader, ledgerStateCommitment *utxo.LedgerStateCommitment, compressedInfo *snapshot.CompressedSnapshotInfo) error {
 
 	fullHeaderProtoParams, err := fullHeader.ProtocolParameters()
 	if err != nil {
@@ -99,6 +119,8 @@ func printFullSnapshotHeaderInfo(name string, path string, fullHeader *snapshot.
 		OutputCount              uint64                     `json:"outputCount"`
 		MilestoneDiffCount       uint32                     `json:"milestoneDiffCount"`
 		SEPCount                 uint16                     `json:"sepCount"`
+		LedgerStateCommitment    string                     `json:"ledgerStateCommitment,omitempty"`
+		Compression              *compressedSnapshotInfo    `json:"compression,omitempty"`
 	}{
 		SnapshotName:             name,
 		FilePath:                 path,
@@ -114,25 +136,32 @@ func printFullSnapshotHeaderInfo(name string, path string, fullHeader *snapshot.
 		OutputCount:              fullHeader.OutputCount,
 		MilestoneDiffCount:       fullHeader.MilestoneDiffCount,
 		SEPCount:                 fullHeader.SEPCount,
+		Compression:              newCompressedSnapshotInfo(compressedInfo),
+	}
+
+	if ledgerStateCommitment != nil {
+		result.LedgerStateCommitment = ledgerStateCommitment.DigestHex()
 	}
 
 	return printJSON(result)
 }
 
 // prints information about the given delta snapshot file header.
-func printDeltaSnapshotHeaderInfo(name string, path string, deltaHeader *snapshot.DeltaSnapshotHeader) error {
+// the compression info is optional and only printed if given.
+func printDeltaSnapshotHeaderInfo(name string, path string, deltaHeader *snapshot.DeltaSnapshotHeader, compressedInfo *snapshot.CompressedSnapshotInfo) error {
 
 	result := struct {
-		SnapshotName                  string                `json:"snapshotName,omitempty"`
-		FilePath                      string                `json:"filePath"`
-		Version                       byte                  `json:"version"`
-		Type                          string                `json:"type"`
-		TargetMilestoneIndex          iotago.MilestoneIndex `json:"targetMilestoneIndex"`
-		TargetMilestoneTimestamp      time.Time             `json:"targetMilestoneTimestamp"`
-		FullSnapshotTargetMilestoneID string                `json:"fullSnapshotTargetMilestoneID"`
-		SEPFileOffset                 int64                 `json:"sepFileOffset"`
-		MilestoneDiffCount            uint32                `json:"milestoneDiffCount"`
-		SEPCount                      uint16                `json:"sepCount"`
+		SnapshotName                  string                  `json:"snapshotName,omitempty"`
+		FilePath                      string                  `json:"filePath"`
+		Version                       byte                    `json:"version"`
+		Type                          string                  `json:"type"`
+		TargetMilestoneIndex          iotago.MilestoneIndex   `json:"targetMilestoneIndex"`
+		TargetMilestoneTimestamp      time.Time               `json:"targetMilestoneTimestamp"`
+		FullSnapshotTargetMilestoneID string                  `json:"fullSnapshotTargetMilestoneID"`
+		SEPFileOffset                 int64                   `json:"sepFileOffset"`
+		MilestoneDiffCount            uint32                  `json:"milestoneDiffCount"`
+		SEPCount                      uint16                  `json:"sepCount"`
+		Compression                   *compressedSnapshotInfo `json:"compression,omitempty"`
 	}{
 		SnapshotName:                  name,
 		FilePath:                      path,
@@ -144,6 +173,7 @@ func printDeltaSnapshotHeaderInfo(name string, path string, deltaHeader *snapsho
 		SEPFileOffset:                 deltaHeader.SEPFileOffset,
 		MilestoneDiffCount:            deltaHeader.MilestoneDiffCount,
 		SEPCount:                      deltaHeader.SEPCount,
+		Compression:                   newCompressedSnapshotInfo(compressedInfo),
 	}
 
 	return printJSON(result)
@@ -47,6 +47,10 @@ const (
 	FlagToolPassword  = "password"
 	FlagToolSalt      = "salt"
 
+	FlagToolJWTScopes = "scopes"
+	FlagToolJWTExpiry = "expiry"
+	FlagToolJWTINX    = "inx"
+
 	FlagToolOutputJSON            = "json"
 	FlagToolDescriptionOutputJSON = "format output as JSON"
 
@@ -58,6 +62,8 @@ const (
 	FlagToolSnapGenMintAddress        = "mintAddress"
 	FlagToolSnapGenTreasuryAllocation = "treasuryAllocation"
 
+	FlagToolSnapCompressDecompress = "decompress"
+
 	FlagToolDatabaseTargetIndex            = "targetIndex"
 	FlagToolDatabaseMergeNodeURL           = "nodeURL"
 	FlagToolDatabaseMergeChronicle         = "chronicleMode"
@@ -75,6 +81,7 @@ const (
 	ToolSnapMerge              = "snap-merge"
 	ToolSnapInfo               = "snap-info"
 	ToolSnapHash               = "snap-hash"
+	ToolSnapCompress           = "snap-compress"
 	ToolBenchmarkIO            = "bench-io"
 	ToolBenchmarkCPU           = "bench-cpu"
 	ToolDatabaseLedgerHash     = "db-hash"
@@ -88,6 +95,7 @@ const (
 
 const (
 	DefaultValueAPIJWTTokenSalt     = "HORNET"
+	DefaultValueINXJWTTokenSalt     = "HORNET-INX"
 	DefaultValueMainnetDatabasePath = "mainnetdb"
 	DefaultValueP2PDatabasePath     = "p2pstore"
 	DefaultValueDatabaseEngine      = database.EngineRocksDB
@@ -132,6 +140,7 @@ func HandleTools() {
 		ToolSnapMerge:              snapshotMerge,
 		ToolSnapInfo:               snapshotInfo,
 		ToolSnapHash:               snapshotHash,
+		ToolSnapCompress:           snapshotCompress,
 		ToolBenchmarkIO:            benchmarkIO,
 		ToolBenchmarkCPU:           benchmarkCPU,
 		ToolDatabaseLedgerHash:     databaseLedgerHash,
@@ -174,6 +183,7 @@ func listTools() {
 	fmt.Printf("%-20s merges a full and delta snapshot into an updated full snapshot\n", fmt.Sprintf("%s:", ToolSnapMerge))
 	fmt.Printf("%-20s outputs information about a snapshot file\n", fmt.Sprintf("%s:", ToolSnapInfo))
 	fmt.Printf("%-20s calculates the sha256 hash of the ledger state inside a snapshot file\n", fmt.Sprintf("%s:", ToolSnapHash))
+	fmt.Printf("%-20s compresses a snapshot file and adds a checksum for distribution\n", fmt.Sprintf("%s:", ToolSnapCompress))
 	fmt.Printf("%-20s benchmarks the IO throughput\n", fmt.Sprintf("%s:", ToolBenchmarkIO))
 	fmt.Printf("%-20s benchmarks the CPU performance\n", fmt.Sprintf("%s:", ToolBenchmarkCPU))
 	fmt.Printf("%-20s calculates the sha256 hash of the ledger state of a database\n", fmt.Sprintf("%s:", ToolDatabaseLedgerHash))
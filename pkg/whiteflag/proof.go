@@ -0,0 +1,135 @@
+package whiteflag
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hornet/pkg/dag"
+	"github.com/iotaledger/hornet/pkg/model/storage"
+	iotago "github.com/iotaledger/iota.go/v3"
+	"github.com/iotaledger/iota.go/v3/merklehasher"
+)
+
+var (
+	// ErrBlockNotIncluded is returned when a block was not included as a transaction in the ledger by the given milestone.
+	ErrBlockNotIncluded = errors.New("block was not included in the ledger by the milestone")
+	// ErrInvalidInclusionProof is returned when an inclusion proof does not match the applied merkle root of the milestone.
+	ErrInvalidInclusionProof = errors.New("inclusion proof does not match the applied merkle root of the milestone")
+)
+
+// ConfirmedReferencedBlocks reconstructs the blocks referenced by an already confirmed milestone in white-flag order
+// from the stored block metadata, without the need to re-apply the cone to the ledger state.
+func ConfirmedReferencedBlocks(ctx context.Context, parentsTraverserStorage dag.ParentsTraverserStorage, msIndex iotago.MilestoneIndex, parents iotago.BlockIDs) (ReferencedBlocks, error) {
+
+	type referencedBlockWithIndex struct {
+		ReferencedBlock
+		whiteFlagIndex uint32
+	}
+
+	var referencedBlocks []*referencedBlockWithIndex
+	if err := dag.TraverseParents(
+		ctx,
+		parentsTraverserStorage,
+		parents,
+		// traversal stops if no more blocks pass the given condition
+		// Caution: condition func is not in DFS order
+		func(cachedBlockMeta *storage.CachedMetadata) (bool, error) { // meta +1
+			defer cachedBlockMeta.Release(true) // meta -1
+
+			// only traverse the blocks that were referenced by the given milestone
+			referenced, at := cachedBlockMeta.Metadata().ReferencedWithIndex()
+			return referenced && at == msIndex, nil
+		},
+		// consumer
+		func(cachedBlockMeta *storage.CachedMetadata) error { // meta +1
+			defer cachedBlockMeta.Release(true) // meta -1
+
+			metadata := cachedBlockMeta.Metadata()
+			_, _, whiteFlagIndex := metadata.ReferencedWithIndexAndWhiteFlagIndex()
+
+			referencedBlocks = append(referencedBlocks, &referencedBlockWithIndex{
+				ReferencedBlock: ReferencedBlock{
+					BlockID:       metadata.BlockID(),
+					IsTransaction: !metadata.IsNoTransaction(),
+					Conflict:      metadata.Conflict(),
+				},
+				whiteFlagIndex: whiteFlagIndex,
+			})
+			return nil
+		},
+		// called on missing parents
+		// return error on missing parents
+		nil,
+		// called on solid entry points
+		// Ignore solid entry points (snapshot milestone included)
+		nil,
+		false); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(referencedBlocks, func(i, j int) bool {
+		return referencedBlocks[i].whiteFlagIndex < referencedBlocks[j].whiteFlagIndex
+	})
+
+	result := make(ReferencedBlocks, len(referencedBlocks))
+	for i, referencedBlock := range referencedBlocks {
+		result[i] = referencedBlock.ReferencedBlock
+	}
+
+	return result, nil
+}
+
+// ComputeInclusionProof computes the merkle audit path of the given block
+// in the merkle tree of the included transaction blocks, whose root is the AppliedMerkleRoot of the milestone.
+// If the block is the only included transaction block, the root is the leaf hash of the block itself
+// and no audit path exists, so nil is returned.
+func ComputeInclusionProof(referencedBlocks ReferencedBlocks, blockID iotago.BlockID) (*merklehasher.Proof, error) {
+	includedBlockIDs := referencedBlocks.IncludedTransactionBlockIDs()
+
+	for i := range includedBlockIDs {
+		if includedBlockIDs[i] != blockID {
+			continue
+		}
+
+		if len(includedBlockIDs) == 1 {
+			return nil, nil
+		}
+
+		return merklehasher.NewHasher(crypto.BLAKE2b_256).ComputeProofForIndex(includedBlockIDs, i)
+	}
+
+	return nil, ErrBlockNotIncluded
+}
+
+// VerifyInclusionProof checks that the given proof contains the block
+// and that it hashes to the AppliedMerkleRoot of the given milestone payload.
+// A nil proof is only valid if the block is the only included transaction block of the milestone.
+// The milestone payload itself must be verified by the caller (e.g. by checking the signatures).
+func VerifyInclusionProof(milestonePayload *iotago.Milestone, blockID iotago.BlockID, proof *merklehasher.Proof) error {
+	hasher := merklehasher.NewHasher(crypto.BLAKE2b_256)
+
+	if proof == nil {
+		if !bytes.Equal(hasher.HashBlockIDs(iotago.BlockIDs{blockID}), milestonePayload.AppliedMerkleRoot[:]) {
+			return ErrInvalidInclusionProof
+		}
+		return nil
+	}
+
+	containsBlock, err := proof.ContainsValue(blockID)
+	if err != nil {
+		return err
+	}
+	if !containsBlock {
+		return ErrBlockNotIncluded
+	}
+
+	if !bytes.Equal(proof.Hash(hasher), milestonePayload.AppliedMerkleRoot[:]) {
+		return ErrInvalidInclusionProof
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hornet/pkg/testsuite"
+	"github.com/iotaledger/hornet/pkg/testsuite/utils"
+	"github.com/iotaledger/hornet/pkg/whiteflag"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func TestWhiteFlagInclusionProof(t *testing.T) {
+
+	seed1Wallet := utils.NewHDWallet("Seed1", seed1, 0)
+	seed2Wallet := utils.NewHDWallet("Seed2", seed2, 0)
+	seed3Wallet := utils.NewHDWallet("Seed3", seed3, 0)
+
+	genesisAddress := seed1Wallet.Address()
+
+	te := testsuite.SetupTestEnvironment(t, genesisAddress, 2, ProtocolVersion, BelowMaxDepth, MinPoWScore, ShowConfirmationGraphs)
+	defer te.CleanupTestEnvironment(!ShowConfirmationGraphs)
+
+	//Add token supply to our local HDWallet
+	seed1Wallet.BookOutput(te.GenesisOutput)
+
+	blockA := te.NewBlockBuilder("A").
+		Parents(te.LastMilestoneParents()).
+		FromWallet(seed1Wallet).
+		Amount(1_000_000).
+		BuildTransactionToWallet(seed2Wallet).
+		Store().
+		BookOnWallets()
+
+	blockB := te.NewBlockBuilder("B").
+		Parents(append(te.LastMilestoneParents(), blockA.StoredBlockID())).
+		FromWallet(seed1Wallet).
+		Amount(2_000_000).
+		BuildTransactionToWallet(seed2Wallet).
+		Store().
+		BookOnWallets()
+
+	// Invalid transfer (invalid input)
+	blockC := te.NewBlockBuilder("C").
+		Parents(append(te.LastMilestoneParents(), blockB.StoredBlockID())).
+		FromWallet(seed3Wallet).
+		Amount(1_000_000).
+		FakeInputs().
+		BuildTransactionToWallet(seed2Wallet).
+		Store()
+
+	conf, _ := te.IssueAndConfirmMilestoneOnTips(iotago.BlockIDs{blockC.StoredBlockID()}, true)
+	milestonePayload := te.LastMilestonePayload()
+
+	// the referenced blocks reconstructed from the metadata must match the ones of the confirmation
+	referencedBlocks, err := whiteflag.ConfirmedReferencedBlocks(context.Background(), te.Storage(), conf.MilestoneIndex, conf.MilestoneParents)
+	require.NoError(t, err)
+	require.Equal(t, conf.Mutations.ReferencedBlocks, referencedBlocks)
+
+	for _, blockID := range (iotago.BlockIDs{blockA.StoredBlockID(), blockB.StoredBlockID()}) {
+		proof, err := whiteflag.ComputeInclusionProof(referencedBlocks, blockID)
+		require.NoError(t, err)
+		require.NotNil(t, proof)
+		require.NoError(t, whiteflag.VerifyInclusionProof(milestonePayload, blockID, proof))
+	}
+
+	// the conflicting block was not included
+	_, err = whiteflag.ComputeInclusionProof(referencedBlocks, blockC.StoredBlockID())
+	require.ErrorIs(t, err, whiteflag.ErrBlockNotIncluded)
+
+	// a valid proof for a different block must not verify
+	proofA, err := whiteflag.ComputeInclusionProof(referencedBlocks, blockA.StoredBlockID())
+	require.NoError(t, err)
+	require.ErrorIs(t, whiteflag.VerifyInclusionProof(milestonePayload, blockC.StoredBlockID(), proofA), whiteflag.ErrBlockNotIncluded)
+
+	// a milestone with a single included block has no audit path
+	blockD := te.NewBlockBuilder("D").
+		Parents(append(te.LastMilestoneParents(), blockC.StoredBlockID())).
+		FromWallet(seed2Wallet).
+		Amount(3_000_000).
+		BuildTransactionToWallet(seed3Wallet).
+		Store().
+		BookOnWallets()
+
+	conf, _ = te.IssueAndConfirmMilestoneOnTips(iotago.BlockIDs{blockD.StoredBlockID()}, true)
+	milestonePayload = te.LastMilestonePayload()
+
+	referencedBlocks, err = whiteflag.ConfirmedReferencedBlocks(context.Background(), te.Storage(), conf.MilestoneIndex, conf.MilestoneParents)
+	require.NoError(t, err)
+
+	proofD, err := whiteflag.ComputeInclusionProof(referencedBlocks, blockD.StoredBlockID())
+	require.NoError(t, err)
+	require.Nil(t, proofD)
+	require.NoError(t, whiteflag.VerifyInclusionProof(milestonePayload, blockD.StoredBlockID(), proofD))
+
+	// the proof of the previous milestone does not match the applied merkle root of this milestone
+	require.ErrorIs(t, whiteflag.VerifyInclusionProof(milestonePayload, blockA.StoredBlockID(), proofA), whiteflag.ErrInvalidInclusionProof)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/iotaledger/hornet/pkg/model/storage"
 	"github.com/iotaledger/hornet/pkg/restapi"
 	"github.com/iotaledger/hornet/pkg/tangle"
+	restapiplugin "github.com/iotaledger/hornet/plugins/restapi"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
@@ -26,13 +27,18 @@ func blockMetadataByID(c echo.Context) (*blockMetadataResponse, error) {
 		return nil, err
 	}
 
-	cachedBlockMeta := deps.Storage.CachedBlockMetadataOrNil(blockID)
-	if cachedBlockMeta == nil {
+	return blockMetadataByBlockID(blockID)
+}
+
+func blockMetadataByBlockID(blockID iotago.BlockID) (*blockMetadataResponse, error) {
+	// blocks that were pruned are looked up in the archive
+	metadata, err := deps.Storage.BlockMetadataOrArchivedOrNil(blockID)
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "loading block metadata failed: %s, error: %s", blockID.ToHex(), err)
+	}
+	if metadata == nil {
 		return nil, errors.WithMessagef(echo.ErrNotFound, "block not found: %s", blockID.ToHex())
 	}
-	defer cachedBlockMeta.Release(true) // meta -1
-
-	metadata := cachedBlockMeta.Metadata()
 
 	referenced, referencedIndex, wfIndex := metadata.ReferencedWithIndexAndWhiteFlagIndex()
 
@@ -44,13 +50,15 @@ func blockMetadataByID(c echo.Context) (*blockMetadataResponse, error) {
 	}
 
 	if metadata.IsMilestone() {
-		cachedBlock := deps.Storage.CachedBlockOrNil(blockID)
-		if cachedBlock == nil {
+		block, err := deps.Storage.BlockOrArchivedOrNil(blockID)
+		if err != nil {
+			return nil, errors.WithMessagef(echo.ErrInternalServerError, "loading block failed: %s, error: %s", blockID.ToHex(), err)
+		}
+		if block == nil {
 			return nil, errors.WithMessagef(echo.ErrNotFound, "block not found: %s", blockID.ToHex())
 		}
-		defer cachedBlock.Release(true)
 
-		milestone := cachedBlock.Block().Milestone()
+		milestone := block.Milestone()
 		if milestone == nil {
 			return nil, errors.WithMessagef(echo.ErrNotFound, "milestone for block not found: %s", blockID.ToHex())
 		}
@@ -72,7 +80,7 @@ func blockMetadataByID(c echo.Context) (*blockMetadataResponse, error) {
 		// determine info about the quality of the tip if not referenced
 		cmi := deps.SyncManager.ConfirmedMilestoneIndex()
 
-		tipScore, err := deps.TipScoreCalculator.TipScore(Plugin.Daemon().ContextStopped(), cachedBlockMeta.Metadata().BlockID(), cmi)
+		tipScore, err := deps.TipScoreCalculator.TipScore(Plugin.Daemon().ContextStopped(), metadata.BlockID(), cmi)
 		if err != nil {
 			if errors.Is(err, common.ErrOperationAborted) {
 				return nil, errors.WithMessage(echo.ErrServiceUnavailable, err.Error())
@@ -110,13 +118,16 @@ func storageBlockByID(c echo.Context) (*storage.Block, error) {
 		return nil, err
 	}
 
-	cachedBlock := deps.Storage.CachedBlockOrNil(blockID) // block +1
-	if cachedBlock == nil {
+	// blocks that were pruned are looked up in the archive
+	block, err := deps.Storage.BlockOrArchivedOrNil(blockID)
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "loading block failed: %s, error: %s", blockID.ToHex(), err)
+	}
+	if block == nil {
 		return nil, errors.WithMessagef(echo.ErrNotFound, "block not found: %s", blockID.ToHex())
 	}
-	defer cachedBlock.Release(true) // block -1
 
-	return cachedBlock.Block(), nil
+	return block, nil
 }
 
 func blockByID(c echo.Context) (*iotago.Block, error) {
@@ -135,6 +146,19 @@ func blockBytesByID(c echo.Context) ([]byte, error) {
 	return block.Data(), nil
 }
 
+// nodeDoesPoW tells whether the node has to do the PoW for the given block when it is attached.
+func nodeDoesPoW(iotaBlock *iotago.Block) bool {
+	if !restapiplugin.ParamsRestAPI.PoW.Enabled || deps.ProtocolManager.Current().MinPoWScore == 0 {
+		return false
+	}
+
+	if _, isMilestone := iotaBlock.Payload.(*iotago.Milestone); isMilestone {
+		return false
+	}
+
+	return iotaBlock.Nonce == 0
+}
+
 func sendBlock(c echo.Context) (*blockCreatedResponse, error) {
 	mimeType, err := restapi.GetRequestContentType(c, restapi.MIMEApplicationVendorIOTASerializerV1, echo.MIMEApplicationJSON)
 	if err != nil {
@@ -181,6 +205,13 @@ func sendBlock(c echo.Context) (*blockCreatedResponse, error) {
 	default:
 	}
 
+	// blocks without a nonce are expensive, since the node has to do the PoW
+	if nodeDoesPoW(iotaBlock) {
+		if err := deps.RestAPIRateLimiter.Allow(c, restapi.RateLimitClassPoW); err != nil {
+			return nil, err
+		}
+	}
+
 	mergedCtx, mergedCtxCancel := contextutils.MergeContexts(c.Request().Context(), Plugin.Daemon().ContextStopped())
 	defer mergedCtxCancel()
 
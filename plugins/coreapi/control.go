@@ -2,27 +2,32 @@ package coreapi
 
 import (
 	"fmt"
+	"net/http"
 	"path/filepath"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/gommon/bytes"
 	"github.com/pkg/errors"
 
+	"github.com/iotaledger/hornet/pkg/pruning"
 	"github.com/iotaledger/hornet/pkg/restapi"
+	"github.com/iotaledger/hornet/pkg/snapshot"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
-func pruneDatabase(c echo.Context) (*pruneDatabaseResponse, error) {
-
-	if deps.SnapshotManager.IsSnapshotting() || deps.PruningManager.IsPruning() {
-		return nil, errors.WithMessage(echo.ErrServiceUnavailable, "node is already creating a snapshot or pruning is running")
-	}
+func parsePruneDatabaseRequest(c echo.Context) (*pruneDatabaseRequest, error) {
 
 	request := &pruneDatabaseRequest{}
 	if err := c.Bind(request); err != nil {
 		return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid request, error: %s", err)
 	}
 
+	// the estimation doesn't modify the database, so it is allowed while a snapshot is created or pruning is running
+	if !request.DryRun && (deps.SnapshotManager.IsSnapshotting() || deps.PruningManager.IsPruning()) {
+		return nil, errors.WithMessage(echo.ErrServiceUnavailable, "node is already creating a snapshot or pruning is running")
+	}
+
 	if (request.Index == nil && request.Depth == nil && request.TargetDatabaseSize == nil) ||
 		(request.Index != nil && request.Depth != nil) ||
 		(request.Index != nil && request.TargetDatabaseSize != nil) ||
@@ -30,6 +35,17 @@ func pruneDatabase(c echo.Context) (*pruneDatabaseResponse, error) {
 		return nil, errors.WithMessage(restapi.ErrInvalidParameter, "either index, depth or size has to be specified")
 	}
 
+	if request.TargetDatabaseSize != nil {
+		if _, err := bytes.Parse(*request.TargetDatabaseSize); err != nil {
+			return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid targetDatabaseSize, error: %s", err)
+		}
+	}
+
+	return request, nil
+}
+
+func pruneDatabase(request *pruneDatabaseRequest) (*pruneDatabaseResponse, error) {
+
 	var err error
 	var targetIndex iotago.MilestoneIndex
 
@@ -48,10 +64,7 @@ func pruneDatabase(c echo.Context) (*pruneDatabaseResponse, error) {
 	}
 
 	if request.TargetDatabaseSize != nil {
-		pruningTargetDatabaseSizeBytes, err := bytes.Parse(*request.TargetDatabaseSize)
-		if err != nil {
-			return nil, errors.WithMessagef(echo.ErrInternalServerError, "pruning database failed: %s", err)
-		}
+		pruningTargetDatabaseSizeBytes, _ := bytes.Parse(*request.TargetDatabaseSize)
 
 		targetIndex, err = deps.PruningManager.PruneDatabaseBySize(Plugin.Daemon().ContextStopped(), pruningTargetDatabaseSizeBytes)
 		if err != nil {
@@ -64,6 +77,51 @@ func pruneDatabase(c echo.Context) (*pruneDatabaseResponse, error) {
 	}, nil
 }
 
+func estimatePruneDatabase(request *pruneDatabaseRequest) (*pruneDatabaseEstimateResponse, error) {
+
+	var err error
+	var estimate *pruning.PruningEstimate
+
+	switch {
+	case request.Index != nil:
+		estimate, err = deps.PruningManager.EstimatePruneDatabaseByTargetIndex(Plugin.Daemon().ContextStopped(), *request.Index)
+	case request.Depth != nil:
+		estimate, err = deps.PruningManager.EstimatePruneDatabaseByDepth(Plugin.Daemon().ContextStopped(), *request.Depth)
+	default:
+		pruningTargetDatabaseSizeBytes, _ := bytes.Parse(*request.TargetDatabaseSize)
+		estimate, err = deps.PruningManager.EstimatePruneDatabaseBySize(Plugin.Daemon().ContextStopped(), pruningTargetDatabaseSizeBytes)
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "estimating database pruning failed: %s", err)
+	}
+
+	classIndexes := make(map[string]iotago.MilestoneIndex, len(estimate.ClassTargetIndexes))
+	for class, index := range estimate.ClassTargetIndexes {
+		classIndexes[class.String()] = index
+	}
+
+	solidEntryPoints := make([]*solidEntryPointResponse, 0, len(estimate.SolidEntryPoints))
+	for _, sep := range estimate.SolidEntryPoints {
+		solidEntryPoints = append(solidEntryPoints, &solidEntryPointResponse{
+			BlockID: sep.BlockID.ToHex(),
+			Index:   sep.Index,
+		})
+	}
+
+	return &pruneDatabaseEstimateResponse{
+		Index:                    estimate.TargetIndex,
+		ClassIndexes:             classIndexes,
+		Milestones:               estimate.Milestones,
+		Blocks:                   estimate.Blocks,
+		UnreferencedBlocks:       estimate.UnreferencedBlocks,
+		Outputs:                  estimate.Outputs,
+		Receipts:                 estimate.Receipts,
+		Bytes:                    estimate.Bytes,
+		SolidEntryPoints:         solidEntryPoints,
+		EstimatedDurationSeconds: int64(estimate.EstimatedDuration.Seconds()),
+	}, nil
+}
+
 func createSnapshots(c echo.Context) (*createSnapshotsResponse, error) {
 
 	if deps.SnapshotManager.IsSnapshotting() || deps.PruningManager.IsPruning() {
@@ -89,3 +147,118 @@ func createSnapshots(c echo.Context) (*createSnapshotsResponse, error) {
 		FilePath: filePath,
 	}, nil
 }
+
+func newSnapshotJobResponse(job *snapshot.Job) *snapshotJobResponse {
+	resp := &snapshotJobResponse{
+		JobID:                   job.ID,
+		Type:                    job.Type.String(),
+		Index:                   job.TargetIndex,
+		FilePath:                job.FilePath,
+		State:                   string(job.State),
+		Progress:                job.Progress.Fraction(),
+		OutputsWritten:          job.Progress.OutputsWritten,
+		OutputsTotal:            job.Progress.OutputsTotal,
+		MilestoneDiffsWritten:   job.Progress.MilestoneDiffsWritten,
+		MilestoneDiffsTotal:     job.Progress.MilestoneDiffsTotal,
+		SolidEntryPointsWritten: job.Progress.SolidEntryPointsWritten,
+		CreatedAt:               job.CreatedAt.Unix(),
+	}
+
+	if !job.StartedAt.IsZero() {
+		resp.StartedAt = job.StartedAt.Unix()
+	}
+	if !job.FinishedAt.IsZero() {
+		resp.FinishedAt = job.FinishedAt.Unix()
+	}
+	if job.State == snapshot.JobStateRunning {
+		if remaining, ok := job.Progress.EstimatedTimeRemaining(); ok {
+			seconds := int64(remaining.Seconds())
+			resp.EstimatedSecondsRemaining = &seconds
+		}
+	}
+	if job.Error != nil {
+		resp.Error = job.Error.Error()
+	}
+
+	return resp
+}
+
+func addSnapshotJob(c echo.Context) (*snapshotJobResponse, error) {
+
+	request := &addSnapshotJobRequest{}
+	if err := c.Bind(request); err != nil {
+		return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid request, error: %s", err)
+	}
+
+	var snapshotType snapshot.Type
+	var fileName string
+	switch strings.ToLower(request.Type) {
+	case "", "full":
+		snapshotType = snapshot.Full
+		fileName = fmt.Sprintf("full_snapshot_%d.bin", request.Index)
+	case "delta":
+		snapshotType = snapshot.Delta
+		fileName = fmt.Sprintf("delta_snapshot_%d.bin", request.Index)
+	default:
+		return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid snapshot type: %s", request.Type)
+	}
+
+	if request.Index == 0 {
+		return nil, errors.WithMessage(restapi.ErrInvalidParameter, "index needs to be specified")
+	}
+
+	snapshotInfo := deps.Storage.SnapshotInfo()
+	if snapshotInfo == nil {
+		return nil, errors.WithMessage(echo.ErrInternalServerError, "snapshot info not found")
+	}
+
+	ledgerIndex, err := deps.UTXOManager.ReadLedgerIndex()
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading ledger index failed: %s", err)
+	}
+
+	if request.Index <= snapshotInfo.PruningIndex() || request.Index > ledgerIndex {
+		return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "index needs to be between pruning index (%d) and ledger index (%d)", snapshotInfo.PruningIndex()+1, ledgerIndex)
+	}
+
+	filePath := filepath.Join(filepath.Dir(deps.SnapshotsFullPath), fileName)
+
+	job, err := deps.SnapshotJobManager.Add(snapshotType, request.Index, filePath)
+	if err != nil {
+		switch {
+		case errors.Is(err, snapshot.ErrJobQueueFull):
+			return nil, errors.WithMessage(echo.ErrServiceUnavailable, err.Error())
+		case errors.Is(err, snapshot.ErrTargetIndexTooNew),
+			errors.Is(err, snapshot.ErrTargetIndexTooOld),
+			errors.Is(err, snapshot.ErrNotEnoughHistory),
+			errors.Is(err, snapshot.ErrSnapshotCreationFailed):
+			return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid snapshot job: %s", err)
+		}
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "adding snapshot job failed: %s", err)
+	}
+
+	return newSnapshotJobResponse(job), nil
+}
+
+func snapshotJob(c echo.Context) (*snapshotJobResponse, error) {
+
+	job, err := deps.SnapshotJobManager.Job(c.Param(restapi.ParameterSnapshotJobID))
+	if err != nil {
+		return nil, errors.WithMessage(echo.ErrNotFound, err.Error())
+	}
+
+	return newSnapshotJobResponse(job), nil
+}
+
+func cancelSnapshotJob(c echo.Context) (*snapshotJobResponse, error) {
+
+	job, err := deps.SnapshotJobManager.Cancel(c.Param(restapi.ParameterSnapshotJobID))
+	if err != nil {
+		if errors.Is(err, snapshot.ErrJobAlreadyFinished) {
+			return nil, errors.WithMessage(echo.NewHTTPError(http.StatusConflict), err.Error())
+		}
+		return nil, errors.WithMessage(echo.ErrNotFound, err.Error())
+	}
+
+	return newSnapshotJobResponse(job), nil
+}
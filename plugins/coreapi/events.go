@@ -0,0 +1,226 @@
+package coreapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/events"
+	"github.com/iotaledger/hive.go/workerpool"
+	"github.com/iotaledger/hornet/pkg/model/storage"
+	"github.com/iotaledger/hornet/pkg/model/utxo"
+	"github.com/iotaledger/hornet/pkg/restapi"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// EventTopicMilestoneLatest is the topic for changes of the latest known milestone.
+	EventTopicMilestoneLatest = "milestone-info/latest"
+	// EventTopicMilestoneConfirmed is the topic for changes of the confirmed milestone.
+	EventTopicMilestoneConfirmed = "milestone-info/confirmed"
+	// EventTopicBlockMetadataSolid is the topic for the metadata of blocks that became solid.
+	EventTopicBlockMetadataSolid = "block-metadata/solid"
+	// EventTopicBlockMetadataReferenced is the topic for the metadata of blocks that got referenced by a milestone.
+	EventTopicBlockMetadataReferenced = "block-metadata/referenced"
+	// EventTopicLedgerUpdates is the topic for the ledger changes of confirmed milestones.
+	EventTopicLedgerUpdates = "ledger-updates"
+
+	eventsWorkerCount     = 1
+	eventsWorkerQueueSize = 10000
+	eventsWriteTimeout    = 5 * time.Second
+)
+
+var (
+	eventTopics = []string{
+		EventTopicMilestoneLatest,
+		EventTopicMilestoneConfirmed,
+		EventTopicBlockMetadataSolid,
+		EventTopicBlockMetadataReferenced,
+		EventTopicLedgerUpdates,
+	}
+
+	eventsUpgrader = websocket.Upgrader{
+		// CORS is allowed for all origins by the REST API, access to the route is controlled by the JWT middleware.
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+)
+
+func parseEventTopicsQueryParam(c echo.Context) (map[string]struct{}, error) {
+	topics := make(map[string]struct{})
+
+	topicsParam := strings.ToLower(c.QueryParam(restapi.QueryParameterTopics))
+	if len(topicsParam) == 0 {
+		// subscribe to all topics by default
+		for _, topic := range eventTopics {
+			topics[topic] = struct{}{}
+		}
+		return topics, nil
+	}
+
+	for _, topic := range strings.Split(topicsParam, ",") {
+		topic = strings.TrimSpace(topic)
+
+		known := false
+		for _, eventTopic := range eventTopics {
+			if topic == eventTopic {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid topic: %s", topic)
+		}
+		topics[topic] = struct{}{}
+	}
+
+	return topics, nil
+}
+
+func milestoneInfoForCachedMilestone(cachedMilestone *storage.CachedMilestone) *milestoneInfoResponse {
+	defer cachedMilestone.Release(true) // milestone -1
+
+	return &milestoneInfoResponse{
+		Index:       cachedMilestone.Milestone().Index(),
+		Timestamp:   cachedMilestone.Milestone().TimestampUnix(),
+		MilestoneID: cachedMilestone.Milestone().MilestoneIDHex(),
+	}
+}
+
+func ledgerUpdate(msIndex iotago.MilestoneIndex, newOutputs utxo.Outputs, newSpents utxo.Spents) *milestoneUTXOChangesResponse {
+	createdOutputs := make([]string, len(newOutputs))
+	consumedOutputs := make([]string, len(newSpents))
+
+	for i, output := range newOutputs {
+		createdOutputs[i] = output.OutputID().ToHex()
+	}
+
+	for i, spent := range newSpents {
+		consumedOutputs[i] = spent.OutputID().ToHex()
+	}
+
+	return &milestoneUTXOChangesResponse{
+		Index:           msIndex,
+		CreatedOutputs:  createdOutputs,
+		ConsumedOutputs: consumedOutputs,
+	}
+}
+
+// subscribeEvents upgrades the connection to a websocket and streams
+// the events of the requested topics until the client disconnects.
+func subscribeEvents(c echo.Context) error {
+	topics, err := parseEventTopicsQueryParam(c)
+	if err != nil {
+		return err
+	}
+
+	conn, err := eventsUpgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// the upgrader already replied to the client
+		Plugin.LogDebugf("upgrading events connection failed: %s", err)
+		return nil
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(Plugin.Daemon().ContextStopped())
+	defer cancel()
+
+	// the client is not supposed to send anything, but we need to read to process control frames and to detect disconnects.
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	wp := workerpool.New(func(task workerpool.Task) {
+		defer task.Return(nil)
+
+		topic := task.Param(0).(string)
+		payload := task.Param(1)
+
+		// block metadata is loaded in the worker to not slow down the solidifier
+		if blockID, ok := payload.(iotago.BlockID); ok {
+			metadata, err := blockMetadataByBlockID(blockID)
+			if err != nil {
+				// the block might have been pruned in the meantime
+				return
+			}
+			payload = metadata
+		}
+
+		if err := conn.SetWriteDeadline(time.Now().Add(eventsWriteTimeout)); err != nil {
+			cancel()
+			return
+		}
+		if err := conn.WriteJSON(&eventResponse{Topic: topic, Payload: payload}); err != nil {
+			Plugin.LogDebugf("sending event failed: %s", err)
+			cancel()
+		}
+	}, workerpool.WorkerCount(eventsWorkerCount), workerpool.QueueSize(eventsWorkerQueueSize), workerpool.FlushTasksAtShutdown(false))
+
+	submit := func(topic string, payload interface{}) {
+		if _, added := wp.TrySubmit(topic, payload); !added {
+			// the client is too slow to consume the events, disconnect it instead of blocking the node
+			cancel()
+		}
+	}
+
+	onLatestMilestoneChanged := events.NewClosure(func(cachedMilestone *storage.CachedMilestone) {
+		submit(EventTopicMilestoneLatest, milestoneInfoForCachedMilestone(cachedMilestone)) // milestone pass +1
+	})
+
+	onConfirmedMilestoneChanged := events.NewClosure(func(cachedMilestone *storage.CachedMilestone) {
+		submit(EventTopicMilestoneConfirmed, milestoneInfoForCachedMilestone(cachedMilestone)) // milestone pass +1
+	})
+
+	onBlockSolid := events.NewClosure(func(cachedBlockMeta *storage.CachedMetadata) {
+		defer cachedBlockMeta.Release(true) // meta -1
+		submit(EventTopicBlockMetadataSolid, cachedBlockMeta.Metadata().BlockID())
+	})
+
+	onBlockReferenced := events.NewClosure(func(cachedBlockMeta *storage.CachedMetadata, _ iotago.MilestoneIndex, _ uint32) {
+		defer cachedBlockMeta.Release(true) // meta -1
+		submit(EventTopicBlockMetadataReferenced, cachedBlockMeta.Metadata().BlockID())
+	})
+
+	onLedgerUpdated := events.NewClosure(func(msIndex iotago.MilestoneIndex, newOutputs utxo.Outputs, newSpents utxo.Spents) {
+		submit(EventTopicLedgerUpdates, ledgerUpdate(msIndex, newOutputs, newSpents))
+	})
+
+	wp.Start()
+	defer wp.Stop()
+
+	if _, ok := topics[EventTopicMilestoneLatest]; ok {
+		deps.Tangle.Events.LatestMilestoneChanged.Attach(onLatestMilestoneChanged)
+		defer deps.Tangle.Events.LatestMilestoneChanged.Detach(onLatestMilestoneChanged)
+	}
+	if _, ok := topics[EventTopicMilestoneConfirmed]; ok {
+		deps.Tangle.Events.ConfirmedMilestoneChanged.Attach(onConfirmedMilestoneChanged)
+		defer deps.Tangle.Events.ConfirmedMilestoneChanged.Detach(onConfirmedMilestoneChanged)
+	}
+	if _, ok := topics[EventTopicBlockMetadataSolid]; ok {
+		deps.Tangle.Events.BlockSolid.Attach(onBlockSolid)
+		defer deps.Tangle.Events.BlockSolid.Detach(onBlockSolid)
+	}
+	if _, ok := topics[EventTopicBlockMetadataReferenced]; ok {
+		deps.Tangle.Events.BlockReferenced.Attach(onBlockReferenced)
+		defer deps.Tangle.Events.BlockReferenced.Detach(onBlockReferenced)
+	}
+	if _, ok := topics[EventTopicLedgerUpdates]; ok {
+		deps.Tangle.Events.LedgerUpdated.Attach(onLedgerUpdated)
+		defer deps.Tangle.Events.LedgerUpdated.Detach(onLedgerUpdated)
+	}
+
+	<-ctx.Done()
+
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(eventsWriteTimeout))
+
+	return nil
+}
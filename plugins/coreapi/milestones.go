@@ -1,9 +1,15 @@
 package coreapi
 
 import (
+	"math"
+	"sort"
+
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 
+	"github.com/iotaledger/hornet/pkg/common"
+	"github.com/iotaledger/hornet/pkg/dag"
 	"github.com/iotaledger/hornet/pkg/model/storage"
 	"github.com/iotaledger/hornet/pkg/restapi"
 
@@ -11,6 +17,16 @@ import (
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
+const (
+	// the amount of milestone cones whose block IDs are kept in memory for the pages of the referenced blocks.
+	milestoneConeCacheSize = 32
+)
+
+var (
+	// the block IDs of the recently requested milestone cones in white flag order, keyed by milestone ID.
+	milestoneConeCache *lru.Cache
+)
+
 func storageMilestoneByIndex(c echo.Context) (*storage.Milestone, error) {
 
 	msIndex, err := restapi.ParseMilestoneIndexParam(c, restapi.ParameterMilestoneIndex)
@@ -84,22 +100,7 @@ func milestoneUTXOChanges(msIndex iotago.MilestoneIndex) (*milestoneUTXOChangesR
 		return nil, errors.WithMessagef(echo.ErrInternalServerError, "can't load milestone diff for index: %d, error: %s", msIndex, err)
 	}
 
-	createdOutputs := make([]string, len(diff.Outputs))
-	consumedOutputs := make([]string, len(diff.Spents))
-
-	for i, output := range diff.Outputs {
-		createdOutputs[i] = output.OutputID().ToHex()
-	}
-
-	for i, output := range diff.Spents {
-		consumedOutputs[i] = output.OutputID().ToHex()
-	}
-
-	return &milestoneUTXOChangesResponse{
-		Index:           msIndex,
-		CreatedOutputs:  createdOutputs,
-		ConsumedOutputs: consumedOutputs,
-	}, nil
+	return ledgerUpdate(msIndex, diff.Outputs, diff.Spents), nil
 }
 
 func milestoneUTXOChangesByIndex(c echo.Context) (*milestoneUTXOChangesResponse, error) {
@@ -119,3 +120,217 @@ func milestoneUTXOChangesByID(c echo.Context) (*milestoneUTXOChangesResponse, er
 
 	return milestoneUTXOChanges(ms.Index())
 }
+
+// milestoneIndexByTimestamp returns the lowest milestone index in the given range
+// whose milestone timestamp is equal or bigger than the given timestamp.
+// If there is no such milestone, endIndex+1 is returned.
+func milestoneIndexByTimestamp(startIndex iotago.MilestoneIndex, endIndex iotago.MilestoneIndex, timestamp uint32) (iotago.MilestoneIndex, error) {
+	if startIndex > endIndex {
+		return startIndex, nil
+	}
+
+	var innerErr error
+	offset := sort.Search(int(endIndex-startIndex)+1, func(i int) bool {
+		msIndex := startIndex + iotago.MilestoneIndex(i)
+
+		cachedMilestone := deps.Storage.CachedMilestoneByIndexOrNil(msIndex) // milestone +1
+		if cachedMilestone == nil {
+			innerErr = errors.WithMessagef(echo.ErrNotFound, "milestone index not found: %d", msIndex)
+			return true
+		}
+		defer cachedMilestone.Release(true) // milestone -1
+
+		return cachedMilestone.Milestone().TimestampUnix() >= timestamp
+	})
+	if innerErr != nil {
+		return 0, innerErr
+	}
+
+	return startIndex + iotago.MilestoneIndex(offset), nil
+}
+
+func milestones(c echo.Context) (*milestonesResponse, error) {
+	pageSize, err := restapi.ParsePageSizeQueryParam(c, deps.RestAPILimitsMaxResults)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := restapi.ParseCursorQueryParam(c)
+	if err != nil {
+		return nil, err
+	}
+
+	startIndex, err := restapi.ParseMilestoneIndexQueryParam(c, restapi.QueryParameterStartIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	endIndex, err := restapi.ParseMilestoneIndexQueryParam(c, restapi.QueryParameterEndIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	startTimestamp, err := restapi.ParseUnixTimestampQueryParam(c, restapi.QueryParameterStartTimestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	endTimestamp, err := restapi.ParseUnixTimestampQueryParam(c, restapi.QueryParameterEndTimestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotInfo := deps.Storage.SnapshotInfo()
+	if snapshotInfo == nil {
+		return nil, errors.WithMessage(echo.ErrInternalServerError, common.ErrSnapshotInfoNotFound.Error())
+	}
+
+	// only confirmed milestones above the pruning index of the milestone payloads are available
+	lowestIndex := snapshotInfo.PruningIndexOf(storage.PruningClassMilestones) + 1
+	highestIndex := deps.SyncManager.ConfirmedMilestoneIndex()
+
+	if startIndex > lowestIndex {
+		lowestIndex = startIndex
+	}
+	if endIndex != 0 && endIndex < highestIndex {
+		highestIndex = endIndex
+	}
+
+	if startTimestamp != 0 {
+		if lowestIndex, err = milestoneIndexByTimestamp(lowestIndex, highestIndex, startTimestamp); err != nil {
+			return nil, err
+		}
+	}
+	if endTimestamp != 0 && endTimestamp != math.MaxUint32 {
+		firstIndexAfterEnd, err := milestoneIndexByTimestamp(lowestIndex, highestIndex, endTimestamp+1)
+		if err != nil {
+			return nil, err
+		}
+		highestIndex = firstIndexAfterEnd - 1
+	}
+
+	if cursor > lowestIndex {
+		lowestIndex = cursor
+	}
+
+	response := &milestonesResponse{
+		PageSize:   pageSize,
+		Milestones: make([]*milestoneInfoResponse, 0),
+	}
+
+	for msIndex := lowestIndex; msIndex <= highestIndex; msIndex++ {
+		if len(response.Milestones) >= pageSize {
+			nextCursor := msIndex
+			response.Cursor = &nextCursor
+			break
+		}
+
+		cachedMilestone := deps.Storage.CachedMilestoneByIndexOrNil(msIndex) // milestone +1
+		if cachedMilestone == nil {
+			continue
+		}
+		response.Milestones = append(response.Milestones, milestoneInfoForCachedMilestone(cachedMilestone)) // milestone pass +1
+	}
+
+	return response, nil
+}
+
+// milestoneConeBlockIDs returns the IDs of all blocks referenced by the given milestone in white flag order.
+// The cone of a confirmed milestone doesn't change, so it is cached to serve the following pages without walking it again.
+func milestoneConeBlockIDs(ms *storage.Milestone) (iotago.BlockIDs, error) {
+
+	if ms.Index() > deps.SyncManager.ConfirmedMilestoneIndex() {
+		return nil, errors.WithMessagef(echo.ErrNotFound, "milestone %d not confirmed yet", ms.Index())
+	}
+
+	milestoneID := ms.MilestoneID()
+	if blockIDs, exists := milestoneConeCache.Get(milestoneID); exists {
+		return blockIDs.(iotago.BlockIDs), nil
+	}
+
+	memcachedTraverserStorage := dag.NewMemcachedTraverserStorage(deps.Storage, storage.NewMetadataMemcache(deps.Storage.CachedBlockMetadata))
+	defer memcachedTraverserStorage.Cleanup(true)
+
+	blockIDs, err := dag.MilestoneConeBlockIDs(Plugin.Daemon().ContextStopped(), memcachedTraverserStorage, ms.Index(), ms.Parents())
+	if err != nil {
+		if errors.Is(err, common.ErrOperationAborted) {
+			return nil, errors.WithMessagef(echo.ErrServiceUnavailable, "traverse parents failed, error: %s", err)
+		}
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "traverse parents failed, error: %s", err)
+	}
+	milestoneConeCache.Add(milestoneID, blockIDs)
+
+	return blockIDs, nil
+}
+
+func milestoneReferencedBlocks(c echo.Context, ms *storage.Milestone) (*milestoneReferencedBlocksResponse, error) {
+	pageSize, err := restapi.ParsePageSizeQueryParam(c, deps.RestAPILimitsMaxResults)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := restapi.ParseCursorQueryParam(c)
+	if err != nil {
+		return nil, err
+	}
+
+	blockIDs, err := milestoneConeBlockIDs(ms)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &milestoneReferencedBlocksResponse{
+		Index:       ms.Index(),
+		MilestoneID: ms.MilestoneIDHex(),
+		PageSize:    pageSize,
+		Blocks:      make([]*blockWithMetadataResponse, 0),
+	}
+
+	// the white flag index of a block is its position in the cone
+	for wfIndex := cursor; wfIndex < uint32(len(blockIDs)); wfIndex++ {
+		if len(response.Blocks) >= pageSize {
+			nextCursor := wfIndex
+			response.Cursor = &nextCursor
+			break
+		}
+
+		blockID := blockIDs[wfIndex]
+
+		cachedBlock := deps.Storage.CachedBlockOrNil(blockID) // block +1
+		if cachedBlock == nil {
+			return nil, errors.WithMessagef(echo.ErrInternalServerError, "block not found: %s", blockID.ToHex())
+		}
+		block := cachedBlock.Block().Block()
+		cachedBlock.Release(true) // block -1
+
+		blockMetadata, err := blockMetadataByBlockID(blockID)
+		if err != nil {
+			return nil, err
+		}
+
+		response.Blocks = append(response.Blocks, &blockWithMetadataResponse{
+			Block:    block,
+			Metadata: blockMetadata,
+		})
+	}
+
+	return response, nil
+}
+
+func milestoneReferencedBlocksByIndex(c echo.Context) (*milestoneReferencedBlocksResponse, error) {
+	ms, err := storageMilestoneByIndex(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return milestoneReferencedBlocks(c, ms)
+}
+
+func milestoneReferencedBlocksByID(c echo.Context) (*milestoneReferencedBlocksResponse, error) {
+	ms, err := storageMilestoneByID(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return milestoneReferencedBlocks(c, ms)
+}
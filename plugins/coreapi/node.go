@@ -7,11 +7,12 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/iotaledger/hornet/pkg/common"
+	"github.com/iotaledger/hornet/pkg/model/storage"
+	"github.com/iotaledger/hornet/pkg/model/utxo"
 	"github.com/iotaledger/hornet/pkg/tipselect"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
-//nolint:unparam // even if the error is never used, the structure of all routes should be the same
 func info() (*infoResponse, error) {
 
 	var blocksPerSecond, referencedBlocksPerSecond, referencedRate float64
@@ -46,9 +47,28 @@ func info() (*infoResponse, error) {
 
 	// pruning index
 	var pruningIndex iotago.MilestoneIndex
+	var pruningIndexes map[string]iotago.MilestoneIndex
 	snapshotInfo := deps.Storage.SnapshotInfo()
 	if snapshotInfo != nil {
 		pruningIndex = snapshotInfo.PruningIndex()
+
+		pruningIndexes = make(map[string]iotago.MilestoneIndex, len(storage.PruningClasses))
+		for _, class := range storage.PruningClasses {
+			pruningIndexes[class.String()] = snapshotInfo.PruningIndexOf(class)
+		}
+	}
+
+	// ledger state commitment
+	var ledgerStateCommitmentInfo *ledgerStateCommitmentResponse
+	ledgerIndex, ledgerStateCommitment, err := deps.UTXOManager.LedgerStateCommitment()
+	switch {
+	case err == nil:
+		ledgerStateCommitmentInfo = &ledgerStateCommitmentResponse{
+			LedgerIndex: ledgerIndex,
+			Commitment:  ledgerStateCommitment.DigestHex(),
+		}
+	case !errors.Is(err, utxo.ErrLedgerStateCommitmentNotLoaded):
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading ledger state commitment failed, error: %s", err)
 	}
 
 	return &infoResponse{
@@ -66,7 +86,9 @@ func info() (*infoResponse, error) {
 				Timestamp:   confirmedMilestoneTimestamp,
 				MilestoneID: confirmedMilestoneIDHex,
 			},
-			PruningIndex: pruningIndex,
+			PruningIndex:          pruningIndex,
+			PruningIndexes:        pruningIndexes,
+			LedgerStateCommitment: ledgerStateCommitmentInfo,
 		},
 		SupportedProtocolVersions: deps.ProtocolManager.SupportedVersions(),
 		ProtocolParameters:        deps.ProtocolManager.Current(),
@@ -0,0 +1,135 @@
+package coreapi
+
+import (
+	"bytes"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hornet/pkg/model/utxo"
+	"github.com/iotaledger/hornet/pkg/restapi"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func indexedOutputIDs(c echo.Context, indexType utxo.OutputIndexType, key []byte) (*indexedOutputsResponse, error) {
+	pageSize, err := restapi.ParsePageSizeQueryParam(c, deps.RestAPILimitsMaxResults)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor *iotago.OutputID
+	if cursorParam := c.QueryParam(restapi.QueryParameterCursor); len(cursorParam) > 0 {
+		outputID, err := iotago.OutputIDFromHex(cursorParam)
+		if err != nil {
+			return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid cursor: %s, error: %s", cursorParam, err)
+		}
+		cursor = &outputID
+	}
+
+	atIndex, err := restapi.ParseMilestoneIndexQueryParam(c, restapi.QueryParameterAtIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	// we need to lock the ledger here to have the correct index for the unspent outputs.
+	deps.UTXOManager.ReadLockLedger()
+	defer deps.UTXOManager.ReadUnlockLedger()
+
+	ledgerIndex, err := deps.UTXOManager.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading ledger index failed, error: %s", err)
+	}
+
+	response := &indexedOutputsResponse{
+		LedgerIndex: ledgerIndex,
+		PageSize:    pageSize,
+		Items:       make([]string, 0),
+	}
+
+	consumer := func(outputID iotago.OutputID) bool {
+		if cursor != nil && bytes.Compare(outputID[:], cursor[:]) < 0 {
+			return true
+		}
+
+		if len(response.Items) >= pageSize {
+			nextCursor := outputID.ToHex()
+			response.Cursor = &nextCursor
+			return false
+		}
+
+		response.Items = append(response.Items, outputID.ToHex())
+		return true
+	}
+
+	// the output IDs are iterated in lexical order
+	if atIndex != 0 {
+		response.LedgerIndex = atIndex
+		if err := deps.UTXOManager.ForEachUnspentOutputIDInIndexAtIndexWithoutLocking(indexType, key, atIndex, consumer); err != nil {
+			if errors.Is(err, utxo.ErrHistoricalStateUnavailable) {
+				return nil, errors.WithMessagef(echo.ErrNotFound, "ledger state at milestone index %d is not available", atIndex)
+			}
+			return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading indexed outputs failed, error: %s", err)
+		}
+
+		return response, nil
+	}
+
+	if err := deps.UTXOManager.ForEachUnspentOutputIDInIndexWithoutLocking(indexType, key, consumer); err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading indexed outputs failed, error: %s", err)
+	}
+
+	return response, nil
+}
+
+func indexedOutputIDsByAddress(c echo.Context, indexType utxo.OutputIndexType) (*indexedOutputsResponse, error) {
+	address, err := restapi.ParseBech32AddressParam(c, deps.ProtocolManager.Current().Bech32HRP)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := utxo.OutputIndexKeyForAddress(address)
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "serializing address failed, error: %s", err)
+	}
+
+	return indexedOutputIDs(c, indexType, key)
+}
+
+func outputsByAddress(c echo.Context) (*indexedOutputsResponse, error) {
+	return indexedOutputIDsByAddress(c, utxo.OutputIndexTypeAddress)
+}
+
+func outputsBySender(c echo.Context) (*indexedOutputsResponse, error) {
+	return indexedOutputIDsByAddress(c, utxo.OutputIndexTypeSender)
+}
+
+func outputsByIssuer(c echo.Context) (*indexedOutputsResponse, error) {
+	return indexedOutputIDsByAddress(c, utxo.OutputIndexTypeIssuer)
+}
+
+func outputsByAliasID(c echo.Context) (*indexedOutputsResponse, error) {
+	aliasID, err := restapi.ParseAliasIDParam(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexedOutputIDs(c, utxo.OutputIndexTypeAliasID, aliasID[:])
+}
+
+func outputsByNFTID(c echo.Context) (*indexedOutputsResponse, error) {
+	nftID, err := restapi.ParseNFTIDParam(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexedOutputIDs(c, utxo.OutputIndexTypeNFTID, nftID[:])
+}
+
+func outputsByFoundryID(c echo.Context) (*indexedOutputsResponse, error) {
+	foundryID, err := restapi.ParseFoundryIDParam(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexedOutputIDs(c, utxo.OutputIndexTypeFoundryID, foundryID[:])
+}
@@ -1,6 +1,8 @@
 package coreapi
 
 import (
+	"time"
+
 	"github.com/labstack/echo/v4"
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/multiformats/go-multiaddr"
@@ -30,6 +32,18 @@ func WrapInfoSnapshot(info *p2p.PeerInfoSnapshot) *PeerResponse {
 		gossipInfo = gossipProto.Info()
 	}
 
+	score := &peerScoreResponse{
+		Value:               info.Score.Value(),
+		InvalidData:         info.Score.InvalidData,
+		UnansweredRequests:  info.Score.UnansweredRequests,
+		NewBlocks:           info.Score.NewBlocks,
+		LatencyMilliseconds: info.Score.Latency.Milliseconds(),
+		Banned:              info.Score.Banned(time.Now()),
+	}
+	if score.Banned {
+		score.BannedUntil = info.Score.BannedUntil.Unix()
+	}
+
 	return &PeerResponse{
 		ID:             info.ID,
 		MultiAddresses: multiAddresses,
@@ -37,6 +51,7 @@ func WrapInfoSnapshot(info *p2p.PeerInfoSnapshot) *PeerResponse {
 		Relation:       info.Relation,
 		Connected:      info.Connected,
 		Gossip:         gossipInfo,
+		Score:          score,
 	}
 }
 
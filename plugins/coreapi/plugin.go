@@ -3,6 +3,7 @@ package coreapi
 import (
 	"net/http"
 
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 	"go.uber.org/dig"
@@ -30,6 +31,11 @@ const (
 	// GET returns the node info.
 	RouteInfo = "/info"
 
+	// RouteEvents is the route for subscribing to node events.
+	// GET upgrades the connection to a websocket and streams the events of the topics given in the "topics" query parameter.
+	// If no topics are given, all topics are streamed.
+	RouteEvents = "/events"
+
 	// RouteTips is the route for getting tips.
 	// GET returns the tips.
 	RouteTips = "/tips"
@@ -44,6 +50,12 @@ const (
 	// GET returns block metadata (including info about "promotion/reattachment needed").
 	RouteBlockMetadata = "/blocks/:" + restapipkg.ParameterBlockID + "/metadata"
 
+	// RouteBlockInclusionProof is the route for getting the proof that a transaction block was included in the ledger.
+	// GET returns the milestone that included the block and the merkle audit path of the block against its applied merkle root.
+	// There is no INX method for the proof on purpose, since the INX protocol is defined outside of the node,
+	// INX extensions get the proof from this route via PerformAPIRequest instead.
+	RouteBlockInclusionProof = "/blocks/:" + restapipkg.ParameterBlockID + "/inclusion-proof"
+
 	// RouteBlocks is the route for creating new blocks.
 	// POST creates a single new block and returns the new block ID.
 	// The block is parsed based on the given type in the request "Content-Type" header.
@@ -57,6 +69,11 @@ const (
 	// MIMEVendorIOTASerializer => bytes
 	RouteTransactionsIncludedBlock = "/transactions/:" + restapipkg.ParameterTransactionID + "/included-block"
 
+	// RouteMilestones is the route for getting the confirmed milestones of an index or time range.
+	// GET returns the milestones, paginated by the "pageSize" and "cursor" query parameters.
+	// The range can be filtered by the "startIndex", "endIndex", "startTimestamp" and "endTimestamp" query parameters.
+	RouteMilestones = "/milestones"
+
 	// RouteMilestoneByID is the route for getting a milestone by its ID.
 	// GET returns the milestone.
 	// MIMEApplicationJSON => json
@@ -67,6 +84,10 @@ const (
 	// GET returns the output IDs of all UTXO changes.
 	RouteMilestoneByIDUTXOChanges = "/milestones/:" + restapipkg.ParameterMilestoneID + "/utxo-changes"
 
+	// RouteMilestoneByIDReferencedBlocks is the route for getting all blocks referenced by a milestone by its ID.
+	// GET returns the blocks and their metadata in white flag order, paginated by the "pageSize" and "cursor" query parameters.
+	RouteMilestoneByIDReferencedBlocks = "/milestones/:" + restapipkg.ParameterMilestoneID + "/referenced-blocks"
+
 	// RouteMilestoneByIndex is the route for getting a milestone by its milestoneIndex.
 	// GET returns the milestone.
 	// MIMEApplicationJSON => json
@@ -77,16 +98,56 @@ const (
 	// GET returns the output IDs of all UTXO changes.
 	RouteMilestoneByIndexUTXOChanges = "/milestones/by-index/:" + restapipkg.ParameterMilestoneIndex + "/utxo-changes"
 
+	// RouteMilestoneByIndexReferencedBlocks is the route for getting all blocks referenced by a milestone by its milestoneIndex.
+	// GET returns the blocks and their metadata in white flag order, paginated by the "pageSize" and "cursor" query parameters.
+	RouteMilestoneByIndexReferencedBlocks = "/milestones/by-index/:" + restapipkg.ParameterMilestoneIndex + "/referenced-blocks"
+
 	// RouteOutput is the route for getting an output by its outputID (transactionHash + outputIndex).
 	// GET returns the output based on the given type in the request "Accept" header.
 	// MIMEApplicationJSON => json
 	// MIMEVendorIOTASerializer => bytes
+	// The optional "atIndex" query parameter returns the output as it was known to the ledger at the given milestone index.
 	RouteOutput = "/outputs/:" + restapipkg.ParameterOutputID
 
 	// RouteOutputMetadata is the route for getting output metadata by its outputID (transactionHash + outputIndex) without getting the data again.
 	// GET returns the output metadata.
+	// The optional "atIndex" query parameter returns the metadata as it was at the given milestone index.
 	RouteOutputMetadata = "/outputs/:" + restapipkg.ParameterOutputID + "/metadata"
 
+	// RouteOutputsByAddress is the route for getting the IDs of all unspent outputs that can be unlocked by a bech32 address.
+	// GET returns the output IDs, paginated by the "pageSize" and "cursor" query parameters.
+	// Only available if the output index is enabled.
+	// The optional "atIndex" query parameter returns the IDs of the outputs that were unspent at the given milestone index,
+	// which are the balance of the address at that index. INX extensions query it via PerformAPIRequest.
+	RouteOutputsByAddress = "/outputs/by-address/:" + restapipkg.ParameterAddress
+
+	// RouteOutputsBySender is the route for getting the IDs of all unspent outputs with the given bech32 address in the sender feature.
+	// GET returns the output IDs, paginated by the "pageSize" and "cursor" query parameters.
+	// Only available if the output index is enabled.
+	// The optional "atIndex" query parameter returns the IDs of the outputs that were unspent at the given milestone index.
+	RouteOutputsBySender = "/outputs/by-sender/:" + restapipkg.ParameterAddress
+
+	// RouteOutputsByIssuer is the route for getting the IDs of all unspent outputs with the given bech32 address in the issuer feature.
+	// GET returns the output IDs, paginated by the "pageSize" and "cursor" query parameters.
+	// Only available if the output index is enabled.
+	// The optional "atIndex" query parameter returns the IDs of the outputs that were unspent at the given milestone index.
+	RouteOutputsByIssuer = "/outputs/by-issuer/:" + restapipkg.ParameterAddress
+
+	// RouteOutputsByAliasID is the route for getting the ID of the unspent output of an alias by its aliasID.
+	// GET returns the output IDs.
+	// Only available if the output index is enabled.
+	RouteOutputsByAliasID = "/outputs/alias/:" + restapipkg.ParameterAliasID
+
+	// RouteOutputsByNFTID is the route for getting the ID of the unspent output of a NFT by its nftID.
+	// GET returns the output IDs.
+	// Only available if the output index is enabled.
+	RouteOutputsByNFTID = "/outputs/nft/:" + restapipkg.ParameterNFTID
+
+	// RouteOutputsByFoundryID is the route for getting the ID of the unspent output of a foundry by its foundryID.
+	// GET returns the output IDs.
+	// Only available if the output index is enabled.
+	RouteOutputsByFoundryID = "/outputs/foundry/:" + restapipkg.ParameterFoundryID
+
 	// RouteTreasury is the route for getting the current treasury output.
 	// GET returns the treasury.
 	RouteTreasury = "/treasury"
@@ -114,12 +175,23 @@ const (
 	RoutePeers = "/peers"
 
 	// RouteControlDatabasePrune is the control route to manually prune the database.
-	// POST prunes the database.
+	// POST prunes the database, or estimates the impact of the pruning if "dryRun" is set.
 	RouteControlDatabasePrune = "/control/database/prune"
 
 	// RouteControlSnapshotsCreate is the control route to manually create a snapshot files.
 	// POST creates a full snapshot.
+	// Deprecated: the snapshot is created within the request, use RouteControlSnapshots instead.
 	RouteControlSnapshotsCreate = "/control/snapshots/create"
+
+	// RouteControlSnapshots is the control route to schedule the creation of snapshot files.
+	// POST adds a job that creates a full or delta snapshot at the given index.
+	// The job is rejected right away if the database doesn't contain enough history for the snapshot.
+	RouteControlSnapshots = "/control/snapshots"
+
+	// RouteControlSnapshotJob is the control route to manage a scheduled snapshot creation.
+	// GET returns the state and the progress of the job.
+	// DELETE cancels the job.
+	RouteControlSnapshotJob = "/control/snapshots/:" + restapipkg.ParameterSnapshotJobID
 )
 
 func init() {
@@ -152,6 +224,7 @@ type dependencies struct {
 	UTXOManager             *utxo.Manager
 	PoWHandler              *pow.Handler
 	SnapshotManager         *snapshot.Manager
+	SnapshotJobManager      *snapshot.JobManager
 	PruningManager          *pruning.Manager
 	AppInfo                 *app.AppInfo
 	PeeringConfigManager    *p2p.ConfigManager
@@ -163,6 +236,7 @@ type dependencies struct {
 	TipSelector             *tipselect.TipSelector    `optional:"true"`
 	RestRouteManager        *restapi.RestRouteManager `optional:"true"`
 	RestAPIMetrics          *metrics.RestAPIMetrics
+	RestAPIRateLimiter      *restapipkg.RateLimiter
 }
 
 func configure() error {
@@ -189,6 +263,12 @@ func configure() error {
 
 	attacher = deps.Tangle.BlockAttacher(attacherOpts...)
 
+	coneCache, err := lru.New(milestoneConeCacheSize)
+	if err != nil {
+		return err
+	}
+	milestoneConeCache = coneCache
+
 	routeGroup.GET(RouteInfo, func(c echo.Context) error {
 		resp, err := info()
 		if err != nil {
@@ -197,6 +277,8 @@ func configure() error {
 		return restapipkg.JSONResponse(c, http.StatusOK, resp)
 	})
 
+	routeGroup.GET(RouteEvents, subscribeEvents)
+
 	// only handle tips api calls if the URTS plugin is enabled
 	if deps.TipSelector != nil {
 		routeGroup.GET(RouteTips, func(c echo.Context) error {
@@ -216,6 +298,14 @@ func configure() error {
 		return restapipkg.JSONResponse(c, http.StatusOK, resp)
 	}, checkNodeAlmostSynced())
 
+	routeGroup.GET(RouteBlockInclusionProof, func(c echo.Context) error {
+		resp, err := blockInclusionProof(c)
+		if err != nil {
+			return err
+		}
+		return restapipkg.JSONResponse(c, http.StatusOK, resp)
+	})
+
 	routeGroup.GET(RouteBlock, func(c echo.Context) error {
 		mimeType, err := restapipkg.GetAcceptHeaderContentType(c, restapipkg.MIMEApplicationVendorIOTASerializerV1, echo.MIMEApplicationJSON)
 		if err != nil && err != restapipkg.ErrNotAcceptable {
@@ -273,6 +363,14 @@ func configure() error {
 		}
 	})
 
+	routeGroup.GET(RouteMilestones, func(c echo.Context) error {
+		resp, err := milestones(c)
+		if err != nil {
+			return err
+		}
+		return restapipkg.JSONResponse(c, http.StatusOK, resp)
+	})
+
 	routeGroup.GET(RouteMilestoneByID, func(c echo.Context) error {
 		mimeType, err := restapipkg.GetAcceptHeaderContentType(c, restapipkg.MIMEApplicationVendorIOTASerializerV1, echo.MIMEApplicationJSON)
 		if err != nil && err != restapipkg.ErrNotAcceptable {
@@ -305,6 +403,14 @@ func configure() error {
 		return restapipkg.JSONResponse(c, http.StatusOK, resp)
 	})
 
+	routeGroup.GET(RouteMilestoneByIDReferencedBlocks, func(c echo.Context) error {
+		resp, err := milestoneReferencedBlocksByID(c)
+		if err != nil {
+			return err
+		}
+		return restapipkg.JSONResponse(c, http.StatusOK, resp)
+	})
+
 	routeGroup.GET(RouteMilestoneByIndex, func(c echo.Context) error {
 		mimeType, err := restapipkg.GetAcceptHeaderContentType(c, restapipkg.MIMEApplicationVendorIOTASerializerV1, echo.MIMEApplicationJSON)
 		if err != nil && err != restapipkg.ErrNotAcceptable {
@@ -337,6 +443,14 @@ func configure() error {
 		return restapipkg.JSONResponse(c, http.StatusOK, resp)
 	})
 
+	routeGroup.GET(RouteMilestoneByIndexReferencedBlocks, func(c echo.Context) error {
+		resp, err := milestoneReferencedBlocksByIndex(c)
+		if err != nil {
+			return err
+		}
+		return restapipkg.JSONResponse(c, http.StatusOK, resp)
+	})
+
 	routeGroup.GET(RouteOutput, func(c echo.Context) error {
 		mimeType, err := restapipkg.GetAcceptHeaderContentType(c, restapipkg.MIMEApplicationVendorIOTASerializerV1, echo.MIMEApplicationJSON)
 		if err != nil && err != restapipkg.ErrNotAcceptable {
@@ -369,6 +483,59 @@ func configure() error {
 		return restapipkg.JSONResponse(c, http.StatusOK, resp)
 	})
 
+	// only handle output index api calls if the output index is enabled
+	if deps.UTXOManager.OutputIndexEnabled() {
+		AddFeature("OutputIndex")
+
+		routeGroup.GET(RouteOutputsByAddress, func(c echo.Context) error {
+			resp, err := outputsByAddress(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+
+		routeGroup.GET(RouteOutputsBySender, func(c echo.Context) error {
+			resp, err := outputsBySender(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+
+		routeGroup.GET(RouteOutputsByIssuer, func(c echo.Context) error {
+			resp, err := outputsByIssuer(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+
+		routeGroup.GET(RouteOutputsByAliasID, func(c echo.Context) error {
+			resp, err := outputsByAliasID(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+
+		routeGroup.GET(RouteOutputsByNFTID, func(c echo.Context) error {
+			resp, err := outputsByNFTID(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+
+		routeGroup.GET(RouteOutputsByFoundryID, func(c echo.Context) error {
+			resp, err := outputsByFoundryID(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+	}
+
 	routeGroup.GET(RouteTreasury, func(c echo.Context) error {
 		resp, err := treasury(c)
 		if err != nil {
@@ -433,7 +600,20 @@ func configure() error {
 	}, checkNodeAlmostSynced(), checkUpcomingUnsupportedProtocolVersion())
 
 	routeGroup.POST(RouteControlDatabasePrune, func(c echo.Context) error {
-		resp, err := pruneDatabase(c)
+		request, err := parsePruneDatabaseRequest(c)
+		if err != nil {
+			return err
+		}
+
+		if request.DryRun {
+			resp, err := estimatePruneDatabase(request)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		}
+
+		resp, err := pruneDatabase(request)
 		if err != nil {
 			return err
 		}
@@ -448,6 +628,30 @@ func configure() error {
 		return restapipkg.JSONResponse(c, http.StatusOK, resp)
 	})
 
+	routeGroup.POST(RouteControlSnapshots, func(c echo.Context) error {
+		resp, err := addSnapshotJob(c)
+		if err != nil {
+			return err
+		}
+		return restapipkg.JSONResponse(c, http.StatusAccepted, resp)
+	})
+
+	routeGroup.GET(RouteControlSnapshotJob, func(c echo.Context) error {
+		resp, err := snapshotJob(c)
+		if err != nil {
+			return err
+		}
+		return restapipkg.JSONResponse(c, http.StatusOK, resp)
+	})
+
+	routeGroup.DELETE(RouteControlSnapshotJob, func(c echo.Context) error {
+		resp, err := cancelSnapshotJob(c)
+		if err != nil {
+			return err
+		}
+		return restapipkg.JSONResponse(c, http.StatusOK, resp)
+	})
+
 	return nil
 }
 
@@ -0,0 +1,61 @@
+package coreapi
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hornet/pkg/common"
+	"github.com/iotaledger/hornet/pkg/dag"
+	"github.com/iotaledger/hornet/pkg/model/storage"
+	"github.com/iotaledger/hornet/pkg/restapi"
+	"github.com/iotaledger/hornet/pkg/whiteflag"
+)
+
+func blockInclusionProof(c echo.Context) (*blockInclusionProofResponse, error) {
+	blockID, err := restapi.ParseBlockIDParam(c)
+	if err != nil {
+		return nil, err
+	}
+
+	cachedBlockMeta := deps.Storage.CachedBlockMetadataOrNil(blockID) // meta +1
+	if cachedBlockMeta == nil {
+		return nil, errors.WithMessagef(echo.ErrNotFound, "block not found: %s", blockID.ToHex())
+	}
+	included := cachedBlockMeta.Metadata().IsIncludedTxInLedger()
+	_, msIndex := cachedBlockMeta.Metadata().ReferencedWithIndex()
+	cachedBlockMeta.Release(true) // meta -1
+
+	if !included {
+		return nil, errors.WithMessagef(echo.ErrNotFound, "block was not included in the ledger: %s", blockID.ToHex())
+	}
+
+	cachedMilestone := deps.Storage.CachedMilestoneByIndexOrNil(msIndex) // milestone +1
+	if cachedMilestone == nil {
+		return nil, errors.WithMessagef(echo.ErrNotFound, "milestone not found: %d", msIndex)
+	}
+	milestone := cachedMilestone.Milestone()
+	cachedMilestone.Release(true) // milestone -1
+
+	memcachedTraverserStorage := dag.NewMemcachedTraverserStorage(deps.Storage, storage.NewMetadataMemcache(deps.Storage.CachedBlockMetadata))
+	defer memcachedTraverserStorage.Cleanup(true)
+
+	referencedBlocks, err := whiteflag.ConfirmedReferencedBlocks(Plugin.Daemon().ContextStopped(), memcachedTraverserStorage, msIndex, milestone.Parents())
+	if err != nil {
+		if errors.Is(err, common.ErrOperationAborted) {
+			return nil, errors.WithMessagef(echo.ErrServiceUnavailable, "traverse parents failed, error: %s", err)
+		}
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "traverse parents failed, error: %s", err)
+	}
+
+	proof, err := whiteflag.ComputeInclusionProof(referencedBlocks, blockID)
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "computing inclusion proof failed, error: %s", err)
+	}
+
+	return &blockInclusionProofResponse{
+		BlockID:     blockID.ToHex(),
+		MilestoneID: milestone.MilestoneIDHex(),
+		Milestone:   milestone.Milestone(),
+		Proof:       proof,
+	}, nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/iotaledger/hornet/pkg/model/utxo"
 	"github.com/iotaledger/hornet/pkg/protocol/gossip"
 	iotago "github.com/iotaledger/iota.go/v3"
+	"github.com/iotaledger/iota.go/v3/merklehasher"
 )
 
 // milestoneInfoResponse defines the milestone info response.
@@ -35,6 +36,19 @@ type nodeStatus struct {
 	ConfirmedMilestone milestoneInfoResponse `json:"confirmedMilestone"`
 	// The milestone index at which the last pruning commenced.
 	PruningIndex iotago.MilestoneIndex `json:"pruningIndex"`
+	// The milestone indexes at which the last pruning of the data classes commenced.
+	// The data of classes with a longer retention is still available below the pruning index.
+	PruningIndexes map[string]iotago.MilestoneIndex `json:"pruningIndexes,omitempty"`
+	// The commitment of the current ledger state, omitted if it was not loaded yet.
+	LedgerStateCommitment *ledgerStateCommitmentResponse `json:"ledgerStateCommitment,omitempty"`
+}
+
+// ledgerStateCommitmentResponse defines the commitment of the ledger state at a milestone index.
+type ledgerStateCommitmentResponse struct {
+	// The milestone index of the ledger state.
+	LedgerIndex iotago.MilestoneIndex `json:"ledgerIndex"`
+	// The hex encoded digest of the order-independent commitment to all unspent outputs.
+	Commitment string `json:"commitment"`
 }
 
 type nodeMetrics struct {
@@ -68,6 +82,14 @@ type infoResponse struct {
 	Features []string `json:"features"`
 }
 
+// eventResponse defines the message that is sent to clients subscribed to the events route.
+type eventResponse struct {
+	// The topic of the event.
+	Topic string `json:"topic"`
+	// The payload of the event.
+	Payload interface{} `json:"payload"`
+}
+
 // tipsResponse defines the response of a GET tips REST API call.
 type tipsResponse struct {
 	// The hex encoded block IDs of the tips.
@@ -119,6 +141,54 @@ type milestoneUTXOChangesResponse struct {
 	ConsumedOutputs []string `json:"consumedOutputs"`
 }
 
+// milestonesResponse defines the response of a GET milestones REST API call.
+type milestonesResponse struct {
+	// The milestones of the requested range.
+	Milestones []*milestoneInfoResponse `json:"milestones"`
+	// The maximum number of results per page.
+	PageSize int `json:"pageSize"`
+	// The cursor to use for getting the next results.
+	// The cursor is omitted if there are no more results.
+	Cursor *iotago.MilestoneIndex `json:"cursor,omitempty"`
+}
+
+// blockWithMetadataResponse defines a block and its metadata.
+type blockWithMetadataResponse struct {
+	// The block.
+	Block *iotago.Block `json:"block"`
+	// The metadata of the block.
+	Metadata *blockMetadataResponse `json:"metadata"`
+}
+
+// blockInclusionProofResponse defines the response of a GET block inclusion proof REST API call.
+type blockInclusionProofResponse struct {
+	// The hex encoded block ID of the included transaction block.
+	BlockID string `json:"blockId"`
+	// The hex encoded ID of the milestone that included the block.
+	MilestoneID string `json:"milestoneId"`
+	// The milestone that included the block. The proof hashes to its applied merkle root.
+	Milestone *iotago.Milestone `json:"milestone"`
+	// The merkle audit path of the block.
+	// The proof is omitted if the block is the only included transaction block of the milestone,
+	// in that case the applied merkle root is the leaf hash of the block ID.
+	Proof *merklehasher.Proof `json:"proof,omitempty"`
+}
+
+// milestoneReferencedBlocksResponse defines the response of a GET milestone referenced blocks REST API call.
+type milestoneReferencedBlocksResponse struct {
+	// The index of the milestone.
+	Index iotago.MilestoneIndex `json:"index"`
+	// The ID of the milestone.
+	MilestoneID string `json:"milestoneId"`
+	// The blocks referenced by the milestone in white flag order.
+	Blocks []*blockWithMetadataResponse `json:"blocks"`
+	// The maximum number of results per page.
+	PageSize int `json:"pageSize"`
+	// The cursor (white flag index) to use for getting the next results.
+	// The cursor is omitted if there are no more results.
+	Cursor *uint32 `json:"cursor,omitempty"`
+}
+
 // OutputMetadataResponse defines the response of a GET outputs metadata REST API call.
 type OutputMetadataResponse struct {
 	// The hex encoded block ID of the block.
@@ -150,6 +220,19 @@ type OutputResponse struct {
 	RawOutput *json.RawMessage `json:"output"`
 }
 
+// indexedOutputsResponse defines the response of a GET indexed outputs REST API call.
+type indexedOutputsResponse struct {
+	// The ledger index at which the output IDs were collected.
+	LedgerIndex iotago.MilestoneIndex `json:"ledgerIndex"`
+	// The maximum number of results per page.
+	PageSize int `json:"pageSize"`
+	// The hex encoded output IDs of the unspent outputs.
+	Items []string `json:"items"`
+	// The cursor (hex encoded output ID) to use for getting the next results.
+	// The cursor is omitted if there are no more results.
+	Cursor *string `json:"cursor,omitempty"`
+}
+
 // addPeerRequest defines the request for a POST peer REST API call.
 type addPeerRequest struct {
 	// The libp2p multi address of the peer.
@@ -172,6 +255,26 @@ type PeerResponse struct {
 	Connected bool `json:"connected"`
 	// The gossip protocol information of the peer.
 	Gossip *gossip.Info `json:"gossip,omitempty"`
+	// The reputation of the peer.
+	Score *peerScoreResponse `json:"score"`
+}
+
+// peerScoreResponse defines the reputation of a peer.
+type peerScoreResponse struct {
+	// The score of the peer including the penalty for its latency.
+	Value float64 `json:"value"`
+	// The amount of invalid blocks and requests received from the peer.
+	InvalidData uint64 `json:"invalidData"`
+	// The amount of requests the peer didn't answer.
+	UnansweredRequests uint64 `json:"unansweredRequests"`
+	// The amount of new blocks received from the peer.
+	NewBlocks uint64 `json:"newBlocks"`
+	// The average time in milliseconds it took the peer to answer a request.
+	LatencyMilliseconds int64 `json:"latencyMs"`
+	// Whether the peer is currently banned.
+	Banned bool `json:"banned"`
+	// The unix timestamp until the peer is banned.
+	BannedUntil int64 `json:"bannedUntil,omitempty"`
 }
 
 // pruneDatabaseRequest defines the request of a prune database REST API call.
@@ -182,6 +285,8 @@ type pruneDatabaseRequest struct {
 	Depth *iotago.MilestoneIndex `json:"depth,omitempty"`
 	// The target size of the database.
 	TargetDatabaseSize *string `json:"targetDatabaseSize,omitempty"`
+	// Whether only the impact of the pruning is estimated without pruning the database.
+	DryRun bool `json:"dryRun,omitempty"`
 }
 
 // pruneDatabaseResponse defines the response of a prune database REST API call.
@@ -190,6 +295,38 @@ type pruneDatabaseResponse struct {
 	Index iotago.MilestoneIndex `json:"index"`
 }
 
+// solidEntryPointResponse defines a solid entry point.
+type solidEntryPointResponse struct {
+	// The hex encoded block ID of the solid entry point.
+	BlockID string `json:"blockId"`
+	// The index of the milestone that referenced the solid entry point.
+	Index iotago.MilestoneIndex `json:"index"`
+}
+
+// pruneDatabaseEstimateResponse defines the response of a prune database REST API call in dry-run mode.
+type pruneDatabaseEstimateResponse struct {
+	// The target index of the tangle history.
+	Index iotago.MilestoneIndex `json:"index"`
+	// The target indexes of the pruning classes.
+	ClassIndexes map[string]iotago.MilestoneIndex `json:"classIndexes"`
+	// The amount of milestones that would be removed.
+	Milestones int `json:"milestones"`
+	// The amount of blocks in the milestone cones whose data would be removed.
+	Blocks int `json:"blocks"`
+	// The amount of unreferenced blocks that would be removed.
+	UnreferencedBlocks int `json:"unreferencedBlocks"`
+	// The amount of spent outputs that would be removed from the ledger.
+	Outputs int `json:"outputs"`
+	// The amount of receipts that would be removed.
+	Receipts int `json:"receipts"`
+	// The approximate amount of bytes that would be removed from the database.
+	Bytes uint64 `json:"bytes"`
+	// The solid entry points that would be created.
+	SolidEntryPoints []*solidEntryPointResponse `json:"solidEntryPoints"`
+	// The estimated duration of the pruning in seconds.
+	EstimatedDurationSeconds int64 `json:"estimatedDurationSeconds"`
+}
+
 // createSnapshotsRequest defines the request of a create snapshots REST API call.
 type createSnapshotsRequest struct {
 	// The index of the snapshot.
@@ -204,6 +341,50 @@ type createSnapshotsResponse struct {
 	FilePath string `json:"filePath"`
 }
 
+// addSnapshotJobRequest defines the request of an add snapshot job REST API call.
+type addSnapshotJobRequest struct {
+	// The type of the snapshot ("full" or "delta").
+	Type string `json:"type"`
+	// The target index of the snapshot.
+	Index iotago.MilestoneIndex `json:"index"`
+}
+
+// snapshotJobResponse defines the response of the snapshot job REST API calls.
+type snapshotJobResponse struct {
+	// The ID of the job.
+	JobID string `json:"jobId"`
+	// The type of the snapshot.
+	Type string `json:"type"`
+	// The target index of the snapshot.
+	Index iotago.MilestoneIndex `json:"index"`
+	// The file path of the snapshot file.
+	FilePath string `json:"filePath"`
+	// The state of the job.
+	State string `json:"state"`
+	// The completed fraction of the snapshot creation between 0 and 1.
+	Progress float64 `json:"progress"`
+	// The estimated remaining seconds of the snapshot creation.
+	EstimatedSecondsRemaining *int64 `json:"estimatedSecondsRemaining,omitempty"`
+	// The amount of outputs written to the snapshot file.
+	OutputsWritten uint64 `json:"outputsWritten"`
+	// The amount of outputs of the snapshot file, zero if unknown.
+	OutputsTotal uint64 `json:"outputsTotal"`
+	// The amount of milestone diffs written to the snapshot file.
+	MilestoneDiffsWritten uint32 `json:"milestoneDiffsWritten"`
+	// The amount of milestone diffs of the snapshot file.
+	MilestoneDiffsTotal uint32 `json:"milestoneDiffsTotal"`
+	// The amount of solid entry points written to the snapshot file.
+	SolidEntryPointsWritten uint16 `json:"solidEntryPointsWritten"`
+	// The unix time the job was added.
+	CreatedAt int64 `json:"createdAt"`
+	// The unix time the job was started.
+	StartedAt int64 `json:"startedAt,omitempty"`
+	// The unix time the job was finished, failed or canceled.
+	FinishedAt int64 `json:"finishedAt,omitempty"`
+	// The error if the job failed.
+	Error string `json:"error,omitempty"`
+}
+
 // ComputeWhiteFlagMutationsRequest defines the request for a POST debugComputeWhiteFlagMutations REST API call.
 type ComputeWhiteFlagMutationsRequest struct {
 	// The index of the milestone.
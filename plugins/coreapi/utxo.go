@@ -64,12 +64,44 @@ func NewSpentResponse(spent *utxo.Spent, ledgerIndex iotago.MilestoneIndex) (*Ou
 	}, nil
 }
 
+// outputAtIndex returns the output and the spent (if it was already spent) as known to the ledger at the given milestone index.
+func outputAtIndex(outputID iotago.OutputID, msIndex iotago.MilestoneIndex) (*utxo.Output, *utxo.Spent, error) {
+	output, spent, err := deps.UTXOManager.ReadOutputAtIndex(outputID, msIndex)
+	if err != nil {
+		if errors.Is(err, utxo.ErrHistoricalStateUnavailable) {
+			return nil, nil, errors.WithMessagef(echo.ErrNotFound, "ledger state at milestone index %d is not available", msIndex)
+		}
+		if errors.Is(err, kvstore.ErrKeyNotFound) {
+			return nil, nil, errors.WithMessagef(echo.ErrNotFound, "output not found at milestone index %d: %s", msIndex, outputID.ToHex())
+		}
+		return nil, nil, errors.WithMessagef(echo.ErrInternalServerError, "reading output failed: %s, error: %s", outputID.ToHex(), err)
+	}
+
+	return output, spent, nil
+}
+
 func outputByID(c echo.Context) (*OutputResponse, error) {
 	outputID, err := restapi.ParseOutputIDParam(c)
 	if err != nil {
 		return nil, err
 	}
 
+	atIndex, err := restapi.ParseMilestoneIndexQueryParam(c, restapi.QueryParameterAtIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	if atIndex != 0 {
+		output, spent, err := outputAtIndex(outputID, atIndex)
+		if err != nil {
+			return nil, err
+		}
+		if spent != nil {
+			return NewSpentResponse(spent, atIndex)
+		}
+		return NewOutputResponse(output, atIndex)
+	}
+
 	// we need to lock the ledger here to have the correct index for unspent info of the output.
 	deps.UTXOManager.ReadLockLedger()
 	defer deps.UTXOManager.ReadUnlockLedger()
@@ -111,6 +143,22 @@ func outputMetadataByID(c echo.Context) (*OutputMetadataResponse, error) {
 		return nil, err
 	}
 
+	atIndex, err := restapi.ParseMilestoneIndexQueryParam(c, restapi.QueryParameterAtIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	if atIndex != 0 {
+		output, spent, err := outputAtIndex(outputID, atIndex)
+		if err != nil {
+			return nil, err
+		}
+		if spent != nil {
+			return NewSpentMetadataResponse(spent, atIndex), nil
+		}
+		return NewOutputMetadataResponse(output, atIndex), nil
+	}
+
 	// we need to lock the ledger here to have the correct index for unspent info of the output.
 	deps.UTXOManager.ReadLockLedger()
 	defer deps.UTXOManager.ReadUnlockLedger()
@@ -152,6 +200,18 @@ func rawOutputByID(c echo.Context) ([]byte, error) {
 		return nil, err
 	}
 
+	atIndex, err := restapi.ParseMilestoneIndexQueryParam(c, restapi.QueryParameterAtIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	if atIndex != 0 {
+		// the output itself doesn't change, but it needs to be known to the ledger at the given index
+		if _, _, err := outputAtIndex(outputID, atIndex); err != nil {
+			return nil, err
+		}
+	}
+
 	bytes, err := deps.UTXOManager.ReadRawOutputBytesByOutputIDWithoutLocking(outputID)
 	if err != nil {
 		if errors.Is(err, kvstore.ErrKeyNotFound) {
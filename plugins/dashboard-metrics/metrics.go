@@ -8,6 +8,9 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 
+	"github.com/iotaledger/hornet/pkg/common"
+	"github.com/iotaledger/hornet/pkg/model/storage"
+	restapipkg "github.com/iotaledger/hornet/pkg/restapi"
 	"github.com/iotaledger/hornet/pkg/tangle"
 )
 
@@ -58,6 +61,70 @@ func databaseSizesMetrics(c echo.Context) (*DatabaseSizesMetric, error) {
 	}, nil
 }
 
+func milestoneConeSizesMetrics(c echo.Context) (*MilestoneConeSizesMetric, error) {
+	pageSize, err := restapipkg.ParsePageSizeQueryParam(c, deps.RestAPILimitsMaxResults)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := restapipkg.ParseCursorQueryParam(c)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotInfo := deps.Storage.SnapshotInfo()
+	if snapshotInfo == nil {
+		return nil, errors.WithMessage(echo.ErrInternalServerError, common.ErrSnapshotInfoNotFound.Error())
+	}
+
+	// the sizes are kept until all data of the milestone cone was pruned
+	lowestIndex := snapshotInfo.PruningIndex() + 1
+	for _, class := range storage.PruningClasses {
+		if classPruningIndex := snapshotInfo.PruningIndexOf(class); classPruningIndex+1 < lowestIndex {
+			lowestIndex = classPruningIndex + 1
+		}
+	}
+	highestIndex := deps.SyncManager.ConfirmedMilestoneIndex()
+
+	if cursor > lowestIndex {
+		lowestIndex = cursor
+	}
+
+	response := &MilestoneConeSizesMetric{
+		Milestones: make([]*MilestoneConeSizeMetric, 0),
+		PageSize:   pageSize,
+		Time:       time.Now().Unix(),
+	}
+
+	for msIndex := lowestIndex; msIndex <= highestIndex; msIndex++ {
+		if len(response.Milestones) >= pageSize {
+			nextCursor := msIndex
+			response.Cursor = &nextCursor
+			break
+		}
+
+		coneSize, err := deps.Storage.MilestoneConeSize(msIndex)
+		if err != nil {
+			return nil, errors.WithMessagef(echo.ErrInternalServerError, "loading milestone cone size failed, error: %s", err)
+		}
+		if coneSize == nil {
+			continue
+		}
+
+		response.Milestones = append(response.Milestones, &MilestoneConeSizeMetric{
+			Index:       coneSize.Index,
+			Blocks:      coneSize.Blocks,
+			Metadata:    coneSize.Metadata,
+			Children:    coneSize.Children,
+			Milestones:  coneSize.Milestones,
+			LedgerDiffs: coneSize.LedgerDiffs,
+			Total:       coneSize.Total(),
+		})
+	}
+
+	return response, nil
+}
+
 func gossipMetrics(c echo.Context) *tangle.BPSMetrics {
 	lastGossipMetricsLock.RLock()
 	defer lastGossipMetricsLock.RUnlock()
@@ -12,6 +12,8 @@ import (
 	"github.com/iotaledger/hive.go/events"
 	"github.com/iotaledger/hornet/pkg/daemon"
 	"github.com/iotaledger/hornet/pkg/database"
+	"github.com/iotaledger/hornet/pkg/model/storage"
+	"github.com/iotaledger/hornet/pkg/model/syncmanager"
 	restapipkg "github.com/iotaledger/hornet/pkg/restapi"
 	"github.com/iotaledger/hornet/pkg/tangle"
 	"github.com/iotaledger/hornet/plugins/restapi"
@@ -26,6 +28,11 @@ const (
 	// GET returns the sizes of the databases.
 	RouteDatabaseSizes = "/database/sizes"
 
+	// RouteDatabaseMilestoneConeSizes is the route to get the sizes of the milestone cones in the database.
+	// GET returns the approximate amount of bytes written to the database per milestone cone,
+	// paginated by the "pageSize" and "cursor" query parameters.
+	RouteDatabaseMilestoneConeSizes = "/database/milestone-sizes"
+
 	// RouteGossipMetrics is the route to get metrics about gossip.
 	// GET returns the gossip metrics.
 	RouteGossipMetrics = "/gossip"
@@ -50,13 +57,16 @@ var (
 
 type dependencies struct {
 	dig.In
-	RestRouteManager *restapi.RestRouteManager `optional:"true"`
-	AppInfo          *app.AppInfo
-	Host             host.Host
-	NodeAlias        string             `name:"nodeAlias"`
-	TangleDatabase   *database.Database `name:"tangleDatabase"`
-	UTXODatabase     *database.Database `name:"utxoDatabase"`
-	Tangle           *tangle.Tangle
+	RestRouteManager        *restapi.RestRouteManager `optional:"true"`
+	AppInfo                 *app.AppInfo
+	Host                    host.Host
+	NodeAlias               string             `name:"nodeAlias"`
+	TangleDatabase          *database.Database `name:"tangleDatabase"`
+	UTXODatabase            *database.Database `name:"utxoDatabase"`
+	Storage                 *storage.Storage
+	SyncManager             *syncmanager.SyncManager
+	Tangle                  *tangle.Tangle
+	RestAPILimitsMaxResults int `name:"restAPILimitsMaxResults"`
 }
 
 func configure() error {
@@ -80,6 +90,15 @@ func configure() error {
 		return restapipkg.JSONResponse(c, http.StatusOK, resp)
 	})
 
+	routeGroup.GET(RouteDatabaseMilestoneConeSizes, func(c echo.Context) error {
+		resp, err := milestoneConeSizesMetrics(c)
+		if err != nil {
+			return err
+		}
+
+		return restapipkg.JSONResponse(c, http.StatusOK, resp)
+	})
+
 	routeGroup.GET(RouteGossipMetrics, func(c echo.Context) error {
 		return restapipkg.JSONResponse(c, http.StatusOK, gossipMetrics(c))
 	})
@@ -17,3 +17,25 @@ type DatabaseSizesMetric struct {
 	Total  int64 `json:"total"`
 	Time   int64 `json:"ts"`
 }
+
+// MilestoneConeSizeMetric represents the approximate amount of bytes written to the database for a milestone cone.
+type MilestoneConeSizeMetric struct {
+	Index       uint32 `json:"index"`
+	Blocks      uint64 `json:"blocks"`
+	Metadata    uint64 `json:"metadata"`
+	Children    uint64 `json:"children"`
+	Milestones  uint64 `json:"milestones"`
+	LedgerDiffs uint64 `json:"ledgerDiffs"`
+	Total       uint64 `json:"total"`
+}
+
+// MilestoneConeSizesMetric represents the sizes of the milestone cones in the database.
+type MilestoneConeSizesMetric struct {
+	Milestones []*MilestoneConeSizeMetric `json:"milestones"`
+	// The maximum number of results per page.
+	PageSize int `json:"pageSize"`
+	// The cursor to use for getting the next results.
+	// The cursor is omitted if there are no more results.
+	Cursor *uint32 `json:"cursor,omitempty"`
+	Time   int64   `json:"ts"`
+}
@@ -0,0 +1,120 @@
+package inx
+
+import (
+	"net"
+	"strings"
+
+	"github.com/iotaledger/hornet/pkg/jwt"
+	inx "github.com/iotaledger/inx/go"
+)
+
+const (
+	// ScopeRead grants access to read the node status, blocks, milestones and tips.
+	ScopeRead = "inx:read"
+	// ScopeLedger grants access to read the ledger.
+	ScopeLedger = "inx:ledger"
+	// ScopeSubmitBlocks grants access to submit blocks.
+	ScopeSubmitBlocks = "inx:submit-blocks"
+	// ScopeWhiteFlag grants access to compute the white flag confirmation of milestones.
+	ScopeWhiteFlag = "inx:whiteflag"
+	// ScopeAPIRequests grants access to call the REST API of the node.
+	ScopeAPIRequests = "inx:api-requests"
+	// ScopeRoutesPrefix is the prefix of the scopes which grant access to register a route of the REST API.
+	// The route is appended to the prefix, e.g. "inx:routes:indexer/v1", or "inx:routes:indexer/*" for all routes starting with "indexer/".
+	ScopeRoutesPrefix = "inx:routes:"
+)
+
+// methodScopes are the scopes needed to call the INX methods.
+// Methods without an entry can't be called if authentication is enabled.
+var methodScopes = map[string]string{
+	"ReadNodeStatus":              ScopeRead,
+	"ReadNodeConfiguration":       ScopeRead,
+	"ReadBlock":                   ScopeRead,
+	"ReadBlockMetadata":           ScopeRead,
+	"ListenToBlocks":              ScopeRead,
+	"ListenToSolidBlocks":         ScopeRead,
+	"ListenToReferencedBlocks":    ScopeRead,
+	"ListenToTipScoreUpdates":     ScopeRead,
+	"ReadMilestone":               ScopeRead,
+	"ListenToLatestMilestones":    ScopeRead,
+	"ListenToConfirmedMilestones": ScopeRead,
+	"ReadMilestoneCone":           ScopeRead,
+	"ReadMilestoneConeMetadata":   ScopeRead,
+	"RequestTips":                 ScopeRead,
+	"ListenToTipsMetrics":         ScopeRead,
+	"ReadOutput":                  ScopeLedger,
+	"ReadUnspentOutputs":          ScopeLedger,
+	"ListenToLedgerUpdates":       ScopeLedger,
+	"ListenToTreasuryUpdates":     ScopeLedger,
+	"ListenToMigrationReceipts":   ScopeLedger,
+	"SubmitBlock":                 ScopeSubmitBlocks,
+	"ComputeWhiteFlag":            ScopeWhiteFlag,
+	"PerformAPIRequest":           ScopeAPIRequests,
+	// the scopes of the route methods depend on the route, see requiredScope.
+	"RegisterAPIRoute":   ScopeRoutesPrefix,
+	"UnregisterAPIRoute": ScopeRoutesPrefix,
+}
+
+// requiredScope returns the scope a token needs to call the given method with the given request.
+// Returns false if the method can't be called with a token.
+func requiredScope(fullMethod string, req interface{}) (string, bool) {
+	servicePrefix := "/" + inx.INX_ServiceDesc.ServiceName + "/"
+	if !strings.HasPrefix(fullMethod, servicePrefix) {
+		return "", false
+	}
+
+	scope, exists := methodScopes[strings.TrimPrefix(fullMethod, servicePrefix)]
+	if !exists {
+		return "", false
+	}
+
+	if scope != ScopeRoutesPrefix {
+		return scope, true
+	}
+
+	routeReq, ok := req.(*inx.APIRouteRequest)
+	if !ok || len(routeReq.GetRoute()) == 0 {
+		return "", false
+	}
+
+	return ScopeRoutesPrefix + routeReq.GetRoute(), true
+}
+
+// allowUnary tells whether the given claims grant access to call the given unary method with the given request.
+// Only the INX scopes of the claims are taken into account, so tokens without scopes
+// or with scopes like "*" don't grant access to INX.
+func allowUnary(fullMethod string, req interface{}, claims *jwt.AuthClaims) bool {
+	scope, ok := requiredScope(fullMethod, req)
+	if !ok || claims == nil {
+		return false
+	}
+
+	inxScopes := make([]string, 0, len(claims.Scopes))
+	for _, grantedScope := range claims.Scopes {
+		if strings.HasPrefix(grantedScope, jwt.ScopeINXPrefix) {
+			inxScopes = append(inxScopes, grantedScope)
+		}
+	}
+
+	return (&jwt.AuthClaims{Scopes: inxScopes}).HasScope(scope)
+}
+
+// allowStream tells whether the given claims grant access to call the given streaming method.
+func allowStream(fullMethod string, claims *jwt.AuthClaims) bool {
+	return allowUnary(fullMethod, nil, claims)
+}
+
+// isLoopbackBindAddress tells whether the given bind address is only reachable from the local host.
+func isLoopbackBindAddress(bindAddress string) bool {
+	host, _, err := net.SplitHostPort(bindAddress)
+	if err != nil {
+		return false
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -9,6 +9,24 @@ type ParametersINX struct {
 	// the bind address on which the INX can be accessed from
 	BindAddress string `default:"localhost:9029" usage:"the bind address on which the INX can be accessed from"`
 
+	TLS struct {
+		// whether the INX server uses TLS
+		Enabled bool `default:"false" usage:"whether the INX server uses TLS"`
+		// the path to the certificate file of the INX server
+		CertPath string `default:"" usage:"the path to the certificate file of the INX server"`
+		// the path to the private key file of the INX server
+		KeyPath string `default:"" usage:"the path to the private key file of the INX server"`
+		// the path to the CA file the client certificates must be signed by. Clients don't need a certificate if empty
+		ClientCAPath string `default:"" usage:"the path to the CA file the client certificates must be signed by. Clients don't need a certificate if empty"`
+	} `name:"tls"`
+
+	Auth struct {
+		// whether INX clients must authenticate with a JWT
+		Enabled bool `default:"false" usage:"whether INX clients must authenticate with a JWT"`
+		// salt used inside the JWT tokens for INX. Change this to a different value to invalidate JWT tokens not matching this new value
+		Salt string `default:"HORNET-INX" usage:"salt used inside the JWT tokens for INX. Change this to a different value to invalidate JWT tokens not matching this new value"`
+	} `name:"auth"`
+
 	PoW struct {
 		// the amount of workers used for calculating PoW when issuing blocks via INX
 		WorkerCount int `default:"0" usage:"the amount of workers used for calculating PoW when issuing blocks via INX. (use 0 to use the maximum possible)"`
@@ -21,5 +39,5 @@ var params = &app.ComponentParams{
 	Params: map[string]any{
 		"inx": ParamsINX,
 	},
-	Masked: nil,
+	Masked: []string{"inx.auth.salt"},
 }
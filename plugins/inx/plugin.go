@@ -2,15 +2,20 @@ package inx
 
 import (
 	"context"
+	"path/filepath"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/host"
 	"go.uber.org/dig"
 
 	"github.com/iotaledger/hive.go/app"
 	"github.com/iotaledger/hive.go/events"
+	"github.com/iotaledger/hive.go/timeutil"
 	"github.com/iotaledger/hornet/core/protocfg"
 	"github.com/iotaledger/hornet/pkg/daemon"
+	"github.com/iotaledger/hornet/pkg/jwt"
 	"github.com/iotaledger/hornet/pkg/metrics"
 	"github.com/iotaledger/hornet/pkg/model/storage"
 	"github.com/iotaledger/hornet/pkg/model/syncmanager"
@@ -19,6 +24,7 @@ import (
 	"github.com/iotaledger/hornet/pkg/protocol"
 	"github.com/iotaledger/hornet/pkg/tangle"
 	"github.com/iotaledger/hornet/pkg/tipselect"
+	"github.com/iotaledger/hornet/pkg/tlsutil"
 	"github.com/iotaledger/hornet/plugins/restapi"
 	"github.com/iotaledger/iota.go/v3/keymanager"
 )
@@ -76,8 +82,55 @@ func provide(c *dig.Container) error {
 		Plugin.LogPanic(err)
 	}
 
-	if err := c.Provide(func() *INXServer {
-		return newINXServer()
+	type serverDeps struct {
+		dig.In
+		Host            host.Host
+		NodePrivateKey  crypto.PrivKey      `name:"nodePrivateKey"`
+		P2PDatabasePath string              `name:"p2pDatabasePath"`
+		RevocationList  *jwt.RevocationList `optional:"true"`
+	}
+
+	if err := c.Provide(func(deps serverDeps) *INXServer {
+		var serverTLS *tlsutil.ServerTLS
+		if ParamsINX.TLS.Enabled {
+			var err error
+			serverTLS, err = tlsutil.NewServerTLS(ParamsINX.TLS.CertPath, ParamsINX.TLS.KeyPath, ParamsINX.TLS.ClientCAPath)
+			if err != nil {
+				Plugin.LogPanicf("INX TLS initialization failed: %s", err)
+			}
+		}
+
+		var jwtAuth *jwt.JWTAuth
+		var revocationList *jwt.RevocationList
+		if ParamsINX.Auth.Enabled {
+			salt := ParamsINX.Auth.Salt
+			if len(salt) == 0 {
+				Plugin.LogErrorfAndExit("'%s' should not be empty", Plugin.App.Config().GetParameterPath(&(ParamsINX.Auth.Salt)))
+			}
+
+			// the expiry is set per token.
+			var err error
+			jwtAuth, err = jwt.NewJWTAuth(salt,
+				0,
+				deps.Host.ID().String(),
+				deps.NodePrivateKey,
+			)
+			if err != nil {
+				Plugin.LogPanicf("INX JWT auth initialization failed: %s", err)
+			}
+
+			// the revoked tokens are shared with the REST API. if it is disabled, tokens can't be revoked
+			// while the node is running, but the tokens revoked before are still rejected.
+			revocationList = deps.RevocationList
+			if revocationList == nil {
+				revocationList, err = jwt.NewRevocationList(filepath.Join(deps.P2PDatabasePath, jwt.RevokedTokensFileName))
+				if err != nil {
+					Plugin.LogPanicf("failed to load revoked tokens: %s", err)
+				}
+			}
+		}
+
+		return newINXServer(serverTLS, jwtAuth, revocationList)
 	}); err != nil {
 		Plugin.LogPanic(err)
 	}
@@ -87,6 +140,12 @@ func provide(c *dig.Container) error {
 
 func configure() error {
 
+	if !ParamsINX.Auth.Enabled && !isLoopbackBindAddress(ParamsINX.BindAddress) {
+		Plugin.LogWarnf("INX is reachable on %s without authentication, set '%s' to require INX extensions to authenticate",
+			ParamsINX.BindAddress,
+			Plugin.App.Config().GetParameterPath(&(ParamsINX.Auth.Enabled)))
+	}
+
 	attacherOpts := []tangle.BlockAttacherOption{
 		tangle.WithTimeout(blockProcessedTimeout),
 		tangle.WithPoW(deps.PoWHandler, ParamsINX.PoW.WorkerCount),
@@ -113,5 +172,14 @@ func run() error {
 		Plugin.LogPanicf("failed to start worker: %s", err)
 	}
 
+	if ParamsINX.TLS.Enabled {
+		if err := Plugin.Daemon().BackgroundWorker("INX TLS certificate reloader", func(ctx context.Context) {
+			ticker := timeutil.NewTicker(deps.INXServer.ReloadCertificates, tlsutil.DefaultReloadInterval, ctx)
+			ticker.WaitForGracefulShutdown()
+		}, daemon.PriorityIndexer); err != nil {
+			Plugin.LogPanicf("failed to start worker: %s", err)
+		}
+	}
+
 	return nil
 }
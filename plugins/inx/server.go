@@ -6,9 +6,13 @@ import (
 
 	grpcprometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"github.com/iotaledger/hive.go/workerpool"
 	"github.com/iotaledger/hornet/pkg/common"
+	"github.com/iotaledger/hornet/pkg/jwt"
+	"github.com/iotaledger/hornet/pkg/model/storage"
+	"github.com/iotaledger/hornet/pkg/tlsutil"
 	inx "github.com/iotaledger/inx/go"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
@@ -18,12 +22,29 @@ const (
 	workerQueueSize = 10000
 )
 
-func newINXServer() *INXServer {
-	grpcServer := grpc.NewServer(
-		grpc.StreamInterceptor(grpcprometheus.StreamServerInterceptor),
-		grpc.UnaryInterceptor(grpcprometheus.UnaryServerInterceptor),
-	)
-	s := &INXServer{grpcServer: grpcServer}
+func newINXServer(serverTLS *tlsutil.ServerTLS, jwtAuth *jwt.JWTAuth, revocationList *jwt.RevocationList) *INXServer {
+	streamInterceptors := []grpc.StreamServerInterceptor{grpcprometheus.StreamServerInterceptor}
+	unaryInterceptors := []grpc.UnaryServerInterceptor{grpcprometheus.UnaryServerInterceptor}
+	if jwtAuth != nil {
+		// revoked tokens are rejected even if they didn't expire yet
+		streamInterceptors = append(streamInterceptors, jwtAuth.StreamServerInterceptor(func(fullMethod string, claims *jwt.AuthClaims) bool {
+			return !revocationList.IsRevoked(claims.RevocationID()) && allowStream(fullMethod, claims)
+		}))
+		unaryInterceptors = append(unaryInterceptors, jwtAuth.UnaryServerInterceptor(func(fullMethod string, req interface{}, claims *jwt.AuthClaims) bool {
+			return !revocationList.IsRevoked(claims.RevocationID()) && allowUnary(fullMethod, req, claims)
+		}))
+	}
+
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+	}
+	if serverTLS != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(serverTLS.Config())))
+	}
+
+	grpcServer := grpc.NewServer(serverOpts...)
+	s := &INXServer{grpcServer: grpcServer, serverTLS: serverTLS}
 	inx.RegisterINXServer(grpcServer, s)
 	return s
 }
@@ -31,6 +52,24 @@ func newINXServer() *INXServer {
 type INXServer struct {
 	inx.UnimplementedINXServer
 	grpcServer *grpc.Server
+	// the certificates of the server, nil if TLS is disabled.
+	serverTLS *tlsutil.ServerTLS
+}
+
+// ReloadCertificates loads the TLS certificates again if the files changed.
+func (s *INXServer) ReloadCertificates() {
+	if s.serverTLS == nil {
+		return
+	}
+
+	reloaded, err := s.serverTLS.Reload()
+	if err != nil {
+		Plugin.LogWarnf("failed to reload INX TLS certificates: %s", err)
+		return
+	}
+	if reloaded {
+		Plugin.LogInfo("Reloaded INX TLS certificates")
+	}
 }
 
 func (s *INXServer) ConfigurePrometheus() {
@@ -105,9 +144,9 @@ func (s *INXServer) ReadNodeStatus(context.Context, *inx.NoParams) (*inx.NodeSta
 		IsHealthy:              deps.Tangle.IsNodeHealthy(),
 		LatestMilestone:        lmi,
 		ConfirmedMilestone:     cmi,
-		TanglePruningIndex:     pruningIndex,
-		MilestonesPruningIndex: pruningIndex,
-		LedgerPruningIndex:     pruningIndex,
+		TanglePruningIndex:     snapshotInfo.PruningIndexOf(storage.PruningClassBlocks),
+		MilestonesPruningIndex: snapshotInfo.PruningIndexOf(storage.PruningClassMilestones),
+		LedgerPruningIndex:     snapshotInfo.PruningIndexOf(storage.PruningClassLedgerDiffs),
 		LedgerIndex:            index,
 	}, nil
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"net/http/httptest"
 
+	"github.com/pkg/errors"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -27,6 +28,9 @@ func (s *INXServer) RegisterAPIRoute(_ context.Context, req *inx.APIRouteRequest
 		return nil, status.Error(codes.InvalidArgument, "port can not be zero")
 	}
 	if err := deps.RestRouteManager.AddProxyRoute(req.GetRoute(), req.GetHost(), req.GetPort()); err != nil {
+		if errors.Is(err, restapi.ErrReservedRoute) {
+			return nil, status.Errorf(codes.PermissionDenied, "error adding route to proxy: %s", err.Error())
+		}
 		Plugin.LogErrorf("Error registering proxy %s", req.GetRoute())
 		return nil, status.Errorf(codes.Internal, "error adding route to proxy: %s", err.Error())
 	}
@@ -42,6 +46,17 @@ func (s *INXServer) UnregisterAPIRoute(_ context.Context, req *inx.APIRouteReque
 	if len(req.GetRoute()) == 0 {
 		return nil, status.Error(codes.InvalidArgument, "route can not be empty")
 	}
+	if deps.RestRouteManager.IsReservedRoute(req.GetRoute()) {
+		return nil, status.Errorf(codes.PermissionDenied, "route %s is reserved by the node", req.GetRoute())
+	}
+
+	// only the backend of the extension is removed, other extensions may still serve the route
+	if len(req.GetHost()) > 0 && req.GetPort() != 0 {
+		deps.RestRouteManager.RemoveProxyRoute(req.GetRoute(), req.GetHost(), req.GetPort())
+		Plugin.LogInfof("Removed proxy %s => %s:%d", req.GetRoute(), req.GetHost(), req.GetPort())
+		return &inx.NoParams{}, nil
+	}
+
 	deps.RestRouteManager.RemoveRoute(req.GetRoute())
 	Plugin.LogInfof("Removed proxy %s", req.GetRoute())
 	return &inx.NoParams{}, nil
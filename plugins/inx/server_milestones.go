@@ -145,7 +145,7 @@ func (s *INXServer) ListenToConfirmedMilestones(req *inx.MilestoneRangeRequest,
 		}
 
 		// Stream all available milestones first
-		pruningIndex := snapshotInfo.PruningIndex()
+		pruningIndex := snapshotInfo.PruningIndexOf(storage.PruningClassMilestones)
 		if startIndex <= pruningIndex {
 			return 0, status.Errorf(codes.InvalidArgument, "given startMilestoneIndex %d is older than the current pruningIndex %d", startIndex, pruningIndex)
 		}
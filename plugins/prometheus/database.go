@@ -9,14 +9,16 @@ import (
 	"github.com/iotaledger/hornet/pkg/database"
 	"github.com/iotaledger/hornet/pkg/metrics"
 	"github.com/iotaledger/hornet/pkg/model/storage"
+	"github.com/iotaledger/hornet/pkg/tangle"
 )
 
 type storageMetrics struct {
 	storage        *storage.Storage
 	storageMetrics *metrics.StorageMetrics
 
-	pruningCount   prometheus.Counter
-	pruningRunning prometheus.Gauge
+	pruningCount           prometheus.Counter
+	pruningRunning         prometheus.Gauge
+	milestoneConeSizeBytes *prometheus.HistogramVec
 }
 
 type databaseMetrics struct {
@@ -28,10 +30,10 @@ type databaseMetrics struct {
 	compactionRunning prometheus.Gauge
 }
 
-func configureStorage(storage *storage.Storage, metrics *metrics.StorageMetrics) {
+func configureStorage(dbStorage *storage.Storage, metrics *metrics.StorageMetrics, tangle *tangle.Tangle) {
 
 	m := &storageMetrics{
-		storage:        storage,
+		storage:        dbStorage,
 		storageMetrics: metrics,
 	}
 
@@ -44,7 +46,7 @@ func configureStorage(storage *storage.Storage, metrics *metrics.StorageMetrics)
 		},
 	)
 
-	storage.Events.PruningStateChanged.Attach(events.NewClosure(func(running bool) {
+	dbStorage.Events.PruningStateChanged.Attach(events.NewClosure(func(running bool) {
 		if running {
 			m.pruningCount.Inc()
 		}
@@ -57,8 +59,29 @@ func configureStorage(storage *storage.Storage, metrics *metrics.StorageMetrics)
 		Help:      "Current state of database pruning process.",
 	})
 
+	m.milestoneConeSizeBytes = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "iota",
+			Subsystem: "database",
+			Name:      "milestone_cone_size_bytes",
+			Help:      "The approximate amount of bytes written to the database per confirmed milestone cone.",
+			Buckets:   prometheus.ExponentialBuckets(1024, 2, 16),
+		},
+		[]string{"type"},
+	)
+
+	tangle.Events.MilestoneConeSizeStored.Attach(events.NewClosure(func(size *storage.MilestoneConeSize) {
+		m.milestoneConeSizeBytes.WithLabelValues("blocks").Observe(float64(size.Blocks))
+		m.milestoneConeSizeBytes.WithLabelValues("metadata").Observe(float64(size.Metadata))
+		m.milestoneConeSizeBytes.WithLabelValues("children").Observe(float64(size.Children))
+		m.milestoneConeSizeBytes.WithLabelValues("milestones").Observe(float64(size.Milestones))
+		m.milestoneConeSizeBytes.WithLabelValues("ledger_diffs").Observe(float64(size.LedgerDiffs))
+		m.milestoneConeSizeBytes.WithLabelValues("total").Observe(float64(size.Total()))
+	}))
+
 	registry.MustRegister(m.pruningRunning)
 	registry.MustRegister(m.pruningCount)
+	registry.MustRegister(m.milestoneConeSizeBytes)
 
 	addCollect(m.collect)
 }
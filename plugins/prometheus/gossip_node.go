@@ -9,6 +9,7 @@ var (
 	gossipRequests       *prometheus.GaugeVec
 	gossipHeartbeats     *prometheus.GaugeVec
 	gossipDroppedPackets *prometheus.GaugeVec
+	gossipBytes          *prometheus.GaugeVec
 )
 
 func configureGossipNode() {
@@ -52,10 +53,21 @@ func configureGossipNode() {
 		[]string{"type"},
 	)
 
+	gossipBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "iota",
+			Subsystem: "gossip_node",
+			Name:      "byte_count",
+			Help:      "Number of bytes sent to and received from all peers.",
+		},
+		[]string{"type"},
+	)
+
 	registry.MustRegister(gossipBlocks)
 	registry.MustRegister(gossipRequests)
 	registry.MustRegister(gossipHeartbeats)
 	registry.MustRegister(gossipDroppedPackets)
+	registry.MustRegister(gossipBytes)
 
 	addCollect(collectServer)
 }
@@ -79,4 +91,7 @@ func collectServer() {
 	gossipHeartbeats.WithLabelValues("sent").Set(float64(deps.ServerMetrics.SentHeartbeats.Load()))
 
 	gossipDroppedPackets.WithLabelValues("sent").Set(float64(deps.ServerMetrics.DroppedPackets.Load()))
+
+	gossipBytes.WithLabelValues("sent").Set(float64(deps.ServerMetrics.SentBytes.Load()))
+	gossipBytes.WithLabelValues("received").Set(float64(deps.ServerMetrics.ReceivedBytes.Load()))
 }
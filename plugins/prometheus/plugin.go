@@ -110,7 +110,7 @@ func configure() error {
 	if ParamsPrometheus.DatabaseMetrics {
 		configureDatabase(coreDatabase.TangleDatabaseDirectoryName, deps.TangleDatabase)
 		configureDatabase(coreDatabase.UTXODatabaseDirectoryName, deps.UTXODatabase)
-		configureStorage(deps.Storage, deps.StorageMetrics)
+		configureStorage(deps.Storage, deps.StorageMetrics, deps.Tangle)
 	}
 	if ParamsPrometheus.NodeMetrics {
 		configureNode()
@@ -11,6 +11,8 @@ import (
 var (
 	restapiHTTPErrorCount prometheus.Gauge
 
+	restapiRateLimitedRequestCount *prometheus.GaugeVec
+
 	restapiPoWCompletedCount prometheus.Gauge
 	restapiPoWBlockSizes     prometheus.Histogram
 	restapiPoWDurations      prometheus.Histogram
@@ -26,6 +28,16 @@ func configureRestAPI() {
 		},
 	)
 
+	restapiRateLimitedRequestCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "iota",
+			Subsystem: "restapi",
+			Name:      "rate_limited_request_count",
+			Help:      "The amount of HTTP requests rejected by the rate limits.",
+		},
+		[]string{"class"},
+	)
+
 	restapiPoWCompletedCount = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Namespace: "iota",
@@ -54,6 +66,7 @@ func configureRestAPI() {
 		})
 
 	registry.MustRegister(restapiHTTPErrorCount)
+	registry.MustRegister(restapiRateLimitedRequestCount)
 
 	registry.MustRegister(restapiPoWCompletedCount)
 	registry.MustRegister(restapiPoWBlockSizes)
@@ -69,5 +82,8 @@ func configureRestAPI() {
 
 func collectRestAPI() {
 	restapiHTTPErrorCount.Set(float64(deps.RestAPIMetrics.HTTPRequestErrorCounter.Load()))
+	restapiRateLimitedRequestCount.WithLabelValues("reads").Set(float64(deps.RestAPIMetrics.RateLimitedReadsCounter.Load()))
+	restapiRateLimitedRequestCount.WithLabelValues("blocks").Set(float64(deps.RestAPIMetrics.RateLimitedBlocksCounter.Load()))
+	restapiRateLimitedRequestCount.WithLabelValues("pow").Set(float64(deps.RestAPIMetrics.RateLimitedPoWCounter.Load()))
 	restapiPoWCompletedCount.Set(float64(deps.RestAPIMetrics.PoWCompletedCounter.Load()))
 }
@@ -79,13 +79,20 @@ func apiMiddleware() echo.MiddlewareFunc {
 		Plugin.LogPanicf("JWT auth initialization failed: %w", err)
 	}
 
+	compiledRouteScopes := compileRouteScopes(routeScopes)
+
 	jwtAllow := func(c echo.Context, subject string, claims *jwt.AuthClaims) bool {
-		// Allow all JWT created for the API if the endpoints are exposed
-		if matchExposed(c) {
-			return claims.VerifySubject(subject)
+		// Allow the JWT created for the API if the endpoints are exposed and the token grants the scope of the route
+		if !matchExposed(c) || !claims.VerifySubject(subject) {
+			return false
 		}
 
-		return false
+		// revoked tokens are rejected even if they didn't expire yet
+		if deps.RevocationList.IsRevoked(claims.RevocationID()) {
+			return false
+		}
+
+		return claims.HasScope(requiredScope(compiledRouteScopes, c))
 	}
 
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
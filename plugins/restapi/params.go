@@ -1,6 +1,8 @@
 package restapi
 
 import (
+	"time"
+
 	"github.com/iotaledger/hive.go/app"
 )
 
@@ -13,6 +15,15 @@ type ParametersRestAPI struct {
 	// the HTTP REST routes which need to be called with authorization. Wildcards using * are allowed
 	ProtectedRoutes []string `usage:"the HTTP REST routes which need to be called with authorization. Wildcards using * are allowed"`
 
+	TLS struct {
+		// whether the REST API uses TLS
+		Enabled bool `default:"false" usage:"whether the REST API uses TLS"`
+		// the path to the certificate file of the REST API
+		CertPath string `default:"" usage:"the path to the certificate file of the REST API"`
+		// the path to the private key file of the REST API
+		KeyPath string `default:"" usage:"the path to the private key file of the REST API"`
+	} `name:"tls"`
+
 	JWTAuth struct {
 		// salt used inside the JWT tokens for the REST API. Change this to a different value to invalidate JWT tokens not matching this new value
 		Salt string `default:"HORNET" usage:"salt used inside the JWT tokens for the REST API. Change this to a different value to invalidate JWT tokens not matching this new value"`
@@ -31,6 +42,49 @@ type ParametersRestAPI struct {
 		// the maximum number of results that may be returned by an endpoint
 		MaxResults int `default:"1000" usage:"the maximum number of results that may be returned by an endpoint"`
 	}
+
+	RateLimits struct {
+		// whether the requests per client are limited. Clients are identified by their JWT or their IP
+		Enabled bool `default:"true" usage:"whether the requests per client are limited. Clients are identified by their JWT or their IP"`
+		// the maximum amount of clients whose requests are tracked. The least recently seen clients are forgotten first
+		MaxClients int `default:"100000" usage:"the maximum amount of clients whose requests are tracked. The least recently seen clients are forgotten first"`
+		// the IP ranges of trusted reverse proxies in CIDR notation. The X-Forwarded-For header is only used to identify clients if it was set by these proxies
+		TrustedProxies []string `usage:"the IP ranges of trusted reverse proxies in CIDR notation. The X-Forwarded-For header is only used to identify clients if it was set by these proxies"`
+		// the length of the prefix IPv6 clients are identified by, since a single client usually gets a whole subnet assigned
+		IPv6PrefixLength int `default:"64" usage:"the length of the prefix IPv6 clients are identified by, since a single client usually gets a whole subnet assigned"`
+
+		Reads struct {
+			// the amount of requests per second a client may send (0 = unlimited)
+			RequestsPerSecond float64 `default:"50" usage:"the amount of requests per second a client may send (0 = unlimited)"`
+			// the amount of requests a client may send at once
+			Burst int `default:"100" usage:"the amount of requests a client may send at once"`
+		}
+
+		Blocks struct {
+			// the amount of blocks per second a client may submit (0 = unlimited)
+			RequestsPerSecond float64 `default:"10" usage:"the amount of blocks per second a client may submit (0 = unlimited)"`
+			// the amount of blocks a client may submit at once
+			Burst int `default:"20" usage:"the amount of blocks a client may submit at once"`
+		}
+
+		PoW struct {
+			// the amount of blocks per second a client may submit for which the node does the PoW (0 = unlimited)
+			RequestsPerSecond float64 `default:"1" usage:"the amount of blocks per second a client may submit for which the node does the PoW (0 = unlimited)"`
+			// the amount of blocks a client may submit at once for which the node does the PoW
+			Burst int `default:"5" usage:"the amount of blocks a client may submit at once for which the node does the PoW"`
+		} `name:"pow"`
+	}
+
+	Proxy struct {
+		// the strategy to distribute the requests of a route registered via INX between its backends (roundRobin, leastConnections)
+		BalancingStrategy string `default:"roundRobin" usage:"the strategy to distribute the requests of a route registered via INX between its backends (roundRobin, leastConnections)"`
+		// the interval in which the backends of the routes registered via INX are checked
+		HealthCheckInterval time.Duration `default:"10s" usage:"the interval in which the backends of the routes registered via INX are checked"`
+		// the timeout for connecting to a backend during a health check
+		HealthCheckTimeout time.Duration `default:"2s" usage:"the timeout for connecting to a backend during a health check"`
+		// the duration after which unhealthy backends are removed. Removed backends are not checked anymore and have to register their route again
+		RemoveUnhealthyAfter time.Duration `default:"1m" usage:"the duration after which unhealthy backends are removed. Removed backends are not checked anymore and have to register their route again"`
+	}
 }
 
 var ParamsRestAPI = &ParametersRestAPI{
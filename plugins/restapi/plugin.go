@@ -3,6 +3,7 @@ package restapi
 import (
 	"context"
 	"net/http"
+	"path/filepath"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -14,10 +15,13 @@ import (
 
 	"github.com/iotaledger/hive.go/app"
 	"github.com/iotaledger/hive.go/events"
+	"github.com/iotaledger/hive.go/timeutil"
 	"github.com/iotaledger/hornet/pkg/daemon"
 	"github.com/iotaledger/hornet/pkg/jwt"
 	"github.com/iotaledger/hornet/pkg/metrics"
+	restapipkg "github.com/iotaledger/hornet/pkg/restapi"
 	"github.com/iotaledger/hornet/pkg/tangle"
+	"github.com/iotaledger/hornet/pkg/tlsutil"
 )
 
 func init() {
@@ -39,6 +43,8 @@ var (
 	Plugin  *app.Plugin
 	deps    dependencies
 	jwtAuth *jwt.JWTAuth
+	// the certificates of the REST API, nil if TLS is disabled.
+	serverTLS *tlsutil.ServerTLS
 )
 
 type dependencies struct {
@@ -50,6 +56,8 @@ type dependencies struct {
 	RestAPIBindAddress string         `name:"restAPIBindAddress"`
 	NodePrivateKey     crypto.PrivKey `name:"nodePrivateKey"`
 	RestRouteManager   *RestRouteManager
+	RateLimiter        *restapipkg.RateLimiter
+	RevocationList     *jwt.RevocationList
 }
 
 func initConfigPars(c *dig.Container) error {
@@ -84,9 +92,68 @@ func provide(c *dig.Container) error {
 		Plugin.LogPanic(err)
 	}
 
+	type rateLimiterDeps struct {
+		dig.In
+		RestAPIMetrics *metrics.RestAPIMetrics
+	}
+
+	if err := c.Provide(func(deps rateLimiterDeps) *restapipkg.RateLimiter {
+		if ParamsRestAPI.RateLimits.IPv6PrefixLength < 0 || ParamsRestAPI.RateLimits.IPv6PrefixLength > 128 {
+			Plugin.LogErrorfAndExit("'%s' must be between 0 and 128", Plugin.App.Config().GetParameterPath(&(ParamsRestAPI.RateLimits.IPv6PrefixLength)))
+		}
+
+		// every rejected request tells the client when to retry, which is not possible if no request is allowed at all
+		for _, burst := range []*int{
+			&(ParamsRestAPI.RateLimits.Reads.Burst),
+			&(ParamsRestAPI.RateLimits.Blocks.Burst),
+			&(ParamsRestAPI.RateLimits.PoW.Burst),
+		} {
+			if *burst < 1 {
+				Plugin.LogErrorfAndExit("'%s' must be at least 1", Plugin.App.Config().GetParameterPath(burst))
+			}
+		}
+
+		limits := make(map[restapipkg.RateLimitClass]restapipkg.RateLimit)
+		if ParamsRestAPI.RateLimits.Enabled {
+			limits[restapipkg.RateLimitClassReads] = restapipkg.RateLimit{
+				RequestsPerSecond: ParamsRestAPI.RateLimits.Reads.RequestsPerSecond,
+				Burst:             ParamsRestAPI.RateLimits.Reads.Burst,
+			}
+			limits[restapipkg.RateLimitClassBlocks] = restapipkg.RateLimit{
+				RequestsPerSecond: ParamsRestAPI.RateLimits.Blocks.RequestsPerSecond,
+				Burst:             ParamsRestAPI.RateLimits.Blocks.Burst,
+			}
+			limits[restapipkg.RateLimitClassPoW] = restapipkg.RateLimit{
+				RequestsPerSecond: ParamsRestAPI.RateLimits.PoW.RequestsPerSecond,
+				Burst:             ParamsRestAPI.RateLimits.PoW.Burst,
+			}
+		}
+		return restapipkg.NewRateLimiter(limits, ParamsRestAPI.RateLimits.MaxClients, ParamsRestAPI.RateLimits.IPv6PrefixLength, deps.RestAPIMetrics)
+	}); err != nil {
+		Plugin.LogPanic(err)
+	}
+
+	type revocationListDeps struct {
+		dig.In
+		P2PDatabasePath string `name:"p2pDatabasePath"`
+	}
+
+	// the revoked tokens are shared with INX, so that tokens of extensions can be revoked via the REST API as well
+	if err := c.Provide(func(deps revocationListDeps) *jwt.RevocationList {
+		// the revoked tokens are stored next to the identity of the node, so that they survive a deletion of the database
+		revocationList, err := jwt.NewRevocationList(filepath.Join(deps.P2PDatabasePath, jwt.RevokedTokensFileName))
+		if err != nil {
+			Plugin.LogPanicf("failed to load revoked tokens: %s", err)
+		}
+		return revocationList
+	}); err != nil {
+		Plugin.LogPanic(err)
+	}
+
 	if err := c.Provide(func() *echo.Echo {
 		e := echo.New()
 		e.HideBanner = true
+		e.IPExtractor = ipExtractor()
 		e.Use(middleware.Recover())
 		e.Use(middleware.CORS())
 		e.Use(middleware.Gzip())
@@ -102,7 +169,12 @@ func provide(c *dig.Container) error {
 	}
 
 	if err := c.Provide(func(deps proxyDeps) *RestRouteManager {
-		return newRestRouteManager(deps.Echo)
+		strategy, err := restapipkg.ParseBalancingStrategy(ParamsRestAPI.Proxy.BalancingStrategy)
+		if err != nil {
+			Plugin.LogPanicf("invalid '%s': %s", Plugin.App.Config().GetParameterPath(&(ParamsRestAPI.Proxy.BalancingStrategy)), err)
+		}
+
+		return newRestRouteManager(deps.Echo, strategy)
 	}); err != nil {
 		Plugin.LogPanic(err)
 	}
@@ -111,7 +183,17 @@ func provide(c *dig.Container) error {
 }
 
 func configure() error {
+	if ParamsRestAPI.TLS.Enabled {
+		var err error
+		serverTLS, err = tlsutil.NewServerTLS(ParamsRestAPI.TLS.CertPath, ParamsRestAPI.TLS.KeyPath, "")
+		if err != nil {
+			Plugin.LogPanicf("REST-API TLS initialization failed: %s", err)
+		}
+	}
+
 	deps.Echo.Use(apiMiddleware())
+	// the rate limits are applied after the authorization, so that clients with a JWT are identified by their token
+	deps.Echo.Use(rateLimitMiddleware())
 	setupRoutes()
 	return nil
 }
@@ -126,8 +208,18 @@ func run() error {
 		bindAddr := deps.RestAPIBindAddress
 
 		go func() {
-			Plugin.LogInfof("You can now access the API using: http://%s", bindAddr)
-			if err := deps.Echo.Start(bindAddr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			var err error
+			if serverTLS != nil {
+				Plugin.LogInfof("You can now access the API using: https://%s", bindAddr)
+				server := deps.Echo.TLSServer
+				server.Addr = bindAddr
+				server.TLSConfig = serverTLS.Config()
+				err = deps.Echo.StartServer(server)
+			} else {
+				Plugin.LogInfof("You can now access the API using: http://%s", bindAddr)
+				err = deps.Echo.Start(bindAddr)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
 				Plugin.LogWarnf("Stopped REST-API server due to an error (%s)", err)
 			}
 		}()
@@ -145,5 +237,51 @@ func run() error {
 		Plugin.LogPanicf("failed to start worker: %s", err)
 	}
 
+	if serverTLS != nil {
+		if err := Plugin.Daemon().BackgroundWorker("REST-API TLS certificate reloader", func(ctx context.Context) {
+			ticker := timeutil.NewTicker(reloadCertificates, tlsutil.DefaultReloadInterval, ctx)
+			ticker.WaitForGracefulShutdown()
+		}, daemon.PriorityRestAPI); err != nil {
+			Plugin.LogPanicf("failed to start worker: %s", err)
+		}
+	}
+
+	if err := Plugin.Daemon().BackgroundWorker("REST-API proxy health checks", func(ctx context.Context) {
+		ticker := timeutil.NewTicker(checkProxyHealth, ParamsRestAPI.Proxy.HealthCheckInterval, ctx)
+		ticker.WaitForGracefulShutdown()
+	}, daemon.PriorityRestAPI); err != nil {
+		Plugin.LogPanicf("failed to start worker: %s", err)
+	}
+
+	if err := Plugin.Daemon().BackgroundWorker("REST-API rate limiter cleanup", func(ctx context.Context) {
+		ticker := timeutil.NewTicker(func() {
+			deps.RateLimiter.Cleanup(rateLimitClientIdleTimeout)
+		}, rateLimitCleanupInterval, ctx)
+		ticker.WaitForGracefulShutdown()
+	}, daemon.PriorityRestAPI); err != nil {
+		Plugin.LogPanicf("failed to start worker: %s", err)
+	}
+
 	return nil
 }
+
+// reloadCertificates loads the TLS certificates of the REST API again if the files changed.
+func reloadCertificates() {
+	reloaded, err := serverTLS.Reload()
+	if err != nil {
+		Plugin.LogWarnf("failed to reload REST-API TLS certificates: %s", err)
+		return
+	}
+	if reloaded {
+		Plugin.LogInfo("Reloaded REST-API TLS certificates")
+	}
+}
+
+// checkProxyHealth checks the backends of the routes registered via INX and removes the dead ones.
+func checkProxyHealth() {
+	for _, routeStatus := range deps.RestRouteManager.CheckProxyHealth(ParamsRestAPI.Proxy.HealthCheckTimeout, ParamsRestAPI.Proxy.RemoveUnhealthyAfter) {
+		for _, backend := range routeStatus.Backends {
+			Plugin.LogWarnf("Removed unhealthy proxy %s => %s, the route has to be registered again via INX", routeStatus.Route, backend.Address)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package restapi
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	restapipkg "github.com/iotaledger/hornet/pkg/restapi"
+)
+
+const (
+	// the route for submitting blocks, which has its own rate limit.
+	nodeAPIBlocksRoute = "/api/core/v2/blocks"
+
+	// the interval in which the token buckets of idle clients are removed.
+	rateLimitCleanupInterval = 1 * time.Minute
+	// the duration after which the token bucket of a client without requests is removed.
+	rateLimitClientIdleTimeout = 10 * time.Minute
+)
+
+// classifyRequest returns the rate limit class of the given request.
+func classifyRequest(c echo.Context) restapipkg.RateLimitClass {
+	if c.Request().Method == http.MethodPost && strings.ToLower(c.Path()) == nodeAPIBlocksRoute {
+		return restapipkg.RateLimitClassBlocks
+	}
+
+	return restapipkg.RateLimitClassReads
+}
+
+func rateLimitMiddleware() echo.MiddlewareFunc {
+	return deps.RateLimiter.Middleware(classifyRequest)
+}
+
+// ipExtractor returns the function to determine the IP of the client which sent a request.
+// Headers are only trusted if they were set by one of the configured reverse proxies,
+// otherwise clients could choose their own IP to bypass the rate limits.
+func ipExtractor() echo.IPExtractor {
+	if len(ParamsRestAPI.RateLimits.TrustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	trustOptions := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+
+	for _, trustedProxy := range ParamsRestAPI.RateLimits.TrustedProxies {
+		_, ipRange, err := net.ParseCIDR(trustedProxy)
+		if err != nil {
+			Plugin.LogPanicf("invalid '%s': %s", Plugin.App.Config().GetParameterPath(&(ParamsRestAPI.RateLimits.TrustedProxies)), err)
+		}
+		trustOptions = append(trustOptions, echo.TrustIPRange(ipRange))
+	}
+
+	return echo.ExtractIPFromXFFHeader(trustOptions...)
+}
@@ -0,0 +1,116 @@
+package restapi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hornet/pkg/jwt"
+	"github.com/iotaledger/hornet/pkg/restapi"
+)
+
+const (
+	// nodeAPIRevocationsRoute is the route for listing the revoked tokens and revoking tokens.
+	// GET returns the revoked tokens.
+	// POST revokes a token.
+	nodeAPIRevocationsRoute = "/api/auth/v1/revocations"
+)
+
+// revokeTokenRequest defines the request for a POST revocations REST API call.
+// Either the token or its ID must be given.
+type revokeTokenRequest struct {
+	// The encoded token, the token is removed from the list after it expired.
+	Token string `json:"token,omitempty"`
+	// The ID of the token, the token is kept in the list forever, since the time it expires is unknown.
+	// Tokens issued before the token IDs were random can't be revoked by their ID.
+	TokenID string `json:"tokenId,omitempty"`
+}
+
+// revokedTokenResponse defines a revoked token.
+type revokedTokenResponse struct {
+	// The ID of the token.
+	TokenID string `json:"tokenId"`
+	// The unix timestamp at which the token expires, omitted if it never expires.
+	ExpiresAt int64 `json:"expiresAt,omitempty"`
+}
+
+// revokedTokensResponse defines the response of a GET revocations REST API call.
+type revokedTokensResponse struct {
+	// The revoked tokens.
+	Tokens []*revokedTokenResponse `json:"tokens"`
+}
+
+func revokedTokens(_ echo.Context) *revokedTokensResponse {
+	revoked := deps.RevocationList.RevokedTokens()
+
+	tokens := make([]*revokedTokenResponse, 0, len(revoked))
+	for _, token := range revoked {
+		response := &revokedTokenResponse{
+			TokenID: token.ID,
+		}
+		if !token.ExpiresAt.IsZero() {
+			response.ExpiresAt = token.ExpiresAt.Unix()
+		}
+		tokens = append(tokens, response)
+	}
+
+	return &revokedTokensResponse{Tokens: tokens}
+}
+
+func revokeToken(c echo.Context) error {
+	request := &revokeTokenRequest{}
+
+	if err := c.Bind(request); err != nil {
+		return errors.WithMessagef(restapi.ErrInvalidParameter, "invalid revokeTokenRequest, error: %s", err)
+	}
+
+	var revocationID string
+	var expiresAt time.Time
+
+	switch {
+	case len(request.Token) > 0 && len(request.TokenID) > 0:
+		return errors.WithMessage(restapi.ErrInvalidParameter, "either token or tokenId must be given")
+
+	case len(request.Token) > 0:
+		claims, err := jwt.ParseClaimsUnverified(request.Token)
+		if err != nil {
+			return errors.WithMessagef(restapi.ErrInvalidParameter, "invalid token, error: %s", err)
+		}
+
+		revocationID = claims.RevocationID()
+		if claims.ExpiresAt != 0 {
+			expiresAt = time.Unix(claims.ExpiresAt, 0)
+		}
+
+	case len(request.TokenID) > 0:
+		if !jwt.IsRandomTokenID(request.TokenID) {
+			return errors.WithMessage(restapi.ErrInvalidParameter, "tokenId is not unique, revoke the token by the encoded token instead")
+		}
+
+		revocationID = request.TokenID
+
+	default:
+		return errors.WithMessage(restapi.ErrInvalidParameter, "token or tokenId must be given")
+	}
+
+	if err := deps.RevocationList.Revoke(revocationID, expiresAt); err != nil {
+		return errors.WithMessagef(echo.ErrInternalServerError, "revoking token failed, error: %s", err)
+	}
+
+	return nil
+}
+
+func setupRevocationRoutes() {
+	deps.Echo.GET(nodeAPIRevocationsRoute, func(c echo.Context) error {
+		return restapi.JSONResponse(c, http.StatusOK, revokedTokens(c))
+	})
+
+	deps.Echo.POST(nodeAPIRevocationsRoute, func(c echo.Context) error {
+		if err := revokeToken(c); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	})
+}
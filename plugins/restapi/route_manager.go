@@ -1,23 +1,47 @@
 package restapi
 
 import (
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 
 	restapipkg "github.com/iotaledger/hornet/pkg/restapi"
 )
 
+var (
+	// ErrReservedRoute is returned if a proxy route is added for a route which is reserved by the node.
+	ErrReservedRoute = errors.New("route is reserved by the node")
+
+	// reservedRoutePrefixes are the first segments of the routes of the node's own endpoints below "/api".
+	reservedRoutePrefixes = []string{"routes", "auth"}
+)
+
+// firstRouteSegment returns the first segment of the given route.
+func firstRouteSegment(route string) string {
+	return strings.SplitN(strings.Trim(route, "/"), "/", 2)[0]
+}
+
 type RestRouteManager struct {
 	sync.RWMutex
 	routes []string
-	proxy  *restapipkg.DynamicProxy
+	// the first segments of the routes added by the plugins of the node.
+	builtInPrefixes map[string]struct{}
+	proxy           *restapipkg.DynamicProxy
 }
 
-func newRestRouteManager(e *echo.Echo) *RestRouteManager {
+func newRestRouteManager(e *echo.Echo, strategy restapipkg.BalancingStrategy) *RestRouteManager {
+	builtInPrefixes := make(map[string]struct{}, len(reservedRoutePrefixes))
+	for _, prefix := range reservedRoutePrefixes {
+		builtInPrefixes[prefix] = struct{}{}
+	}
+
 	return &RestRouteManager{
-		routes: []string{},
-		proxy:  restapipkg.NewDynamicProxy(e, "/api"),
+		routes:          []string{},
+		builtInPrefixes: builtInPrefixes,
+		proxy:           restapipkg.NewDynamicProxy(e, "/api", strategy),
 	}
 }
 func (p *RestRouteManager) Routes() []string {
@@ -42,15 +66,23 @@ func (p *RestRouteManager) AddRoute(route string) *echo.Group {
 	if !found {
 		p.routes = append(p.routes, route)
 	}
+	p.builtInPrefixes[firstRouteSegment(route)] = struct{}{}
+
 	// existing groups get overwritten (necessary if last plugin was not cleaned up properly)
 	return p.proxy.AddGroup(route)
 }
 
-// AddProxyRoute adds a proxy route to the Routes endpoint and configures a remote proxy for this route.
+// AddProxyRoute adds a proxy route to the Routes endpoint and adds the given host and port as backend of this route.
+// Requests are distributed between all healthy backends of a route.
+// Routes starting with the same segment as the routes of the node itself, e.g. "core/", are rejected.
 func (p *RestRouteManager) AddProxyRoute(route string, host string, port uint32) error {
 	p.Lock()
 	defer p.Unlock()
 
+	if p.isReservedRouteWithoutLocking(route) {
+		return errors.Wrapf(ErrReservedRoute, "route %s", route)
+	}
+
 	found := false
 	for _, r := range p.routes {
 		if r == route {
@@ -61,15 +93,27 @@ func (p *RestRouteManager) AddProxyRoute(route string, host string, port uint32)
 	if !found {
 		p.routes = append(p.routes, route)
 	}
-	// existing proxies get overwritten (necessary if last plugin was not cleaned up properly)
+	// a backend which registers again is marked as healthy (necessary if last plugin was not cleaned up properly)
 	return p.proxy.AddReverseProxy(route, host, port)
 }
 
-// RemoveRoute removes a route from the Routes endpoint.
-func (p *RestRouteManager) RemoveRoute(route string) {
-	p.Lock()
-	defer p.Unlock()
+// IsReservedRoute tells whether the given route starts with the same segment as the routes of the node itself.
+func (p *RestRouteManager) IsReservedRoute(route string) bool {
+	p.RLock()
+	defer p.RUnlock()
 
+	return p.isReservedRouteWithoutLocking(route)
+}
+
+// the lock must be held.
+func (p *RestRouteManager) isReservedRouteWithoutLocking(route string) bool {
+	_, reserved := p.builtInPrefixes[firstRouteSegment(route)]
+	return reserved
+}
+
+// removes a route from the Routes endpoint.
+// the lock must be held.
+func (p *RestRouteManager) removeRouteWithoutLocking(route string) {
 	newRoutes := make([]string, 0)
 	for _, r := range p.routes {
 		if r != route {
@@ -77,5 +121,47 @@ func (p *RestRouteManager) RemoveRoute(route string) {
 		}
 	}
 	p.routes = newRoutes
+}
+
+// RemoveRoute removes a route from the Routes endpoint.
+func (p *RestRouteManager) RemoveRoute(route string) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.removeRouteWithoutLocking(route)
 	p.proxy.RemoveReverseProxy(route)
 }
+
+// RemoveProxyRoute removes a backend from a proxied route.
+// The route is removed from the Routes endpoint if it has no backends left.
+func (p *RestRouteManager) RemoveProxyRoute(route string, host string, port uint32) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.proxy.RemoveReverseProxyBackend(route, host, port)
+	if !p.proxy.HasReverseProxy(route) {
+		p.removeRouteWithoutLocking(route)
+	}
+}
+
+// ProxyStatus returns the status of the backends of the proxied routes.
+func (p *RestRouteManager) ProxyStatus() []*restapipkg.ProxyRouteStatus {
+	return p.proxy.Status()
+}
+
+// CheckProxyHealth checks the backends of the proxied routes and removes the ones which are unhealthy for too long.
+// Routes without backends are removed from the Routes endpoint. Returns the removed backends.
+func (p *RestRouteManager) CheckProxyHealth(timeout time.Duration, removeUnhealthyAfter time.Duration) []*restapipkg.ProxyRouteStatus {
+	removed := p.proxy.CheckHealth(timeout, removeUnhealthyAfter)
+
+	p.Lock()
+	defer p.Unlock()
+
+	for _, routeStatus := range removed {
+		if !p.proxy.HasReverseProxy(routeStatus.Route) {
+			p.removeRouteWithoutLocking(routeStatus.Route)
+		}
+	}
+
+	return removed
+}
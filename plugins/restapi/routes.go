@@ -12,12 +12,22 @@ const (
 	nodeAPIHealthRoute = "/health"
 
 	nodeAPIRoutesRoute = "/api/routes"
+
+	// nodeAPIRoutesProxiesRoute is the route for getting the backends of the routes registered via INX.
+	// It needs the ScopeControlProxies, since the addresses of the backends are not meant to be public.
+	nodeAPIRoutesProxiesRoute = "/api/routes/proxies"
 )
 
 type RoutesResponse struct {
 	Routes []string `json:"routes"`
 }
 
+// ProxiesResponse defines the response of a GET proxies REST API call.
+type ProxiesResponse struct {
+	// The backends of the routes registered via INX.
+	Proxies []*restapi.ProxyRouteStatus `json:"proxies"`
+}
+
 func setupRoutes() {
 	errorHandler := restapi.ErrorHandler()
 
@@ -27,6 +37,8 @@ func setupRoutes() {
 		errorHandler(err, c)
 	}
 
+	setupRevocationRoutes()
+
 	deps.Echo.GET(nodeAPIHealthRoute, func(c echo.Context) error {
 		// node mode
 		if deps.Tangle != nil && !deps.Tangle.IsNodeHealthy() {
@@ -43,5 +55,12 @@ func setupRoutes() {
 			}
 			return restapi.JSONResponse(c, http.StatusOK, resp)
 		})
+
+		deps.Echo.GET(nodeAPIRoutesProxiesRoute, func(c echo.Context) error {
+			resp := &ProxiesResponse{
+				Proxies: deps.RestRouteManager.ProxyStatus(),
+			}
+			return restapi.JSONResponse(c, http.StatusOK, resp)
+		})
 	}
 }
@@ -0,0 +1,103 @@
+package restapi
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// ScopeRead grants access to all routes which don't modify the node.
+	ScopeRead = "read"
+	// ScopeWrite grants access to all routes which modify the node and have no dedicated scope.
+	ScopeWrite = "write"
+	// ScopeSubmitBlocks grants access to submit blocks.
+	ScopeSubmitBlocks = "submit-blocks"
+	// ScopePeersWrite grants access to add and remove peers.
+	ScopePeersWrite = "peers:write"
+	// ScopeControlDatabase grants access to prune the database.
+	ScopeControlDatabase = "control:database"
+	// ScopeControlSnapshots grants access to create snapshots.
+	ScopeControlSnapshots = "control:snapshots"
+	// ScopeControlTokens grants access to revoke tokens.
+	ScopeControlTokens = "control:tokens"
+	// ScopeControlProxies grants access to the backends of the routes registered via INX.
+	ScopeControlProxies = "control:proxies"
+)
+
+// routeScope defines the scope a token needs to call the matching routes.
+type routeScope struct {
+	// The HTTP methods of the routes, all methods match if empty.
+	methods []string
+	// The routes, wildcards using * are allowed.
+	route string
+	// The scope needed to call the routes.
+	scope string
+}
+
+// routeScopes are the scopes needed to call the protected routes. The first matching entry is used.
+// Routes which match no entry need the ScopeWrite.
+var routeScopes = []*routeScope{
+	{methods: nil, route: "/api/core/v2/control/database/*", scope: ScopeControlDatabase},
+	{methods: nil, route: "/api/core/v2/control/snapshots*", scope: ScopeControlSnapshots},
+	{methods: nil, route: nodeAPIRevocationsRoute, scope: ScopeControlTokens},
+	{methods: nil, route: nodeAPIRoutesProxiesRoute, scope: ScopeControlProxies},
+	{methods: []string{http.MethodPost}, route: nodeAPIBlocksRoute, scope: ScopeSubmitBlocks},
+	{methods: []string{http.MethodPost, http.MethodDelete}, route: "/api/core/v2/peers*", scope: ScopePeersWrite},
+	{methods: []string{http.MethodGet, http.MethodHead}, route: "*", scope: ScopeRead},
+}
+
+// compiledRouteScope is a routeScope with a compiled route.
+type compiledRouteScope struct {
+	methods []string
+	route   *regexp.Regexp
+	scope   string
+}
+
+func (s *compiledRouteScope) matches(method string, loweredPath string) bool {
+	if len(s.methods) > 0 {
+		methodMatches := false
+		for _, m := range s.methods {
+			if m == method {
+				methodMatches = true
+				break
+			}
+		}
+		if !methodMatches {
+			return false
+		}
+	}
+
+	return s.route.MatchString(loweredPath)
+}
+
+func compileRouteScopes(scopes []*routeScope) []*compiledRouteScope {
+	compiledScopes := make([]*compiledRouteScope, 0, len(scopes))
+	for _, s := range scopes {
+		reg := compileRouteAsRegex(s.route)
+		if reg == nil {
+			Plugin.LogPanicf("invalid route of scope %s: %s", s.scope, s.route)
+		}
+		compiledScopes = append(compiledScopes, &compiledRouteScope{
+			methods: s.methods,
+			route:   reg,
+			scope:   s.scope,
+		})
+	}
+	return compiledScopes
+}
+
+// requiredScope returns the scope a token needs to call the route of the given request.
+func requiredScope(compiledScopes []*compiledRouteScope, c echo.Context) string {
+	loweredPath := strings.ToLower(c.Path())
+
+	for _, s := range compiledScopes {
+		if s.matches(c.Request().Method, loweredPath) {
+			return s.scope
+		}
+	}
+
+	return ScopeWrite
+}